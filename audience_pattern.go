@@ -0,0 +1,75 @@
+package auth0
+
+import (
+	"regexp"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// audiencePattern is a compiled audience pattern, see
+// ValidatorOptions.AudiencePatterns
+type audiencePattern struct {
+	pattern string
+	regexp  *regexp.Regexp
+}
+
+// compileAudiencePatterns compiles the patterns into anchored regexps. A *
+// matches one DNS label in the host of a URI, e.g. "https://*.example.com/",
+// and anything after the host, e.g. "https://api.example.com/*", or anywhere
+// in audiences which are not URIs.
+func compileAudiencePatterns(patterns []string) []audiencePattern {
+	compiled := make([]audiencePattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		// the host ends at the first / following the scheme
+		hostEnd := 0
+		if i := strings.Index(pattern, "://"); i >= 0 {
+			hostEnd = len(pattern)
+			if j := strings.IndexByte(pattern[i+3:], '/'); j >= 0 {
+				hostEnd = i + 3 + j
+			}
+		}
+
+		var expr strings.Builder
+		expr.WriteString("^")
+		start := 0
+		for i := 0; i < len(pattern); i++ {
+			if pattern[i] != '*' {
+				continue
+			}
+			expr.WriteString(regexp.QuoteMeta(pattern[start:i]))
+			if i < hostEnd {
+				expr.WriteString(`[A-Za-z0-9-]+`)
+			} else {
+				expr.WriteString(`.*`)
+			}
+			start = i + 1
+		}
+		expr.WriteString(regexp.QuoteMeta(pattern[start:]))
+		expr.WriteString("$")
+		compiled = append(compiled, audiencePattern{pattern: pattern, regexp: regexp.MustCompile(expr.String())})
+	}
+	return compiled
+}
+
+// matchAudience tells whether one of the audiences matches one of the
+// patterns
+func matchAudience(patterns []audiencePattern, audience jwt.Audience) bool {
+	for _, aud := range audience {
+		for _, pattern := range patterns {
+			if pattern.regexp.MatchString(aud) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// audiencePatternStrings returns the patterns as configured
+func audiencePatternStrings(patterns []audiencePattern) []string {
+	strs := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		strs[i] = pattern.pattern
+	}
+	return strs
+}
@@ -0,0 +1,71 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestMatchAudience(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		audience string
+		want     bool
+	}{
+		{"https://api.example.com/*", "https://api.example.com/", true},
+		{"https://api.example.com/*", "https://api.example.com/v1/users", true},
+		{"https://api.example.com/*", "https://api.example.com", false},
+		{"https://api.example.com/*", "https://api.example.com.evil.com/", false},
+		{"https://*.example.com/", "https://eu.example.com/", true},
+		{"https://*.example.com/", "https://eu.api.example.com/", false},
+		{"https://*.example.com/", "https://evil.com/.example.com/", false},
+		{"https://*.example.com/", "https://evil.com?.example.com/", false},
+		{"https://*.example.com/", "https://.example.com/", false},
+		{"https://*.example.com/*", "https://eu.example.com/orders", true},
+		{"https://*.example.com", "https://eu.example.com", true},
+		{"https://api.example.com/", "https://api.example.com/", true},
+		{"https://api.example.com/", "https://apixexample.com/", false},
+		{"urn:api:*", "urn:api:orders", true},
+		{"urn:api:*", "urn:other:orders", false},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.audience, func(t *testing.T) {
+			patterns := compileAudiencePatterns([]string{test.pattern})
+			assert.Equal(t, test.want, matchAudience(patterns, jwt.Audience{test.audience}))
+		})
+	}
+
+	patterns := compileAudiencePatterns([]string{"https://a.example.com/*", "https://b.example.com/*"})
+	assert.True(t, matchAudience(patterns, jwt.Audience{"other", "https://b.example.com/x"}))
+	assert.False(t, matchAudience(patterns, jwt.Audience{"other"}))
+	assert.False(t, matchAudience(patterns, nil))
+}
+
+func TestValidatorAudiencePatterns(t *testing.T) {
+	validator, err := NewValidatorBuilder(defaultSecretProvider).
+		WithIssuer(defaultIssuer).
+		WithAlgorithm(jose.HS256).
+		WithAudiencePatterns("https://api.example.com/*").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := getTestToken([]string{"https://api.example.com/orders"}, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	assert.NoError(t, err)
+
+	token = getTestToken([]string{"https://other.example.com/orders"}, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+	var validationErr *ValidationError
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, "aud", validationErr.Claim)
+		assert.Equal(t, []string{"https://other.example.com/orders"}, validationErr.Value)
+		assert.Equal(t, []string{"https://api.example.com/*"}, validationErr.Expected)
+	}
+}
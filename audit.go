@@ -0,0 +1,137 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ErrAuditRecordDropped is returned by channel audit sinks
+// when the channel is full.
+var ErrAuditRecordDropped = errors.New("audit record dropped")
+
+// Audit outcomes of the validation decisions.
+const (
+	AuditOutcomeAllowed = "allowed"
+	AuditOutcomeDenied  = "denied"
+)
+
+// AuditRecord is the record of a validation decision. The claims of denied
+// tokens are read without verifying the tokens, so they may be forged.
+type AuditRecord struct {
+	// Time is the time of the decision, in UTC.
+	Time time.Time `json:"time"`
+	// Subject is the sub claim of the token.
+	Subject string `json:"sub,omitempty"`
+	// ClientID is the client_id claim of the token, or else its azp claim.
+	ClientID string `json:"client_id,omitempty"`
+	// Audience is the aud claim of the token.
+	Audience []string `json:"aud,omitempty"`
+	// Outcome is AuditOutcomeAllowed or AuditOutcomeDenied.
+	Outcome string `json:"outcome"`
+	// Reason is the ErrorReason of denied tokens.
+	Reason string `json:"reason,omitempty"`
+	// SourceIP is the IP address of the remote address of the request.
+	SourceIP string `json:"source_ip,omitempty"`
+}
+
+// AuditSink receives the audit records of a validator, e.g. to write them to
+// a compliance trail or to forward them to a message broker. It is called
+// synchronously by every validation, so slow sinks should buffer the records.
+type AuditSink interface {
+	WriteAudit(record AuditRecord) error
+}
+
+// AuditSinkFunc is a function implementing AuditSink.
+type AuditSinkFunc func(record AuditRecord) error
+
+// WriteAudit calls f.
+func (f AuditSinkFunc) WriteAudit(record AuditRecord) error {
+	return f(record)
+}
+
+// writerAuditSink writes the records as JSON lines
+type writerAuditSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWriterAuditSink creates a sink writing the records to w as JSON lines,
+// one write per record.
+func NewWriterAuditSink(w io.Writer) AuditSink {
+	return &writerAuditSink{encoder: json.NewEncoder(w)}
+}
+
+// WriteAudit implements AuditSink.
+func (s *writerAuditSink) WriteAudit(record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(record)
+}
+
+// NewChannelAuditSink creates a sink sending the records to ch without
+// blocking, failing with ErrAuditRecordDropped when ch is full.
+func NewChannelAuditSink(ch chan<- AuditRecord) AuditSink {
+	return AuditSinkFunc(func(record AuditRecord) error {
+		select {
+		case ch <- record:
+			return nil
+		default:
+			return ErrAuditRecordDropped
+		}
+	})
+}
+
+// observedClaims are the claims reported to the hooks and the audit sink
+type observedClaims struct {
+	jwt.Claims
+	ClientID        string `json:"client_id,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+}
+
+// unverifiedClaims reads the claims of the token of the request without
+// verifying it, leaving them empty when the token cannot be read
+func unverifiedClaims(extractor RequestTokenExtractor, r *http.Request) observedClaims {
+	var claims observedClaims
+	token, err := extractor.Extract(r)
+	if err == nil {
+		_ = token.UnsafeClaimsWithoutVerification(&claims)
+	}
+	return claims
+}
+
+// audit writes the record of the validation decision to the audit sink
+func (v *JWTValidator) audit(r *http.Request, claims observedClaims, err error) {
+	record := AuditRecord{
+		Time:     v.clock.Now().UTC(),
+		Subject:  claims.Subject,
+		ClientID: claims.ClientID,
+		Audience: claims.Audience,
+		Outcome:  AuditOutcomeAllowed,
+		SourceIP: sourceIP(r),
+	}
+	if record.ClientID == "" {
+		record.ClientID = claims.AuthorizedParty
+	}
+	if err != nil {
+		record.Outcome, record.Reason = AuditOutcomeDenied, ErrorReason(err)
+	}
+	if err := v.auditSink.WriteAudit(record); err != nil && v.logger != nil {
+		v.logger.Warn("auth0: audit record dropped", "error", err)
+	}
+}
+
+// sourceIP returns the IP address of the remote address of the request
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
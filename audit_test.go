@@ -0,0 +1,92 @@
+package auth0
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// getTestTokenWithClient returns a token of the subject and the client
+// holding the client ID in the claim
+func getTestTokenWithClient(expTime time.Time, subject, claim, clientID string) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		panic(err)
+	}
+	cl := jwt.Claims{
+		Subject:  subject,
+		Issuer:   defaultIssuer,
+		Audience: defaultAudience,
+		Expiry:   jwt.NewNumericDate(expTime),
+	}
+	raw, err := jwt.Signed(signer).Claims(cl).Claims(map[string]interface{}{claim: clientID}).CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func TestAuditSink(t *testing.T) {
+	records := make(chan AuditRecord, 3)
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{AuditSink: NewChannelAuditSink(records)})
+
+	for _, token := range []string{
+		getTestTokenWithClient(time.Now().Add(time.Hour), "user1", "client_id", "client1"),
+		getTestTokenWithClient(time.Now().Add(-time.Hour), "user2", "azp", "client2"),
+		"malformed",
+	} {
+		_, req := genTestConfiguration(configuration, token)
+		req.RemoteAddr = "192.0.2.1:1234"
+		validator.ValidateRequest(req)
+	}
+	close(records)
+
+	var audited []AuditRecord
+	for record := range records {
+		assert.WithinDuration(t, time.Now(), record.Time, time.Minute)
+		record.Time = time.Time{}
+		audited = append(audited, record)
+	}
+	assert.Equal(t, []AuditRecord{
+		{Subject: "user1", ClientID: "client1", Audience: defaultAudience, Outcome: AuditOutcomeAllowed, SourceIP: "192.0.2.1"},
+		{Subject: "user2", ClientID: "client2", Audience: defaultAudience, Outcome: AuditOutcomeDenied, Reason: "expired", SourceIP: "192.0.2.1"},
+		{Outcome: AuditOutcomeDenied, Reason: "other", SourceIP: "192.0.2.1"},
+	}, audited)
+}
+
+func TestWriterAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterAuditSink(&buf)
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, sink.WriteAudit(AuditRecord{Time: at, Subject: "user", Outcome: AuditOutcomeAllowed, SourceIP: "192.0.2.1"}))
+	assert.NoError(t, sink.WriteAudit(AuditRecord{Time: at, Outcome: AuditOutcomeDenied, Reason: "expired"}))
+
+	assert.Equal(t, `{"time":"2020-01-02T03:04:05Z","sub":"user","outcome":"allowed","source_ip":"192.0.2.1"}
+{"time":"2020-01-02T03:04:05Z","outcome":"denied","reason":"expired"}
+`, buf.String())
+
+	var record AuditRecord
+	assert.NoError(t, json.Unmarshal(bytes.Split(buf.Bytes(), []byte("\n"))[0], &record))
+	assert.Equal(t, "user", record.Subject)
+}
+
+func TestAuditSinkErrors(t *testing.T) {
+	sink := NewChannelAuditSink(make(chan AuditRecord))
+	assert.Equal(t, ErrAuditRecordDropped, sink.WriteAudit(AuditRecord{}))
+
+	logger := &recordingLogger{}
+	failing := AuditSinkFunc(func(AuditRecord) error { return errors.New("unavailable") })
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{AuditSink: failing, Logger: logger})
+	_, req := genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+	_, err := validator.ValidateRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"warn auth0: audit record dropped error=unavailable"}, logger.lines())
+}
@@ -1,6 +1,7 @@
 package auth0
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"time"
@@ -31,6 +32,11 @@ func NewKeyProvider(key interface{}) SecretProvider {
 	})
 }
 
+// RotatedKeys are verification keys returned by a secret provider while
+// rotating keys, e.g. the new and the previous HMAC secrets. Tokens are
+// verified with the first key validating their signature.
+type RotatedKeys []interface{}
+
 var (
 	// ErrNoJWTHeaders is returned when there are no headers in the JWT.
 	ErrNoJWTHeaders = errors.New("No headers in the token")
@@ -38,7 +44,7 @@ var (
 
 // Configuration contains
 // all the information about the
-// Auth0 service.
+// Auth0 service. It cannot be changed once created.
 type Configuration struct {
 	secretProvider SecretProvider
 	expectedClaims jwt.Expected
@@ -49,7 +55,7 @@ type Configuration struct {
 func NewConfiguration(provider SecretProvider, audience []string, issuer string, method jose.SignatureAlgorithm) Configuration {
 	return Configuration{
 		secretProvider: provider,
-		expectedClaims: jwt.Expected{Issuer: issuer, Audience: audience},
+		expectedClaims: jwt.Expected{Issuer: issuer, Audience: copyAudience(audience)},
 		signIn:         method,
 	}
 }
@@ -58,29 +64,283 @@ func NewConfiguration(provider SecretProvider, audience []string, issuer string,
 func NewConfigurationTrustProvider(provider SecretProvider, audience []string, issuer string) Configuration {
 	return Configuration{
 		secretProvider: provider,
-		expectedClaims: jwt.Expected{Issuer: issuer, Audience: audience},
+		expectedClaims: jwt.Expected{Issuer: issuer, Audience: copyAudience(audience)},
 	}
 }
 
+// copyAudience copies the audience so that the
+// configuration cannot be changed behind it
+func copyAudience(audience []string) jwt.Audience {
+	return append(jwt.Audience(nil), audience...)
+}
+
+// ValidatorOptions configures the optional behaviors of the validator.
+type ValidatorOptions struct {
+	// Backend, when set, verifies the raw tokens instead of
+	// gopkg.in/square/go-jose.v2. See JOSEBackend.
+	Backend JOSEBackend
+	// ResultCacheTTL, when positive, is how long a successfully validated
+	// token is remembered, bounded by its expiry, so that a token seen again
+	// is not verified again. Tokens stay valid for up to the TTL after their
+	// key is revoked, so keep it short. Only the tokens of extractors
+	// providing the raw token are remembered.
+	ResultCacheTTL time.Duration
+	// ResultCacheSize bounds the number of remembered tokens.
+	// Defaults to DefaultResultCacheSize.
+	ResultCacheSize int
+	// BatchConcurrency bounds the tokens validated at once by
+	// ValidateTokens. Defaults to GOMAXPROCS.
+	BatchConcurrency int
+	// Metrics, when set, records the outcome and the duration of every
+	// validation.
+	Metrics ValidatorMetrics
+	// Tracer, when set, traces every validation, the span being the parent
+	// of the spans of the secret provider.
+	Tracer Tracer
+	// Logger, when set, logs the rejected tokens at the debug level along
+	// with the ErrorReason of their rejection.
+	Logger Logger
+	// DebugTokens logs, along with the rejected tokens, their redacted
+	// metadata: header, claim names and truncated signature hash, never the
+	// tokens nor their claim values. See RedactToken. Meant to troubleshoot
+	// rejected tokens in staging.
+	DebugTokens bool
+	// OnValidationSuccess, when set, is called with the registered claims of
+	// every valid token, e.g. to count the requests of each client.
+	OnValidationSuccess func(claims jwt.Claims)
+	// OnValidationFailure, when set, is called with the error of every
+	// rejected token and its ErrorReason, e.g. for security analytics.
+	OnValidationFailure func(err error, reason string)
+	// AuditSink, when set, receives the record of every validation
+	// decision. Failing writes are logged with Logger.
+	AuditSink AuditSink
+	// Clock tells the time the tokens are validated at.
+	// Defaults to SystemClock.
+	Clock Clock
+	// Algorithms, when set, are the signature algorithms the tokens may be
+	// signed with, e.g. while migrating from RS256 to ES256. The algorithm
+	// of the configuration, when set, is checked as well.
+	Algorithms []jose.SignatureAlgorithm
+	// Leeway is the clock skew tolerated when validating the exp, nbf and
+	// iat claims. Defaults to jwt.DefaultLeeway, one minute, when zero, and
+	// disables the tolerance when negative.
+	Leeway time.Duration
+	// RequiredClaims are the names of the claims the tokens must have, e.g.
+	// "sub" or a namespaced custom claim. Tokens lacking one of them fail
+	// with ErrMissingClaim.
+	RequiredClaims []string
+	// AudiencePatterns, when set, accept the tokens with an audience
+	// matching one of them, e.g. "https://api.example.com/*" for the
+	// resource identifiers under a common prefix, or
+	// "https://*.example.com/" for the subdomains of a domain. A * matches
+	// one DNS label in the host, and anything after it. The audience of the
+	// configuration, when set, is checked as well.
+	AudiencePatterns []string
+	// JSONCodec, when set, unmarshalls the claims of the tokens once their
+	// signature is verified, e.g. with jsoniter or sonic. See JSONCodec.
+	JSONCodec JSONCodec
+	// Policy, when set, authorizes the valid tokens from their claims. The
+	// tokens it denies fail with ErrPolicyDenied. See Policy.
+	Policy Policy
+}
+
+// Validator validates the tokens of http requests. It is implemented by
+// JWTValidator, MultiTenantValidator, and by auth0test.MockValidator to test
+// the handlers depending on a validator without keys nor tokens.
+type Validator interface {
+	// ValidateRequest validates the token within the http request.
+	ValidateRequest(r *http.Request) (*jwt.JSONWebToken, error)
+	// ValidateRequestWithClaims validates the token within the http request,
+	// unmarshalling its claims into values.
+	ValidateRequestWithClaims(r *http.Request, values ...interface{}) (*jwt.JSONWebToken, error)
+	// Validate validates the token within the http request, returning the
+	// token with its decoded claims.
+	Validate(r *http.Request) (*ValidationResult, error)
+}
+
 // JWTValidator helps middleware
-// to validate token
+// to validate token. It cannot be changed once created, and is safe for
+// concurrent use by multiple goroutines as long as its secret provider and
+// extractor are, which holds for the ones of this package.
 type JWTValidator struct {
 	config    Configuration
 	extractor RequestTokenExtractor
+	backend   JOSEBackend
+	results   *resultCache
+	// batchConcurrency bounds the tokens validated at once by ValidateTokens
+	batchConcurrency int
+	metrics          ValidatorMetrics
+	tracer           Tracer
+	logger           Logger
+	debugTokens      bool
+	onSuccess        func(claims jwt.Claims)
+	onFailure        func(err error, reason string)
+	auditSink        AuditSink
+	clock            Clock
+	algorithms       []jose.SignatureAlgorithm
+	leeway           time.Duration
+	requiredClaims   []string
+	audiencePatterns []audiencePattern
+	jsonCodec        JSONCodec
+	policy           Policy
 }
 
 // NewValidator creates a new
 // validator with the provided configuration.
 func NewValidator(config Configuration, extractor RequestTokenExtractor) *JWTValidator {
+	return NewValidatorWithOptions(config, extractor, ValidatorOptions{})
+}
+
+// NewValidatorWithBackend creates a new validator verifying tokens with the
+// provided JOSE backend. The extractor must implement RawTokenExtractor, the
+// backend verifying the raw token.
+func NewValidatorWithBackend(config Configuration, extractor RequestTokenExtractor, backend JOSEBackend) *JWTValidator {
+	return NewValidatorWithOptions(config, extractor, ValidatorOptions{Backend: backend})
+}
+
+// NewValidatorWithOptions creates a new validator
+// with the provided configuration and options.
+func NewValidatorWithOptions(config Configuration, extractor RequestTokenExtractor, options ValidatorOptions) *JWTValidator {
 	if extractor == nil {
-		extractor = RequestTokenExtractorFunc(FromHeader)
+		extractor = RawTokenExtractorFunc(FromHeaderRaw)
+	}
+	clock := clockOrSystem(options.Clock)
+	leeway := options.Leeway
+	if leeway == 0 {
+		leeway = jwt.DefaultLeeway
+	} else if leeway < 0 {
+		leeway = 0
+	}
+	return &JWTValidator{
+		config:    config,
+		extractor: extractor,
+		backend:   options.Backend,
+		results:   newResultCache(options.ResultCacheTTL, options.ResultCacheSize, clock),
+
+		batchConcurrency: options.BatchConcurrency,
+		metrics:          options.Metrics,
+		tracer:           options.Tracer,
+		logger:           options.Logger,
+		debugTokens:      options.DebugTokens,
+		onSuccess:        options.OnValidationSuccess,
+		onFailure:        options.OnValidationFailure,
+		auditSink:        options.AuditSink,
+		clock:            clock,
+		algorithms:       append([]jose.SignatureAlgorithm(nil), options.Algorithms...),
+		leeway:           leeway,
+		requiredClaims:   append([]string(nil), options.RequiredClaims...),
+		audiencePatterns: compileAudiencePatterns(options.AudiencePatterns),
+		jsonCodec:        options.JSONCodec,
+		policy:           options.Policy,
 	}
-	return &JWTValidator{config, extractor}
 }
 
 // ValidateRequest validates the token within
 // the http request.
 func (v *JWTValidator) ValidateRequest(r *http.Request) (*jwt.JSONWebToken, error) {
+	return v.ValidateRequestWithClaims(r)
+}
+
+// ValidateRequestWithClaims validates the token within the http request and
+// unmarshalls its claims into values. The token is extracted and verified
+// once, where ValidateRequest followed by Claims verify it twice.
+func (v *JWTValidator) ValidateRequestWithClaims(r *http.Request, values ...interface{}) (*jwt.JSONWebToken, error) {
+	if !v.observed() {
+		return v.validateRequest(r, values...)
+	}
+	var claims observedClaims
+	if v.onSuccess != nil || v.auditSink != nil {
+		values = append([]interface{}{&claims}, values...)
+	}
+	start := time.Now()
+	token, err := v.tracedValidateRequest(r, values...)
+	if v.metrics != nil {
+		v.metrics.ObserveValidation(err, time.Since(start))
+	}
+	if err == nil {
+		if v.onSuccess != nil {
+			v.onSuccess(claims.Claims)
+		}
+		if v.auditSink != nil {
+			v.audit(r, claims, nil)
+		}
+		return token, nil
+	}
+	if v.logger != nil {
+		v.logRejection(r, err)
+	}
+	if v.onFailure != nil {
+		v.onFailure(err, ErrorReason(err))
+	}
+	if v.auditSink != nil {
+		v.audit(r, unverifiedClaims(v.extractor, r), err)
+	}
+	return token, err
+}
+
+// observed tells whether validations are measured, traced, logged, audited
+// or reported to hooks
+func (v *JWTValidator) observed() bool {
+	return v.metrics != nil || v.tracer != nil || v.logger != nil ||
+		v.onSuccess != nil || v.onFailure != nil || v.auditSink != nil
+}
+
+// logRejection logs the rejection of the token of the request,
+// with its redacted metadata in debug mode
+func (v *JWTValidator) logRejection(r *http.Request, err error) {
+	keysAndValues := []interface{}{"reason", ErrorReason(err), "error", err}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		keysAndValues = append(keysAndValues, "issuer", validationErr.Issuer, "audience", validationErr.Audience)
+		if !v.debugTokens {
+			// the redacted token holds the key ID otherwise
+			keysAndValues = append(keysAndValues, "kid", validationErr.KeyID)
+		}
+	}
+	if v.debugTokens {
+		// the metadata decoded before the token turned out malformed
+		redacted, _ := redactRequestToken(v.extractor, r)
+		keysAndValues = append(keysAndValues, redacted.logValues()...)
+	}
+	v.logger.Debug("auth0: token rejected", keysAndValues...)
+}
+
+// tracedValidateRequest validates the token within the http request
+// within a span
+func (v *JWTValidator) tracedValidateRequest(r *http.Request, values ...interface{}) (*jwt.JSONWebToken, error) {
+	if v.tracer == nil {
+		return v.validateRequest(r, values...)
+	}
+	ctx, span := v.tracer.Start(r.Context(), SpanValidateRequest)
+	span.SetAttribute(AttributeIssuer, v.config.expectedClaims.Issuer)
+	token, err := v.validateRequest(r.WithContext(ctx), values...)
+	if token != nil && len(token.Headers) > 0 {
+		span.SetAttribute(AttributeKeyID, token.Headers[0].KeyID)
+		span.SetAttribute(AttributeAlgorithm, token.Headers[0].Algorithm)
+	}
+	endSpan(span, err)
+	return token, err
+}
+
+// validateRequest validates the token within the http request and
+// unmarshalls its claims into values
+func (v *JWTValidator) validateRequest(r *http.Request, values ...interface{}) (*jwt.JSONWebToken, error) {
+	var raw string
+	if v.results != nil {
+		var err error
+		raw, err = ExtractRaw(v.extractor, r)
+		if err != nil && err != ErrRawTokenUnsupported {
+			return nil, err
+		}
+		if token, ok := v.results.get(raw); ok {
+			// the token was verified when it was remembered
+			if len(values) == 0 {
+				return token, nil
+			}
+			return token, wrapJOSEError(v.unsafeClaims(token, values...))
+		}
+	}
+
 	token, err := v.extractor.Extract(r)
 	if err != nil {
 		return nil, err
@@ -91,26 +351,66 @@ func (v *JWTValidator) ValidateRequest(r *http.Request) (*jwt.JSONWebToken, erro
 	}
 
 	// trust secret provider when sig alg not configured and skip check
+	header := token.Headers[0]
+	claims := jwt.Claims{}
+	expected := v.config.expectedClaims.WithTime(v.clock.Now())
 	if v.config.signIn != "" {
-		header := token.Headers[0]
 		if header.Algorithm != string(v.config.signIn) {
-			return nil, ErrInvalidAlgorithm
+			validationErr := newValidationError(ErrInvalidAlgorithm, header, claims, expected)
+			validationErr.Expected = string(v.config.signIn)
+			return nil, validationErr
 		}
 	}
+	if len(v.algorithms) > 0 && !containsAlgorithm(v.algorithms, header.Algorithm) {
+		validationErr := newValidationError(ErrInvalidAlgorithm, header, claims, expected)
+		validationErr.Expected = append([]jose.SignatureAlgorithm(nil), v.algorithms...)
+		return nil, validationErr
+	}
 
-	claims := jwt.Claims{}
 	key, err := v.config.secretProvider.GetSecret(r)
 	if err != nil {
-		return nil, err
+		return nil, newValidationError(err, header, claims, expected)
 	}
 
-	if err = token.Claims(key, &claims); err != nil {
-		return nil, err
+	verify, err := v.verifier(r, token)
+	if err != nil {
+		return nil, newValidationError(err, header, claims, expected)
+	}
+	// the claims present in the token, decoded only to check the required
+	// ones, and the claims evaluated by the policy
+	var present map[string]json.RawMessage
+	var policyClaims map[string]interface{}
+	decoded := []interface{}{&claims}
+	if len(v.requiredClaims) > 0 {
+		decoded = append(decoded, &present)
+	}
+	if v.policy != nil {
+		decoded = append(decoded, &policyClaims)
+	}
+	if err = verifiedClaims(verify, key, append(decoded, values...)...); err != nil {
+		return nil, newValidationError(wrapJOSEError(err), header, claims, expected)
 	}
 
-	expected := v.config.expectedClaims.WithTime(time.Now())
-	err = claims.Validate(expected)
-	return token, err
+	if err = claims.ValidateWithLeeway(expected, v.leeway); err != nil {
+		return token, newValidationError(wrapJOSEError(err), header, claims, expected)
+	}
+	if len(v.audiencePatterns) > 0 && !matchAudience(v.audiencePatterns, claims.Audience) {
+		validationErr := newValidationError(ErrInvalidAudience, header, claims, expected)
+		validationErr.Expected = audiencePatternStrings(v.audiencePatterns)
+		return token, validationErr
+	}
+	for _, name := range v.requiredClaims {
+		if value, ok := present[name]; !ok || string(value) == "null" {
+			validationErr := newValidationError(ErrMissingClaim, header, claims, expected)
+			validationErr.Claim = name
+			return token, validationErr
+		}
+	}
+	if v.policy != nil && !v.policy.Evaluate(policyClaims) {
+		return token, newValidationError(ErrPolicyDenied, header, claims, expected)
+	}
+	v.results.add(raw, token, claims.Expiry)
+	return token, nil
 }
 
 // Claims unmarshall the claims of the provided token
@@ -119,5 +419,52 @@ func (v *JWTValidator) Claims(r *http.Request, token *jwt.JSONWebToken, values .
 	if err != nil {
 		return err
 	}
-	return token.Claims(key, values...)
+	verify, err := v.verifier(r, token)
+	if err != nil {
+		return err
+	}
+	return wrapJOSEError(verifiedClaims(verify, key, values...))
+}
+
+// unsafeClaims unmarshalls the claims of the token without verifying its
+// signature, with the JSON codec when set
+func (v *JWTValidator) unsafeClaims(token *jwt.JSONWebToken, values ...interface{}) error {
+	if v.jsonCodec == nil {
+		return token.UnsafeClaimsWithoutVerification(values...)
+	}
+	var payload json.RawMessage
+	if err := token.UnsafeClaimsWithoutVerification(&payload); err != nil {
+		return err
+	}
+	return unmarshalClaims(v.jsonCodec, payload, values...)
+}
+
+// containsAlgorithm tells whether algorithm is one of algorithms
+func containsAlgorithm(algorithms []jose.SignatureAlgorithm, algorithm string) bool {
+	for _, allowed := range algorithms {
+		if string(allowed) == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// verifiedClaims unmarshalls the claims of the token once its signature is
+// verified with key, or with the first valid key of RotatedKeys or of
+// chained providers
+func verifiedClaims(verify claimsVerifier, key interface{}, values ...interface{}) error {
+	if chained, ok := key.(chainedKeys); ok {
+		return chained.verifiedClaims(verify, values...)
+	}
+	keys, ok := key.(RotatedKeys)
+	if !ok {
+		return verify(key, values...)
+	}
+	err := ErrNoKeyFound
+	for _, key := range keys {
+		if err = verify(key, values...); err == nil {
+			return nil
+		}
+	}
+	return err
 }
@@ -4,11 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
 )
 
 func genTestConfiguration(configuration Configuration, token string) (*JWTValidator, *http.Request) {
@@ -21,8 +22,10 @@ func genTestConfiguration(configuration Configuration, token string) (*JWTValida
 	return validator, req
 }
 
+var errInvalidProvider = errors.New("invalid secret provider")
+
 func invalidProvider(req *http.Request) (interface{}, error) {
-	return nil, errors.New("invalid secret provider")
+	return nil, errInvalidProvider
 }
 
 func TestValidateRequestAndClaims(t *testing.T) {
@@ -33,7 +36,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 		// token attr
 		token string
 		// test result
-		expectedErrorMsg string
+		expectedErr error
 	}{
 		{
 			name: "pass - token HS256",
@@ -50,7 +53,6 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				defaultSecret,
 			),
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - token ES384",
@@ -67,7 +69,6 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.ES384,
 				defaultSecretES384,
 			),
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - token, config empty iss, aud",
@@ -84,7 +85,6 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				defaultSecret,
 			),
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - token HS256 config no enforce sig alg",
@@ -100,7 +100,6 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				defaultSecret,
 			),
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - token ES384 config no enforce sig alg",
@@ -116,7 +115,6 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.ES384,
 				defaultSecretES384,
 			),
-			expectedErrorMsg: "",
 		},
 		{
 			name: "fail - config no enforce sig alg but invalid token alg",
@@ -132,7 +130,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.RS256,
 				defaultSecretRS256,
 			),
-			expectedErrorMsg: "error in cryptographic primitive",
+			expectedErr: ErrInvalidSignature,
 		},
 		{
 			name: "fail - invalid config secret provider",
@@ -149,7 +147,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				defaultSecret,
 			),
-			expectedErrorMsg: "invalid secret provider",
+			expectedErr: errInvalidProvider,
 		},
 		{
 			name: "fail - invalid token aud",
@@ -166,7 +164,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				defaultSecret,
 			),
-			expectedErrorMsg: "invalid audience claim (aud)",
+			expectedErr: ErrInvalidAudience,
 		},
 		{
 			name: "fail - invalid token iss",
@@ -183,7 +181,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				defaultSecret,
 			),
-			expectedErrorMsg: "invalid issuer claim (iss)",
+			expectedErr: ErrInvalidIssuer,
 		},
 		{
 			name: "fail - invalid token expiry",
@@ -200,7 +198,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				defaultSecret,
 			),
-			expectedErrorMsg: "token is expired (exp)",
+			expectedErr: ErrTokenExpired,
 		},
 		{
 			name: "fail - invalid token alg",
@@ -217,7 +215,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS384,
 				defaultSecret,
 			),
-			expectedErrorMsg: "algorithm is invalid",
+			expectedErr: ErrInvalidAlgorithm,
 		},
 		{
 			name: "fail - invalid token secret",
@@ -234,7 +232,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.HS256,
 				[]byte("invalid secret"),
 			),
-			expectedErrorMsg: "error in cryptographic primitive",
+			expectedErr: ErrInvalidSignature,
 		},
 	}
 
@@ -244,11 +242,9 @@ func TestValidateRequestAndClaims(t *testing.T) {
 
 			jwt, err := validator.ValidateRequest(req)
 
-			if test.expectedErrorMsg != "" {
-				if err == nil {
-					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg)
-				} else if !strings.Contains(err.Error(), test.expectedErrorMsg) {
-					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg + ", but got: " + err.Error())
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Errorf("Validation should have failed with error %v, but got: %v", test.expectedErr, err)
 				}
 			} else {
 				if err != nil {
@@ -265,3 +261,191 @@ func TestValidateRequestAndClaims(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRequestWithClaims(t *testing.T) {
+	verifications := 0
+	backend := JOSEBackendFunc(func(token string, key interface{}, values ...interface{}) error {
+		verifications++
+		return GoJoseV2Backend{}.VerifyClaims(token, key, values...)
+	})
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithBackend(configuration, nil, backend)
+	_, req := genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+
+	claims := map[string]interface{}{}
+	_, err := validator.ValidateRequestWithClaims(req, &claims)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultIssuer, claims["iss"])
+	assert.Equal(t, 1, verifications)
+
+	_, req = genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret))
+	_, err = validator.ValidateRequestWithClaims(req, &claims)
+	assert.Error(t, err)
+}
+
+func TestConfigurationAudienceCopied(t *testing.T) {
+	audience := []string{"audience"}
+	configuration := NewConfiguration(defaultSecretProvider, audience, defaultIssuer, jose.HS256)
+	trustProvider := NewConfigurationTrustProvider(defaultSecretProvider, audience, defaultIssuer)
+	audience[0] = "changed"
+	assert.Equal(t, []string{"audience"}, []string(configuration.expectedClaims.Audience))
+	assert.Equal(t, []string{"audience"}, []string(trustProvider.expectedClaims.Audience))
+}
+
+func TestValidationHooks(t *testing.T) {
+	var successes []string
+	var failures []string
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{
+		ResultCacheTTL: time.Minute,
+		OnValidationSuccess: func(claims jwt.Claims) {
+			successes = append(successes, claims.Issuer)
+		},
+		OnValidationFailure: func(err error, reason string) {
+			failures = append(failures, reason)
+		},
+	})
+
+	valid := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	expired := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret)
+	for _, token := range []string{valid, valid, expired} {
+		_, req := genTestConfiguration(configuration, token)
+		claims := map[string]interface{}{}
+		_, err := validator.ValidateRequestWithClaims(req, &claims)
+		if err == nil {
+			assert.Equal(t, defaultIssuer, claims["iss"])
+		}
+	}
+	// the second validation is served by the result cache
+	assert.Equal(t, []string{defaultIssuer, defaultIssuer}, successes)
+	assert.Equal(t, []string{"expired"}, failures)
+}
+
+func BenchmarkValidateRequest(b *testing.B) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator, req := genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+
+	b.Run("ValidateRequest and Claims", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			claims := map[string]interface{}{}
+			token, err := validator.ValidateRequest(req)
+			if err == nil {
+				err = validator.Claims(req, token, &claims)
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ValidateRequestWithClaims", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			claims := map[string]interface{}{}
+			if _, err := validator.ValidateRequestWithClaims(req, &claims); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestValidatorAllowedAlgorithms(t *testing.T) {
+	validator, err := NewValidatorBuilder(defaultSecretProvider).
+		WithAudience(defaultAudience...).
+		WithIssuer(defaultIssuer).
+		WithAllowedAlgorithms(jose.HS256, jose.HS384).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, alg := range []jose.SignatureAlgorithm{jose.HS256, jose.HS384} {
+		token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), alg, defaultSecret)
+		_, err := validator.ValidateRequest(bearerRequest(token))
+		assert.NoError(t, err, alg)
+	}
+
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS512, defaultSecret)
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	assert.ErrorIs(t, err, ErrInvalidAlgorithm)
+	var validationErr *ValidationError
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, "alg", validationErr.Claim)
+		assert.Equal(t, "HS512", validationErr.Value)
+		assert.Equal(t, []jose.SignatureAlgorithm{jose.HS256, jose.HS384}, validationErr.Expected)
+	}
+}
+
+func TestValidatorLeeway(t *testing.T) {
+	tests := []struct {
+		name    string
+		leeway  time.Duration
+		expiry  time.Duration
+		wantErr error
+	}{
+		{name: "default leeway", expiry: -30 * time.Second},
+		{name: "default leeway exceeded", expiry: -2 * time.Minute, wantErr: ErrTokenExpired},
+		{name: "custom leeway", leeway: 5 * time.Minute, expiry: -2 * time.Minute},
+		{name: "no leeway", leeway: -1, expiry: -30 * time.Second, wantErr: ErrTokenExpired},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+			validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{Leeway: test.leeway})
+			token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(test.expiry), jose.HS256, defaultSecret)
+			_, err := validator.ValidateRequest(bearerRequest(token))
+			if test.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorRequiredClaims(t *testing.T) {
+	validator, err := NewValidatorBuilder(defaultSecretProvider).
+		WithAudience(defaultAudience...).
+		WithIssuer(defaultIssuer).
+		WithRequiredClaims("sub", "https://example.com/org_id").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(claims map[string]interface{}) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+		token, err := jwt.Signed(signer).Claims(registered).Claims(claims).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	var custom struct {
+		OrgID string `json:"https://example.com/org_id"`
+	}
+	_, err = validator.ValidateRequestWithClaims(bearerRequest(sign(map[string]interface{}{
+		"sub":                        "user",
+		"https://example.com/org_id": "org_123",
+	})), &custom)
+	assert.NoError(t, err)
+	assert.Equal(t, "org_123", custom.OrgID)
+
+	for name, claims := range map[string]map[string]interface{}{
+		"https://example.com/org_id": {"sub": "user"},
+		"sub":                        {"sub": nil, "https://example.com/org_id": "org_123"},
+	} {
+		_, err = validator.ValidateRequest(bearerRequest(sign(claims)))
+		assert.ErrorIs(t, err, ErrMissingClaim)
+		assert.Equal(t, "missing_claim", ErrorReason(err))
+		var validationErr *ValidationError
+		if assert.True(t, errors.As(err, &validationErr)) {
+			assert.Equal(t, ReasonMissingClaim, validationErr.Reason)
+			assert.Equal(t, name, validationErr.Claim)
+		}
+	}
+}
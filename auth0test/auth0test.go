@@ -0,0 +1,279 @@
+// Package auth0test provides the infrastructure to test code validating
+// tokens with go-auth0: an in-memory JWKS server serving generated signing
+// keys, tokens signed with these keys, and validators wired to the server.
+//
+//	server, err := auth0test.NewServer(auth0test.Options{})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer server.Close()
+//
+//	token, err := server.Token(map[string]interface{}{"scope": "read:users"})
+//	...
+//	handler := middleware(server.Validator())
+//	handler.ServeHTTP(w, auth0test.Request(token))
+package auth0test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	// DefaultIssuer is the issuer of the tokens when no issuer is configured
+	DefaultIssuer = "https://auth0test.local/"
+	// DefaultAudience is the audience of the tokens when no audience is
+	// configured
+	DefaultAudience = "https://api.auth0test.local/"
+	// DefaultTokenTTL is the time the tokens are valid for when no TTL is
+	// configured
+	DefaultTokenTTL = time.Hour
+	// JWKSPath is the path of the JWKS served by the server
+	JWKSPath = "/.well-known/jwks.json"
+)
+
+var (
+	// ErrUnsupportedAlgorithm is returned when generating a key for an
+	// algorithm other than the RSA, ECDSA and EdDSA signature algorithms.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+	// ErrKeyNotFound is returned when signing a token with a key the server
+	// does not serve.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// NewKey generates a signing key of ID kid for the algorithm: a 2048 bits
+// RSA key for RS* and PS*, an ECDSA key on the matching curve for ES*, and
+// an Ed25519 key for EdDSA.
+func NewKey(alg jose.SignatureAlgorithm, kid string) (jose.JSONWebKey, error) {
+	var key interface{}
+	var err error
+	switch alg {
+	case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jose.ES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jose.ES384:
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case jose.ES512:
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case jose.EdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return jose.JSONWebKey{}, ErrUnsupportedAlgorithm
+	}
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+	return jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(alg), Use: "sig"}, nil
+}
+
+// Sign returns a token of the claims, e.g. jwt.Claims, structs or maps,
+// signed with the key. The token has the key ID of the key in its header.
+// The claims are merged in order, so that later claims override earlier
+// ones.
+func Sign(key jose.JSONWebKey, claims ...interface{}) (string, error) {
+	options := (&jose.SignerOptions{}).WithType("JWT")
+	if key.KeyID != "" {
+		options = options.WithHeader("kid", key.KeyID)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(key.Algorithm), Key: key}, options)
+	if err != nil {
+		return "", err
+	}
+	builder := jwt.Signed(signer)
+	for _, c := range claims {
+		builder = builder.Claims(c)
+	}
+	return builder.CompactSerialize()
+}
+
+// Options configures the server.
+type Options struct {
+	// Issuer is the issuer of the tokens. Defaults to DefaultIssuer.
+	Issuer string
+	// Audience is the audience of the tokens.
+	// Defaults to DefaultAudience.
+	Audience []string
+	// Algorithms are the algorithms of the signing keys generated by the
+	// server, one key per algorithm with the algorithm as key ID, e.g.
+	// "RS256". Defaults to RS256.
+	Algorithms []jose.SignatureAlgorithm
+	// TokenTTL is the time the tokens are valid for.
+	// Defaults to DefaultTokenTTL.
+	TokenTTL time.Duration
+}
+
+// Server is a JWKS server serving the public keys of its signing keys, and
+// signing tokens with them. It is safe for concurrent use.
+type Server struct {
+	options Options
+	server  *httptest.Server
+
+	mu   sync.RWMutex
+	keys []jose.JSONWebKey
+}
+
+// NewServer generates the signing keys and starts the server, which is
+// closed with Close.
+func NewServer(options Options) (*Server, error) {
+	if options.Issuer == "" {
+		options.Issuer = DefaultIssuer
+	}
+	if len(options.Audience) == 0 {
+		options.Audience = []string{DefaultAudience}
+	}
+	if len(options.Algorithms) == 0 {
+		options.Algorithms = []jose.SignatureAlgorithm{jose.RS256}
+	}
+	if options.TokenTTL <= 0 {
+		options.TokenTTL = DefaultTokenTTL
+	}
+	s := &Server{options: options}
+	for _, alg := range options.Algorithms {
+		key, err := NewKey(alg, string(alg))
+		if err != nil {
+			return nil, err
+		}
+		s.keys = append(s.keys, key)
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveJWKS))
+	return s, nil
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URI returns the URI of the JWKS.
+func (s *Server) URI() string {
+	return s.server.URL + JWKSPath
+}
+
+// Issuer returns the issuer of the tokens.
+func (s *Server) Issuer() string {
+	return s.options.Issuer
+}
+
+// Audience returns the audience of the tokens.
+func (s *Server) Audience() []string {
+	return append([]string(nil), s.options.Audience...)
+}
+
+// Keys returns the signing keys, in the order of the Algorithms followed by
+// the added keys.
+func (s *Server) Keys() []jose.JSONWebKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]jose.JSONWebKey(nil), s.keys...)
+}
+
+// AddKey adds a signing key, e.g. to test key rotations. The server serves
+// its public key from then on, replacing the key of the same ID.
+func (s *Server) AddKey(key jose.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.keys {
+		if existing.KeyID == key.KeyID {
+			s.keys[i] = key
+			return
+		}
+	}
+	s.keys = append(s.keys, key)
+}
+
+// RemoveKey stops serving the key of ID kid, e.g. to test revoked keys.
+func (s *Server) RemoveKey(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, key := range s.keys {
+		if key.KeyID == kid {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// Token returns a token signed with the first signing key. See
+// TokenWithKey.
+func (s *Server) Token(claims ...interface{}) (string, error) {
+	keys := s.Keys()
+	if len(keys) == 0 {
+		return "", ErrKeyNotFound
+	}
+	return s.sign(keys[0], claims...)
+}
+
+// TokenWithKey returns a token signed with the signing key of ID kid, or
+// ErrKeyNotFound. The token is issued for the issuer and audience of the
+// server, now, and expires after the TokenTTL. The claims, e.g. custom
+// claims or an expired jwt.Claims, override these claims.
+func (s *Server) TokenWithKey(kid string, claims ...interface{}) (string, error) {
+	for _, key := range s.Keys() {
+		if key.KeyID == kid {
+			return s.sign(key, claims...)
+		}
+	}
+	return "", ErrKeyNotFound
+}
+
+// sign returns a token of the default claims and claims signed with key
+func (s *Server) sign(key jose.JSONWebKey, claims ...interface{}) (string, error) {
+	now := time.Now()
+	defaults := jwt.Claims{
+		Issuer:   s.options.Issuer,
+		Audience: jwt.Audience(s.options.Audience),
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(s.options.TokenTTL)),
+	}
+	return Sign(key, append([]interface{}{defaults}, claims...)...)
+}
+
+// Configuration returns the configuration of a validator downloading the
+// keys from the server and expecting its issuer and audience. The algorithm
+// is not checked, since the server may sign with several algorithms.
+func (s *Server) Configuration() auth0.Configuration {
+	client := auth0.NewJWKClient(auth0.JWKClientOptions{URI: s.URI()}, nil)
+	return auth0.NewConfigurationTrustProvider(client, s.options.Audience, s.options.Issuer)
+}
+
+// Validator returns a validator of the Configuration extracting the tokens
+// from the Authorization header.
+func (s *Server) Validator() *auth0.JWTValidator {
+	return auth0.NewValidator(s.Configuration(), nil)
+}
+
+// Request returns a GET request bearing the token in its Authorization
+// header.
+func Request(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+// serveJWKS serves the public keys of the signing keys
+func (s *Server) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != JWKSPath {
+		http.NotFound(w, r)
+		return
+	}
+	jwks := auth0.JWKS{Keys: []jose.JSONWebKey{}}
+	for _, key := range s.Keys() {
+		jwks.Keys = append(jwks.Keys, key.Public())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(jwks)
+}
@@ -0,0 +1,87 @@
+package auth0test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestServer(t *testing.T) {
+	server, err := NewServer(Options{Algorithms: []jose.SignatureAlgorithm{jose.RS256, jose.ES384, jose.EdDSA}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	validator := server.Validator()
+
+	for _, alg := range []jose.SignatureAlgorithm{jose.RS256, jose.ES384, jose.EdDSA} {
+		t.Run(string(alg), func(t *testing.T) {
+			token, err := server.TokenWithKey(string(alg), map[string]interface{}{"scope": "read:users"})
+			assert.NoError(t, err)
+
+			claims := struct {
+				jwt.Claims
+				Scope string `json:"scope"`
+			}{}
+			_, err = validator.ValidateRequestWithClaims(Request(token), &claims)
+			assert.NoError(t, err)
+			assert.Equal(t, DefaultIssuer, claims.Issuer)
+			assert.Equal(t, jwt.Audience{DefaultAudience}, claims.Audience)
+			assert.Equal(t, "read:users", claims.Scope)
+		})
+	}
+}
+
+func TestServerTokenClaimsOverride(t *testing.T) {
+	server, err := NewServer(Options{Issuer: "https://tenant/", Audience: []string{"api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	token, err := server.Token(jwt.Claims{Expiry: jwt.NewNumericDate(time.Now().Add(-time.Minute))})
+	assert.NoError(t, err)
+	_, err = server.Validator().ValidateRequest(Request(token))
+	assert.ErrorIs(t, err, auth0.ErrTokenExpired)
+
+	_, err = server.TokenWithKey("unknown")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestServerKeyRotation(t *testing.T) {
+	server, err := NewServer(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	key, err := NewKey(jose.PS256, "rotated")
+	assert.NoError(t, err)
+	server.AddKey(key)
+	assert.Len(t, server.Keys(), 2)
+	token, err := server.TokenWithKey("rotated")
+	assert.NoError(t, err)
+	_, err = server.Validator().ValidateRequest(Request(token))
+	assert.NoError(t, err)
+
+	server.RemoveKey("rotated")
+	assert.Len(t, server.Keys(), 1)
+	_, err = server.Validator().ValidateRequest(Request(token))
+	assert.True(t, errors.Is(err, auth0.ErrNoKeyFound), err)
+}
+
+func TestNewKey(t *testing.T) {
+	_, err := NewKey(jose.HS256, "hmac")
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+
+	key, err := NewKey(jose.ES512, "kid")
+	assert.NoError(t, err)
+	assert.Equal(t, "kid", key.KeyID)
+	assert.Equal(t, "ES512", key.Algorithm)
+	assert.False(t, key.IsPublic())
+}
@@ -0,0 +1,24 @@
+package auth0test
+
+import (
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+)
+
+// Clock is an auth0.Clock whose time only moves when told to, with its
+// Advance and Set methods, so that the expiry of tokens and keys can be
+// tested without sleeping:
+//
+//	clock := auth0test.NewClock(time.Now())
+//	validator := auth0.NewValidatorWithOptions(configuration, nil, auth0.ValidatorOptions{Clock: clock})
+//	...
+//	clock.Advance(2 * time.Hour) // the tokens are now expired
+//
+// It is safe for concurrent use.
+type Clock = clocktest.Clock
+
+// NewClock creates a clock telling now.
+func NewClock(now time.Time) *Clock {
+	return clocktest.NewClock(now)
+}
@@ -0,0 +1,38 @@
+package auth0test
+
+import (
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(now)
+	assert.Equal(t, now, clock.Now())
+	clock.Advance(time.Hour)
+	assert.Equal(t, now.Add(time.Hour), clock.Now())
+	clock.Set(now)
+	assert.Equal(t, now, clock.Now())
+}
+
+func TestClockValidator(t *testing.T) {
+	server, err := NewServer(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	clock := NewClock(time.Now())
+	validator := auth0.NewValidatorWithOptions(server.Configuration(), nil, auth0.ValidatorOptions{Clock: clock})
+
+	token, err := server.Token()
+	assert.NoError(t, err)
+	_, err = validator.ValidateRequest(Request(token))
+	assert.NoError(t, err)
+
+	clock.Advance(DefaultTokenTTL + time.Minute)
+	_, err = validator.ValidateRequest(Request(token))
+	assert.ErrorIs(t, err, auth0.ErrTokenExpired)
+}
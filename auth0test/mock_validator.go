@@ -0,0 +1,105 @@
+package auth0test
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// mockKey signs the tokens of the mock validator, which are never verified
+var mockKey = jose.JSONWebKey{Key: []byte("auth0test mock validator key"), KeyID: "mock", Algorithm: string(jose.HS256)}
+
+// registeredClaims are the claim names of jwt.Claims
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// MockValidator is an auth0.Validator returning programmed claims or errors,
+// to test the handlers depending on a validator without keys nor tokens:
+//
+//	validator := &auth0test.MockValidator{Claims: map[string]interface{}{"sub": "user", "scope": "read:users"}}
+//	handler := auth0.NewMiddleware(validator, auth0.MiddlewareOptions{})(api)
+//
+// It is safe for concurrent use once configured.
+type MockValidator struct {
+	// Claims are the claims of the token of every request, e.g.
+	// jwt.Claims, structs or maps.
+	Claims interface{}
+	// Err, when set, is returned for every request instead of the claims.
+	Err error
+	// ValidateFunc, when set, returns the claims or the error of each
+	// request instead of Claims and Err.
+	ValidateFunc func(r *http.Request) (claims interface{}, err error)
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+// ValidateRequest implements auth0.Validator.
+func (m *MockValidator) ValidateRequest(r *http.Request) (*jwt.JSONWebToken, error) {
+	return m.ValidateRequestWithClaims(r)
+}
+
+// ValidateRequestWithClaims implements auth0.Validator. The programmed
+// claims are unmarshalled into values, and the returned token has them
+// as claims.
+func (m *MockValidator) ValidateRequestWithClaims(r *http.Request, values ...interface{}) (*jwt.JSONWebToken, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, r)
+	m.mu.Unlock()
+
+	claims, err := m.Claims, m.Err
+	if m.ValidateFunc != nil {
+		claims, err = m.ValidateFunc(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		claims = map[string]interface{}{}
+	}
+	raw, err := Sign(mockKey, claims)
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > 0 {
+		if err := token.UnsafeClaimsWithoutVerification(values...); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
+
+// Validate implements auth0.Validator.
+func (m *MockValidator) Validate(r *http.Request) (*auth0.ValidationResult, error) {
+	result := &auth0.ValidationResult{}
+	token, err := m.ValidateRequestWithClaims(r, &result.Claims, &result.CustomClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range registeredClaims {
+		delete(result.CustomClaims, name)
+	}
+	result.Token = token
+	result.KeyID = token.Headers[0].KeyID
+	result.Algorithm = token.Headers[0].Algorithm
+	if result.Claims.Expiry != 0 {
+		result.Expiry = result.Claims.Expiry.Time()
+		result.TTL = time.Until(result.Expiry)
+	}
+	return result, nil
+}
+
+// Requests returns the requests validated so far.
+func (m *MockValidator) Requests() []*http.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*http.Request(nil), m.requests...)
+}
@@ -0,0 +1,81 @@
+package auth0test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestMockValidator(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	var validator auth0.Validator = &MockValidator{
+		Claims: map[string]interface{}{"sub": "user", "exp": expiry.Unix(), "scope": "read:users"},
+	}
+
+	claims := struct {
+		jwt.Claims
+		Scope string `json:"scope"`
+	}{}
+	token, err := validator.ValidateRequestWithClaims(Request("any"), &claims)
+	assert.NoError(t, err)
+	assert.NotNil(t, token)
+	assert.Equal(t, "user", claims.Subject)
+	assert.Equal(t, "read:users", claims.Scope)
+
+	result, err := validator.Validate(Request("any"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user", result.Claims.Subject)
+	assert.Equal(t, expiry, result.Expiry)
+	assert.Equal(t, []string{"scope"}, keys(result.CustomClaims))
+	assert.Len(t, validator.(*MockValidator).Requests(), 2)
+}
+
+func TestMockValidatorErrors(t *testing.T) {
+	validator := &MockValidator{Err: auth0.ErrTokenExpired}
+	_, err := validator.ValidateRequest(Request("any"))
+	assert.Equal(t, auth0.ErrTokenExpired, err)
+
+	validator = &MockValidator{ValidateFunc: func(r *http.Request) (interface{}, error) {
+		if r.Header.Get("Authorization") == "" {
+			return nil, auth0.ErrTokenNotFound
+		}
+		return jwt.Claims{Subject: "user"}, nil
+	}}
+	_, err = validator.Validate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, errors.Is(err, auth0.ErrTokenNotFound))
+	result, err := validator.Validate(Request("any"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user", result.Claims.Subject)
+}
+
+func TestMockValidatorMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, _ := auth0.ResultFromContext(r.Context())
+		fmt.Fprint(w, result.Claims.Subject)
+	})
+
+	w := httptest.NewRecorder()
+	auth0.NewMiddleware(&MockValidator{Claims: jwt.Claims{Subject: "user"}}, auth0.MiddlewareOptions{})(next).ServeHTTP(w, Request("any"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user", w.Body.String())
+
+	w = httptest.NewRecorder()
+	auth0.NewMiddleware(&MockValidator{Err: auth0.ErrTokenExpired}, auth0.MiddlewareOptions{})(next).ServeHTTP(w, Request("any"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func keys(claims map[string]json.RawMessage) []string {
+	var names []string
+	for name := range claims {
+		names = append(names, name)
+	}
+	return names
+}
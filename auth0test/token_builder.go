@@ -0,0 +1,135 @@
+package auth0test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// TokenBuilder builds test tokens with arbitrary claims:
+//
+//	token, err := auth0test.NewToken().
+//		Issuer("https://tenant.auth0.com/").
+//		Audience("https://api").
+//		Claim("scope", "read:users").
+//		Claim("https://example.com/roles", []string{"admin"}).
+//		SignRS256(key, "kid")
+type TokenBuilder struct {
+	claims map[string]interface{}
+}
+
+// NewToken creates a builder of a token issued now and expiring after
+// DefaultTokenTTL.
+func NewToken() *TokenBuilder {
+	now := time.Now()
+	return &TokenBuilder{claims: map[string]interface{}{
+		"iat": jwt.NewNumericDate(now),
+		"exp": jwt.NewNumericDate(now.Add(DefaultTokenTTL)),
+	}}
+}
+
+// NewToken creates a builder of a token issued now for the issuer and
+// audience of the server, and expiring after its TokenTTL. It is signed with
+// a key of the server, e.g. Sign(server.Keys()[0]).
+func (s *Server) NewToken() *TokenBuilder {
+	return NewToken().
+		Issuer(s.options.Issuer).
+		Audience(s.options.Audience...).
+		ExpiresIn(s.options.TokenTTL)
+}
+
+// Issuer sets the iss claim.
+func (b *TokenBuilder) Issuer(issuer string) *TokenBuilder {
+	return b.Claim("iss", issuer)
+}
+
+// Subject sets the sub claim.
+func (b *TokenBuilder) Subject(subject string) *TokenBuilder {
+	return b.Claim("sub", subject)
+}
+
+// Audience sets the aud claim, a string for a single audience.
+func (b *TokenBuilder) Audience(audience ...string) *TokenBuilder {
+	return b.Claim("aud", jwt.Audience(audience))
+}
+
+// ID sets the jti claim.
+func (b *TokenBuilder) ID(id string) *TokenBuilder {
+	return b.Claim("jti", id)
+}
+
+// IssuedAt sets the iat claim.
+func (b *TokenBuilder) IssuedAt(issuedAt time.Time) *TokenBuilder {
+	return b.Claim("iat", jwt.NewNumericDate(issuedAt))
+}
+
+// NotBefore sets the nbf claim.
+func (b *TokenBuilder) NotBefore(notBefore time.Time) *TokenBuilder {
+	return b.Claim("nbf", jwt.NewNumericDate(notBefore))
+}
+
+// Expiry sets the exp claim, e.g. in the past to build an expired token.
+func (b *TokenBuilder) Expiry(expiry time.Time) *TokenBuilder {
+	return b.Claim("exp", jwt.NewNumericDate(expiry))
+}
+
+// ExpiresIn sets the exp claim to ttl from now, e.g. a negative duration to
+// build an expired token.
+func (b *TokenBuilder) ExpiresIn(ttl time.Duration) *TokenBuilder {
+	return b.Expiry(time.Now().Add(ttl))
+}
+
+// Claim sets the claim of the name, e.g. a custom or namespaced claim. A nil
+// value removes the claim.
+func (b *TokenBuilder) Claim(name string, value interface{}) *TokenBuilder {
+	if value == nil {
+		delete(b.claims, name)
+		return b
+	}
+	b.claims[name] = value
+	return b
+}
+
+// Claims returns the claims of the token.
+func (b *TokenBuilder) Claims() map[string]interface{} {
+	claims := make(map[string]interface{}, len(b.claims))
+	for name, value := range b.claims {
+		claims[name] = value
+	}
+	return claims
+}
+
+// Sign returns the token signed with the key, with the key ID of the key in
+// its header. See Sign.
+func (b *TokenBuilder) Sign(key jose.JSONWebKey) (string, error) {
+	return Sign(key, b.claims)
+}
+
+// SignRS256 returns the token signed with the RSA key using RS256.
+func (b *TokenBuilder) SignRS256(key *rsa.PrivateKey, kid string) (string, error) {
+	return b.Sign(jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.RS256)})
+}
+
+// SignES256 returns the token signed with the P-256 ECDSA key using ES256.
+func (b *TokenBuilder) SignES256(key *ecdsa.PrivateKey, kid string) (string, error) {
+	return b.Sign(jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.ES256)})
+}
+
+// SignES384 returns the token signed with the P-384 ECDSA key using ES384.
+func (b *TokenBuilder) SignES384(key *ecdsa.PrivateKey, kid string) (string, error) {
+	return b.Sign(jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.ES384)})
+}
+
+// SignEdDSA returns the token signed with the Ed25519 key using EdDSA.
+func (b *TokenBuilder) SignEdDSA(key ed25519.PrivateKey, kid string) (string, error) {
+	return b.Sign(jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.EdDSA)})
+}
+
+// SignHS256 returns the token signed with the secret using HS256.
+func (b *TokenBuilder) SignHS256(secret []byte, kid string) (string, error) {
+	return b.Sign(jose.JSONWebKey{Key: secret, KeyID: kid, Algorithm: string(jose.HS256)})
+}
@@ -0,0 +1,92 @@
+package auth0test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestTokenBuilder(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPublicKey, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	secret := []byte("secret")
+
+	tests := []struct {
+		name      string
+		sign      func(b *TokenBuilder) (string, error)
+		algorithm jose.SignatureAlgorithm
+		key       interface{}
+	}{
+		{"RS256", func(b *TokenBuilder) (string, error) { return b.SignRS256(rsaKey, "kid") }, jose.RS256, &rsaKey.PublicKey},
+		{"ES384", func(b *TokenBuilder) (string, error) { return b.SignES384(ecKey, "kid") }, jose.ES384, &ecKey.PublicKey},
+		{"EdDSA", func(b *TokenBuilder) (string, error) { return b.SignEdDSA(edKey, "kid") }, jose.EdDSA, edPublicKey},
+		{"HS256", func(b *TokenBuilder) (string, error) { return b.SignHS256(secret, "kid") }, jose.HS256, secret},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := test.sign(NewToken().
+				Issuer("https://tenant/").
+				Audience("api").
+				Subject("user").
+				Claim("scope", "read:users").
+				Claim("https://example.com/roles", []string{"admin"}))
+			assert.NoError(t, err)
+
+			configuration := auth0.NewConfiguration(auth0.NewKeyProvider(test.key), []string{"api"}, "https://tenant/", test.algorithm)
+			claims := struct {
+				jwt.Claims
+				Scope string   `json:"scope"`
+				Roles []string `json:"https://example.com/roles"`
+			}{}
+			parsed, err := auth0.NewValidator(configuration, nil).ValidateRequestWithClaims(Request(token), &claims)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "kid", parsed.Headers[0].KeyID)
+			assert.Equal(t, "user", claims.Subject)
+			assert.Equal(t, "read:users", claims.Scope)
+			assert.Equal(t, []string{"admin"}, claims.Roles)
+			assert.WithinDuration(t, time.Now().Add(DefaultTokenTTL), claims.Expiry.Time(), time.Minute)
+		})
+	}
+}
+
+func TestTokenBuilderClaims(t *testing.T) {
+	expiry := time.Now().Add(-time.Hour)
+	builder := NewToken().ID("jti").Expiry(expiry).NotBefore(expiry).Claim("iat", nil)
+
+	claims := builder.Claims()
+	assert.Equal(t, "jti", claims["jti"])
+	assert.Equal(t, jwt.NewNumericDate(expiry), claims["exp"])
+	assert.Equal(t, jwt.NewNumericDate(expiry), claims["nbf"])
+	assert.NotContains(t, claims, "iat")
+}
+
+func TestServerNewToken(t *testing.T) {
+	server, err := NewServer(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	token, err := server.NewToken().Claim("scope", "read:users").Sign(server.Keys()[0])
+	assert.NoError(t, err)
+	_, err = server.Validator().ValidateRequest(Request(token))
+	assert.NoError(t, err)
+
+	token, err = server.NewToken().ExpiresIn(-time.Minute).Sign(server.Keys()[0])
+	assert.NoError(t, err)
+	_, err = server.Validator().ValidateRequest(Request(token))
+	assert.ErrorIs(t, err, auth0.ErrTokenExpired)
+}
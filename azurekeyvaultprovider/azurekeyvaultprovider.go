@@ -0,0 +1,186 @@
+// Package azurekeyvaultprovider provides secret providers serving the
+// verification keys stored in Azure Key Vault, either as the public key of a
+// Key Vault key or as a Key Vault secret.
+//
+// It lives in its own module so the core package does not depend on the
+// Azure SDK.
+package azurekeyvaultprovider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	auth0 "github.com/paulusrobin/go-auth0"
+)
+
+// DefaultTTL is the time the keys are cached for
+const DefaultTTL = 5 * time.Minute
+
+var (
+	// ErrUnsupportedKeyType is returned when the Key Vault key is neither an
+	// RSA nor an elliptic curve key.
+	ErrUnsupportedKeyType = errors.New("unsupported key vault key type")
+	// ErrEmptySecret is returned when the Key Vault secret is empty.
+	ErrEmptySecret = errors.New("key vault secret is empty")
+)
+
+// KeysClient reads Key Vault keys, implemented by *azkeys.Client.
+type KeysClient interface {
+	GetKey(ctx context.Context, name string, version string, options *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error)
+}
+
+// SecretsClient reads Key Vault secrets, implemented by *azsecrets.Client.
+type SecretsClient interface {
+	GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error)
+}
+
+// Options configures the Key Vault secret providers.
+type Options struct {
+	// Name is the name of the Key Vault key or secret.
+	Name string
+	// Version is the version of the key or secret.
+	// Defaults to the latest version.
+	Version string
+	// TTL is the time the key is cached for. Defaults to DefaultTTL.
+	TTL time.Duration
+	// RetryInterval is the time after which the key is refreshed again once
+	// refreshing it failed. Defaults to auth0.DefaultRefreshRetryInterval.
+	RetryInterval time.Duration
+	// OnRefreshError, when set, is called when the key cannot be refreshed.
+	// The previous key is served until it can be.
+	OnRefreshError func(err error)
+	// Clock tells the time the key expires at. Defaults to auth0.SystemClock.
+	Clock auth0.Clock
+}
+
+// Provider is a secret provider serving a key read from Key Vault, refreshed
+// once its TTL expires as described by auth0.RefreshingKeyProvider.
+type Provider struct {
+	options Options
+	key     *auth0.RefreshingKeyProvider
+}
+
+// NewKeyProvider creates a provider serving the public key of a Key Vault
+// key, verifying tokens signed with the sign operation of Key Vault. The
+// client credential, e.g. a managed identity, needs the get keys permission.
+// It fails when the key cannot be read.
+func NewKeyProvider(client KeysClient, options Options) (*Provider, error) {
+	return newProvider(options, func(ctx context.Context) (interface{}, error) {
+		resp, err := client.GetKey(ctx, options.Name, options.Version, nil)
+		if err != nil {
+			return nil, err
+		}
+		key, err := publicKey(resp.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key vault key %s: %w", options.Name, err)
+		}
+		return key, nil
+	})
+}
+
+// NewSecretProvider creates a provider serving a Key Vault secret, as a
+// public key when it holds a PEM encoded public key or certificate, and as an
+// HMAC secret otherwise. The client credential, e.g. a managed identity,
+// needs the get secrets permission. It fails when the secret cannot be read.
+func NewSecretProvider(client SecretsClient, options Options) (*Provider, error) {
+	return newProvider(options, func(ctx context.Context) (interface{}, error) {
+		resp, err := client.GetSecret(ctx, options.Name, options.Version, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Value == nil || *resp.Value == "" {
+			return nil, fmt.Errorf("%w: %s", ErrEmptySecret, options.Name)
+		}
+		value := *resp.Value
+		if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+			// certificates stored as PEM secrets hold the private key as
+			// well, only the certificate is used
+			key, err := auth0.ParsePEMPublicKey([]byte(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid key vault secret %s: %w", options.Name, err)
+			}
+			return key, nil
+		}
+		return []byte(value), nil
+	})
+}
+
+func newProvider(options Options, load func(ctx context.Context) (interface{}, error)) (*Provider, error) {
+	if options.Name == "" {
+		return nil, errors.New("azurekeyvaultprovider: Name must be set")
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+
+	p := &Provider{options: options}
+	p.key = auth0.NewRefreshingKeyProvider(func(ctx context.Context) (interface{}, time.Duration, error) {
+		key, err := load(ctx)
+		return key, options.TTL, err
+	}, auth0.RefreshingKeyProviderOptions{
+		RetryInterval:  options.RetryInterval,
+		OnRefreshError: options.OnRefreshError,
+		Clock:          options.Clock,
+	})
+	if err := p.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSecret returns the cached key, refreshing it first when expired.
+func (p *Provider) GetSecret(r *http.Request) (interface{}, error) {
+	return p.key.GetSecret(r)
+}
+
+// Refresh reads the key from Key Vault, replacing the cached key on success
+// and keeping it on failure.
+func (p *Provider) Refresh(ctx context.Context) error {
+	return p.key.Refresh(ctx)
+}
+
+// publicKey returns the public key of a Key Vault JSON Web Key
+func publicKey(key *azkeys.JSONWebKey) (interface{}, error) {
+	if key == nil || key.Kty == nil {
+		return nil, ErrUnsupportedKeyType
+	}
+	switch *key.Kty {
+	case azkeys.KeyTypeRSA, azkeys.KeyTypeRSAHSM:
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(key.N),
+			E: int(new(big.Int).SetBytes(key.E).Int64()),
+		}, nil
+	case azkeys.KeyTypeEC, azkeys.KeyTypeECHSM:
+		var crv azkeys.CurveName
+		if key.Crv != nil {
+			crv = *key.Crv
+		}
+		var curve elliptic.Curve
+		switch crv {
+		case azkeys.CurveNameP256:
+			curve = elliptic.P256()
+		case azkeys.CurveNameP384:
+			curve = elliptic.P384()
+		case azkeys.CurveNameP521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKeyType, crv)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(key.X),
+			Y:     new(big.Int).SetBytes(key.Y),
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, *key.Kty)
+}
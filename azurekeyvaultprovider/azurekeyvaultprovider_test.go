@@ -0,0 +1,186 @@
+package azurekeyvaultprovider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/paulusrobin/go-auth0/auth0test"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKeysClient serves the Key Vault keys by name, counting the reads
+type fakeKeysClient struct {
+	keys  map[string]*azkeys.JSONWebKey
+	reads int
+}
+
+func (c *fakeKeysClient) GetKey(_ context.Context, name string, _ string, _ *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error) {
+	c.reads++
+	key, ok := c.keys[name]
+	if !ok {
+		return azkeys.GetKeyResponse{}, errors.New("key not found")
+	}
+	return azkeys.GetKeyResponse{KeyBundle: azkeys.KeyBundle{Key: key}}, nil
+}
+
+// fakeSecretsClient serves the Key Vault secrets by name and version
+type fakeSecretsClient struct {
+	secrets map[string]string
+	err     error
+}
+
+func (c *fakeSecretsClient) GetSecret(_ context.Context, name string, version string, _ *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error) {
+	if c.err != nil {
+		return azsecrets.GetSecretResponse{}, c.err
+	}
+	value, ok := c.secrets[name+"/"+version]
+	if !ok {
+		return azsecrets.GetSecretResponse{}, errors.New("secret not found")
+	}
+	return azsecrets.GetSecretResponse{Secret: azsecrets.Secret{Value: &value}}, nil
+}
+
+func keyType(kty azkeys.KeyType) *azkeys.KeyType { return &kty }
+
+func curveName(crv azkeys.CurveName) *azkeys.CurveName { return &crv }
+
+func TestKeyProvider(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+
+	client := &fakeKeysClient{keys: map[string]*azkeys.JSONWebKey{
+		"rsa":     {Kty: keyType(azkeys.KeyTypeRSAHSM), N: rsaKey.N.Bytes(), E: big.NewInt(int64(rsaKey.E)).Bytes()},
+		"ec":      {Kty: keyType(azkeys.KeyTypeEC), Crv: curveName(azkeys.CurveNameP384), X: ecKey.X.Bytes(), Y: ecKey.Y.Bytes()},
+		"secp256": {Kty: keyType(azkeys.KeyTypeEC), Crv: curveName(azkeys.CurveNameP256K)},
+		"oct":     {Kty: keyType(azkeys.KeyTypeOct)},
+	}}
+
+	tests := []struct {
+		name        string
+		expectedKey interface{}
+		expectedErr error
+	}{
+		{name: "rsa", expectedKey: &rsaKey.PublicKey},
+		{name: "ec", expectedKey: &ecKey.PublicKey},
+		{name: "secp256", expectedErr: ErrUnsupportedKeyType},
+		{name: "oct", expectedErr: ErrUnsupportedKeyType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := NewKeyProvider(client, Options{Name: test.name})
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedKey, key)
+		})
+	}
+}
+
+func TestSecretProvider(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+
+	client := &fakeSecretsClient{secrets: map[string]string{
+		"hmac/":   "secret",
+		"hmac/v1": "old",
+		"pem/":    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		"empty/":  "",
+	}}
+
+	tests := []struct {
+		name        string
+		options     Options
+		expectedKey interface{}
+		expectedErr error
+	}{
+		{name: "pass - hmac secret", options: Options{Name: "hmac"}, expectedKey: []byte("secret")},
+		{name: "pass - version", options: Options{Name: "hmac", Version: "v1"}, expectedKey: []byte("old")},
+		{name: "pass - pem public key", options: Options{Name: "pem"}, expectedKey: &rsaKey.PublicKey},
+		{name: "fail - empty secret", options: Options{Name: "empty"}, expectedErr: ErrEmptySecret},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := NewSecretProvider(client, test.options)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedKey, key)
+		})
+	}
+}
+
+func TestProviderOptions(t *testing.T) {
+	_, err := NewSecretProvider(&fakeSecretsClient{}, Options{})
+	assert.Error(t, err)
+	_, err = NewKeyProvider(&fakeKeysClient{}, Options{})
+	assert.Error(t, err)
+}
+
+func TestProviderRefresh(t *testing.T) {
+	client := &fakeSecretsClient{secrets: map[string]string{"hmac/": "secret"}}
+	var refreshErr error
+	clock := auth0test.NewClock(time.Now())
+	provider, err := NewSecretProvider(client, Options{
+		Name:           "hmac",
+		TTL:            time.Hour,
+		RetryInterval:  time.Minute,
+		OnRefreshError: func(err error) { refreshErr = err },
+		Clock:          clock,
+	})
+	assert.NoError(t, err)
+
+	client.secrets["hmac/"] = "rotated"
+	key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), key, "the secret should be cached for the ttl")
+
+	clock.Advance(time.Hour)
+	key, err = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("rotated"), key)
+
+	client.err = errors.New("forbidden")
+	clock.Advance(time.Hour)
+	key, err = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err, "the previous secret should be served when it cannot be refreshed")
+	assert.Equal(t, []byte("rotated"), key)
+	assert.Equal(t, client.err, refreshErr)
+
+	client.err = nil
+	client.secrets["hmac/"] = "retried"
+	key, _ = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []byte("rotated"), key, "the refresh should not be retried before the retry interval")
+
+	clock.Advance(time.Minute)
+	key, _ = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []byte("retried"), key, "the refresh should be retried after the retry interval")
+}
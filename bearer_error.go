@@ -0,0 +1,146 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrInsufficientScope is returned, e.g. by authorization checks following
+// the validation, when the token lacks the scope required by the request.
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// Error codes of the Bearer authentication scheme, see RFC 6750.
+const (
+	BearerErrorInvalidRequest    = "invalid_request"
+	BearerErrorInvalidToken      = "invalid_token"
+	BearerErrorInsufficientScope = "insufficient_scope"
+)
+
+// BearerError is the response to a request failing the Bearer
+// authentication, see RFC 6750.
+type BearerError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the error code, BearerErrorInvalidRequest,
+	// BearerErrorInvalidToken or BearerErrorInsufficientScope. It is empty
+	// for requests without token, and for failures of the server.
+	Code string
+	// Description is a short description of the error for developers.
+	// It never discloses the token nor the configuration of the server.
+	Description string
+	// Realm, when set, is the protection space of the server.
+	Realm string
+	// Scope, when set, is the space separated scope required by the request.
+	Scope string
+}
+
+// bearerDescriptions are the descriptions of the failure reasons
+var bearerDescriptions = map[string]string{
+	"malformed_token":   "The access token is malformed",
+	"invalid_algorithm": "The access token is signed with an unexpected algorithm",
+	"invalid_signature": "The access token signature is invalid",
+	"expired":           "The access token expired",
+	"not_valid_yet":     "The access token is not valid yet",
+	"invalid_audience":  "The access token audience is invalid",
+	"invalid_issuer":    "The access token issuer is invalid",
+	"invalid_subject":   "The access token subject is invalid",
+	"invalid_token_id":  "The access token ID is invalid",
+	"invalid_claims":    "The access token claims are invalid",
+	"missing_claim":     "The access token lacks a required claim",
+	"key_not_found":     "The access token signing key is unknown",
+}
+
+// NewBearerError maps err, returned by the validation of the request, to its
+// response:
+//
+//   - 401 without error code when the request has no token
+//   - 400 invalid_request when the request is malformed
+//   - 401 invalid_token when the token is rejected
+//   - 403 insufficient_scope for ErrInsufficientScope and ErrPolicyDenied
+//   - 503 without error code when the keys cannot be downloaded, or the
+//     decision of the authorizer cannot be obtained
+func NewBearerError(err error) *BearerError {
+	reason := ErrorReason(err)
+	switch {
+	case errors.Is(err, ErrInsufficientScope):
+		return &BearerError{
+			StatusCode:  http.StatusForbidden,
+			Code:        BearerErrorInsufficientScope,
+			Description: "The access token has insufficient scope",
+		}
+	case errors.Is(err, ErrPolicyDenied):
+		return &BearerError{
+			StatusCode:  http.StatusForbidden,
+			Code:        BearerErrorInsufficientScope,
+			Description: "The access token is not authorized for the request",
+		}
+	case errors.Is(err, ErrMalformedHeader), errors.Is(err, ErrFormBodyTooLarge):
+		return &BearerError{
+			StatusCode:  http.StatusBadRequest,
+			Code:        BearerErrorInvalidRequest,
+			Description: "The authorization request is malformed",
+		}
+	case reason == "token_not_found":
+		return &BearerError{StatusCode: http.StatusUnauthorized}
+	case reason == "jwks_unavailable", reason == "jwks_status", reason == "invalid_jwks",
+		reason == "authorization_unavailable", reason == "timeout", reason == "canceled":
+		return &BearerError{StatusCode: http.StatusServiceUnavailable}
+	}
+	description, ok := bearerDescriptions[reason]
+	if !ok {
+		description = "The access token is invalid"
+	}
+	return &BearerError{
+		StatusCode:  http.StatusUnauthorized,
+		Code:        BearerErrorInvalidToken,
+		Description: description,
+	}
+}
+
+// Challenge returns the WWW-Authenticate header of the response, empty for
+// failures of the server.
+func (e *BearerError) Challenge() string {
+	if e.StatusCode >= http.StatusInternalServerError {
+		return ""
+	}
+	var params []string
+	for _, param := range []struct{ name, value string }{
+		{"realm", e.Realm},
+		{"scope", e.Scope},
+		{"error", e.Code},
+		{"error_description", e.Description},
+	} {
+		if param.value != "" {
+			params = append(params, param.name+"="+quote(param.value))
+		}
+	}
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// Write writes the response, the WWW-Authenticate header and the description
+// as plain text body.
+func (e *BearerError) Write(w http.ResponseWriter) {
+	if challenge := e.Challenge(); challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	body := e.Description
+	if body == "" {
+		body = http.StatusText(e.StatusCode)
+	}
+	http.Error(w, body, e.StatusCode)
+}
+
+// WriteBearerError writes the response to the request failing the validation
+// with err. See NewBearerError.
+func WriteBearerError(w http.ResponseWriter, err error) {
+	NewBearerError(err).Write(w)
+}
+
+// quote returns the quoted-string of value, escaping quotes and backslashes
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,68 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestNewBearerError(t *testing.T) {
+	tests := []struct {
+		err               error
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{ErrTokenNotFound, http.StatusUnauthorized, `Bearer`},
+		{ErrMalformedHeader, http.StatusBadRequest, `Bearer error="invalid_request", error_description="The authorization request is malformed"`},
+		{ErrMalformedToken, http.StatusUnauthorized, `Bearer error="invalid_token", error_description="The access token is malformed"`},
+		{wrapJOSEError(jose.ErrCryptoFailure), http.StatusUnauthorized, `Bearer error="invalid_token", error_description="The access token signature is invalid"`},
+		{ErrTokenExpired, http.StatusUnauthorized, `Bearer error="invalid_token", error_description="The access token expired"`},
+		{fmt.Errorf("wrapped: %w", ErrNoKeyFound), http.StatusUnauthorized, `Bearer error="invalid_token", error_description="The access token signing key is unknown"`},
+		{errors.New("unknown"), http.StatusUnauthorized, `Bearer error="invalid_token", error_description="The access token is invalid"`},
+		{ErrInsufficientScope, http.StatusForbidden, `Bearer error="insufficient_scope", error_description="The access token has insufficient scope"`},
+		{ErrCircuitOpen, http.StatusServiceUnavailable, ""},
+		{&StatusCodeError{StatusCode: http.StatusBadGateway}, http.StatusServiceUnavailable, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			bearerErr := NewBearerError(test.err)
+			assert.Equal(t, test.expectedStatus, bearerErr.StatusCode)
+			assert.Equal(t, test.expectedChallenge, bearerErr.Challenge())
+		})
+	}
+}
+
+func TestBearerErrorChallenge(t *testing.T) {
+	bearerErr := &BearerError{
+		StatusCode:  http.StatusForbidden,
+		Code:        BearerErrorInsufficientScope,
+		Description: `quoted "description" \ escaped`,
+		Realm:       "api",
+		Scope:       "read:users write:users",
+	}
+	assert.Equal(t, `Bearer realm="api", scope="read:users write:users", error="insufficient_scope", error_description="quoted \"description\" \\ escaped"`, bearerErr.Challenge())
+}
+
+func TestWriteBearerError(t *testing.T) {
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret)
+	validator, req := genTestConfiguration(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), token)
+	_, err := validator.ValidateRequest(req)
+
+	w := httptest.NewRecorder()
+	WriteBearerError(w, err)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token", error_description="The access token expired"`, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, "The access token expired\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteBearerError(w, ErrCircuitOpen)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, "Service Unavailable\n", w.Body.String())
+}
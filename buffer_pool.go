@@ -0,0 +1,33 @@
+package auth0
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize bounds the buffers kept in the pool, so that a single
+// large JWKS does not pin its memory
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool recycles the buffers holding downloaded JWKS and decoded token
+// segments, reducing the garbage produced under load
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool, unless it grew too large. Nothing may
+// reference the content of buf once returned.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,20 @@
+package auth0
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutBuffer(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("pooled")
+	putBuffer(buf)
+	assert.Equal(t, 0, buf.Len())
+
+	large := bytes.NewBuffer(make([]byte, 0, maxPooledBufferSize+1))
+	large.WriteString("dropped")
+	putBuffer(large)
+	assert.Equal(t, "dropped", large.String())
+}
@@ -0,0 +1,325 @@
+package auth0
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidClaimsTarget is returned when binding claims into a value which
+// is not a pointer to a struct, or whose claim tags are invalid.
+var ErrInvalidClaimsTarget = errors.New("invalid claims binding target")
+
+// ClaimFieldError is the failure to bind a claim into a field of a struct.
+type ClaimFieldError struct {
+	// Field is the name of the field, e.g. "Roles".
+	Field string
+	// Claim is the name of the claim, e.g. "https://myapp.example.com/roles".
+	Claim string
+	// Err is ErrMissingClaim for required claims the token does not have,
+	// and wraps ErrClaimType for claims which cannot be converted to the
+	// type of the field.
+	Err error
+}
+
+// Error returns the message of the error of the field.
+func (e *ClaimFieldError) Error() string {
+	return fmt.Sprintf("field %s (claim %s): %v", e.Field, e.Claim, e.Err)
+}
+
+// Unwrap returns the error of the field.
+func (e *ClaimFieldError) Unwrap() error {
+	return e.Err
+}
+
+// ClaimsBindingError is the failure to bind the claims of a token into a
+// struct, with the error of every failing field. errors.Is matches the
+// errors of the fields, e.g. ErrMissingClaim.
+type ClaimsBindingError struct {
+	Fields []*ClaimFieldError
+}
+
+// Error returns the messages of the errors of the fields.
+func (e *ClaimsBindingError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+	return "cannot bind claims: " + strings.Join(messages, "; ")
+}
+
+// Is reports whether the error of one of the fields matches target.
+func (e *ClaimsBindingError) Is(target error) bool {
+	for _, field := range e.Fields {
+		if errors.Is(field, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// boundField is a field of a struct bound to a claim
+type boundField struct {
+	index    []int
+	name     string
+	claim    string
+	required bool
+}
+
+// boundFields caches the []boundField of the struct types
+var boundFields sync.Map
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Bind binds the claims of the token, registered and custom ones, into the
+// fields of the struct pointed to by dst tagged with the name of their
+// claim, followed by ",required" for the claims the token must have:
+//
+//	var claims struct {
+//		Subject string    `claim:"sub,required"`
+//		Expiry  time.Time `claim:"exp"`
+//		OrgID   string    `claim:"https://myapp.example.com/org_id,required"`
+//		Roles   []string  `claim:"https://myapp.example.com/roles"`
+//		Admin   bool      `claim:"https://myapp.example.com/admin"`
+//	}
+//	err := result.Bind(&claims)
+//
+// The claims are converted to the type of their field: numbers and booleans
+// to strings and back, a single string to a slice of strings, numeric dates
+// and RFC 3339 strings to time.Time. Other types are unmarshalled from JSON.
+// Fields of missing or null claims are left unchanged. Bind fails with a
+// *ClaimsBindingError listing every failing field.
+func (r *ValidationResult) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidClaimsTarget
+	}
+	fields, err := structFields(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	claims, err := r.allClaims()
+	if err != nil {
+		return err
+	}
+
+	var bindingErr ClaimsBindingError
+	for _, field := range fields {
+		raw, ok := claims[field.claim]
+		if !ok || string(raw) == "null" {
+			if field.required {
+				bindingErr.Fields = append(bindingErr.Fields, &ClaimFieldError{Field: field.name, Claim: field.claim, Err: ErrMissingClaim})
+			}
+			continue
+		}
+		if err := bindValue(raw, v.Elem().FieldByIndex(field.index)); err != nil {
+			bindingErr.Fields = append(bindingErr.Fields, &ClaimFieldError{Field: field.name, Claim: field.claim, Err: err})
+		}
+	}
+	if len(bindingErr.Fields) > 0 {
+		return &bindingErr
+	}
+	return nil
+}
+
+// allClaims returns the registered and the custom claims of the result
+func (r *ValidationResult) allClaims() (map[string]json.RawMessage, error) {
+	registered, err := json.Marshal(r.Claims)
+	if err != nil {
+		return nil, err
+	}
+	claims := make(map[string]json.RawMessage, len(r.CustomClaims)+len(registeredClaims))
+	if err := json.Unmarshal(registered, &claims); err != nil {
+		return nil, err
+	}
+	for name, value := range r.CustomClaims {
+		claims[name] = value
+	}
+	return claims, nil
+}
+
+// structFields returns the fields of the struct type bound to claims,
+// including the ones of its embedded structs
+func structFields(t reflect.Type) ([]boundField, error) {
+	if cached, ok := boundFields.Load(t); ok {
+		return cached.([]boundField), nil
+	}
+	var fields []boundField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("claim")
+		if !tagged {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				embedded, err := structFields(f.Type)
+				if err != nil {
+					return nil, err
+				}
+				for _, field := range embedded {
+					field.index = append([]int{i}, field.index...)
+					fields = append(fields, field)
+				}
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if f.PkgPath != "" {
+			return nil, fmt.Errorf("%w: unexported field %s", ErrInvalidClaimsTarget, f.Name)
+		}
+		parts := strings.Split(tag, ",")
+		field := boundField{index: []int{i}, name: f.Name, claim: parts[0]}
+		if field.claim == "" {
+			return nil, fmt.Errorf("%w: field %s without claim name", ErrInvalidClaimsTarget, f.Name)
+		}
+		for _, option := range parts[1:] {
+			if option != "required" {
+				return nil, fmt.Errorf("%w: field %s with unknown option %q", ErrInvalidClaimsTarget, f.Name, option)
+			}
+			field.required = true
+		}
+		fields = append(fields, field)
+	}
+	boundFields.Store(t, fields)
+	return fields, nil
+}
+
+// bindValue converts the JSON value raw to the type of v and sets it,
+// failing with ErrClaimType
+func bindValue(raw json.RawMessage, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return bindValue(raw, v.Elem())
+	}
+	if v.Type() == timeType {
+		return bindTime(raw, v)
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		scalar, err := decodeScalar(raw)
+		if err != nil {
+			return err
+		}
+		return bindScalar(scalar, v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var elements []json.RawMessage
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			if err := json.Unmarshal(raw, &elements); err != nil {
+				return fmt.Errorf("%w: %v", ErrClaimType, err)
+			}
+		} else {
+			// a single value, e.g. a single role
+			elements = []json.RawMessage{raw}
+		}
+		slice := reflect.MakeSlice(v.Type(), len(elements), len(elements))
+		for i, element := range elements {
+			if err := bindValue(element, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	if err := json.Unmarshal(raw, v.Addr().Interface()); err != nil {
+		return fmt.Errorf("%w: %v", ErrClaimType, err)
+	}
+	return nil
+}
+
+// decodeScalar decodes the JSON string, number or boolean raw, numbers
+// being decoded as json.Number
+func decodeScalar(raw json.RawMessage) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var scalar interface{}
+	if err := decoder.Decode(&scalar); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrClaimType, err)
+	}
+	switch scalar.(type) {
+	case string, json.Number, bool:
+		return scalar, nil
+	}
+	return nil, fmt.Errorf("%w: %s is not a string, a number nor a boolean", ErrClaimType, raw)
+}
+
+// bindScalar converts the string, json.Number or bool scalar to the kind of
+// v and sets it
+func bindScalar(scalar interface{}, v reflect.Value) error {
+	text := fmt.Sprint(scalar)
+	if v.Kind() == reflect.String {
+		v.SetString(text)
+		return nil
+	}
+	if _, ok := scalar.(bool); ok && v.Kind() != reflect.Bool {
+		return fmt.Errorf("%w: cannot convert %s to %s", ErrClaimType, text, v.Type())
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(text); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(text, 10, v.Type().Bits()); err == nil {
+			v.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(text, 10, v.Type().Bits()); err == nil {
+			v.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(text, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: cannot convert %s to %s", ErrClaimType, text, v.Type())
+	}
+	return nil
+}
+
+// bindTime converts the numeric date or RFC 3339 string raw to a time.Time
+// and sets it
+func bindTime(raw json.RawMessage, v reflect.Value) error {
+	scalar, err := decodeScalar(raw)
+	if err != nil {
+		return err
+	}
+	var t time.Time
+	switch scalar := scalar.(type) {
+	case json.Number:
+		var seconds float64
+		if seconds, err = scalar.Float64(); err == nil {
+			t = time.Unix(0, int64(seconds*float64(time.Second)))
+		}
+	case string:
+		t, err = time.Parse(time.RFC3339, scalar)
+	default:
+		err = errors.New("not a date")
+	}
+	if err != nil {
+		return fmt.Errorf("%w: cannot convert %s to %s", ErrClaimType, raw, v.Type())
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
@@ -0,0 +1,146 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type orgClaims struct {
+	OrgID string `claim:"https://myapp.example.com/org_id,required"`
+}
+
+type boundClaims struct {
+	orgClaims
+	Subject    string    `claim:"sub,required"`
+	Audience   []string  `claim:"aud"`
+	Expiry     time.Time `claim:"exp"`
+	Roles      []string  `claim:"https://myapp.example.com/roles"`
+	Role       []string  `claim:"https://myapp.example.com/role"`
+	Admin      bool      `claim:"https://myapp.example.com/admin"`
+	LoginCount int       `claim:"https://myapp.example.com/login_count"`
+	Plan       *string   `claim:"https://myapp.example.com/plan"`
+	Tier       string    `claim:"https://myapp.example.com/tier"`
+	UpdatedAt  time.Time `claim:"https://myapp.example.com/updated_at"`
+	Metadata   struct {
+		Locale string `json:"locale"`
+	} `claim:"https://myapp.example.com/metadata"`
+	Ignored  string `claim:"-"`
+	Untagged string
+}
+
+func testBindingResult() *ValidationResult {
+	return &ValidationResult{
+		Claims: jwt.Claims{
+			Subject:  "user",
+			Audience: jwt.Audience{"api"},
+			Expiry:   jwt.NewNumericDate(time.Unix(1700000000, 0)),
+		},
+		CustomClaims: map[string]json.RawMessage{
+			"https://myapp.example.com/org_id":      json.RawMessage(`"org_123"`),
+			"https://myapp.example.com/roles":       json.RawMessage(`["admin","editor"]`),
+			"https://myapp.example.com/role":        json.RawMessage(`"admin"`),
+			"https://myapp.example.com/admin":       json.RawMessage(`"true"`),
+			"https://myapp.example.com/login_count": json.RawMessage(`"3"`),
+			"https://myapp.example.com/plan":        json.RawMessage(`"pro"`),
+			"https://myapp.example.com/tier":        json.RawMessage(`2`),
+			"https://myapp.example.com/updated_at":  json.RawMessage(`"2023-11-14T22:13:20Z"`),
+			"https://myapp.example.com/metadata":    json.RawMessage(`{"locale":"en"}`),
+			"-":                                     json.RawMessage(`"ignored"`),
+			"Untagged":                              json.RawMessage(`"ignored"`),
+		},
+	}
+}
+
+func TestValidationResultBind(t *testing.T) {
+	var claims boundClaims
+	if !assert.NoError(t, testBindingResult().Bind(&claims)) {
+		return
+	}
+	assert.Equal(t, "org_123", claims.OrgID)
+	assert.Equal(t, "user", claims.Subject)
+	assert.Equal(t, []string{"api"}, claims.Audience)
+	assert.True(t, claims.Expiry.Equal(time.Unix(1700000000, 0)))
+	assert.Equal(t, []string{"admin", "editor"}, claims.Roles)
+	assert.Equal(t, []string{"admin"}, claims.Role)
+	assert.True(t, claims.Admin)
+	assert.Equal(t, 3, claims.LoginCount)
+	if assert.NotNil(t, claims.Plan) {
+		assert.Equal(t, "pro", *claims.Plan)
+	}
+	assert.Equal(t, "2", claims.Tier)
+	assert.True(t, claims.UpdatedAt.Equal(time.Unix(1700000000, 0)))
+	assert.Equal(t, "en", claims.Metadata.Locale)
+	assert.Empty(t, claims.Ignored)
+	assert.Empty(t, claims.Untagged)
+}
+
+func TestValidationResultBindErrors(t *testing.T) {
+	result := &ValidationResult{CustomClaims: map[string]json.RawMessage{
+		"https://myapp.example.com/org_id":      json.RawMessage(`null`),
+		"https://myapp.example.com/admin":       json.RawMessage(`"yes"`),
+		"https://myapp.example.com/login_count": json.RawMessage(`3.5`),
+		"https://myapp.example.com/roles":       json.RawMessage(`[1,{"name":"admin"}]`),
+		"https://myapp.example.com/tier":        json.RawMessage(`true`),
+	}}
+	var claims boundClaims
+	err := result.Bind(&claims)
+
+	var bindingErr *ClaimsBindingError
+	if !assert.True(t, errors.As(err, &bindingErr)) {
+		return
+	}
+	fields := map[string]error{}
+	for _, field := range bindingErr.Fields {
+		fields[field.Field] = field.Err
+	}
+	assert.Len(t, fields, 5)
+	assert.Equal(t, ErrMissingClaim, fields["OrgID"])
+	assert.Equal(t, ErrMissingClaim, fields["Subject"])
+	assert.ErrorIs(t, fields["Admin"], ErrClaimType)
+	assert.ErrorIs(t, fields["LoginCount"], ErrClaimType)
+	assert.ErrorIs(t, fields["Roles"], ErrClaimType)
+	assert.ErrorIs(t, err, ErrMissingClaim)
+	assert.ErrorIs(t, err, ErrClaimType)
+	assert.NotErrorIs(t, err, ErrClaimNotFound)
+	assert.Contains(t, err.Error(), "field OrgID (claim https://myapp.example.com/org_id): missing required claim")
+
+	// numbers are converted to strings, booleans are not converted to numbers
+	var overflow struct {
+		Small int8 `claim:"small"`
+		Flag  int  `claim:"flag"`
+	}
+	err = (&ValidationResult{CustomClaims: map[string]json.RawMessage{
+		"small": json.RawMessage(`300`),
+		"flag":  json.RawMessage(`true`),
+	}}).Bind(&overflow)
+	assert.ErrorIs(t, err, ErrClaimType)
+	assert.Len(t, err.(*ClaimsBindingError).Fields, 2)
+}
+
+func TestValidationResultBindInvalidTarget(t *testing.T) {
+	result := testBindingResult()
+	var claims boundClaims
+	assert.Equal(t, ErrInvalidClaimsTarget, result.Bind(claims))
+	assert.Equal(t, ErrInvalidClaimsTarget, result.Bind((*boundClaims)(nil)))
+	var notStruct string
+	assert.Equal(t, ErrInvalidClaimsTarget, result.Bind(&notStruct))
+
+	var unexported struct {
+		role string `claim:"role"`
+	}
+	assert.ErrorIs(t, result.Bind(&unexported), ErrInvalidClaimsTarget)
+	assert.Empty(t, unexported.role)
+	var unknownOption struct {
+		Role string `claim:"role,omitempty"`
+	}
+	assert.ErrorIs(t, result.Bind(&unknownOption), ErrInvalidClaimsTarget)
+	var noName struct {
+		Role string `claim:",required"`
+	}
+	assert.ErrorIs(t, result.Bind(&noName), ErrInvalidClaimsTarget)
+}
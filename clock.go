@@ -0,0 +1,31 @@
+package auth0
+
+import "time"
+
+// Clock tells the current time. The validators, the JWKS clients and the
+// in-memory key cachers read the time from their clock to check the expiry
+// of tokens and keys, so that tests can control it instead of sleeping or
+// issuing tokens in the past. The periodic tasks, e.g. the background JWKS
+// refresh, still run on the time of the system.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to allow the use of ordinary functions as Clock.
+type ClockFunc func() time.Time
+
+// Now implements the Now method of the Clock interface.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the clock of the system, used when no clock is configured.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// clockOrSystem returns clock, or SystemClock when it is nil
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
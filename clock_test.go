@@ -0,0 +1,38 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestValidatorClock(t *testing.T) {
+	clock := clocktest.NewClock(time.Now())
+	validator, err := NewValidatorBuilder(defaultSecretProvider).
+		WithAudience(defaultAudience...).
+		WithIssuer(defaultIssuer).
+		WithAlgorithm(jose.HS256).
+		WithClock(clock).
+		Build()
+	assert.NoError(t, err)
+	token := getTestToken(defaultAudience, defaultIssuer, clock.Now().Add(time.Hour), jose.HS256, defaultSecret)
+
+	_, req := genTestConfiguration(validator.config, token)
+	result, err := validator.Validate(req)
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(time.Hour), float64(result.TTL), float64(time.Second))
+
+	clock.Advance(2 * time.Hour)
+	_, err = validator.ValidateRequest(req)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestClockFunc(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, ClockFunc(func() time.Time { return now }).Now())
+	assert.NotNil(t, clockOrSystem(nil))
+	assert.WithinDuration(t, time.Now(), SystemClock.Now(), time.Second)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// unverifiedBanner labels the output of the decode command
+const unverifiedBanner = "UNVERIFIED: the signature and the claims of the token are not checked, use validate to validate it"
+
+// runDecode prints the header and the claims of a token without verifying
+// it, rendering its time based claims as times
+func runDecode(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var tokenFlag string
+	flags := flag.NewFlagSet("decode", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&tokenFlag, "token", "", "token to decode, read from stdin when empty or -")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: auth0-check decode [-token token]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	raw, err := readToken(tokenFlag, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "auth0-check: %v\n", err)
+		return exitUsage
+	}
+
+	token, err := jwt.ParseSigned(raw)
+	if err != nil {
+		fmt.Fprintf(stderr, "auth0-check: malformed token: %v\n", err)
+		return exitFailure
+	}
+	header, err := decodeHeader(raw)
+	if err != nil {
+		fmt.Fprintf(stderr, "auth0-check: malformed token header: %v\n", err)
+		return exitFailure
+	}
+	var claims map[string]interface{}
+	var registered jwt.Claims
+	if err := token.UnsafeClaimsWithoutVerification(&claims, &registered); err != nil {
+		fmt.Fprintf(stderr, "auth0-check: malformed token claims: %v\n", err)
+		return exitFailure
+	}
+
+	fmt.Fprintln(stdout, unverifiedBanner)
+	fmt.Fprintln(stdout, "header:")
+	if code := printJSON(stdout, stderr, header); code != exitOK {
+		return code
+	}
+	fmt.Fprintln(stdout, "claims:")
+	if code := printJSON(stdout, stderr, claims); code != exitOK {
+		return code
+	}
+	printTimes(stdout, registered, time.Now())
+	return exitOK
+}
+
+// decodeHeader returns the header of the compact serialized token, with the
+// parameters go-jose does not expose
+func decodeHeader(raw string) (map[string]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.SplitN(raw, ".", 2)[0])
+	if err != nil {
+		return nil, err
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// printTimes prints the time based claims as RFC 3339 times, relative to
+// now, along with the remaining time to live of the token
+func printTimes(w io.Writer, claims jwt.Claims, now time.Time) {
+	for _, claim := range []struct {
+		name  string
+		value jwt.NumericDate
+	}{
+		{"iat", claims.IssuedAt},
+		{"nbf", claims.NotBefore},
+		{"exp", claims.Expiry},
+	} {
+		if claim.value == 0 {
+			continue
+		}
+		t := claim.value.Time().UTC()
+		fmt.Fprintf(w, "%s: %s (%s)\n", claim.name, t.Format(time.RFC3339), relative(t, now))
+	}
+	if claims.Expiry == 0 {
+		fmt.Fprintln(w, "ttl: never expires")
+		return
+	}
+	if ttl := claims.Expiry.Time().Sub(now); ttl > 0 {
+		fmt.Fprintf(w, "ttl: %s\n", ttl.Round(time.Second))
+	} else {
+		fmt.Fprintln(w, "ttl: expired")
+	}
+}
+
+// relative describes t relative to now, e.g. "in 1h0m0s" or "5m0s ago"
+func relative(t, now time.Time) string {
+	d := t.Sub(now).Round(time.Second)
+	if d < 0 {
+		return (-d).String() + " ago"
+	}
+	return "in " + d.String()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/auth0test"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestDecode(t *testing.T) {
+	token, err := auth0test.NewToken().
+		Issuer("https://tenant.auth0.com/").
+		Subject("user").
+		Claim("scope", "read:users").
+		ExpiresIn(-time.Hour).
+		SignHS256([]byte("secret"), "kid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"decode"}, strings.NewReader("Bearer "+token), &stdout, &stderr)
+	assert.Equal(t, exitOK, code, stderr.String())
+	output := stdout.String()
+	assert.True(t, strings.HasPrefix(output, unverifiedBanner+"\n"))
+	assert.Contains(t, output, `"alg": "HS256"`)
+	assert.Contains(t, output, `"kid": "kid"`)
+	assert.Contains(t, output, `"scope": "read:users"`)
+	assert.Contains(t, output, "exp: ")
+	assert.Contains(t, output, "ttl: expired")
+}
+
+func TestDecodeMalformedToken(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitFailure, run([]string{"decode", "-token", "not.a.token"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "malformed token")
+	assert.Empty(t, stdout.String())
+
+	stderr.Reset()
+	assert.Equal(t, exitUsage, run([]string{"decode"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "no token")
+}
+
+func TestPrintTimes(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		claims jwt.Claims
+		want   string
+	}{
+		{
+			name: "valid",
+			claims: jwt.Claims{
+				IssuedAt: jwt.NewNumericDate(now.Add(-5 * time.Minute)),
+				Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+			want: "iat: 2021-10-01T11:55:00Z (5m0s ago)\nexp: 2021-10-01T13:00:00Z (in 1h0m0s)\nttl: 1h0m0s\n",
+		},
+		{
+			name: "expired",
+			claims: jwt.Claims{
+				NotBefore: jwt.NewNumericDate(now.Add(-2 * time.Hour)),
+				Expiry:    jwt.NewNumericDate(now.Add(-time.Hour)),
+			},
+			want: "nbf: 2021-10-01T10:00:00Z (2h0m0s ago)\nexp: 2021-10-01T11:00:00Z (1h0m0s ago)\nttl: expired\n",
+		},
+		{
+			name:   "without expiry",
+			claims: jwt.Claims{},
+			want:   "ttl: never expires\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var w bytes.Buffer
+			printTimes(&w, test.claims, now)
+			assert.Equal(t, test.want, w.String())
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// defaultMinRSABits is the size under which RSA keys are weak
+const defaultMinRSABits = 2048
+
+// keyInfo describes a key of a JWKS
+type keyInfo struct {
+	kid, alg, kty, use string
+	// bits is the size of the key, zero when unknown
+	bits   int
+	issues []string
+}
+
+// runJWKS downloads a JWKS and lists its keys, flagging the weak and the
+// duplicate ones
+func runJWKS(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	var options validateOptions
+	var minRSABits int
+	flags := flag.NewFlagSet("jwks", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&options.domain, "domain", "", "Auth0 domain, e.g. tenant.auth0.com, setting the JWKS URI")
+	flags.StringVar(&options.jwksURI, "jwks-uri", "", "URI of the JWKS, overriding the one of the domain")
+	flags.DurationVar(&options.timeout, "timeout", 10*time.Second, "timeout of the JWKS download")
+	flags.IntVar(&minRSABits, "min-rsa-bits", defaultMinRSABits, "size under which RSA keys are flagged as weak")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: auth0-check jwks [-domain domain | -jwks-uri uri]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := options.resolve(); err != nil {
+		fmt.Fprintf(stderr, "auth0-check: %v\n", err)
+		flags.Usage()
+		return exitUsage
+	}
+
+	client := auth0.NewJWKClient(auth0.JWKClientOptions{URI: options.jwksURI}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), options.timeout)
+	defer cancel()
+	keys, err := client.DownloadKeys(ctx)
+	if err != nil {
+		fmt.Fprintf(stdout, "cannot download the JWKS: %s\n", auth0.ErrorReason(err))
+		fmt.Fprintf(stdout, "error: %v\n", err)
+		return exitFailure
+	}
+
+	infos := inspectKeys(keys, minRSABits)
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALG\tKTY\tSIZE\tUSE\tISSUES")
+	flagged := 0
+	for _, info := range infos {
+		size := "-"
+		if info.bits > 0 {
+			size = strconv.Itoa(info.bits)
+		}
+		issues := "-"
+		if len(info.issues) > 0 {
+			issues = strings.Join(info.issues, ", ")
+			flagged++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", orDash(info.kid), orDash(info.alg), info.kty, size, orDash(info.use), issues)
+	}
+	w.Flush()
+	fmt.Fprintf(stdout, "%d keys, %d flagged\n", len(infos), flagged)
+	if flagged > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// inspectKeys describes the keys, flagging the weak, symmetric, unidentified
+// and duplicate ones
+func inspectKeys(keys []jose.JSONWebKey, minRSABits int) []keyInfo {
+	seen := map[string]int{}
+	for _, key := range keys {
+		seen[key.KeyID]++
+	}
+	infos := make([]keyInfo, 0, len(keys))
+	for _, key := range keys {
+		info := keyInfo{kid: key.KeyID, alg: key.Algorithm, use: key.Use}
+		switch k := key.Key.(type) {
+		case *rsa.PublicKey:
+			info.kty, info.bits = "RSA", k.N.BitLen()
+			if info.bits < minRSABits {
+				info.issues = append(info.issues, fmt.Sprintf("weak: RSA key under %d bits", minRSABits))
+			}
+		case *ecdsa.PublicKey:
+			info.kty, info.bits = "EC", k.Curve.Params().BitSize
+		case ed25519.PublicKey:
+			info.kty, info.bits = "OKP", 256
+		case []byte:
+			info.kty, info.bits = "oct", len(k)*8
+			info.issues = append(info.issues, "symmetric key published")
+		default:
+			info.kty = fmt.Sprintf("%T", key.Key)
+			info.issues = append(info.issues, "unsupported key type")
+		}
+		switch {
+		case key.KeyID == "":
+			info.issues = append(info.issues, "no kid")
+		case seen[key.KeyID] > 1:
+			info.issues = append(info.issues, "duplicate kid")
+		}
+		if strings.HasPrefix(key.Algorithm, "HS") || key.Algorithm == "none" {
+			info.issues = append(info.issues, "weak: "+key.Algorithm+" algorithm")
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/paulusrobin/go-auth0/auth0test"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestJWKS(t *testing.T) {
+	server, err := auth0test.NewServer(auth0test.Options{Algorithms: []jose.SignatureAlgorithm{jose.RS256, jose.ES384, jose.EdDSA}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"jwks", "-jwks-uri", server.URI()}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, code, stderr.String())
+	assert.Contains(t, stdout.String(), "KID")
+	assert.Contains(t, stdout.String(), "RS256  RSA  2048")
+	assert.Contains(t, stdout.String(), "ES384  EC   384")
+	assert.Contains(t, stdout.String(), "EdDSA  OKP  256")
+	assert.Contains(t, stdout.String(), "3 keys, 0 flagged")
+}
+
+func TestJWKSFlaggedKeys(t *testing.T) {
+	weakKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwks := auth0.JWKS{Keys: []jose.JSONWebKey{
+		{Key: &weakKey.PublicKey, KeyID: "weak", Algorithm: "RS256", Use: "sig"},
+		{Key: &ecKey.PublicKey, KeyID: "dup", Algorithm: "ES256"},
+		{Key: &ecKey.PublicKey, KeyID: "dup", Algorithm: "ES256"},
+	}}
+	body, err := json.Marshal(jwks)
+	assert.NoError(t, err)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"jwks", "-jwks-uri", ts.URL}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitFailure, code, stderr.String())
+	assert.Contains(t, stdout.String(), "weak: RSA key under 2048 bits")
+	assert.Contains(t, stdout.String(), "duplicate kid")
+	assert.Contains(t, stdout.String(), "3 keys, 3 flagged")
+}
+
+func TestInspectKeys(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	infos := inspectKeys([]jose.JSONWebKey{
+		{Key: []byte("secret"), KeyID: "hmac", Algorithm: "HS256"},
+		{Key: &ecKey.PublicKey, Algorithm: "ES384"},
+	}, defaultMinRSABits)
+
+	assert.Equal(t, []keyInfo{
+		{kid: "hmac", alg: "HS256", kty: "oct", bits: 48, issues: []string{"symmetric key published", "weak: HS256 algorithm"}},
+		{alg: "ES384", kty: "EC", bits: 384, issues: []string{"no kid"}},
+	}, infos)
+}
+
+func TestJWKSDownloadFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitFailure, run([]string{"jwks", "-jwks-uri", ts.URL}, strings.NewReader(""), &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "cannot download the JWKS: jwks_status")
+	assert.Equal(t, exitUsage, run([]string{"jwks"}, strings.NewReader(""), &stdout, &stderr))
+}
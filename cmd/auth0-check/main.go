@@ -0,0 +1,98 @@
+// Command auth0-check troubleshoots the tokens of APIs validating them with
+// go-auth0, e.g. for support and on-call debugging.
+//
+// Usage:
+//
+//	auth0-check <command> [flags]
+//
+// The commands are:
+//
+//	validate  validates a token against a JWKS, printing its claims or the
+//	          reason of its rejection
+//	jwks      downloads a JWKS and lists its keys, flagging the weak and the
+//	          duplicate ones
+//	decode    prints the header and the claims of a token, without verifying
+//	          it
+//
+// Run "auth0-check <command> -h" for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes of the commands
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is a subcommand of auth0-check
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "validate", summary: "validate a token against a JWKS", run: runValidate},
+	{name: "jwks", summary: "download a JWKS and inspect its keys", run: runJWKS},
+	{name: "decode", summary: "print the claims of a token, without verifying it", run: runDecode},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command of args and returns its exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stdout)
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "auth0-check: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: auth0-check <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+}
+
+// readToken returns the token of the flag, or else the token read from stdin,
+// without its Bearer prefix
+func readToken(token string, stdin io.Reader) (string, error) {
+	if token == "" || token == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", err
+		}
+		token = string(data)
+	}
+	token = strings.TrimSpace(token)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	if token == "" {
+		return "", fmt.Errorf("no token, set -token or pipe it to stdin")
+	}
+	return token, nil
+}
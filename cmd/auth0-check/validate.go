@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// validateOptions are the flags of the validate command
+type validateOptions struct {
+	token     string
+	domain    string
+	jwksURI   string
+	audience  string
+	issuer    string
+	algorithm string
+	timeout   time.Duration
+}
+
+// runValidate validates a token against a JWKS, printing its claims when it
+// is valid and the reason of its rejection otherwise
+func runValidate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var options validateOptions
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&options.token, "token", "", "token to validate, read from stdin when empty or -")
+	flags.StringVar(&options.domain, "domain", "", "Auth0 domain, e.g. tenant.auth0.com, setting the JWKS URI and the issuer")
+	flags.StringVar(&options.jwksURI, "jwks-uri", "", "URI of the JWKS, overriding the one of the domain")
+	flags.StringVar(&options.audience, "audience", "", "comma separated expected audiences")
+	flags.StringVar(&options.issuer, "issuer", "", "expected issuer, overriding the one of the domain")
+	flags.StringVar(&options.algorithm, "alg", "", "expected signature algorithm, e.g. RS256, any when empty")
+	flags.DurationVar(&options.timeout, "timeout", 10*time.Second, "timeout of the JWKS download")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: auth0-check validate [-domain domain | -jwks-uri uri] [-audience aud] [-issuer iss] [-token token]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := options.resolve(); err != nil {
+		fmt.Fprintf(stderr, "auth0-check: %v\n", err)
+		flags.Usage()
+		return exitUsage
+	}
+	token, err := readToken(options.token, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "auth0-check: %v\n", err)
+		return exitUsage
+	}
+
+	validator := newValidator(options)
+	ctx, cancel := context.WithTimeout(context.Background(), options.timeout)
+	defer cancel()
+	result := validator.ValidateTokens(ctx, []string{token})[0]
+	if result.Err != nil {
+		printRejection(stdout, result.Err)
+		return exitFailure
+	}
+	fmt.Fprintln(stdout, "valid token")
+	fmt.Fprintf(stdout, "alg: %s\nkid: %s\n", result.Result.Algorithm, result.Result.KeyID)
+	if !result.Result.Expiry.IsZero() {
+		fmt.Fprintf(stdout, "expires: %s (in %s)\n", result.Result.Expiry.Format(time.RFC3339), result.Result.TTL.Round(time.Second))
+	}
+	var claims map[string]interface{}
+	if err := result.Result.Token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		fmt.Fprintf(stderr, "auth0-check: %v\n", err)
+		return exitFailure
+	}
+	fmt.Fprintln(stdout, "claims:")
+	return printJSON(stdout, stderr, claims)
+}
+
+// resolve sets the JWKS URI and the issuer of the Auth0 domain, unless they
+// are set, and fails without JWKS URI
+func (o *validateOptions) resolve() error {
+	if o.domain != "" {
+		if o.jwksURI == "" {
+			o.jwksURI = "https://" + o.domain + "/.well-known/jwks.json"
+		}
+		if o.issuer == "" {
+			o.issuer = "https://" + o.domain + "/"
+		}
+	}
+	if o.jwksURI == "" {
+		return errors.New("-domain or -jwks-uri is required")
+	}
+	return nil
+}
+
+// newValidator returns the validator of the options
+func newValidator(options validateOptions) *auth0.JWTValidator {
+	client := auth0.NewJWKClient(auth0.JWKClientOptions{URI: options.jwksURI}, nil)
+	var audience []string
+	for _, aud := range strings.Split(options.audience, ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			audience = append(audience, aud)
+		}
+	}
+	configuration := auth0.NewConfiguration(client, audience, options.issuer, jose.SignatureAlgorithm(options.algorithm))
+	return auth0.NewValidator(configuration, nil)
+}
+
+// printRejection prints the reason of the rejection of the token, along with
+// the offending claim and the context of the validation
+func printRejection(w io.Writer, err error) {
+	fmt.Fprintf(w, "invalid token: %s\n", auth0.ErrorReason(err))
+	fmt.Fprintf(w, "error: %v\n", err)
+	var validationErr *auth0.ValidationError
+	if !errors.As(err, &validationErr) {
+		return
+	}
+	if validationErr.Claim != "" {
+		fmt.Fprintf(w, "claim: %s\n", validationErr.Claim)
+		fmt.Fprintf(w, "value: %v\n", formatValue(validationErr.Value))
+		if validationErr.Expected != nil {
+			fmt.Fprintf(w, "expected: %v\n", formatValue(validationErr.Expected))
+		}
+	}
+	if validationErr.KeyID != "" {
+		fmt.Fprintf(w, "kid: %s\n", validationErr.KeyID)
+	}
+}
+
+// formatValue formats the times of the time based claims as RFC 3339
+func formatValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return value
+}
+
+// printJSON prints value as indented JSON
+func printJSON(stdout, stderr io.Writer, value interface{}) int {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		fmt.Fprintf(stderr, "auth0-check: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
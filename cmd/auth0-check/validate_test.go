@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/auth0test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	server, err := auth0test.NewServer(auth0test.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	valid, err := server.NewToken().Claim("scope", "read:users").Sign(server.Keys()[0])
+	assert.NoError(t, err)
+	expired, err := server.NewToken().ExpiresIn(-time.Hour).Sign(server.Keys()[0])
+	assert.NoError(t, err)
+	args := []string{"validate", "-jwks-uri", server.URI(), "-issuer", server.Issuer(), "-audience", auth0test.DefaultAudience}
+
+	tests := []struct {
+		name         string
+		args         []string
+		stdin        string
+		expectedCode int
+		expectedOut  []string
+	}{
+		{
+			name:         "valid token flag",
+			args:         append(args, "-token", valid),
+			expectedCode: exitOK,
+			expectedOut:  []string{"valid token", "kid: RS256", `"scope": "read:users"`},
+		},
+		{
+			name:         "valid token stdin",
+			args:         append(args, "-alg", "RS256"),
+			stdin:        "Bearer " + valid + "\n",
+			expectedCode: exitOK,
+			expectedOut:  []string{"valid token"},
+		},
+		{
+			name:         "expired token",
+			args:         append(args, "-token", expired),
+			expectedCode: exitFailure,
+			expectedOut:  []string{"invalid token: expired", "claim: exp", "kid: RS256"},
+		},
+		{
+			name:         "invalid audience",
+			args:         []string{"validate", "-jwks-uri", server.URI(), "-audience", "other", "-token", valid},
+			expectedCode: exitFailure,
+			expectedOut:  []string{"invalid token: invalid_audience", "claim: aud", "expected: [other]"},
+		},
+		{
+			name:         "invalid algorithm",
+			args:         append(args, "-alg", "ES256", "-token", valid),
+			expectedCode: exitFailure,
+			expectedOut:  []string{"invalid token: invalid_algorithm", "value: RS256", "expected: ES256"},
+		},
+		{
+			name:         "missing JWKS",
+			args:         []string{"validate", "-token", valid},
+			expectedCode: exitUsage,
+		},
+		{
+			name:         "missing token",
+			args:         args,
+			expectedCode: exitUsage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+			assert.Equal(t, test.expectedCode, code, stderr.String())
+			for _, expected := range test.expectedOut {
+				assert.Contains(t, stdout.String(), expected)
+			}
+		})
+	}
+}
+
+func TestValidateOptionsResolve(t *testing.T) {
+	options := validateOptions{domain: "tenant.auth0.com"}
+	assert.NoError(t, options.resolve())
+	assert.Equal(t, "https://tenant.auth0.com/.well-known/jwks.json", options.jwksURI)
+	assert.Equal(t, "https://tenant.auth0.com/", options.issuer)
+
+	options = validateOptions{domain: "tenant.auth0.com", jwksURI: "https://mirror/jwks.json", issuer: "https://custom/"}
+	assert.NoError(t, options.resolve())
+	assert.Equal(t, "https://mirror/jwks.json", options.jwksURI)
+	assert.Equal(t, "https://custom/", options.issuer)
+
+	options = validateOptions{}
+	assert.Error(t, options.resolve())
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitUsage, run(nil, strings.NewReader(""), &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "validate")
+	assert.Equal(t, exitUsage, run([]string{"unknown"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitOK, run([]string{"help"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "usage: auth0-check")
+}
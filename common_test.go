@@ -54,6 +54,11 @@ func genRSASSAJWK(sigAlg jose.SignatureAlgorithm, kid string) jose.JSONWebKey {
 	return jsonWebKey
 }
 
+func genPublicRSASSAJWK(kid string) jose.JSONWebKey {
+	key := genRSASSAJWK(jose.RS256, kid)
+	return key.Public()
+}
+
 func genECDSAJWK(sigAlg jose.SignatureAlgorithm, kid string) jose.JSONWebKey {
 	var c elliptic.Curve
 	if sigAlg == jose.ES256 {
@@ -0,0 +1,85 @@
+package auth0
+
+import (
+	"errors"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature of the token does
+	// not verify with the key of the secret provider.
+	ErrInvalidSignature = errors.New("invalid token signature")
+	// ErrTokenExpired is returned when the token is expired (exp claim).
+	ErrTokenExpired = errors.New("token is expired (exp)")
+	// ErrTokenNotValidYet is returned when the token is used before it
+	// becomes valid (nbf claim).
+	ErrTokenNotValidYet = errors.New("token not valid yet (nbf)")
+	// ErrInvalidAudience is returned when the token is not issued for the
+	// expected audience (aud claim).
+	ErrInvalidAudience = errors.New("invalid audience claim (aud)")
+	// ErrInvalidIssuer is returned when the token is not issued by the
+	// expected issuer (iss claim).
+	ErrInvalidIssuer = errors.New("invalid issuer claim (iss)")
+	// ErrInvalidSubject is returned when the token is not issued for the
+	// expected subject (sub claim).
+	ErrInvalidSubject = errors.New("invalid subject claim (sub)")
+	// ErrInvalidTokenID is returned when the token has not the expected ID
+	// (jti claim).
+	ErrInvalidTokenID = errors.New("invalid ID claim (jti)")
+	// ErrInvalidClaims is returned when the claims of the token
+	// cannot be decoded.
+	ErrInvalidClaims = errors.New("invalid token claims")
+	// ErrMissingClaim is returned when the token lacks one of the
+	// RequiredClaims of the validator.
+	ErrMissingClaim = errors.New("missing required claim")
+)
+
+// joseErrors maps the errors of go-jose to the errors of the package
+var joseErrors = map[error]error{
+	jose.ErrCryptoFailure:       ErrInvalidSignature,
+	jwt.ErrExpired:              ErrTokenExpired,
+	jwt.ErrNotValidYet:          ErrTokenNotValidYet,
+	jwt.ErrInvalidAudience:      ErrInvalidAudience,
+	jwt.ErrInvalidIssuer:        ErrInvalidIssuer,
+	jwt.ErrInvalidSubject:       ErrInvalidSubject,
+	jwt.ErrInvalidID:            ErrInvalidTokenID,
+	jwt.ErrInvalidClaims:        ErrInvalidClaims,
+	jwt.ErrUnmarshalAudience:    ErrInvalidClaims,
+	jwt.ErrUnmarshalNumericDate: ErrInvalidClaims,
+}
+
+// joseError is an error of go-jose matching the error of the package it is
+// mapped to with errors.Is, along with the go-jose error it wraps
+type joseError struct {
+	err   error
+	cause error
+}
+
+// Error returns the message of the go-jose error.
+func (e *joseError) Error() string {
+	return e.cause.Error()
+}
+
+// Is reports whether target is the error of the package.
+func (e *joseError) Is(target error) bool {
+	return target == e.err
+}
+
+// Unwrap returns the go-jose error.
+func (e *joseError) Unwrap() error {
+	return e.cause
+}
+
+// wrapJOSEError wraps the known errors of go-jose so that they match the
+// errors of the package, returning other errors as is
+func wrapJOSEError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if mapped, ok := joseErrors[err]; ok {
+		return &joseError{err: mapped, cause: err}
+	}
+	return err
+}
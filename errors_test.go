@@ -0,0 +1,47 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestWrapJOSEError(t *testing.T) {
+	for joseErr, err := range joseErrors {
+		wrapped := wrapJOSEError(joseErr)
+		assert.True(t, errors.Is(wrapped, err), joseErr)
+		assert.True(t, errors.Is(wrapped, joseErr), joseErr)
+		assert.Equal(t, joseErr.Error(), wrapped.Error())
+	}
+
+	other := errors.New("other")
+	assert.Equal(t, other, wrapJOSEError(other))
+	assert.Nil(t, wrapJOSEError(nil))
+	assert.False(t, errors.Is(wrapJOSEError(jwt.ErrExpired), ErrInvalidAudience))
+}
+
+func TestValidationErrors(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	tests := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{"expired", getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret), ErrTokenExpired},
+		{"audience", getTestToken([]string{"other"}, defaultIssuer, expiry, jose.HS256, defaultSecret), ErrInvalidAudience},
+		{"issuer", getTestToken(defaultAudience, "other", expiry, jose.HS256, defaultSecret), ErrInvalidIssuer},
+		{"signature", getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("other")), ErrInvalidSignature},
+	}
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator, req := genTestConfiguration(configuration, test.token)
+			_, err := validator.ValidateRequest(req)
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
@@ -93,7 +93,8 @@ func Auth0Groups(wantedGroups ...string) gin.HandlerFunc {
 
 	return gin.HandlerFunc(func(c *gin.Context) {
 
-		tok, err := validator.ValidateRequest(c.Request)
+		claims := map[string]interface{}{}
+		_, err := validator.ValidateRequestWithClaims(c.Request, &claims)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
@@ -101,15 +102,6 @@ func Auth0Groups(wantedGroups ...string) gin.HandlerFunc {
 			return
 		}
 
-		claims := map[string]interface{}{}
-		err = validator.Claims(c.Request, tok, &claims)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
-			c.Abort()
-			log.Println("Invalid claims:", err)
-			return
-		}
-
 		metadata, okMetadata := claims["app_metadata"].(map[string]interface{})
 		authorization, okAuthorization := metadata["authorization"].(map[string]interface{})
 		groups, hasGroups := authorization["groups"].([]interface{})
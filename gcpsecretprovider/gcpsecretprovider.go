@@ -0,0 +1,231 @@
+// Package gcpsecretprovider provides a secret provider serving the
+// verification keys stored in Google Cloud Secret Manager.
+//
+// It calls the Secret Manager REST API with an authenticated HTTP client so
+// that it does not depend on the Google Cloud client libraries.
+package gcpsecretprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+)
+
+const (
+	// DefaultEndpoint is the endpoint of the Secret Manager API
+	DefaultEndpoint = "https://secretmanager.googleapis.com"
+	// DefaultVersion is the version of the secret holding the key
+	DefaultVersion = "latest"
+	// DefaultTTL is the time the keys are cached for
+	DefaultTTL = 5 * time.Minute
+	// DefaultTimeout is the timeout of the requests of the default HTTP
+	// client
+	DefaultTimeout = 10 * time.Second
+)
+
+var (
+	// ErrVersionNotFound is returned when the secret version does not exist
+	// or is not enabled.
+	ErrVersionNotFound = errors.New("secret manager secret version not found")
+	// ErrCorruptedPayload is returned when the checksum of the secret payload
+	// does not match its data.
+	ErrCorruptedPayload = errors.New("secret manager secret payload is corrupted")
+	// ErrEmptySecret is returned when the secret payload is empty.
+	ErrEmptySecret = errors.New("secret manager secret is empty")
+)
+
+// Options configures the Secret Manager secret provider.
+type Options struct {
+	// Secret is the resource name of the secret holding the key, e.g.
+	// "projects/my-project/secrets/jwt".
+	Secret string
+	// Version is the version of the secret holding the key, a version number
+	// or an alias. Defaults to DefaultVersion.
+	Version string
+	// PreviousVersions is the number of versions preceding Version served
+	// along with it, so that tokens signed with the previous keys stay valid
+	// after a new version is added. Disabled and destroyed versions are
+	// skipped.
+	PreviousVersions int
+	// HTTPClient calls the Secret Manager API, authenticating the requests,
+	// e.g. the client of google.DefaultClient with the cloud-platform scope.
+	// Defaults to a client without authentication, timing out after
+	// DefaultTimeout.
+	HTTPClient *http.Client
+	// Endpoint is the endpoint of the Secret Manager API, e.g. a regional
+	// endpoint. Defaults to DefaultEndpoint.
+	Endpoint string
+	// TTL is the time the keys are cached for. Defaults to DefaultTTL.
+	TTL time.Duration
+	// RetryInterval is the time after which the keys are refreshed again
+	// once refreshing them failed. Defaults to
+	// auth0.DefaultRefreshRetryInterval.
+	RetryInterval time.Duration
+	// OnRefreshError, when set, is called when the keys cannot be refreshed.
+	// The previous keys are served until they can be.
+	OnRefreshError func(err error)
+	// Clock tells the time the keys expire at. Defaults to auth0.SystemClock.
+	Clock auth0.Clock
+}
+
+// Provider is a secret provider serving keys read from Secret Manager,
+// refreshed once their TTL expires as described by
+// auth0.RefreshingKeyProvider.
+//
+// Secret payloads holding a PEM encoded public key or certificate are served
+// as public keys, other payloads as HMAC secrets.
+type Provider struct {
+	options Options
+	keys    *auth0.RefreshingKeyProvider
+}
+
+// accessResponse is the response of the access method of the API
+type accessResponse struct {
+	Name    string `json:"name"`
+	Payload struct {
+		Data       string `json:"data"`
+		DataCrc32c string `json:"dataCrc32c"`
+	} `json:"payload"`
+}
+
+// New creates a provider reading keys from Secret Manager. It fails when the
+// keys cannot be read.
+func New(options Options) (*Provider, error) {
+	if options.Secret == "" {
+		return nil, errors.New("gcpsecretprovider: Secret must be set")
+	}
+	if options.Version == "" {
+		options.Version = DefaultVersion
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = DefaultEndpoint
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+
+	p := &Provider{options: options}
+	p.keys = auth0.NewRefreshingKeyProvider(p.load, auth0.RefreshingKeyProviderOptions{
+		RetryInterval:  options.RetryInterval,
+		OnRefreshError: options.OnRefreshError,
+		Clock:          options.Clock,
+	})
+	if err := p.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSecret returns the cached keys, refreshing them first when expired.
+// Several keys are returned as auth0.RotatedKeys, newest first.
+func (p *Provider) GetSecret(r *http.Request) (interface{}, error) {
+	return p.keys.GetSecret(r)
+}
+
+// Refresh reads the keys from Secret Manager, replacing the cached keys on
+// success and keeping them on failure.
+func (p *Provider) Refresh(ctx context.Context) error {
+	return p.keys.Refresh(ctx)
+}
+
+// load reads the keys from Secret Manager along with the time they are
+// cached for
+func (p *Provider) load(ctx context.Context) (interface{}, time.Duration, error) {
+	key, name, err := p.access(ctx, p.options.Version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keys := auth0.RotatedKeys{key}
+	if p.options.PreviousVersions > 0 {
+		// aliases are resolved by the API, the name holds the version number
+		version, err := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid secret version name %s", name)
+		}
+		for previous := version - 1; previous > 0 && previous >= version-p.options.PreviousVersions; previous-- {
+			key, _, err := p.access(ctx, strconv.Itoa(previous))
+			if errors.Is(err, ErrVersionNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 1 {
+		return keys[0], p.options.TTL, nil
+	}
+	return keys, p.options.TTL, nil
+}
+
+// access returns the key of the secret version along with the resource name
+// of the version
+func (p *Provider) access(ctx context.Context, version string) (interface{}, string, error) {
+	name := p.options.Secret + "/versions/" + version
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.options.Endpoint, "/")+"/v1/"+name+":access", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := p.options.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	// disabled and destroyed versions fail the precondition of being enabled
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest:
+		return nil, "", fmt.Errorf("%w: %s", ErrVersionNotFound, name)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, "", fmt.Errorf("secret manager access to %s failed with status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var response accessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, "", err
+	}
+	key, err := parsePayload(response)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid secret %s: %w", name, err)
+	}
+	return key, response.Name, nil
+}
+
+// parsePayload returns the PEM public key or the HMAC secret of the payload
+// of the response, checking its checksum when given
+func parsePayload(response accessResponse) (interface{}, error) {
+	data, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload.DataCrc32c != "" {
+		checksum, err := strconv.ParseUint(response.Payload.DataCrc32c, 10, 32)
+		if err != nil || uint32(checksum) != crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)) {
+			return nil, ErrCorruptedPayload
+		}
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "-----BEGIN") {
+		return auth0.ParsePEMPublicKey(data)
+	}
+	return data, nil
+}
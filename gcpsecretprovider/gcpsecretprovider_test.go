@@ -0,0 +1,237 @@
+package gcpsecretprovider
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/paulusrobin/go-auth0/auth0test"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "projects/project/secrets/jwt"
+
+// fakeSecretManager serves the payloads of the enabled versions of the
+// secret, the latest being the highest one
+type fakeSecretManager struct {
+	mu       sync.Mutex
+	versions map[int]string
+	latest   int
+	status   int
+	reads    int
+}
+
+func (s *fakeSecretManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads++
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/"+secret+"/versions/")
+	version := s.latest
+	if path != "latest:access" {
+		version, _ = strconv.Atoi(strings.TrimSuffix(path, ":access"))
+	}
+	payload, ok := s.versions[version]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var response accessResponse
+	response.Name = secret + "/versions/" + strconv.Itoa(version)
+	response.Payload.Data = base64.StdEncoding.EncodeToString([]byte(payload))
+	response.Payload.DataCrc32c = strconv.FormatUint(uint64(crc32.Checksum([]byte(payload), crc32.MakeTable(crc32.Castagnoli))), 10)
+	json.NewEncoder(w).Encode(response)
+}
+
+func newServer(t *testing.T, handler http.Handler) string {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func genPEMPublicKey(t *testing.T) (*rsa.PublicKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &key.PublicKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestProvider(t *testing.T) {
+	publicKey, publicKeyPEM := genPEMPublicKey(t)
+
+	tests := []struct {
+		name        string
+		options     Options
+		versions    map[int]string
+		latest      int
+		expectedKey interface{}
+		expectedErr error
+	}{
+		{
+			name:        "pass - latest version",
+			versions:    map[int]string{1: "old", 2: "secret"},
+			latest:      2,
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:        "pass - pinned version",
+			options:     Options{Version: "1"},
+			versions:    map[int]string{1: "old", 2: "secret"},
+			latest:      2,
+			expectedKey: []byte("old"),
+		},
+		{
+			name:        "pass - pem public key",
+			versions:    map[int]string{1: publicKeyPEM},
+			latest:      1,
+			expectedKey: publicKey,
+		},
+		{
+			name:        "pass - previous versions",
+			options:     Options{PreviousVersions: 2},
+			versions:    map[int]string{1: "oldest", 2: "old", 3: "secret"},
+			latest:      3,
+			expectedKey: auth0.RotatedKeys{[]byte("secret"), []byte("old"), []byte("oldest")},
+		},
+		{
+			name:        "pass - previous versions limited",
+			options:     Options{PreviousVersions: 1},
+			versions:    map[int]string{1: "oldest", 2: "old", 3: "secret"},
+			latest:      3,
+			expectedKey: auth0.RotatedKeys{[]byte("secret"), []byte("old")},
+		},
+		{
+			name:        "pass - disabled previous version",
+			options:     Options{PreviousVersions: 2},
+			versions:    map[int]string{1: "oldest", 3: "secret"},
+			latest:      3,
+			expectedKey: auth0.RotatedKeys{[]byte("secret"), []byte("oldest")},
+		},
+		{
+			name:        "pass - no previous version",
+			options:     Options{PreviousVersions: 2},
+			versions:    map[int]string{1: "secret"},
+			latest:      1,
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:        "fail - version not found",
+			options:     Options{Version: "3"},
+			versions:    map[int]string{1: "secret"},
+			latest:      1,
+			expectedErr: ErrVersionNotFound,
+		},
+		{
+			name:        "fail - empty secret",
+			versions:    map[int]string{1: ""},
+			latest:      1,
+			expectedErr: ErrEmptySecret,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := test.options
+			options.Secret = secret
+			options.Endpoint = newServer(t, &fakeSecretManager{versions: test.versions, latest: test.latest})
+			provider, err := New(options)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedKey, key)
+		})
+	}
+}
+
+func TestProviderCorruptedPayload(t *testing.T) {
+	endpoint := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"` + secret + `/versions/1","payload":{"data":"c2VjcmV0","dataCrc32c":"1"}}`))
+	}))
+	_, err := New(Options{Secret: secret, Endpoint: endpoint})
+	assert.True(t, errors.Is(err, ErrCorruptedPayload), err)
+}
+
+func TestProviderOptions(t *testing.T) {
+	_, err := New(Options{})
+	assert.Error(t, err)
+
+	secretManager := &fakeSecretManager{versions: map[int]string{1: "secret"}, latest: 1}
+	provider, err := New(Options{Secret: secret, Endpoint: newServer(t, secretManager)})
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultTimeout, provider.options.HTTPClient.Timeout)
+	}
+}
+
+func TestProviderRefresh(t *testing.T) {
+	secretManager := &fakeSecretManager{versions: map[int]string{1: "secret"}, latest: 1}
+	clock := auth0test.NewClock(time.Now())
+	provider, err := New(Options{Secret: secret, Endpoint: newServer(t, secretManager), TTL: time.Hour, Clock: clock})
+	assert.NoError(t, err)
+
+	_, err = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, secretManager.reads, "the key should be cached for the ttl")
+
+	secretManager.mu.Lock()
+	secretManager.versions[2] = "rotated"
+	secretManager.latest = 2
+	secretManager.mu.Unlock()
+	clock.Advance(time.Hour)
+	key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("rotated"), key)
+}
+
+func TestProviderRefreshError(t *testing.T) {
+	secretManager := &fakeSecretManager{versions: map[int]string{1: "secret"}, latest: 1}
+	var refreshErr error
+	clock := auth0test.NewClock(time.Now())
+	provider, err := New(Options{
+		Secret:         secret,
+		Endpoint:       newServer(t, secretManager),
+		OnRefreshError: func(err error) { refreshErr = err },
+		Clock:          clock,
+	})
+	assert.NoError(t, err)
+
+	secretManager.mu.Lock()
+	secretManager.status = http.StatusForbidden
+	secretManager.mu.Unlock()
+	clock.Advance(DefaultTTL)
+	key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err, "the previous key should be served when it cannot be refreshed")
+	assert.Equal(t, []byte("secret"), key)
+	assert.Error(t, refreshErr)
+
+	_, _ = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	secretManager.mu.Lock()
+	defer secretManager.mu.Unlock()
+	assert.Equal(t, 2, secretManager.reads, "the refresh should not be retried before the retry interval")
+}
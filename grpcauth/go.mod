@@ -0,0 +1,11 @@
+module github.com/paulusrobin/go-auth0/grpcauth
+
+go 1.16
+
+require (
+	github.com/paulusrobin/go-auth0 v0.0.0-20261016110519-6a73be2dfea7
+	google.golang.org/grpc v1.51.0
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+replace github.com/paulusrobin/go-auth0 => ../
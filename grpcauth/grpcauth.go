@@ -0,0 +1,58 @@
+// Package grpcauth extracts and validates the tokens of incoming gRPC calls
+// from their metadata, sharing the extraction rules of the auth0 package.
+//
+// It lives in its own module so the core package does not depend on gRPC.
+package grpcauth
+
+import (
+	"context"
+	"net/http"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// FromIncomingContext extracts the JWT from the metadata of an incoming
+// gRPC call with the provided extractor, auth0.FromMetadata when nil.
+func FromIncomingContext(ctx context.Context, extractor auth0.MetadataTokenExtractor) (*jwt.JSONWebToken, error) {
+	if extractor == nil {
+		extractor = auth0.MetadataTokenExtractorFunc(auth0.FromMetadata)
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, auth0.ErrTokenNotFound
+	}
+	return extractor.ExtractMetadata(md)
+}
+
+// Validate validates the token of an incoming gRPC call with the validator,
+// as the token of the request returned by IncomingRequest. The extractor of
+// the validator, auth0.FromHeader by default, thus reads the metadata of the
+// call, e.g. "authorization: Bearer <token>", and so does the secret
+// provider, e.g. an auth0.JWKClient looking the key ID up. Any
+// auth0.Validator applies, such as an auth0.MultiTenantValidator.
+func Validate(ctx context.Context, validator auth0.Validator) (*auth0.ValidationResult, error) {
+	r, err := IncomingRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return validator.Validate(r)
+}
+
+// IncomingRequest returns a request carrying ctx whose headers are the
+// metadata of the incoming gRPC call, so that the request token extractors
+// and the secret providers of the auth0 package apply to gRPC calls.
+func IncomingRequest(ctx context.Context) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,125 @@
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var secret = []byte("secret")
+
+func getTestToken(t *testing.T) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{Issuer: "issuer", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestFromIncomingContext(t *testing.T) {
+	token := getTestToken(t)
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		extractor   auth0.MetadataTokenExtractor
+		expectedErr error
+	}{
+		{
+			name:      "pass - default extractor",
+			ctx:       metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token)),
+			extractor: nil,
+		},
+		{
+			name:      "pass - custom extractor",
+			ctx:       metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-access-token", token)),
+			extractor: auth0.FromMetadataWithConfig("x-access-token", ""),
+		},
+		{
+			name:        "fail - no metadata",
+			ctx:         context.Background(),
+			expectedErr: auth0.ErrTokenNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tok, err := FromIncomingContext(test.ctx, test.extractor)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			claims := jwt.Claims{}
+			if err = tok.Claims(secret, &claims); err != nil || claims.Issuer != "issuer" {
+				t.Errorf("Claims should be decoded correctly, got %v (%v)", claims, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	token := getTestToken(t)
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(secret), nil, "issuer", jose.HS256)
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		extractor   auth0.RequestTokenExtractor
+		expectedErr error
+	}{
+		{
+			name: "pass - default extractor",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token)),
+		},
+		{
+			name:      "pass - custom extractor",
+			ctx:       metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-access-token", token)),
+			extractor: auth0.FromHeaderWithConfig("X-Access-Token", ""),
+		},
+		{
+			name:        "fail - no metadata",
+			ctx:         context.Background(),
+			expectedErr: auth0.ErrTokenNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Validate(test.ctx, auth0.NewValidator(configuration, test.extractor))
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if err == nil && result.Claims.Issuer != "issuer" {
+				t.Errorf("Claims should be decoded correctly, got %v", result.Claims)
+			}
+		})
+	}
+}
+
+func TestIncomingRequest(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token", "x-request-id", "abc"))
+
+	r, err := IncomingRequest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Context() != ctx {
+		t.Error("the request should carry the context of the call")
+	}
+	if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Request-Id") != "abc" {
+		t.Errorf("the headers should be the metadata, got %v", r.Header)
+	}
+}
@@ -0,0 +1,41 @@
+// Package clocktest implements the clock of auth0test.Clock, without
+// depending on the auth0 package so that its own tests can use it too.
+package clocktest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a clock whose time only moves when told to. It is safe for
+// concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock creates a clock telling now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now tells the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the time of the clock by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the time of the clock.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
@@ -0,0 +1,80 @@
+package auth0
+
+import (
+	"net/http"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// JOSEBackend verifies the signature of compact serialized tokens and
+// unmarshalls their claims, so the JOSE library verifying tokens can be
+// replaced, e.g. by go-jose/v4 with the josev4 module, without changing the
+// API of the package.
+//
+// Keys provided as JSON Web Keys are given to the backend as the crypto key
+// they hold, so backends do not depend on gopkg.in/square/go-jose.v2.
+//
+// The backend only verifies the tokens: the API keeping the go-jose.v2
+// types, the validator checks the header of the token parsed by the
+// extractor, and validates the registered claims, with go-jose.v2.
+type JOSEBackend interface {
+	VerifyClaims(token string, key interface{}, values ...interface{}) error
+}
+
+// JOSEBackendFunc simple wrapper to implement
+// JOSEBackend with functions.
+type JOSEBackendFunc func(token string, key interface{}, values ...interface{}) error
+
+// VerifyClaims implements the JOSEBackend interface.
+func (f JOSEBackendFunc) VerifyClaims(token string, key interface{}, values ...interface{}) error {
+	return f(token, key, values...)
+}
+
+// GoJoseV2Backend is the JOSE backend based on gopkg.in/square/go-jose.v2,
+// the library used by the validator when no backend is configured.
+type GoJoseV2Backend struct{}
+
+// VerifyClaims implements the JOSEBackend interface.
+func (GoJoseV2Backend) VerifyClaims(token string, key interface{}, values ...interface{}) error {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return err
+	}
+	return parsed.Claims(key, values...)
+}
+
+// claimsVerifier verifies the signature of the request token with key and
+// unmarshalls its claims
+type claimsVerifier func(key interface{}, values ...interface{}) error
+
+// verifier returns the claims verifier of the request token: the token parsed
+// by the extractor when no backend is configured, or else the raw token given
+// to the backend, the claims being unmarshalled by the JSON codec when set
+func (v *JWTValidator) verifier(r *http.Request, token *jwt.JSONWebToken) (claimsVerifier, error) {
+	verify := claimsVerifier(token.Claims)
+	if v.backend != nil {
+		raw, err := ExtractRaw(v.extractor, r)
+		if err != nil {
+			return nil, err
+		}
+		verify = func(key interface{}, values ...interface{}) error {
+			return v.backend.VerifyClaims(raw, backendKey(key), values...)
+		}
+	}
+	if v.jsonCodec != nil {
+		verify = codecVerifier(verify, v.jsonCodec)
+	}
+	return verify, nil
+}
+
+// backendKey unwraps the crypto key of a JSON Web Key
+func backendKey(key interface{}) interface{} {
+	switch jwk := key.(type) {
+	case jose.JSONWebKey:
+		return jwk.Key
+	case *jose.JSONWebKey:
+		return jwk.Key
+	}
+	return key
+}
@@ -0,0 +1,79 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestValidatorWithBackend(t *testing.T) {
+	jwk := genRSASSAJWK(jose.RS256, "kid")
+	publicJWK := jwk.Public()
+	token := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, jwk, "kid")
+
+	var keys []interface{}
+	backend := JOSEBackendFunc(func(token string, key interface{}, values ...interface{}) error {
+		keys = append(keys, key)
+		return GoJoseV2Backend{}.VerifyClaims(token, key, values...)
+	})
+	validator, req := genTestConfiguration(NewConfiguration(NewKeyProvider(publicJWK), defaultAudience, defaultIssuer, jose.RS256), token)
+	validator = NewValidatorWithBackend(validator.config, nil, backend)
+
+	parsed, err := validator.ValidateRequest(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	claims := jwt.Claims{}
+	assert.NoError(t, validator.Claims(req, parsed, &claims))
+	assert.Equal(t, defaultIssuer, claims.Issuer)
+	assert.Equal(t, []interface{}{publicJWK.Key, publicJWK.Key}, keys)
+}
+
+func TestValidatorWithBackendErrors(t *testing.T) {
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("secret"))
+	backendErr := errors.New("backend failed")
+
+	tests := []struct {
+		name        string
+		provider    SecretProvider
+		extractor   RequestTokenExtractor
+		backend     JOSEBackend
+		expectedErr error
+	}{
+		{
+			name:     "fail - backend error",
+			provider: NewKeyProvider([]byte("secret")),
+			backend: JOSEBackendFunc(func(string, interface{}, ...interface{}) error {
+				return backendErr
+			}),
+			expectedErr: backendErr,
+		},
+		{
+			name:        "fail - extractor without raw token",
+			provider:    NewKeyProvider([]byte("secret")),
+			extractor:   RequestTokenExtractorFunc(FromHeader),
+			backend:     GoJoseV2Backend{},
+			expectedErr: ErrRawTokenUnsupported,
+		},
+		{
+			name:     "pass - rotated keys",
+			provider: NewKeyProvider(RotatedKeys{[]byte("new-secret"), []byte("secret")}),
+			backend:  GoJoseV2Backend{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewConfiguration(test.provider, defaultAudience, defaultIssuer, jose.HS256)
+			validator := NewValidatorWithBackend(config, test.extractor, test.backend)
+			req, _ := http.NewRequest("", "http://localhost", nil)
+			req.Header.Add("Authorization", "Bearer "+token)
+			_, err := validator.ValidateRequest(req)
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/paulusrobin/go-auth0/josev4
+
+go 1.21
+
+require (
+	github.com/go-jose/go-jose/v4 v4.0.5
+	github.com/paulusrobin/go-auth0 v0.0.0-20261016110519-6a73be2dfea7
+	github.com/stretchr/testify v1.10.0
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.32.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/paulusrobin/go-auth0 => ../
@@ -0,0 +1,58 @@
+// Package josev4 verifies the tokens of auth0 validators with
+// github.com/go-jose/go-jose/v4, the maintained successor of the archived
+// gopkg.in/square/go-jose.v2:
+//
+//	validator := auth0.NewValidatorWithBackend(configuration, nil, josev4.Backend{})
+//
+// The backend parses the raw token, verifies its signature and unmarshalls its
+// claims with go-jose/v4. The validator still checks the header of the token
+// parsed by its extractor, and the registered claims, with the go-jose.v2
+// types of its API.
+//
+// It lives in its own module so the core package does not depend on go-jose/v4.
+package josev4
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	auth0 "github.com/paulusrobin/go-auth0"
+)
+
+// DefaultAlgorithms are the signature algorithms accepted by a Backend
+// without Algorithms, the validator restricting them further when configured
+// with a signature algorithm.
+var DefaultAlgorithms = []jose.SignatureAlgorithm{
+	jose.HS256, jose.HS384, jose.HS512,
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Backend is the auth0.JOSEBackend based on go-jose/v4.
+type Backend struct {
+	// Algorithms are the signature algorithms of the accepted tokens, which
+	// go-jose/v4 requires to parse them. Defaults to DefaultAlgorithms.
+	Algorithms []jose.SignatureAlgorithm
+}
+
+// VerifyClaims implements the auth0.JOSEBackend interface. Signatures which
+// do not verify fail with auth0.ErrInvalidSignature.
+func (b Backend) VerifyClaims(token string, key interface{}, values ...interface{}) error {
+	algorithms := b.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = DefaultAlgorithms
+	}
+	parsed, err := jwt.ParseSigned(token, algorithms)
+	if err != nil {
+		return err
+	}
+	err = parsed.Claims(key, values...)
+	if errors.Is(err, jose.ErrCryptoFailure) {
+		return fmt.Errorf("%w: %w", auth0.ErrInvalidSignature, err)
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package josev4
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	josev2 "gopkg.in/square/go-jose.v2"
+)
+
+func genRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newRequest(t *testing.T, algorithm jose.SignatureAlgorithm, key interface{}, expiry time.Time) *http.Request {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: algorithm, Key: key}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "key1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{Issuer: "issuer", Expiry: jwt.NewNumericDate(expiry)}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Set("Authorization", "Bearer "+raw)
+	return req
+}
+
+func TestBackend(t *testing.T) {
+	key, otherKey := genRSAKey(t), genRSAKey(t)
+	secret := []byte("01234567890123456789012345678901")
+	jwk := josev2.JSONWebKey{Key: &key.PublicKey, KeyID: "key1", Algorithm: "RS256"}
+
+	tests := []struct {
+		name        string
+		backend     Backend
+		provider    auth0.SecretProvider
+		algorithm   josev2.SignatureAlgorithm
+		req         *http.Request
+		expectedErr error
+		failed      bool
+	}{
+		{
+			name:      "pass - crypto key",
+			provider:  auth0.NewKeyProvider(&key.PublicKey),
+			algorithm: josev2.RS256,
+			req:       newRequest(t, jose.RS256, key, time.Now().Add(time.Hour)),
+		},
+		{
+			name:      "pass - JSON Web Key",
+			provider:  auth0.NewKeyProvider(jwk),
+			algorithm: josev2.RS256,
+			req:       newRequest(t, jose.RS256, key, time.Now().Add(time.Hour)),
+		},
+		{
+			name:      "pass - HMAC",
+			provider:  auth0.NewKeyProvider(secret),
+			algorithm: josev2.HS256,
+			req:       newRequest(t, jose.HS256, secret, time.Now().Add(time.Hour)),
+		},
+		{
+			name:        "fail - invalid signature",
+			provider:    auth0.NewKeyProvider(&key.PublicKey),
+			algorithm:   josev2.RS256,
+			req:         newRequest(t, jose.RS256, otherKey, time.Now().Add(time.Hour)),
+			expectedErr: auth0.ErrInvalidSignature,
+		},
+		{
+			name:        "fail - expired",
+			provider:    auth0.NewKeyProvider(&key.PublicKey),
+			algorithm:   josev2.RS256,
+			req:         newRequest(t, jose.RS256, key, time.Now().Add(-time.Hour)),
+			expectedErr: auth0.ErrTokenExpired,
+		},
+		{
+			name:     "fail - algorithm not accepted by the backend",
+			backend:  Backend{Algorithms: []jose.SignatureAlgorithm{jose.RS256}},
+			provider: auth0.NewKeyProvider(secret),
+			req:      newRequest(t, jose.HS256, secret, time.Now().Add(time.Hour)),
+			failed:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := auth0.NewConfiguration(test.provider, nil, "issuer", test.algorithm)
+			validator := auth0.NewValidatorWithBackend(configuration, nil, test.backend)
+
+			result, err := validator.Validate(test.req)
+			switch {
+			case test.expectedErr != nil:
+				assert.ErrorIs(t, err, test.expectedErr)
+			case test.failed:
+				assert.Error(t, err)
+			default:
+				if assert.NoError(t, err) {
+					assert.Equal(t, "issuer", result.Claims.Issuer)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package auth0
+
+import (
+	"encoding/json"
+)
+
+// JSONCodec unmarshalls the JSON documents decoded on the hot path: the JWKS
+// downloaded by the JWKClient and the claims of the validated tokens, so a
+// faster decoder can be used instead of encoding/json. The Unmarshal method
+// of jsoniter.ConfigCompatibleWithStandardLibrary and of sonic.ConfigStd
+// implement it.
+//
+// The codec must honour json.Unmarshaler, which the keys of the JWKS and the
+// registered claims implement.
+type JSONCodec interface {
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodecFunc simple wrapper to implement
+// JSONCodec with functions, e.g. sonic.Unmarshal.
+type JSONCodecFunc func(data []byte, v interface{}) error
+
+// Unmarshal implements the JSONCodec interface.
+func (f JSONCodecFunc) Unmarshal(data []byte, v interface{}) error {
+	return f(data, v)
+}
+
+// StdJSONCodec is the JSON codec based on encoding/json, used by the
+// JWKClient when no codec is configured.
+type StdJSONCodec struct{}
+
+// Unmarshal implements the JSONCodec interface.
+func (StdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// codecVerifier returns the claims verifier verifying the signature of the
+// token with verify and unmarshalling its claims with codec. verify only
+// copies the verified payload, which json.RawMessage does not decode.
+func codecVerifier(verify claimsVerifier, codec JSONCodec) claimsVerifier {
+	return func(key interface{}, values ...interface{}) error {
+		var payload json.RawMessage
+		if err := verify(key, &payload); err != nil {
+			return err
+		}
+		return unmarshalClaims(codec, payload, values...)
+	}
+}
+
+// unmarshalClaims unmarshalls the payload of a token into values with codec
+func unmarshalClaims(codec JSONCodec, payload []byte, values ...interface{}) error {
+	for _, value := range values {
+		if err := codec.Unmarshal(payload, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
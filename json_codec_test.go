@@ -0,0 +1,113 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// countingCodec counts the documents it unmarshals with encoding/json
+type countingCodec struct {
+	calls int32
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	atomic.AddInt32(&c.calls, 1)
+	return json.Unmarshal(data, v)
+}
+
+func TestValidatorJSONCodec(t *testing.T) {
+	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret}, nil)
+	token, err := jwt.Signed(signer).
+		Claims(jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}).
+		Claims(map[string]interface{}{"https://myapp.example.com/roles": []string{"admin"}}).
+		CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec := &countingCodec{}
+	validator, err := NewValidatorBuilder(defaultSecretProvider).
+		WithAudience(defaultAudience...).
+		WithIssuer(defaultIssuer).
+		WithJSONCodec(codec).
+		WithResultCache(time.Minute, 10).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := validator.Validate(bearerRequest(token))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, defaultIssuer, result.Claims.Issuer)
+	assert.Equal(t, jwt.Audience(defaultAudience), result.Claims.Audience)
+	assert.Equal(t, json.RawMessage(`["admin"]`), result.CustomClaims["https://myapp.example.com/roles"])
+	// the registered claims of the validator, then the claims of the result
+	assert.Equal(t, int32(3), atomic.LoadInt32(&codec.calls))
+
+	// the claims of the remembered token are unmarshalled by the codec too
+	result, err = validator.Validate(bearerRequest(token))
+	if assert.NoError(t, err) {
+		assert.Equal(t, defaultIssuer, result.Claims.Issuer)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&codec.calls))
+
+	// the signature is still verified by the JOSE library
+	_, err = validator.Validate(bearerRequest(token[:len(token)-4] + "AAAA"))
+	assert.Error(t, err)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&codec.calls))
+}
+
+func TestValidatorJSONCodecErrors(t *testing.T) {
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	codecErr := errors.New("codec failed")
+	codec := JSONCodecFunc(func([]byte, interface{}) error {
+		return codecErr
+	})
+
+	tests := []struct {
+		name    string
+		backend JOSEBackend
+	}{
+		{name: "go-jose"},
+		{name: "backend", backend: GoJoseV2Backend{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidatorWithOptions(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, ValidatorOptions{
+				Backend:   test.backend,
+				JSONCodec: codec,
+			})
+			_, err := validator.ValidateRequest(bearerRequest(token))
+			assert.ErrorIs(t, err, codecErr)
+		})
+	}
+}
+
+func TestJWKClientJSONCodec(t *testing.T) {
+	jwk := genRSASSAJWK(jose.RS256, "kid")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(JWKS{Keys: []jose.JSONWebKey{jwk.Public()}})
+	}))
+	defer server.Close()
+
+	codec := &countingCodec{}
+	client := NewJWKClient(JWKClientOptions{URI: server.URL, JSONCodec: codec}, nil)
+	key, err := client.GetKey("kid")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "kid", key.KeyID)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&codec.calls))
+
+	assert.Equal(t, StdJSONCodec{}, NewJWKClient(JWKClientOptions{URI: server.URL}, nil).options.JSONCodec)
+}
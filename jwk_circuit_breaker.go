@@ -0,0 +1,148 @@
+package auth0
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCircuitOpen is returned instead of downloading the JWKS
+	// while the circuit breaker is open.
+	ErrCircuitOpen = errors.New("JWKS circuit breaker is open")
+
+	// DefaultCircuitOpenTimeout is how long the circuit stays open when
+	// CircuitBreakerOptions.OpenTimeout is not set.
+	DefaultCircuitOpenTimeout = 30 * time.Second
+)
+
+// CircuitState is the state of the circuit breaker guarding JWKS downloads.
+type CircuitState int
+
+const (
+	// CircuitClosed lets downloads through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails downloads immediately with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe download through, closing the
+	// circuit when it succeeds and opening it again when it fails.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerOptions configures the circuit breaker guarding JWKS
+// downloads, so that a failing endpoint is not hammered while cached keys
+// keep being served. The zero value disables the circuit breaker.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failed downloads
+	// opening the circuit, zero disables the circuit breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a probe download
+	// is let through. Defaults to DefaultCircuitOpenTimeout.
+	OpenTimeout time.Duration
+	// OnStateChange, when set, is called on every state transition,
+	// e.g. to export the state as a metric.
+	OnStateChange func(from, to CircuitState)
+}
+
+type circuitBreaker struct {
+	mu       sync.Mutex
+	options  CircuitBreakerOptions
+	clock    Clock
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// newCircuitBreaker returns nil when the circuit breaker is disabled,
+// nil circuit breakers letting every download through.
+func newCircuitBreaker(options CircuitBreakerOptions, clock Clock) *circuitBreaker {
+	if options.FailureThreshold <= 0 {
+		return nil
+	}
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = DefaultCircuitOpenTimeout
+	}
+	return &circuitBreaker{options: options, clock: clock}
+}
+
+// allow reports whether a download may be attempted.
+func (cb *circuitBreaker) allow() error {
+	if cb == nil {
+		return nil
+	}
+	cb.mu.Lock()
+	from := cb.state
+	switch {
+	case cb.state == CircuitOpen && cb.clock.Now().Sub(cb.openedAt) >= cb.options.OpenTimeout:
+		cb.state = CircuitHalfOpen
+		fallthrough
+	case cb.state == CircuitHalfOpen && !cb.probing:
+		cb.probing = true
+	case cb.state != CircuitClosed:
+		cb.mu.Unlock()
+		return ErrCircuitOpen
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(from, to)
+	return nil
+}
+
+// record updates the circuit with the outcome of an allowed download.
+func (cb *circuitBreaker) record(err error) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	from := cb.state
+	cb.probing = false
+	if err == nil {
+		cb.state, cb.failures = CircuitClosed, 0
+	} else if cb.failures++; cb.state == CircuitHalfOpen || cb.failures >= cb.options.FailureThreshold {
+		cb.state, cb.openedAt = CircuitOpen, cb.clock.Now()
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(from, to)
+}
+
+// release lets another probe through when an allowed download
+// was abandoned without telling anything about the endpoint.
+func (cb *circuitBreaker) release() {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
+
+func (cb *circuitBreaker) current() CircuitState {
+	if cb == nil {
+		return CircuitClosed
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+func (cb *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && cb.options.OnStateChange != nil {
+		cb.options.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,88 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	cb := newCircuitBreaker(CircuitBreakerOptions{}, SystemClock)
+	assert.Nil(t, cb)
+	assert.NoError(t, cb.allow())
+	cb.record(ErrNoKeyFound)
+	assert.Equal(t, CircuitClosed, cb.current())
+}
+
+func TestJWKClientCircuitBreaker(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	jwksURI := opts.URI
+
+	var calls, failing uint64 = 0, 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(&calls, 1)
+		if atomic.LoadUint64(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, jwksURI, http.StatusFound)
+	}))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var transitions []string
+	clock := clocktest.NewClock(time.Now())
+	client := NewJWKClient(JWKClientOptions{
+		URI:   ts.URL,
+		Clock: clock,
+		CircuitBreaker: CircuitBreakerOptions{
+			FailureThreshold: 2,
+			OpenTimeout:      time.Minute,
+			OnStateChange: func(from, to CircuitState) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, from.String()+"->"+to.String())
+			},
+		},
+	}, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err = client.downloadKeys(context.Background())
+		assert.ErrorAs(t, err, new(*StatusCodeError))
+	}
+	assert.Equal(t, CircuitOpen, client.CircuitState())
+
+	// the endpoint is not called while the circuit is open
+	_, err = client.downloadKeys(context.Background())
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&calls))
+
+	// a failed probe opens the circuit again
+	clock.Advance(time.Minute)
+	_, err = client.downloadKeys(context.Background())
+	assert.ErrorAs(t, err, new(*StatusCodeError))
+	assert.Equal(t, CircuitOpen, client.CircuitState())
+
+	// a successful probe closes the circuit
+	atomic.StoreUint64(&failing, 0)
+	clock.Advance(time.Minute)
+	keys, err := client.downloadKeys(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, keys)
+	assert.Equal(t, CircuitClosed, client.CircuitState())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}, transitions)
+}
@@ -2,11 +2,14 @@ package auth0
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
+	"io"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/square/go-jose.v2"
 )
@@ -14,11 +17,75 @@ import (
 var (
 	ErrInvalidContentType = errors.New("should have a JSON content type for JWKS endpoint")
 	ErrInvalidAlgorithm   = errors.New("algorithm is invalid")
+	// ErrInvalidRefreshInterval is returned by Start when no positive
+	// RefreshInterval is configured.
+	ErrInvalidRefreshInterval = errors.New("refresh interval should be positive")
+	// ErrRefresherStarted is returned by Start when the refresher is already running.
+	ErrRefresherStarted = errors.New("keys refresher already started")
+	// ErrResponseTooLarge is returned when the JWKS response body
+	// exceeds MaxResponseBytes.
+	ErrResponseTooLarge = errors.New("JWKS response exceeds the size limit")
 )
 
+// DefaultMaxResponseBytes is the maximum size of the JWKS response body
+// read when JWKClientOptions.MaxResponseBytes is not set.
+const DefaultMaxResponseBytes int64 = 1 << 20
+
 type JWKClientOptions struct {
-	URI    string
-	Client *http.Client
+	URI string
+	// FallbackURIs are tried in order when downloading the JWKS
+	// from URI fails, e.g. mirrors in other regions.
+	FallbackURIs []string
+	Client       *http.Client
+	// Transport tunes the client built when Client is not set.
+	Transport TransportOptions
+	// UserAgent, when set, is the User-Agent of JWKS requests.
+	UserAgent string
+	// Headers are added to JWKS requests, e.g. to authenticate
+	// against a gateway fronting the JWKS endpoint.
+	Headers http.Header
+	// RefreshInterval is the period at which the refresher launched
+	// by Start re-downloads the JWKS.
+	RefreshInterval time.Duration
+	// Retry configures how failed JWKS downloads are retried.
+	Retry RetryOptions
+	// CircuitBreaker configures the circuit breaker guarding JWKS downloads.
+	CircuitBreaker CircuitBreakerOptions
+	// RateLimit limits the JWKS downloads triggered by unknown key IDs.
+	RateLimit RateLimitOptions
+	// UnknownKeyTTL is how long a key ID missing from the downloaded JWKS
+	// is remembered, failing with ErrNoKeyFound without downloading the
+	// JWKS again. Zero disables the negative caching.
+	UnknownKeyTTL time.Duration
+	// OutagePolicy decides whether cached keys are served when the JWKS
+	// cannot be downloaded. Defaults to FailClosed.
+	OutagePolicy OutagePolicy
+	// OutageGracePeriod is how long after their expiry keys are served under
+	// the FailOpen policy, zero to serve them as long as they are retained.
+	OutageGracePeriod time.Duration
+	// FetchTimeout bounds every JWKS download attempt, independently of the
+	// timeout of the http.Client. Zero leaves attempts bounded by the
+	// context and the http.Client only.
+	FetchTimeout time.Duration
+	// MaxResponseBytes is the maximum size of the JWKS response body read
+	// once decompressed, DefaultMaxResponseBytes when not positive.
+	MaxResponseBytes int64
+	// Metrics, when set, records the outcome and the duration of every
+	// JWKS download attempt.
+	Metrics JWKSMetrics
+	// Tracer, when set, traces the key lookups of GetSecret
+	// and the JWKS downloads.
+	Tracer Tracer
+	// Logger, when set, logs the failed JWKS download attempts
+	// at the warn level.
+	Logger Logger
+	// Clock tells the time the unknown key IDs, the rate limit windows, the
+	// circuit breaker and the outage grace period expire at.
+	// Defaults to SystemClock.
+	Clock Clock
+	// JSONCodec unmarshalls the downloaded JWKS, e.g. with jsoniter or
+	// sonic. Defaults to StdJSONCodec.
+	JSONCodec JSONCodec
 }
 
 type JWKS struct {
@@ -27,9 +94,33 @@ type JWKS struct {
 
 type JWKClient struct {
 	keyCacher KeyCacher
-	mu        sync.Mutex
 	options   JWKClientOptions
 	extractor RequestTokenExtractor
+	breaker   *circuitBreaker
+	limiter   *downloadLimiter
+	httpCache jwksHTTPCache
+	unknown   *unknownKeyCache
+
+	// cacherMu serializes the calls to key cachers not safe for concurrent
+	// use, and is never held while the JWKS is downloaded
+	cacherMu         sync.Mutex
+	concurrentCacher bool
+
+	// downloadMu guards download, the JWKS download shared by the
+	// lookups of keys missing from the cache
+	downloadMu sync.Mutex
+	download   *keyDownload
+
+	refreshMu     sync.Mutex
+	refreshCancel context.CancelFunc
+	refreshDone   chan struct{}
+	// revalidateCancel and revalidateDone, guarded by refreshMu, let Close
+	// stop the background revalidation and wait for it
+	revalidateCancel context.CancelFunc
+	revalidateDone   chan struct{}
+	revalidating     int32
+	unreachable      int32
+	backoffUntil     int64
 }
 
 // NewJWKClient creates a new JWKClient instance from the
@@ -43,48 +134,449 @@ func NewJWKClient(options JWKClientOptions, extractor RequestTokenExtractor) *JW
 // Passing nil to keyCacher will create a persistent key cacher
 func NewJWKClientWithCache(options JWKClientOptions, extractor RequestTokenExtractor, keyCacher KeyCacher) *JWKClient {
 	if extractor == nil {
-		extractor = RequestTokenExtractorFunc(FromHeader)
+		extractor = RawTokenExtractorFunc(FromHeaderRaw)
 	}
 	if keyCacher == nil {
 		keyCacher = newMemoryPersistentKeyCacher()
 	}
 	if options.Client == nil {
-		options.Client = http.DefaultClient
+		options.Client = options.Transport.newClient()
 	}
+	if options.MaxResponseBytes <= 0 {
+		options.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if options.JSONCodec == nil {
+		options.JSONCodec = StdJSONCodec{}
+	}
+	options.Clock = clockOrSystem(options.Clock)
 
 	return &JWKClient{
-		keyCacher: keyCacher,
-		options:   options,
-		extractor: extractor,
+		keyCacher:        keyCacher,
+		options:          options,
+		extractor:        extractor,
+		breaker:          newCircuitBreaker(options.CircuitBreaker, options.Clock),
+		limiter:          newDownloadLimiter(options.RateLimit, options.Clock),
+		unknown:          newUnknownKeyCache(options.UnknownKeyTTL, options.Clock),
+		concurrentCacher: isConcurrentKeyCacher(keyCacher),
 	}
 }
 
 // GetKey returns the key associated with the provided ID.
+// It is a shortcut for GetKeyWithContext with a background context.
 func (j *JWKClient) GetKey(ID string) (jose.JSONWebKey, error) {
-	j.mu.Lock()
-	defer j.mu.Unlock()
+	return j.GetKeyWithContext(context.Background(), ID)
+}
 
-	searchedKey, err := j.keyCacher.Get(ID)
+// GetKeyWithContext returns the key associated with the provided ID,
+// downloading the JWKS within ctx when the key is not cached.
+// Expired keys still retained by a StaleKeyCacher are served while the JWKS
+// is downloaded again in the background, unless the last download failed.
+// Failed downloads are then handled according to the OutagePolicy.
+// Errors are returned as a *JWKSError holding the JWKS URI and the key ID.
+func (j *JWKClient) GetKeyWithContext(ctx context.Context, ID string) (jose.JSONWebKey, error) {
+	key, err := j.getKey(ctx, ID)
+	if err != nil {
+		return key, jwksError(err, j.options.URI, ID)
+	}
+	return key, nil
+}
+
+// getKey returns the key associated with the provided ID, see
+// GetKeyWithContext. Concurrent lookups of missing keys share a single JWKS
+// download, the key cacher being locked only while it is called.
+func (j *JWKClient) getKey(ctx context.Context, ID string) (jose.JSONWebKey, error) {
+	searchedKey, err := j.cachedKey(ID)
+	if err == nil {
+		return *searchedKey, nil
+	}
+	if err == ErrKeyExpired && j.reachable() {
+		if staleKey, _, staleErr := j.staleKey(ID); staleErr == nil {
+			j.revalidateKeys()
+			return *staleKey, nil
+		}
+	}
+	if j.unknown.contains(ID) {
+		return jose.JSONWebKey{}, ErrNoKeyFound
+	}
+	keys, err := j.awaitDownload(ctx, ID)
+	if err == ErrRateLimited {
+		return jose.JSONWebKey{}, err
+	}
+	if err != nil {
+		return j.outageKey(ID, err)
+	}
+	addedKey, err := j.cacheKeys(ID, keys)
+	if err == ErrNoKeyFound {
+		j.unknown.add(ID)
+	}
 	if err != nil {
-		keys, err := j.downloadKeys()
-		if err != nil {
-			return jose.JSONWebKey{}, err
+		return jose.JSONWebKey{}, err
+	}
+	return *addedKey, nil
+}
+
+// keyDownload is a JWKS download shared by the lookups of missing keys
+type keyDownload struct {
+	done chan struct{}
+	// keys and err are set once done is closed
+	keys []jose.JSONWebKey
+	err  error
+	// waiters counts the lookups waiting for the download, which is
+	// canceled once they all gave up
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// awaitDownload waits for the JWKS download in progress, or starts one
+// unless the rate limit is reached. The download outlives ctx, which only
+// bounds the wait, as long as another lookup waits for it.
+func (j *JWKClient) awaitDownload(ctx context.Context, ID string) ([]jose.JSONWebKey, error) {
+	j.downloadMu.Lock()
+	d := j.download
+	if d == nil {
+		if err := j.limiter.allow(ID); err != nil {
+			j.downloadMu.Unlock()
+			return nil, err
+		}
+		// the download keeps the values of ctx, e.g. its span
+		downloadCtx, cancel := context.WithCancel(detachedContext{ctx})
+		d = &keyDownload{done: make(chan struct{}), cancel: cancel}
+		j.download = d
+		go j.runDownload(downloadCtx, d)
+	}
+	d.waiters++
+	j.downloadMu.Unlock()
+
+	select {
+	case <-d.done:
+		return d.keys, d.err
+	case <-ctx.Done():
+		j.downloadMu.Lock()
+		if d.waiters--; d.waiters == 0 {
+			d.cancel()
+			if j.download == d {
+				j.download = nil
+			}
 		}
-		addedKey, err := j.keyCacher.Add(ID, keys)
-		if err != nil {
-			return jose.JSONWebKey{}, err
+		j.downloadMu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// runDownload downloads the JWKS for the lookups waiting for d
+func (j *JWKClient) runDownload(ctx context.Context, d *keyDownload) {
+	defer d.cancel()
+	d.keys, d.err = j.downloadKeys(ctx)
+
+	j.downloadMu.Lock()
+	if j.download == d {
+		j.download = nil
+	}
+	j.downloadMu.Unlock()
+	close(d.done)
+}
+
+// detachedContext keeps the values of its parent but neither its
+// deadline nor its cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// PreloadKeys downloads the JWKS and caches every key it contains, so that
+// the first requests do not wait for a download. Called at startup, it fails
+// fast when the JWKS endpoint is misconfigured or unreachable.
+func (j *JWKClient) PreloadKeys(ctx context.Context) error {
+	return j.refreshKeys(ctx)
+}
+
+// DownloadKeys downloads the JWKS like the key lookups do, and returns its
+// keys without caching them, e.g. to inspect the published keys.
+func (j *JWKClient) DownloadKeys(ctx context.Context) ([]jose.JSONWebKey, error) {
+	return j.downloadKeys(ctx)
+}
+
+// ExportKeys writes a JSON snapshot of the cached keys to w, or returns
+// ErrSnapshotNotSupported when the key cacher cannot export its keys.
+func (j *JWKClient) ExportKeys(w io.Writer) error {
+	snapshotCacher, ok := j.keyCacher.(SnapshotKeyCacher)
+	if !ok {
+		return ErrSnapshotNotSupported
+	}
+	j.lockCacher()
+	defer j.unlockCacher()
+	return snapshotCacher.ExportSnapshot(w)
+}
+
+// ImportKeys adds the keys of a JSON snapshot written by ExportKeys to the
+// key cacher, or returns ErrSnapshotNotSupported when it cannot import keys.
+func (j *JWKClient) ImportKeys(r io.Reader) error {
+	snapshotCacher, ok := j.keyCacher.(SnapshotKeyCacher)
+	if !ok {
+		return ErrSnapshotNotSupported
+	}
+	j.lockCacher()
+	defer j.unlockCacher()
+	return snapshotCacher.ImportSnapshot(r)
+}
+
+// Start launches a goroutine re-downloading the JWKS every RefreshInterval and
+// caching every key, so that rotated keys are known before the first token
+// using them comes in. Download failures keep the previously cached keys.
+// The refresher runs until ctx is done or Close is called, after which Start
+// can launch it again.
+func (j *JWKClient) Start(ctx context.Context) error {
+	if j.options.RefreshInterval <= 0 {
+		return ErrInvalidRefreshInterval
+	}
+
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+	if j.refreshCancel != nil {
+		return ErrRefresherStarted
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	j.refreshCancel, j.refreshDone = cancel, done
+
+	go func() {
+		defer close(done)
+		defer j.stopRefresher(done)
+		ticker := time.NewTicker(j.options.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = j.refreshKeys(ctx)
+			}
 		}
-		return *addedKey, nil
+	}()
+	return nil
+}
+
+// stopRefresher clears the state of the refresher whose goroutine closes
+// done, unless Close or a later Start replaced it.
+func (j *JWKClient) stopRefresher(done chan struct{}) {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+	if j.refreshDone == done {
+		j.refreshCancel()
+		j.refreshCancel, j.refreshDone = nil, nil
 	}
+}
 
-	return *searchedKey, nil
+// Close stops the refresher launched by Start, and the background
+// revalidation of stale keys, and waits for them to exit. It is safe to call
+// Close when neither is running.
+func (j *JWKClient) Close() error {
+	j.refreshMu.Lock()
+	cancel, done := j.refreshCancel, j.refreshDone
+	j.refreshCancel, j.refreshDone = nil, nil
+	revalidateCancel, revalidateDone := j.revalidateCancel, j.revalidateDone
+	j.revalidateCancel, j.revalidateDone = nil, nil
+	j.refreshMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	if revalidateCancel != nil {
+		revalidateCancel()
+		<-revalidateDone
+	}
+	return nil
 }
 
-func (j *JWKClient) downloadKeys() ([]jose.JSONWebKey, error) {
-	req, err := http.NewRequest("GET", j.options.URI, new(bytes.Buffer))
+// revalidateKeys refreshes the keys in the background while stale keys
+// are served, running at most one refresh at a time, which Close stops.
+func (j *JWKClient) revalidateKeys() {
+	if !atomic.CompareAndSwapInt32(&j.revalidating, 0, 1) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	j.refreshMu.Lock()
+	j.revalidateCancel, j.revalidateDone = cancel, done
+	j.refreshMu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer atomic.StoreInt32(&j.revalidating, 0)
+		defer cancel()
+		_ = j.refreshKeys(ctx)
+	}()
+}
+
+// refreshKeys downloads the JWKS and caches every key it contains.
+func (j *JWKClient) refreshKeys(ctx context.Context) error {
+	keys, err := j.downloadKeys(ctx)
 	if err != nil {
+		return err
+	}
+
+	for i := range keys {
+		j.unknown.remove(keys[i].KeyID)
+		if _, err := j.cacheKeys(keys[i].KeyID, keys[i:i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheKeys adds the downloaded keys to the key cacher, expiring them
+// after the max-age of the JWKS response when the cacher supports it.
+func (j *JWKClient) cacheKeys(ID string, keys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	j.lockCacher()
+	defer j.unlockCacher()
+	return addWithTTL(j.keyCacher, ID, keys, j.httpCache.ttl())
+}
+
+// cachedKey looks the key up in the key cacher
+func (j *JWKClient) cachedKey(ID string) (*jose.JSONWebKey, error) {
+	j.lockCacher()
+	defer j.unlockCacher()
+	return j.keyCacher.Get(ID)
+}
+
+// staleKey looks the key up, even expired, in the key cacher when it
+// retains expired keys
+func (j *JWKClient) staleKey(ID string) (*jose.JSONWebKey, time.Time, error) {
+	staleCacher, ok := j.keyCacher.(StaleKeyCacher)
+	if !ok {
+		return nil, time.Time{}, ErrNoKeyFound
+	}
+	j.lockCacher()
+	defer j.unlockCacher()
+	return staleCacher.GetStale(ID)
+}
+
+// lockCacher locks the key cacher unless it is safe for concurrent use
+func (j *JWKClient) lockCacher() {
+	if !j.concurrentCacher {
+		j.cacherMu.Lock()
+	}
+}
+
+// unlockCacher unlocks the key cacher locked by lockCacher
+func (j *JWKClient) unlockCacher() {
+	if !j.concurrentCacher {
+		j.cacherMu.Unlock()
+	}
+}
+
+// Healthy downloads the JWKS once, without retries nor circuit breaker,
+// reporting whether a key endpoint is reachable and serves a valid key set.
+// It suits readiness probes and the validation of the configuration at
+// startup, and leaves the cached keys and the HTTP caching state of the
+// downloads untouched.
+func (j *JWKClient) Healthy(ctx context.Context) error {
+	// the probe neither sends conditional requests nor records the
+	// validators of the response, which would alter the next downloads
+	_, err := j.fetchKeys(ctx, nil)
+	return err
+}
+
+// CircuitState returns the state of the circuit breaker guarding
+// JWKS downloads, CircuitClosed when it is disabled.
+func (j *JWKClient) CircuitState() CircuitState {
+	return j.breaker.current()
+}
+
+// downloadKeys downloads the JWKS within ctx, retrying according to the
+// Retry options unless the circuit breaker is open. Downloads are suspended
+// for the delay the JWKS endpoint asks for, e.g. when rate limiting.
+func (j *JWKClient) downloadKeys(ctx context.Context) (keys []jose.JSONWebKey, err error) {
+	if j.options.Tracer != nil {
+		var span Span
+		ctx, span = j.options.Tracer.Start(ctx, SpanDownloadKeys)
+		span.SetAttribute(AttributeURI, j.options.URI)
+		defer func() { endSpan(span, err) }()
+	}
+	if j.options.Clock.Now().UnixNano() < atomic.LoadInt64(&j.backoffUntil) {
+		return []jose.JSONWebKey{}, ErrBackingOff
+	}
+	if err := j.breaker.allow(); err != nil {
 		return []jose.JSONWebKey{}, err
 	}
+	keys, err = j.fetchKeys(ctx, &j.httpCache)
+	for attempt := 1; attempt < j.options.Retry.MaxAttempts && err != nil && j.options.Retry.retryable(err); attempt++ {
+		if err = sleepContext(ctx, j.options.Retry.delay(attempt)); err != nil {
+			break
+		}
+		keys, err = j.fetchKeys(ctx, &j.httpCache)
+	}
+	if err != nil && ctx.Err() != nil {
+		// the caller gave up, which tells nothing about the JWKS endpoint
+		j.breaker.release()
+		return []jose.JSONWebKey{}, ctx.Err()
+	}
+	if retryAfter := j.options.Retry.retryAfter(err); retryAfter > 0 {
+		atomic.StoreInt64(&j.backoffUntil, j.options.Clock.Now().Add(retryAfter).UnixNano())
+	}
+	j.breaker.record(err)
+	j.setReachable(err == nil)
+	return keys, err
+}
+
+// fetchKeys downloads the JWKS from the URI, then from the FallbackURIs
+// in order until a download succeeds, revalidating the keys previously
+// downloaded with httpCache unless nil.
+// Errors are returned as a *JWKSError holding the URI of the last download.
+func (j *JWKClient) fetchKeys(ctx context.Context, httpCache *jwksHTTPCache) ([]jose.JSONWebKey, error) {
+	uri := j.options.URI
+	keys, err := j.fetchKeysFrom(ctx, uri, httpCache)
+	for _, fallbackURI := range j.options.FallbackURIs {
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		uri = fallbackURI
+		keys, err = j.fetchKeysFrom(ctx, uri, httpCache)
+	}
+	if err != nil {
+		return keys, &JWKSError{URI: uri, Err: err}
+	}
+	return keys, nil
+}
+
+func (j *JWKClient) fetchKeysFrom(ctx context.Context, uri string, httpCache *jwksHTTPCache) (keys []jose.JSONWebKey, err error) {
+	if j.options.Metrics != nil {
+		start := time.Now()
+		defer func() {
+			j.options.Metrics.ObserveJWKSFetch(uri, err, time.Since(start))
+		}()
+	}
+	if j.options.Logger != nil {
+		defer func() {
+			if err != nil {
+				j.options.Logger.Warn("auth0: JWKS download failed", "uri", uri, "reason", ErrorReason(err), "error", err)
+			}
+		}()
+	}
+	if j.options.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.options.FetchTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, new(bytes.Buffer))
+	if err != nil {
+		return []jose.JSONWebKey{}, err
+	}
+	for name, values := range j.options.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if j.options.UserAgent != "" {
+		req.Header.Set("User-Agent", j.options.UserAgent)
+	}
+	req.Header.Set("Accept-Encoding", jwksAcceptEncoding)
+	httpCache.prepare(uri, req)
 	resp, err := j.options.Client.Do(req)
 
 	if err != nil {
@@ -92,12 +584,30 @@ func (j *JWKClient) downloadKeys() ([]jose.JSONWebKey, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return httpCache.notModified(resp)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return []jose.JSONWebKey{}, &StatusCodeError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header, j.options.Clock.Now()),
+		}
+	}
+
 	if contentH := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentH, "application/json") {
 		return []jose.JSONWebKey{}, ErrInvalidContentType
 	}
 
+	// the keys do not reference the pooled body once decoded
+	body := getBuffer()
+	defer putBuffer(body)
+	if err := j.readBody(resp, body); err != nil {
+		return []jose.JSONWebKey{}, err
+	}
+
 	var jwks = JWKS{}
-	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	err = j.options.JSONCodec.Unmarshal(body.Bytes(), &jwks)
 
 	if err != nil {
 		return []jose.JSONWebKey{}, err
@@ -107,21 +617,29 @@ func (j *JWKClient) downloadKeys() ([]jose.JSONWebKey, error) {
 		return []jose.JSONWebKey{}, ErrNoKeyFound
 	}
 
+	httpCache.store(uri, resp, jwks.Keys)
 	return jwks.Keys, nil
 }
 
 // GetSecret implements the GetSecret method of the SecretProvider interface.
+// It returns the cached jose.JSONWebKey, whose Key, e.g. a *rsa.PublicKey, is
+// parsed once when the JWKS is downloaded rather than on every validation.
 func (j *JWKClient) GetSecret(r *http.Request) (interface{}, error) {
-	token, err := j.extractor.Extract(r)
+	keyID, err := requestKeyID(j.extractor, r)
 	if err != nil {
 		return nil, err
 	}
+	return j.tracedGetKey(r.Context(), keyID)
+}
 
-	if len(token.Headers) < 1 {
-		return nil, ErrNoJWTHeaders
+// tracedGetKey returns the key associated with the provided ID within a span
+func (j *JWKClient) tracedGetKey(ctx context.Context, keyID string) (jose.JSONWebKey, error) {
+	if j.options.Tracer == nil {
+		return j.GetKeyWithContext(ctx, keyID)
 	}
-
-	header := token.Headers[0]
-
-	return j.GetKey(header.KeyID)
+	ctx, span := j.options.Tracer.Start(ctx, SpanGetSecret)
+	span.SetAttribute(AttributeKeyID, keyID)
+	key, err := j.GetKeyWithContext(ctx, keyID)
+	endSpan(span, err)
+	return key, err
 }
@@ -0,0 +1,124 @@
+package auth0
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// JWKClientOptionsBuilder builds JWKClientOptions step by step,
+// tuning the JWKS HTTP behavior without constructing a custom client.
+type JWKClientOptionsBuilder struct {
+	options JWKClientOptions
+}
+
+// NewJWKClientOptionsBuilder creates a builder of options
+// downloading the JWKS from uri.
+func NewJWKClientOptionsBuilder(uri string) *JWKClientOptionsBuilder {
+	return &JWKClientOptionsBuilder{options: JWKClientOptions{URI: uri}}
+}
+
+// WithTimeout bounds a whole JWKS request, redirects and body included.
+func (b *JWKClientOptionsBuilder) WithTimeout(timeout time.Duration) *JWKClientOptionsBuilder {
+	b.options.Transport.Timeout = timeout
+	return b
+}
+
+// WithFetchTimeout bounds every JWKS download attempt.
+func (b *JWKClientOptionsBuilder) WithFetchTimeout(timeout time.Duration) *JWKClientOptionsBuilder {
+	b.options.FetchTimeout = timeout
+	return b
+}
+
+// WithDialTimeout bounds the establishment of connections.
+func (b *JWKClientOptionsBuilder) WithDialTimeout(timeout time.Duration) *JWKClientOptionsBuilder {
+	b.options.Transport.DialTimeout = timeout
+	return b
+}
+
+// WithTLSHandshakeTimeout bounds TLS handshakes.
+func (b *JWKClientOptionsBuilder) WithTLSHandshakeTimeout(timeout time.Duration) *JWKClientOptionsBuilder {
+	b.options.Transport.TLSHandshakeTimeout = timeout
+	return b
+}
+
+// WithResponseHeaderTimeout bounds the wait for response headers.
+func (b *JWKClientOptionsBuilder) WithResponseHeaderTimeout(timeout time.Duration) *JWKClientOptionsBuilder {
+	b.options.Transport.ResponseHeaderTimeout = timeout
+	return b
+}
+
+// WithKeepAlive sets the keep-alive period of connections,
+// negative to disable keep-alives.
+func (b *JWKClientOptionsBuilder) WithKeepAlive(keepAlive time.Duration) *JWKClientOptionsBuilder {
+	b.options.Transport.KeepAlive = keepAlive
+	return b
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections,
+// overall and per host.
+func (b *JWKClientOptionsBuilder) WithMaxIdleConns(maxIdleConns, maxIdleConnsPerHost int) *JWKClientOptionsBuilder {
+	b.options.Transport.MaxIdleConns = maxIdleConns
+	b.options.Transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return b
+}
+
+// WithIdleConnTimeout sets how long idle connections are kept.
+func (b *JWKClientOptionsBuilder) WithIdleConnTimeout(timeout time.Duration) *JWKClientOptionsBuilder {
+	b.options.Transport.IdleConnTimeout = timeout
+	return b
+}
+
+// WithMaxRedirects sets the number of redirects followed,
+// negative to follow none.
+func (b *JWKClientOptionsBuilder) WithMaxRedirects(n int) *JWKClientOptionsBuilder {
+	b.options.Transport.MaxRedirects = n
+	return b
+}
+
+// WithRedirectPolicy sets the redirect policy of the client,
+// see http.Client.CheckRedirect.
+func (b *JWKClientOptionsBuilder) WithRedirectPolicy(checkRedirect func(req *http.Request, via []*http.Request) error) *JWKClientOptionsBuilder {
+	b.options.Transport.CheckRedirect = checkRedirect
+	return b
+}
+
+// WithTLSConfig sets the TLS configuration of JWKS connections.
+func (b *JWKClientOptionsBuilder) WithTLSConfig(config *tls.Config) *JWKClientOptionsBuilder {
+	b.options.Transport.TLSConfig = config
+	return b
+}
+
+// WithProxy sets the proxy every JWKS request goes through.
+func (b *JWKClientOptionsBuilder) WithProxy(proxy *url.URL) *JWKClientOptionsBuilder {
+	b.options.Transport.Proxy = proxy
+	return b
+}
+
+// WithProxyFromEnvironment toggles taking the proxy from the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables, enabled by default.
+func (b *JWKClientOptionsBuilder) WithProxyFromEnvironment(enabled bool) *JWKClientOptionsBuilder {
+	b.options.Transport.DisableProxyFromEnvironment = !enabled
+	return b
+}
+
+// WithUserAgent sets the User-Agent of JWKS requests.
+func (b *JWKClientOptionsBuilder) WithUserAgent(userAgent string) *JWKClientOptionsBuilder {
+	b.options.UserAgent = userAgent
+	return b
+}
+
+// WithHeader adds a header to JWKS requests.
+func (b *JWKClientOptionsBuilder) WithHeader(name, value string) *JWKClientOptionsBuilder {
+	if b.options.Headers == nil {
+		b.options.Headers = http.Header{}
+	}
+	b.options.Headers.Add(name, value)
+	return b
+}
+
+// Build returns the built options.
+func (b *JWKClientOptionsBuilder) Build() JWKClientOptions {
+	return b.options
+}
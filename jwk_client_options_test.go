@@ -0,0 +1,60 @@
+package auth0
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKClientOptionsBuilder(t *testing.T) {
+	options := NewJWKClientOptionsBuilder("https://example.com/.well-known/jwks.json").
+		WithTimeout(time.Second).
+		WithFetchTimeout(2*time.Second).
+		WithDialTimeout(3*time.Second).
+		WithTLSHandshakeTimeout(4*time.Second).
+		WithResponseHeaderTimeout(5*time.Second).
+		WithKeepAlive(6*time.Second).
+		WithMaxIdleConns(7, 8).
+		WithIdleConnTimeout(9 * time.Second).
+		WithMaxRedirects(-1).
+		Build()
+
+	assert.Equal(t, "https://example.com/.well-known/jwks.json", options.URI)
+	assert.Equal(t, 2*time.Second, options.FetchTimeout)
+	assert.Equal(t, TransportOptions{
+		Timeout:               time.Second,
+		DialTimeout:           3 * time.Second,
+		TLSHandshakeTimeout:   4 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+		KeepAlive:             6 * time.Second,
+		MaxIdleConns:          7,
+		MaxIdleConnsPerHost:   8,
+		IdleConnTimeout:       9 * time.Second,
+		MaxRedirects:          -1,
+	}, options.Transport)
+
+	options = NewJWKClientOptionsBuilder("").
+		WithRedirectPolicy(func(req *http.Request, via []*http.Request) error { return nil }).
+		Build()
+	assert.NotNil(t, options.Transport.CheckRedirect)
+}
+
+func TestJWKClientOptionsBuilderProxy(t *testing.T) {
+	proxy, _ := url.Parse("http://proxy.example.com:3128")
+	options := NewJWKClientOptionsBuilder("").WithProxy(proxy).Build()
+	assert.Equal(t, proxy, options.Transport.Proxy)
+	assert.False(t, options.Transport.DisableProxyFromEnvironment)
+
+	options = NewJWKClientOptionsBuilder("").WithProxyFromEnvironment(false).Build()
+	assert.True(t, options.Transport.DisableProxyFromEnvironment)
+}
+
+func TestJWKClientOptionsBuilderTLSConfig(t *testing.T) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	options := NewJWKClientOptionsBuilder("").WithTLSConfig(config).Build()
+	assert.Equal(t, config, options.Transport.TLSConfig)
+}
@@ -0,0 +1,64 @@
+package auth0
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrUnsupportedContentEncoding is returned when the JWKS response
+// is encoded with neither gzip nor deflate.
+var ErrUnsupportedContentEncoding = errors.New("unsupported content encoding for JWKS endpoint")
+
+// jwksAcceptEncoding is advertised on JWKS requests.
+const jwksAcceptEncoding = "gzip, deflate"
+
+// readBody reads the JWKS response body into buf, decompressing it according
+// to its Content-Encoding. At most MaxResponseBytes are read once
+// decompressed, so that compressed responses cannot expand without bounds.
+func (j *JWKClient) readBody(resp *http.Response, buf *bytes.Buffer) error {
+	body, err := decodeBody(resp)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if _, err := buf.ReadFrom(io.LimitReader(body, j.options.MaxResponseBytes+1)); err != nil {
+		return err
+	}
+	if int64(buf.Len()) > j.options.MaxResponseBytes {
+		return ErrResponseTooLarge
+	}
+	return nil
+}
+
+// decodeBody returns a reader decompressing the response body.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		// deflate should be zlib wrapped, but some servers send raw deflate.
+		body := bufio.NewReader(resp.Body)
+		if header, err := body.Peek(2); err == nil && isZlibHeader(header) {
+			return zlib.NewReader(body)
+		}
+		return flate.NewReader(body), nil
+	default:
+		return nil, ErrUnsupportedContentEncoding
+	}
+}
+
+// isZlibHeader reports whether header starts a zlib stream,
+// as specified by RFC 1950.
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
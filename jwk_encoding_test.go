@@ -0,0 +1,112 @@
+package auth0
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		return data
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestJWKDownloadKeyEncoding(t *testing.T) {
+	jwk := genRSASSAJWK(jose.RS256, "keyRS256")
+	body, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{jwk.Public()}})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	tests := []struct {
+		name             string
+		contentEncoding  string
+		compression      string
+		maxResponseBytes int64
+		expectedError    error
+	}{
+		{
+			name:            "pass - identity",
+			contentEncoding: "",
+		},
+		{
+			name:            "pass - gzip",
+			contentEncoding: "gzip",
+			compression:     "gzip",
+		},
+		{
+			name:            "pass - deflate",
+			contentEncoding: "deflate",
+			compression:     "deflate",
+		},
+		{
+			name:            "pass - raw deflate",
+			contentEncoding: "deflate",
+			compression:     "raw-deflate",
+		},
+		{
+			name:            "fail - unsupported encoding",
+			contentEncoding: "br",
+			expectedError:   ErrUnsupportedContentEncoding,
+		},
+		{
+			name:             "fail - decompressed body too large",
+			contentEncoding:  "gzip",
+			compression:      "gzip",
+			maxResponseBytes: int64(len(body)) - 1,
+			expectedError:    ErrResponseTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, jwksAcceptEncoding, r.Header.Get("Accept-Encoding"))
+				w.Header().Set("Content-Type", "application/json")
+				if test.contentEncoding != "" {
+					w.Header().Set("Content-Encoding", test.contentEncoding)
+				}
+				w.Write(compress(t, test.compression, body))
+			}))
+			defer ts.Close()
+
+			client := NewJWKClient(JWKClientOptions{URI: ts.URL, MaxResponseBytes: test.maxResponseBytes}, nil)
+			keys, err := client.downloadKeys(context.Background())
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+				assert.Empty(t, keys)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, keys, 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package auth0
+
+import "fmt"
+
+// JWKSError is the error of the JWKS client along with the JWKS URI and the
+// ID of the key it failed to get. It wraps the error of the failure, e.g.
+// ErrNoKeyFound or a *StatusCodeError, which errors.Is and errors.As still
+// match.
+type JWKSError struct {
+	// URI is the URI of the JWKS, the last one tried when the download
+	// fell back to the FallbackURIs.
+	URI string
+	// KeyID is the ID of the key, empty when the failure is not specific to
+	// a key, e.g. for PreloadKeys.
+	KeyID string
+	// Err is the error of the failure.
+	Err error
+}
+
+// Error returns the message of the error of the failure along with the
+// JWKS URI and the key ID.
+func (e *JWKSError) Error() string {
+	if e.KeyID == "" {
+		return fmt.Sprintf("%v (jwks_uri=%s)", e.Err, e.URI)
+	}
+	return fmt.Sprintf("%v (jwks_uri=%s, kid=%s)", e.Err, e.URI, e.KeyID)
+}
+
+// Unwrap returns the error of the failure.
+func (e *JWKSError) Unwrap() error {
+	return e.Err
+}
+
+// jwksError returns err as a JWKSError for the key ID, keeping the URI of
+// err when it is already a JWKSError
+func jwksError(err error, uri, keyID string) error {
+	if jwksErr, ok := err.(*JWKSError); ok {
+		uri, err = jwksErr.URI, jwksErr.Err
+	}
+	return &JWKSError{URI: uri, KeyID: keyID, Err: err}
+}
@@ -0,0 +1,76 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKSError(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	tests := []struct {
+		name          string
+		options       JWKClientOptions
+		keyID         string
+		expectedErr   error
+		expectedURI   string
+		expectedError string
+	}{
+		{
+			name:          "unknown key ID",
+			options:       opts,
+			keyID:         "unknown",
+			expectedErr:   ErrNoKeyFound,
+			expectedURI:   opts.URI,
+			expectedError: ErrNoKeyFound.Error() + " (jwks_uri=" + opts.URI + ", kid=unknown)",
+		},
+		{
+			name:          "download failure",
+			options:       JWKClientOptions{URI: "http://127.0.0.1:0/jwks.json", FallbackURIs: []string{unavailable.URL}},
+			keyID:         "keyRS256",
+			expectedURI:   unavailable.URL,
+			expectedError: (&StatusCodeError{StatusCode: http.StatusServiceUnavailable}).Error() + " (jwks_uri=" + unavailable.URL + ", kid=keyRS256)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewJWKClient(test.options, nil).GetKey(test.keyID)
+
+			var jwksErr *JWKSError
+			if !assert.True(t, errors.As(err, &jwksErr), err) {
+				return
+			}
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			}
+			assert.Equal(t, test.expectedURI, jwksErr.URI)
+			assert.Equal(t, test.keyID, jwksErr.KeyID)
+			assert.EqualError(t, err, test.expectedError)
+		})
+	}
+}
+
+func TestJWKSErrorWithoutKeyID(t *testing.T) {
+	client := NewJWKClient(JWKClientOptions{URI: "http://127.0.0.1:0/jwks.json"}, nil)
+	err := client.PreloadKeys(context.Background())
+
+	var jwksErr *JWKSError
+	if assert.True(t, errors.As(err, &jwksErr), err) {
+		assert.Equal(t, "http://127.0.0.1:0/jwks.json", jwksErr.URI)
+		assert.Empty(t, jwksErr.KeyID)
+		assert.Contains(t, err.Error(), "(jwks_uri=http://127.0.0.1:0/jwks.json)")
+	}
+}
@@ -0,0 +1,102 @@
+package auth0
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// jwksHTTPCache holds the validators and caching directives of the last JWKS
+// response, so that unchanged key sets are revalidated with conditional
+// requests answered by cheap 304 responses. Nil caches send unconditional
+// requests and record nothing.
+type jwksHTTPCache struct {
+	mu           sync.Mutex
+	uri          string
+	etag         string
+	lastModified string
+	maxAge       time.Duration
+	keys         []jose.JSONWebKey
+}
+
+// prepare makes req conditional when keys were previously downloaded
+// from the same URI, validators of mirrors being unrelated.
+func (c *jwksHTTPCache) prepare(uri string, req *http.Request) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.keys) == 0 || c.uri != uri {
+		return
+	}
+	if c.etag != "" {
+		req.Header.Set("If-None-Match", c.etag)
+	}
+	if c.lastModified != "" {
+		req.Header.Set("If-Modified-Since", c.lastModified)
+	}
+}
+
+// store records the validators and directives of a successful response
+// downloaded from uri.
+func (c *jwksHTTPCache) store(uri string, resp *http.Response, keys []jose.JSONWebKey) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uri = uri
+	c.etag = resp.Header.Get("ETag")
+	c.lastModified = resp.Header.Get("Last-Modified")
+	c.maxAge = parseMaxAge(resp.Header.Get("Cache-Control"))
+	c.keys = keys
+}
+
+// notModified returns the previously downloaded keys on a 304 response,
+// refreshing the directives it carries.
+func (c *jwksHTTPCache) notModified(resp *http.Response) ([]jose.JSONWebKey, error) {
+	if c == nil {
+		return []jose.JSONWebKey{}, &StatusCodeError{StatusCode: resp.StatusCode}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.keys) == 0 {
+		return []jose.JSONWebKey{}, &StatusCodeError{StatusCode: resp.StatusCode}
+	}
+	if cacheControl := resp.Header.Get("Cache-Control"); cacheControl != "" {
+		c.maxAge = parseMaxAge(cacheControl)
+	}
+	return c.keys, nil
+}
+
+// ttl returns the max-age of the last JWKS response, zero if none was sent.
+func (c *jwksHTTPCache) ttl() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maxAge
+}
+
+// parseMaxAge returns the max-age directive of a Cache-Control header,
+// zero when missing or invalid.
+func parseMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value := strings.TrimSpace(directive), ""
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name, value = strings.TrimSpace(name[:i]), strings.Trim(strings.TrimSpace(name[i+1:]), `"`)
+		}
+		if !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		expected     time.Duration
+	}{
+		{"", 0},
+		{"no-cache", 0},
+		{"max-age=60", time.Minute},
+		{"public, Max-Age=3600, must-revalidate", time.Hour},
+		{`max-age="120"`, 2 * time.Minute},
+		{"max-age=-1", 0},
+		{"max-age=abc", 0},
+		{"s-maxage=60", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cacheControl, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseMaxAge(test.cacheControl))
+		})
+	}
+}
+
+func TestJWKClientHTTPCaching(t *testing.T) {
+	jwk := genRSASSAJWK(jose.RS256, "keyRS256")
+	body, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{jwk.Public()}})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var full, notModified uint64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddUint64(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddUint64(&full, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	clock := clocktest.NewClock(time.Now())
+	keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Second,
+		MaxCacheSize: MaxCacheSizeNoCheck,
+		Clock:        clock,
+	})
+	client := NewJWKClientWithCache(JWKClientOptions{URI: ts.URL, Clock: clock}, nil, keyCacher)
+
+	_, err = client.GetKey("keyRS256")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, client.httpCache.ttl())
+
+	// the key expires after the max-age of the response instead of the max key age
+	clock.Advance(5 * time.Second)
+	_, err = keyCacher.Get("keyRS256")
+	assert.NoError(t, err)
+
+	// unchanged keys are revalidated with a conditional request
+	keys, err := client.downloadKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&full))
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&notModified))
+}
+
+func TestJWKClientNotModifiedWithoutKeys(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+	keys, err := client.downloadKeys(context.Background())
+	assert.Equal(t, &JWKSError{URI: ts.URL, Err: &StatusCodeError{StatusCode: http.StatusNotModified}}, err)
+	assert.Empty(t, keys)
+}
+
+func TestJWKClientHealthyHTTPCaching(t *testing.T) {
+	jwk := genRSASSAJWK(jose.RS256, "keyRS256")
+	body, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{jwk.Public()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	var conditional uint64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			atomic.AddUint64(&conditional, 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag.Load().(string))
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+	assert.NoError(t, client.PreloadKeys(context.Background()))
+
+	// probes send unconditional requests and leave the validators untouched
+	etag.Store(`"v2"`)
+	assert.NoError(t, client.Healthy(context.Background()))
+	assert.Equal(t, uint64(0), atomic.LoadUint64(&conditional))
+	assert.Equal(t, `"v1"`, client.httpCache.etag)
+	assert.Equal(t, time.Minute, client.httpCache.ttl())
+}
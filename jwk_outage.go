@@ -0,0 +1,62 @@
+package auth0
+
+import (
+	"sync/atomic"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// OutagePolicy decides how key lookups behave while the JWKS endpoint
+// cannot be downloaded.
+type OutagePolicy int
+
+const (
+	// FailClosed fails key lookups needing a download as soon as the JWKS
+	// cannot be downloaded. Expired keys are not served stale during an
+	// outage, even when the key cacher retains them.
+	FailClosed OutagePolicy = iota
+	// FailOpen serves cached keys, even expired ones, for the
+	// OutageGracePeriod when the JWKS cannot be downloaded. Expired keys
+	// can only be served by a StaleKeyCacher retaining them.
+	FailOpen
+)
+
+func (p OutagePolicy) String() string {
+	switch p {
+	case FailClosed:
+		return "fail-closed"
+	case FailOpen:
+		return "fail-open"
+	}
+	return "unknown"
+}
+
+// setReachable records the outcome of the last JWKS download.
+func (j *JWKClient) setReachable(reachable bool) {
+	var unreachable int32
+	if !reachable {
+		unreachable = 1
+	}
+	atomic.StoreInt32(&j.unreachable, unreachable)
+}
+
+// reachable reports whether the last JWKS download succeeded.
+func (j *JWKClient) reachable() bool {
+	return atomic.LoadInt32(&j.unreachable) == 0
+}
+
+// outageKey applies the OutagePolicy once downloading the JWKS failed
+// with err, returning the cached key to serve under FailOpen.
+func (j *JWKClient) outageKey(ID string, err error) (jose.JSONWebKey, error) {
+	if j.options.OutagePolicy != FailOpen {
+		return jose.JSONWebKey{}, err
+	}
+	staleKey, expiresAt, staleErr := j.staleKey(ID)
+	if staleErr != nil {
+		return jose.JSONWebKey{}, err
+	}
+	if grace := j.options.OutageGracePeriod; grace > 0 && !expiresAt.IsZero() && j.options.Clock.Now().After(expiresAt.Add(grace)) {
+		return jose.JSONWebKey{}, err
+	}
+	return *staleKey, nil
+}
@@ -0,0 +1,110 @@
+package auth0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKClientOutagePolicy(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	jwksURI := opts.URI
+
+	tests := []struct {
+		name        string
+		policy      OutagePolicy
+		grace       time.Duration
+		expectError bool
+	}{
+		{
+			name:        "fail closed",
+			policy:      FailClosed,
+			expectError: true,
+		},
+		{
+			name:        "fail open",
+			policy:      FailOpen,
+			expectError: false,
+		},
+		{
+			name:        "fail open within the grace period",
+			policy:      FailOpen,
+			grace:       time.Hour,
+			expectError: false,
+		},
+		{
+			name:        "fail open past the grace period",
+			policy:      FailOpen,
+			grace:       time.Minute,
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var failing uint64
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.LoadUint64(&failing) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				http.Redirect(w, r, jwksURI, http.StatusFound)
+			}))
+			defer ts.Close()
+
+			clock := clocktest.NewClock(time.Now())
+			keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+				MaxKeyAge:    time.Minute,
+				MaxCacheSize: MaxCacheSizeNoCheck,
+				MaxStaleAge:  time.Hour,
+				Clock:        clock,
+			})
+			client := NewJWKClientWithCache(JWKClientOptions{
+				URI:               ts.URL,
+				OutagePolicy:      test.policy,
+				OutageGracePeriod: test.grace,
+				Clock:             clock,
+			}, nil, keyCacher)
+
+			_, err := client.GetKey("keyRS256")
+			assert.NoError(t, err)
+
+			atomic.StoreUint64(&failing, 1)
+			clock.Advance(3 * time.Minute)
+
+			// the expired key is served while revalidating, which fails
+			_, err = client.GetKey("keyRS256")
+			assert.NoError(t, err)
+			assert.Eventually(t, func() bool { return !client.reachable() }, time.Second, 5*time.Millisecond)
+
+			key, err := client.GetKey("keyRS256")
+			if test.expectError {
+				assert.ErrorAs(t, err, new(*StatusCodeError))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "keyRS256", key.KeyID)
+			}
+
+			// keys are served again once the endpoint recovers
+			atomic.StoreUint64(&failing, 0)
+			_, err = client.GetKey("keyRS256")
+			assert.NoError(t, err)
+			assert.True(t, client.reachable())
+		})
+	}
+}
+
+func TestOutagePolicyString(t *testing.T) {
+	assert.Equal(t, "fail-closed", FailClosed.String())
+	assert.Equal(t, "fail-open", FailOpen.String())
+	assert.Equal(t, "unknown", OutagePolicy(42).String())
+}
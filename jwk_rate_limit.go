@@ -0,0 +1,78 @@
+package auth0
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRateLimited is returned instead of downloading the JWKS when
+	// unknown key IDs triggered too many downloads.
+	ErrRateLimited = errors.New("JWKS download rate limit exceeded")
+
+	// DefaultRateLimitWindow is the rate limiting window used when
+	// RateLimitOptions.Window is not set.
+	DefaultRateLimitWindow = time.Minute
+)
+
+// RateLimitOptions limits the JWKS downloads triggered by tokens whose key ID
+// is not cached, so that tokens with random key IDs cannot force unbounded
+// downloads. Downloads of the refresher are not limited.
+type RateLimitOptions struct {
+	// MaxDownloads is the number of downloads allowed per Window,
+	// zero for no limit.
+	MaxDownloads int
+	// MaxDownloadsPerKeyID is the number of downloads a single key ID
+	// may trigger per Window, zero for no limit.
+	MaxDownloadsPerKeyID int
+	// Window is the period over which downloads are counted.
+	// Defaults to DefaultRateLimitWindow.
+	Window time.Duration
+}
+
+type downloadLimiter struct {
+	mu          sync.Mutex
+	options     RateLimitOptions
+	clock       Clock
+	windowStart time.Time
+	downloads   int
+	perKeyID    map[string]int
+}
+
+// newDownloadLimiter returns nil when no limit is configured,
+// nil limiters allowing every download.
+func newDownloadLimiter(options RateLimitOptions, clock Clock) *downloadLimiter {
+	if options.MaxDownloads <= 0 && options.MaxDownloadsPerKeyID <= 0 {
+		return nil
+	}
+	if options.Window <= 0 {
+		options.Window = DefaultRateLimitWindow
+	}
+	return &downloadLimiter{options: options, clock: clock, perKeyID: map[string]int{}}
+}
+
+// allow counts a download triggered by keyID, returning ErrRateLimited
+// when a limit of the current window is reached.
+func (l *downloadLimiter) allow(keyID string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now := l.clock.Now(); now.Sub(l.windowStart) >= l.options.Window {
+		l.windowStart, l.downloads, l.perKeyID = now, 0, map[string]int{}
+	}
+	if l.options.MaxDownloads > 0 && l.downloads >= l.options.MaxDownloads {
+		return ErrRateLimited
+	}
+	if l.options.MaxDownloadsPerKeyID > 0 && l.perKeyID[keyID] >= l.options.MaxDownloadsPerKeyID {
+		return ErrRateLimited
+	}
+	l.downloads++
+	if l.options.MaxDownloadsPerKeyID > 0 {
+		l.perKeyID[keyID]++
+	}
+	return nil
+}
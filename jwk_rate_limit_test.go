@@ -0,0 +1,90 @@
+package auth0
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadLimiter(t *testing.T) {
+	assert.Nil(t, newDownloadLimiter(RateLimitOptions{Window: time.Second}, SystemClock))
+
+	tests := []struct {
+		name     string
+		options  RateLimitOptions
+		keyIDs   []string
+		expected []error
+	}{
+		{
+			name:     "global limit",
+			options:  RateLimitOptions{MaxDownloads: 2},
+			keyIDs:   []string{"key1", "key2", "key3"},
+			expected: []error{nil, nil, ErrRateLimited},
+		},
+		{
+			name:     "per key ID limit",
+			options:  RateLimitOptions{MaxDownloadsPerKeyID: 1},
+			keyIDs:   []string{"key1", "key1", "key2"},
+			expected: []error{nil, ErrRateLimited, nil},
+		},
+		{
+			name:     "both limits",
+			options:  RateLimitOptions{MaxDownloads: 2, MaxDownloadsPerKeyID: 1},
+			keyIDs:   []string{"key1", "key1", "key2", "key3"},
+			expected: []error{nil, ErrRateLimited, nil, ErrRateLimited},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := newDownloadLimiter(test.options, SystemClock)
+			for i, keyID := range test.keyIDs {
+				assert.Equal(t, test.expected[i], limiter.allow(keyID), keyID)
+			}
+		})
+	}
+}
+
+func TestDownloadLimiterWindow(t *testing.T) {
+	clock := clocktest.NewClock(time.Now())
+	limiter := newDownloadLimiter(RateLimitOptions{MaxDownloads: 1, Window: time.Minute}, clock)
+	assert.NoError(t, limiter.allow("key1"))
+	assert.Equal(t, ErrRateLimited, limiter.allow("key1"))
+
+	clock.Advance(time.Minute)
+	assert.NoError(t, limiter.allow("key1"))
+}
+
+func TestJWKClientRateLimit(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var counter uint64
+	opts.Client = &http.Client{
+		Transport: &mockRoundTripper{
+			ops: &counter,
+			rt:  http.DefaultTransport,
+		},
+	}
+	opts.RateLimit = RateLimitOptions{MaxDownloads: 2}
+	client := NewJWKClient(opts, nil)
+
+	for _, kid := range []string{"unknown1", "unknown2"} {
+		_, err := client.GetKey(kid)
+		assert.ErrorIs(t, err, ErrNoKeyFound)
+	}
+	_, err = client.GetKey("unknown3")
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&counter))
+
+	// cached keys are still served
+	_, err = client.GetKey("keyRS256")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,169 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultRetryBaseDelay is the delay before the first retry
+	// when RetryOptions.BaseDelay is not set.
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	// DefaultRetryableStatusCodes are the JWKS response status codes retried
+	// when RetryOptions.RetryableStatusCodes is empty.
+	DefaultRetryableStatusCodes = []int{
+		http.StatusRequestTimeout,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+	// DefaultMaxRetryAfter caps the delay requested by the JWKS endpoint
+	// when RetryOptions.MaxRetryAfter is not set.
+	DefaultMaxRetryAfter = 10 * time.Minute
+
+	// ErrBackingOff is returned instead of downloading the JWKS until the
+	// delay requested by the JWKS endpoint, e.g. when rate limited, elapsed.
+	ErrBackingOff = errors.New("JWKS endpoint asked to retry later")
+)
+
+// StatusCodeError is returned when the JWKS endpoint
+// responds with a non 2xx status code.
+type StatusCodeError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the Retry-After or
+	// X-RateLimit-Reset response headers, zero when none was sent.
+	RetryAfter time.Duration
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("unexpected JWKS response status: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// RetryOptions configures how failed JWKS downloads are retried.
+// The zero value disables retries.
+type RetryOptions struct {
+	// MaxAttempts is the total number of download attempts,
+	// values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on each
+	// following attempt. Defaults to DefaultRetryBaseDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts, zero means no cap.
+	MaxDelay time.Duration
+	// Jitter is the fraction, between 0 and 1, by which each delay is
+	// randomly shortened so that instances do not retry in lockstep.
+	Jitter float64
+	// RetryableStatusCodes are the response status codes worth retrying,
+	// DefaultRetryableStatusCodes when empty. Network errors are always
+	// retried while invalid JWKS payloads never are.
+	RetryableStatusCodes []int
+	// MaxRetryAfter caps the delay during which downloads are suspended when
+	// the JWKS endpoint asks to retry later. Defaults to DefaultMaxRetryAfter.
+	MaxRetryAfter time.Duration
+}
+
+// delay returns how long to wait before the provided attempt, starting at 1
+// for the first retry.
+func (o RetryOptions) delay(attempt int) time.Duration {
+	delay := o.BaseDelay
+	if delay <= 0 {
+		delay = DefaultRetryBaseDelay
+	}
+	for i := 1; i < attempt && (o.MaxDelay <= 0 || delay < o.MaxDelay); i++ {
+		delay *= 2
+	}
+	if o.MaxDelay > 0 && delay > o.MaxDelay {
+		delay = o.MaxDelay
+	}
+	if o.Jitter > 0 {
+		delay -= time.Duration(o.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// retryAfter returns the delay during which downloads are suspended
+// after err, zero when the JWKS endpoint did not ask to retry later.
+func (o RetryOptions) retryAfter(err error) time.Duration {
+	var statusErr *StatusCodeError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter <= 0 {
+		return 0
+	}
+	maxRetryAfter := o.MaxRetryAfter
+	if maxRetryAfter <= 0 {
+		maxRetryAfter = DefaultMaxRetryAfter
+	}
+	if statusErr.RetryAfter > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return statusErr.RetryAfter
+}
+
+// retryable reports whether a failed download is worth retrying, which it
+// is not when the JWKS endpoint asked to retry later.
+func (o RetryOptions) retryable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var statusErr *StatusCodeError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter > 0 {
+		return false
+	}
+	codes := o.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = DefaultRetryableStatusCodes
+	}
+	for _, code := range codes {
+		if code == statusErr.StatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepContext pauses for d, returning early with the error of ctx
+// when it is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter returns the delay requested by the Retry-After header, in
+// seconds or as an HTTP date, or else by the X-RateLimit-Reset header, as a
+// Unix time. It is zero when none is sent or valid.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			if seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+			return 0
+		}
+		if date, err := http.ParseTime(value); err == nil && date.After(now) {
+			return date.Sub(now)
+		}
+		return 0
+	}
+	if value := strings.TrimSpace(header.Get("X-RateLimit-Reset")); value != "" {
+		if reset, err := strconv.ParseInt(value, 10, 64); err == nil {
+			if date := time.Unix(reset, 0); date.After(now) {
+				return date.Sub(now)
+			}
+		}
+	}
+	return 0
+}
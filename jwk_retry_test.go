@@ -0,0 +1,171 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryOptionsDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  RetryOptions
+		attempt  int
+		expected time.Duration
+	}{
+		{"default base delay", RetryOptions{}, 1, DefaultRetryBaseDelay},
+		{"first retry", RetryOptions{BaseDelay: time.Second}, 1, time.Second},
+		{"exponential backoff", RetryOptions{BaseDelay: time.Second}, 4, 8 * time.Second},
+		{"capped backoff", RetryOptions{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 10, 5 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.options.delay(test.attempt))
+		})
+	}
+
+	jittered := RetryOptions{BaseDelay: time.Second, Jitter: 0.5}
+	for i := 0; i < 10; i++ {
+		delay := jittered.delay(1)
+		assert.True(t, delay > 500*time.Millisecond && delay <= time.Second, "unexpected jittered delay %v", delay)
+	}
+}
+
+func TestRetryOptionsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  RetryOptions
+		err      error
+		expected bool
+	}{
+		{"network error", RetryOptions{}, &url.Error{Op: "Get", URL: "http://localhost", Err: errors.New("connection refused")}, true},
+		{"default retryable status", RetryOptions{}, &StatusCodeError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"default non retryable status", RetryOptions{}, &StatusCodeError{StatusCode: http.StatusNotFound}, false},
+		{"custom retryable status", RetryOptions{RetryableStatusCodes: []int{http.StatusNotFound}}, &StatusCodeError{StatusCode: http.StatusNotFound}, true},
+		{"invalid payload", RetryOptions{}, ErrInvalidContentType, false},
+		{"no keys", RetryOptions{}, ErrNoKeyFound, false},
+		{"retry later", RetryOptions{}, &StatusCodeError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Second}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.options.retryable(test.err))
+		})
+	}
+}
+
+func TestJWKDownloadKeysRetry(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	jwksURI := opts.URI
+
+	tests := []struct {
+		name          string
+		failures      uint64
+		failureStatus int
+		maxAttempts   int
+		expectedCalls uint64
+		expectedErr   error
+	}{
+		{"pass - recovers after transient failures", 2, http.StatusServiceUnavailable, 3, 3, nil},
+		{"fail - attempts exhausted", 5, http.StatusBadGateway, 3, 3, &StatusCodeError{StatusCode: http.StatusBadGateway}},
+		{"fail - non retryable status", 5, http.StatusNotFound, 3, 1, &StatusCodeError{StatusCode: http.StatusNotFound}},
+		{"fail - retries disabled", 1, http.StatusServiceUnavailable, 0, 1, &StatusCodeError{StatusCode: http.StatusServiceUnavailable}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls uint64
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddUint64(&calls, 1) <= test.failures {
+					w.WriteHeader(test.failureStatus)
+					return
+				}
+				http.Redirect(w, r, jwksURI, http.StatusFound)
+			}))
+			defer ts.Close()
+
+			client := NewJWKClient(JWKClientOptions{
+				URI:   ts.URL,
+				Retry: RetryOptions{MaxAttempts: test.maxAttempts, BaseDelay: time.Millisecond},
+			}, nil)
+
+			keys, err := client.downloadKeys(context.Background())
+			assert.Equal(t, test.expectedCalls, atomic.LoadUint64(&calls))
+			if test.expectedErr != nil {
+				assert.Equal(t, &JWKSError{URI: ts.URL, Err: test.expectedErr}, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, keys)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{"no header", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"30"}}, 30 * time.Second},
+		{"negative seconds", http.Header{"Retry-After": {"-30"}}, 0},
+		{"http date", http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, time.Minute},
+		{"past http date", http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0},
+		{"rate limit reset", http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10)}}, 2 * time.Minute},
+		{"retry after wins", http.Header{"Retry-After": {"5"}, "X-Ratelimit-Reset": {strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10)}}, 5 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseRetryAfter(test.header, now))
+		})
+	}
+}
+
+func TestRetryOptionsRetryAfter(t *testing.T) {
+	err := &StatusCodeError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}
+	assert.Equal(t, DefaultMaxRetryAfter, RetryOptions{}.retryAfter(err))
+	assert.Equal(t, time.Minute, RetryOptions{MaxRetryAfter: time.Minute}.retryAfter(err))
+	assert.Equal(t, time.Duration(0), RetryOptions{}.retryAfter(ErrNoKeyFound))
+}
+
+func TestJWKDownloadKeysRetryAfter(t *testing.T) {
+	var calls uint64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(&calls, 1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:   ts.URL,
+		Retry: RetryOptions{MaxAttempts: 3, RetryableStatusCodes: []int{http.StatusTooManyRequests}},
+	}, nil)
+
+	_, err := client.downloadKeys(context.Background())
+	assert.Equal(t, &JWKSError{URI: ts.URL, Err: &StatusCodeError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second}}, err)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&calls), "requests asking to retry later should not be retried")
+
+	_, err = client.downloadKeys(context.Background())
+	assert.Equal(t, ErrBackingOff, err)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&calls))
+
+	// downloads resume once the delay elapsed
+	atomic.StoreInt64(&client.backoffUntil, time.Now().UnixNano())
+	_, err = client.downloadKeys(context.Background())
+	assert.ErrorAs(t, err, new(*StatusCodeError))
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&calls))
+}
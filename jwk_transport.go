@@ -0,0 +1,125 @@
+package auth0
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportOptions tunes the http.Client built for JWKS downloads when
+// JWKClientOptions.Client is not set. Zero values keep the settings of
+// http.DefaultTransport and http.DefaultClient.
+type TransportOptions struct {
+	// Timeout bounds a whole JWKS request, redirects and body included.
+	Timeout time.Duration
+	// DialTimeout bounds the establishment of connections.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout bounds TLS handshakes.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds the wait for response headers
+	// once the request is written.
+	ResponseHeaderTimeout time.Duration
+	// KeepAlive is the keep-alive period of connections,
+	// negative to disable keep-alives.
+	KeepAlive time.Duration
+	// MaxIdleConns is the maximum number of idle connections.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of idle connections
+	// kept per host.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long idle connections are kept.
+	IdleConnTimeout time.Duration
+	// MaxRedirects is the number of redirects followed,
+	// negative to follow none. Ignored when CheckRedirect is set.
+	MaxRedirects int
+	// CheckRedirect is the redirect policy of the client,
+	// see http.Client.CheckRedirect.
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+	// TLSConfig, when set, configures TLS connections, e.g. with a custom
+	// CA pool, client certificates for mTLS or a minimum TLS version.
+	TLSConfig *tls.Config
+	// Proxy, when set, is the proxy every JWKS request goes through.
+	Proxy *url.URL
+	// DisableProxyFromEnvironment stops taking the proxy from the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, as done
+	// by default. Ignored when Proxy is set.
+	DisableProxyFromEnvironment bool
+}
+
+// isZero reports whether no setting is tuned.
+func (o TransportOptions) isZero() bool {
+	return o.Timeout == 0 && o.DialTimeout == 0 && o.TLSHandshakeTimeout == 0 &&
+		o.ResponseHeaderTimeout == 0 && o.KeepAlive == 0 && o.MaxIdleConns == 0 &&
+		o.MaxIdleConnsPerHost == 0 && o.IdleConnTimeout == 0 && o.MaxRedirects == 0 &&
+		o.CheckRedirect == nil && o.TLSConfig == nil && o.Proxy == nil &&
+		!o.DisableProxyFromEnvironment
+}
+
+// newClient builds an http.Client from the options,
+// http.DefaultClient when no setting is tuned.
+func (o TransportOptions) newClient() *http.Client {
+	if o.isZero() {
+		return http.DefaultClient
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.DialTimeout != 0 || o.KeepAlive != 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if o.DialTimeout > 0 {
+			dialer.Timeout = o.DialTimeout
+		}
+		if o.KeepAlive != 0 {
+			dialer.KeepAlive = o.KeepAlive
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if o.KeepAlive < 0 {
+		transport.DisableKeepAlives = true
+	}
+	if o.Proxy != nil {
+		transport.Proxy = http.ProxyURL(o.Proxy)
+	} else if o.DisableProxyFromEnvironment {
+		transport.Proxy = nil
+	}
+	if o.TLSConfig != nil {
+		transport.TLSClientConfig = o.TLSConfig.Clone()
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+	if o.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = o.ResponseHeaderTimeout
+	}
+	if o.MaxIdleConns > 0 {
+		transport.MaxIdleConns = o.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	if o.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = o.IdleConnTimeout
+	}
+
+	client := &http.Client{
+		Transport:     transport,
+		Timeout:       o.Timeout,
+		CheckRedirect: o.CheckRedirect,
+	}
+	if client.CheckRedirect == nil && o.MaxRedirects != 0 {
+		client.CheckRedirect = maxRedirects(o.MaxRedirects)
+	}
+	return client
+}
+
+// maxRedirects is a redirect policy following at most n redirects,
+// none when n is negative.
+func maxRedirects(n int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > n {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
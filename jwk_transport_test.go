@@ -0,0 +1,180 @@
+package auth0
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestTransportOptionsNewClient(t *testing.T) {
+	assert.Equal(t, http.DefaultClient, TransportOptions{}.newClient())
+
+	client := TransportOptions{
+		Timeout:               time.Second,
+		TLSHandshakeTimeout:   2 * time.Second,
+		ResponseHeaderTimeout: 3 * time.Second,
+		KeepAlive:             -1,
+		MaxIdleConns:          4,
+		MaxIdleConnsPerHost:   5,
+		IdleConnTimeout:       6 * time.Second,
+	}.newClient()
+	assert.Equal(t, time.Second, client.Timeout)
+
+	transport := client.Transport.(*http.Transport)
+	assert.True(t, http.DefaultTransport != http.RoundTripper(transport), "the default transport should be cloned")
+	assert.Equal(t, 2*time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 3*time.Second, transport.ResponseHeaderTimeout)
+	assert.True(t, transport.DisableKeepAlives)
+	assert.Equal(t, 4, transport.MaxIdleConns)
+	assert.Equal(t, 5, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 6*time.Second, transport.IdleConnTimeout)
+}
+
+func TestTransportOptionsRedirects(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	ts := httptest.NewServer(http.RedirectHandler(opts.URI, http.StatusFound))
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		transport     TransportOptions
+		expectedError error
+	}{
+		{
+			name:      "pass - default redirect policy",
+			transport: TransportOptions{},
+		},
+		{
+			name:      "pass - redirect followed",
+			transport: TransportOptions{MaxRedirects: 1},
+		},
+		{
+			name:          "fail - redirects disabled",
+			transport:     TransportOptions{MaxRedirects: -1},
+			expectedError: &StatusCodeError{StatusCode: http.StatusFound},
+		},
+		{
+			name: "fail - custom redirect policy",
+			transport: TransportOptions{
+				MaxRedirects: 10,
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			},
+			expectedError: &StatusCodeError{StatusCode: http.StatusFound},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := NewJWKClient(JWKClientOptions{URI: ts.URL, Transport: test.transport}, nil)
+			keys, err := client.downloadKeys(context.Background())
+			if test.expectedError != nil {
+				assert.Equal(t, &JWKSError{URI: ts.URL, Err: test.expectedError}, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, keys)
+			}
+		})
+	}
+}
+
+func TestTransportOptionsProxy(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// the proxy receives the absolute URL of the JWKS and forwards it
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		resp, err := http.Get(opts.URI)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:       "http://jwks.example.com/.well-known/jwks.json",
+		Transport: TransportOptions{Proxy: proxyURL},
+	}, nil)
+	keys, err := client.downloadKeys(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, keys)
+	assert.Equal(t, "http://jwks.example.com/.well-known/jwks.json", proxied)
+
+	transport := TransportOptions{DisableProxyFromEnvironment: true}.newClient().Transport.(*http.Transport)
+	assert.Nil(t, transport.Proxy)
+}
+
+func TestTransportOptionsTLSConfig(t *testing.T) {
+	jwk := genRSASSAJWK(jose.RS256, "keyRS256")
+	body, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{jwk.Public()}})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ts.Certificate())
+
+	tests := []struct {
+		name        string
+		tlsConfig   *tls.Config
+		expectError bool
+	}{
+		{
+			name:        "fail - unknown authority",
+			tlsConfig:   nil,
+			expectError: true,
+		},
+		{
+			name:        "pass - custom CA pool",
+			tlsConfig:   &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12},
+			expectError: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := NewJWKClient(JWKClientOptions{
+				URI:       ts.URL,
+				Transport: TransportOptions{TLSConfig: test.tlsConfig},
+			}, nil)
+			keys, err := client.downloadKeys(context.Background())
+			if test.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, keys, 1)
+			}
+		})
+	}
+}
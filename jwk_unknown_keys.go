@@ -0,0 +1,67 @@
+package auth0
+
+import (
+	"sync"
+	"time"
+)
+
+// unknownKeyCache remembers the key IDs missing from the last downloaded
+// JWKS, so that repeated tokens with an unknown key ID do not trigger
+// repeated downloads.
+type unknownKeyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clock   Clock
+	entries map[string]time.Time
+}
+
+// newUnknownKeyCache returns nil when ttl is not positive,
+// nil caches never remembering key IDs.
+func newUnknownKeyCache(ttl time.Duration, clock Clock) *unknownKeyCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &unknownKeyCache{ttl: ttl, clock: clock, entries: map[string]time.Time{}}
+}
+
+// contains reports whether keyID was recently found missing from the JWKS.
+func (c *unknownKeyCache) contains(keyID string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt, ok := c.entries[keyID]
+	if ok && c.clock.Now().After(expiresAt) {
+		delete(c.entries, keyID)
+		return false
+	}
+	return ok
+}
+
+// add remembers keyID as missing from the JWKS for the ttl,
+// dropping the expired entries.
+func (c *unknownKeyCache) add(keyID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	for id, expiresAt := range c.entries {
+		if now.After(expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[keyID] = now.Add(c.ttl)
+}
+
+// remove forgets keyID once it is part of the JWKS.
+func (c *unknownKeyCache) remove(keyID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, keyID)
+}
@@ -0,0 +1,64 @@
+package auth0
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownKeyCache(t *testing.T) {
+	disabled := newUnknownKeyCache(0, SystemClock)
+	assert.Nil(t, disabled)
+	disabled.add("key1")
+	assert.False(t, disabled.contains("key1"))
+
+	clock := clocktest.NewClock(time.Now())
+	cache := newUnknownKeyCache(time.Minute, clock)
+	assert.False(t, cache.contains("key1"))
+	cache.add("key1")
+	assert.True(t, cache.contains("key1"))
+	cache.remove("key1")
+	assert.False(t, cache.contains("key1"))
+
+	cache.add("key1")
+	clock.Advance(time.Minute + time.Second)
+	cache.add("key2")
+	assert.Len(t, cache.entries, 1, "expired entries should be dropped")
+	assert.False(t, cache.contains("key1"))
+	assert.True(t, cache.contains("key2"))
+}
+
+func TestJWKClientUnknownKeyTTL(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var counter uint64
+	opts.Client = &http.Client{
+		Transport: &mockRoundTripper{
+			ops: &counter,
+			rt:  http.DefaultTransport,
+		},
+	}
+	clock := clocktest.NewClock(time.Now())
+	opts.UnknownKeyTTL = time.Minute
+	opts.Clock = clock
+	client := NewJWKClient(opts, nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetKey("unknown")
+		assert.ErrorIs(t, err, ErrNoKeyFound)
+	}
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter))
+
+	clock.Advance(time.Minute + time.Second)
+	_, err = client.GetKey("unknown")
+	assert.ErrorIs(t, err, ErrNoKeyFound)
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&counter))
+}
@@ -0,0 +1,19 @@
+module github.com/paulusrobin/go-auth0/jwtkeyfunc
+
+go 1.18
+
+require (
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/paulusrobin/go-auth0 v0.0.0-20261016110519-6a73be2dfea7
+	github.com/stretchr/testify v1.8.4
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/paulusrobin/go-auth0 => ../
@@ -0,0 +1,56 @@
+// Package jwtkeyfunc adapts the JWKS client of go-auth0 to
+// github.com/golang-jwt/jwt, so that code parsing tokens with golang-jwt
+// reuses the JWKS caching of the JWKS client.
+//
+// It lives in its own module so the core package does not depend on
+// golang-jwt.
+package jwtkeyfunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// ErrAlgorithmMismatch is returned when the token is signed with another
+// algorithm than the one of its JSON Web Key.
+var ErrAlgorithmMismatch = errors.New("token algorithm does not match the key algorithm")
+
+// KeyGetter looks keys up by key ID, implemented by *auth0.JWKClient.
+type KeyGetter interface {
+	GetKeyWithContext(ctx context.Context, ID string) (jose.JSONWebKey, error)
+}
+
+// New returns a jwt.Keyfunc returning the key of client identified by the
+// key ID of the token:
+//
+//	client := auth0.NewJWKClient(auth0.JWKClientOptions{URI: jwksURI}, nil)
+//	token, err := jwt.Parse(raw, jwtkeyfunc.New(client), jwt.WithValidMethods([]string{"RS256"}))
+//
+// Keys are downloaded with a background context, see NewWithContext.
+func New(client KeyGetter) jwt.Keyfunc {
+	return NewWithContext(context.Background(), client)
+}
+
+// NewWithContext returns a jwt.Keyfunc returning the key of client
+// identified by the key ID of the token, downloading the JWKS within ctx when
+// the key is not cached, e.g. the context of the request being authorized.
+//
+// Tokens signed with another algorithm than the one of their key, when the
+// key specifies it, are rejected with ErrAlgorithmMismatch.
+func NewWithContext(ctx context.Context, client KeyGetter) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		key, err := client.GetKeyWithContext(ctx, keyID)
+		if err != nil {
+			return nil, err
+		}
+		if key.Algorithm != "" && token.Method != nil && key.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("%w: %s, expected %s", ErrAlgorithmMismatch, token.Method.Alg(), key.Algorithm)
+		}
+		return key.Key, nil
+	}
+}
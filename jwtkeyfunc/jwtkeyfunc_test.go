@@ -0,0 +1,77 @@
+package jwtkeyfunc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func newJWKSServer(t *testing.T, keys ...jose.JSONWebKey) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: keys})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string) string {
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		Issuer:    "issuer",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestKeyfunc(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	uri := newJWKSServer(t,
+		jose.JSONWebKey{Key: &key1.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"},
+		jose.JSONWebKey{Key: &key2.PublicKey, KeyID: "key2", Use: "sig"},
+	)
+	keyfunc := New(auth0.NewJWKClient(auth0.JWKClientOptions{URI: uri}, nil))
+
+	tests := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{name: "pass - key with algorithm", token: signToken(t, jwt.SigningMethodRS256, key1, "key1")},
+		{name: "pass - key without algorithm", token: signToken(t, jwt.SigningMethodRS512, key2, "key2")},
+		{name: "fail - wrong key", token: signToken(t, jwt.SigningMethodRS256, key2, "key1"), expectedErr: jwt.ErrTokenSignatureInvalid},
+		{name: "fail - algorithm mismatch", token: signToken(t, jwt.SigningMethodRS512, key1, "key1"), expectedErr: ErrAlgorithmMismatch},
+		{name: "fail - unknown key", token: signToken(t, jwt.SigningMethodRS256, key1, "key3"), expectedErr: auth0.ErrNoKeyFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := jwt.Parse(test.token, keyfunc)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, token.Valid)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+module github.com/paulusrobin/go-auth0/jwxadapter
+
+go 1.23.0
+
+require (
+	github.com/lestrrat-go/jwx/v2 v2.1.6
+	github.com/paulusrobin/go-auth0 v0.0.0-20261016110519-6a73be2dfea7
+	github.com/stretchr/testify v1.10.0
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0 // indirect
+	github.com/goccy/go-json v0.10.3 // indirect
+	github.com/lestrrat-go/blackmagic v1.0.3 // indirect
+	github.com/lestrrat-go/httpcc v1.0.1 // indirect
+	github.com/lestrrat-go/httprc v1.0.6 // indirect
+	github.com/lestrrat-go/iter v1.0.2 // indirect
+	github.com/lestrrat-go/option v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/segmentio/asm v1.2.0 // indirect
+	golang.org/x/crypto v0.32.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/paulusrobin/go-auth0 => ../
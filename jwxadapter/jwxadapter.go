@@ -0,0 +1,112 @@
+// Package jwxadapter converts between the keys of go-auth0 and the ones of
+// github.com/lestrrat-go/jwx, easing the migration from one package to the
+// other in either direction:
+//
+//   - NewSecretProvider verifies tokens with the keys of a jwx jwk.Set, e.g.
+//     the cached set of a jwk.Cache;
+//   - NewKeyProvider verifies jwx parsed tokens with the keys of a JWKClient;
+//   - ToSet and FromSet convert JSON Web Keys, e.g. to add the keys of a jwx
+//     set into a KeyCacher.
+//
+// It lives in its own module so the core package does not depend on jwx.
+package jwxadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// KeyGetter looks keys up by key ID, implemented by *auth0.JWKClient.
+type KeyGetter interface {
+	GetKeyWithContext(ctx context.Context, ID string) (jose.JSONWebKey, error)
+}
+
+// NewSecretProvider provides the key of set selected by the key ID of the
+// token read by extractor, which defaults to reading the Authorization
+// header. Tokens without key ID are verified with the only key of set.
+// Private keys are reduced to their public key.
+//
+// Keys downloaded and refreshed by a jwk.Cache are provided with the set of
+// jwk.NewCachedSet:
+//
+//	provider := jwxadapter.NewSecretProvider(jwk.NewCachedSet(cache, jwksURI), nil)
+func NewSecretProvider(set jwk.Set, extractor auth0.RequestTokenExtractor) auth0.SecretProvider {
+	if extractor == nil {
+		extractor = auth0.RequestTokenExtractorFunc(auth0.FromHeader)
+	}
+	return auth0.SecretProviderFunc(func(r *http.Request) (interface{}, error) {
+		token, err := extractor.Extract(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(token.Headers) < 1 {
+			return nil, auth0.ErrNoJWTHeaders
+		}
+
+		keyID := token.Headers[0].KeyID
+		var key jwk.Key
+		var ok bool
+		if keyID == "" && set.Len() == 1 {
+			key, ok = set.Key(0)
+		} else {
+			key, ok = set.LookupKeyID(keyID)
+		}
+		if !ok {
+			return nil, auth0.ErrNoKeyFound
+		}
+		return jwk.PublicRawKeyOf(key)
+	})
+}
+
+// NewKeyProvider returns a jws.KeyProvider verifying the tokens parsed by jwx
+// with the key of client identified by their key ID, so that jwx reuses the
+// JWKS caching of the client:
+//
+//	token, err := jwt.Parse(raw, jwt.WithKeyProvider(jwxadapter.NewKeyProvider(client)))
+//
+// The algorithm of the key is used when the key specifies it, the one of the
+// token otherwise.
+func NewKeyProvider(client KeyGetter) jws.KeyProvider {
+	return jws.KeyProviderFunc(func(ctx context.Context, sink jws.KeySink, sig *jws.Signature, _ *jws.Message) error {
+		headers := sig.ProtectedHeaders()
+		key, err := client.GetKeyWithContext(ctx, headers.KeyID())
+		if err != nil {
+			return err
+		}
+		alg := headers.Algorithm()
+		if key.Algorithm != "" {
+			alg = jwa.SignatureAlgorithm(key.Algorithm)
+		}
+		sink.Key(alg, key.Key)
+		return nil
+	})
+}
+
+// ToSet converts JSON Web Keys into a jwx set.
+func ToSet(keys []jose.JSONWebKey) (jwk.Set, error) {
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	return jwk.Parse(data)
+}
+
+// FromSet converts the keys of a jwx set into JSON Web Keys.
+func FromSet(set jwk.Set) ([]jose.JSONWebKey, error) {
+	data, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keySet); err != nil {
+		return nil, err
+	}
+	return keySet.Keys, nil
+}
@@ -0,0 +1,167 @@
+package jwxadapter
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	jwxjwt "github.com/lestrrat-go/jwx/v2/jwt"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func genRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newJWXKey(t *testing.T, raw interface{}, kid string) jwk.Key {
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Set(jwk.KeyIDKey, kid); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newRequest(t *testing.T, key *rsa.PrivateKey, kid string) *http.Request {
+	options := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		options = options.WithHeader("kid", kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{Issuer: "issuer", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Set("Authorization", "Bearer "+raw)
+	return req
+}
+
+func TestSecretProvider(t *testing.T) {
+	key1, key2 := genRSAKey(t), genRSAKey(t)
+	set := jwk.NewSet()
+	assert.NoError(t, set.AddKey(newJWXKey(t, key1, "key1")))
+	assert.NoError(t, set.AddKey(newJWXKey(t, &key2.PublicKey, "key2")))
+	validator := auth0.NewValidator(auth0.NewConfiguration(NewSecretProvider(set, nil), nil, "issuer", jose.RS256), nil)
+
+	tests := []struct {
+		name        string
+		req         *http.Request
+		expectedErr error
+	}{
+		{name: "pass - private key", req: newRequest(t, key1, "key1")},
+		{name: "pass - public key", req: newRequest(t, key2, "key2")},
+		{name: "fail - wrong key", req: newRequest(t, key1, "key2"), expectedErr: auth0.ErrInvalidSignature},
+		{name: "fail - unknown key", req: newRequest(t, key1, "key3"), expectedErr: auth0.ErrNoKeyFound},
+		{name: "fail - no key id", req: newRequest(t, key1, ""), expectedErr: auth0.ErrNoKeyFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := validator.ValidateRequest(test.req)
+			assert.True(t, errors.Is(err, test.expectedErr), err)
+		})
+	}
+}
+
+func TestSecretProviderSingleKey(t *testing.T) {
+	key := genRSAKey(t)
+	set := jwk.NewSet()
+	assert.NoError(t, set.AddKey(newJWXKey(t, &key.PublicKey, "key")))
+	validator := auth0.NewValidator(auth0.NewConfiguration(NewSecretProvider(set, nil), nil, "issuer", jose.RS256), nil)
+
+	_, err := validator.ValidateRequest(newRequest(t, key, ""))
+	assert.NoError(t, err)
+}
+
+func TestKeyProvider(t *testing.T) {
+	key1, key2 := genRSAKey(t), genRSAKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key1.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"},
+			{Key: &key2.PublicKey, KeyID: "key2", Use: "sig"},
+		}})
+	}))
+	defer server.Close()
+	keyProvider := NewKeyProvider(auth0.NewJWKClient(auth0.JWKClientOptions{URI: server.URL}, nil))
+
+	sign := func(alg jwa.SignatureAlgorithm, key *rsa.PrivateKey, kid string) []byte {
+		token, err := jwxjwt.NewBuilder().Issuer("issuer").Expiration(time.Now().Add(time.Hour)).Build()
+		assert.NoError(t, err)
+		raw, err := jwxjwt.Sign(token, jwxjwt.WithKey(alg, newJWXKey(t, key, kid)))
+		assert.NoError(t, err)
+		return raw
+	}
+
+	tests := []struct {
+		name        string
+		token       []byte
+		expectedErr bool
+	}{
+		{name: "pass - key with algorithm", token: sign(jwa.RS256, key1, "key1")},
+		{name: "pass - key without algorithm", token: sign(jwa.RS512, key2, "key2")},
+		{name: "fail - wrong key", token: sign(jwa.RS256, key2, "key1"), expectedErr: true},
+		{name: "fail - algorithm mismatch", token: sign(jwa.RS512, key1, "key1"), expectedErr: true},
+		{name: "fail - unknown key", token: sign(jwa.RS256, key1, "key3"), expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := jwxjwt.Parse(test.token, jwxjwt.WithKeyProvider(keyProvider))
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "issuer", token.Issuer())
+		})
+	}
+}
+
+func TestConvertSet(t *testing.T) {
+	key := genRSAKey(t)
+	keys := []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "key", Algorithm: "RS256", Use: "sig"}}
+
+	set, err := ToSet(keys)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, set.Len())
+	jwxKey, ok := set.LookupKeyID("key")
+	assert.True(t, ok)
+	assert.Equal(t, jwa.RS256, jwxKey.Algorithm())
+
+	converted, err := FromSet(set)
+	assert.NoError(t, err)
+	if assert.Len(t, converted, 1) {
+		assert.Equal(t, "key", converted[0].KeyID)
+		assert.Equal(t, "RS256", converted[0].Algorithm)
+		assert.Equal(t, &key.PublicKey, converted[0].Key)
+	}
+
+	keyCacher := auth0.NewMemoryKeyCacher(time.Minute, auth0.MaxCacheSizeNoCheck)
+	cached, err := keyCacher.Add("key", converted)
+	assert.NoError(t, err)
+	assert.Equal(t, &key.PublicKey, cached.Key)
+
+	_, err = ToSet([]jose.JSONWebKey{{Key: "invalid"}})
+	assert.Error(t, err)
+}
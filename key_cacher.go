@@ -2,6 +2,9 @@ package auth0
 
 import (
 	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	jose "gopkg.in/square/go-jose.v2"
@@ -17,30 +20,187 @@ var (
 	MaxCacheSizeNoCheck = -1
 )
 
+// KeyCacher caches the downloaded keys. The JWKClient calls it from one
+// goroutine at a time, unless it implements ConcurrentKeyCacher.
 type KeyCacher interface {
 	Get(keyID string) (*jose.JSONWebKey, error)
 	Add(keyID string, webKeys []jose.JSONWebKey) (*jose.JSONWebKey, error)
 }
 
+// StaleKeyCacher is implemented by key cachers retaining expired keys
+// for a while, letting the JWKClient serve them while it revalidates.
+type StaleKeyCacher interface {
+	// GetStale returns the key whether or not it is expired, along with its
+	// expiry time, which is zero for keys that never expire.
+	GetStale(keyID string) (*jose.JSONWebKey, time.Time, error)
+}
+
+// TTLKeyCacher is implemented by key cachers able to expire keys after a
+// TTL given when adding them, such as the max-age of the JWKS response.
+type TTLKeyCacher interface {
+	AddWithTTL(keyID string, webKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error)
+}
+
+// KeyCacherStats are counters of a key cacher, helping to tune
+// its max key age and size.
+type KeyCacherStats struct {
+	// Hits counts the keys found in the cache.
+	Hits uint64
+	// Misses counts the keys missing from the cache or expired.
+	Misses uint64
+	// Evictions counts the keys evicted as the cache overflowed.
+	Evictions uint64
+	// Expirations counts the expired keys deleted from the cache.
+	Expirations uint64
+	// Size is the current number of cached keys.
+	Size int
+}
+
+// StatsKeyCacher is implemented by key cachers exposing their statistics.
+type StatsKeyCacher interface {
+	Stats() KeyCacherStats
+}
+
+// ConcurrentKeyCacher is implemented by key cachers safe for concurrent use,
+// which the JWKClient calls without serializing the lookups of keys.
+type ConcurrentKeyCacher interface {
+	// Concurrent reports whether the key cacher is safe for concurrent use,
+	// which decorators of other key cachers report according to them.
+	Concurrent() bool
+}
+
+// StoppableKeyCacher is implemented by key cachers running background
+// goroutines, which Stop terminates.
+type StoppableKeyCacher interface {
+	Stop()
+}
+
+// MemoryKeyCacherOptions configures the in-memory key cacher.
+type MemoryKeyCacherOptions struct {
+	// MaxKeyAge is the age after which a key is expired,
+	// MaxKeyAgeNoCheck to never expire keys.
+	MaxKeyAge time.Duration
+	// MaxCacheSize is the maximum number of cached keys,
+	// MaxCacheSizeNoCheck, or any negative size, to cache every downloaded
+	// key.
+	MaxCacheSize int
+	// MaxStaleAge is how long a key is retained once expired, and can be
+	// served stale while fresh keys are downloaded in the background.
+	// Zero drops keys as soon as they expire.
+	MaxStaleAge time.Duration
+	// EvictionPolicy chooses the keys evicted when the cache overflows.
+	// Defaults to EvictLRU.
+	EvictionPolicy EvictionPolicy
+	// MaxKeyAgeJitter is the fraction, between 0 and 1, by which the age of
+	// each key is randomly shortened so that a fleet of instances sharing
+	// the same MaxKeyAge does not download the JWKS simultaneously.
+	MaxKeyAgeJitter float64
+	// CleanupInterval, when positive, is the period at which a background
+	// goroutine purges the expired keys no longer retained, bounding memory
+	// in long-running processes. The goroutine runs until Stop is called.
+	CleanupInterval time.Duration
+	// OnAdd, when set, is called with the ID of every key added.
+	OnAdd func(keyID string)
+	// OnEvict, when set, is called with the ID of every key evicted
+	// as the cache overflowed.
+	OnEvict func(keyID string)
+	// OnExpire, when set, is called with the ID of every expired key
+	// deleted from the cache.
+	OnExpire func(keyID string)
+	// Logger, when set, logs the IDs of the evicted and expired keys
+	// at the debug level.
+	Logger Logger
+	// Clock tells the time the keys are added and expire at.
+	// Defaults to SystemClock.
+	Clock Clock
+	// Shards, when above 1, splits the cache into as many shards with
+	// independent locks, reducing contention when many goroutines look keys
+	// up concurrently. MaxCacheSize is then split evenly between the shards,
+	// each evicting its own keys.
+	Shards int
+}
+
 type memoryKeyCacher struct {
+	// counters are first to be 64-bit aligned for atomic operations
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+
+	mu           sync.RWMutex
 	entries      map[string]keyCacherEntry
 	maxKeyAge    time.Duration
 	maxCacheSize int
+	maxStaleAge  time.Duration
+	eviction     EvictionPolicy
+	jitter       float64
+	// lastAdded is the key just added, spared by the eviction
+	lastAdded string
+	onAdd     func(keyID string)
+	onEvict   func(keyID string)
+	onExpire  func(keyID string)
+	logger    Logger
+	clock     Clock
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	stopped  chan struct{}
+
+	// usage tracks how entries are used for their eviction. It has its own
+	// mutex so that reading keys only takes the read lock.
+	usageMu    sync.Mutex
+	usageClock uint64
+	usage      map[string]keyUsage
 }
 
 type keyCacherEntry struct {
 	addedAt time.Time
 	jose.JSONWebKey
+	// maxAge overrides the max key age of the cacher when positive.
+	maxAge time.Duration
 }
 
 // NewMemoryKeyCacher creates a new Keycacher interface with option
 // to set max age of cached keys and max size of the cache.
 func NewMemoryKeyCacher(maxKeyAge time.Duration, maxCacheSize int) KeyCacher {
-	return &memoryKeyCacher{
+	return NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    maxKeyAge,
+		MaxCacheSize: maxCacheSize,
+	})
+}
+
+// NewMemoryKeyCacherWithOptions creates a new Keycacher interface
+// from the provided options. The key cacher implements StoppableKeyCacher,
+// which must be stopped when a CleanupInterval is configured.
+func NewMemoryKeyCacherWithOptions(options MemoryKeyCacherOptions) KeyCacher {
+	if options.Shards > 1 {
+		return newShardedKeyCacher(options)
+	}
+	return newMemoryKeyCacher(options)
+}
+
+func newMemoryKeyCacher(options MemoryKeyCacherOptions) *memoryKeyCacher {
+	if options.MaxCacheSize < 0 {
+		options.MaxCacheSize = MaxCacheSizeNoCheck
+	}
+	mkc := &memoryKeyCacher{
 		entries:      map[string]keyCacherEntry{},
-		maxKeyAge:    maxKeyAge,
-		maxCacheSize: maxCacheSize,
+		maxKeyAge:    options.MaxKeyAge,
+		maxCacheSize: options.MaxCacheSize,
+		maxStaleAge:  options.MaxStaleAge,
+		eviction:     options.EvictionPolicy,
+		jitter:       options.MaxKeyAgeJitter,
+		onAdd:        options.OnAdd,
+		onEvict:      options.OnEvict,
+		onExpire:     options.OnExpire,
+		logger:       options.Logger,
+		clock:        clockOrSystem(options.Clock),
+	}
+	if options.CleanupInterval > 0 && options.MaxKeyAge != MaxKeyAgeNoCheck {
+		mkc.stop, mkc.stopped = make(chan struct{}), make(chan struct{})
+		go mkc.runJanitor(options.CleanupInterval)
 	}
+	return mkc
 }
 
 func newMemoryPersistentKeyCacher() KeyCacher {
@@ -48,69 +208,250 @@ func newMemoryPersistentKeyCacher() KeyCacher {
 		entries:      map[string]keyCacherEntry{},
 		maxKeyAge:    MaxKeyAgeNoCheck,
 		maxCacheSize: MaxCacheSizeNoCheck,
+		clock:        SystemClock,
 	}
 }
 
 // Get obtains a key from the cache, and checks if the key is expired
 func (mkc *memoryKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	mkc.mu.RLock()
 	searchKey, ok := mkc.entries[keyID]
+	mkc.mu.RUnlock()
 	if ok {
-		if mkc.maxKeyAge == MaxKeyAgeNoCheck || !mkc.keyIsExpired(keyID) {
+		if mkc.maxKeyAge == MaxKeyAgeNoCheck || !mkc.entryIsExpired(keyID, searchKey) {
+			atomic.AddUint64(&mkc.hits, 1)
+			mkc.touch(keyID)
 			return &searchKey.JSONWebKey, nil
 		}
+		atomic.AddUint64(&mkc.misses, 1)
 		return nil, ErrKeyExpired
 	}
+	atomic.AddUint64(&mkc.misses, 1)
 	return nil, ErrNoKeyFound
 }
 
+// Concurrent reports that the cache is safe for concurrent use
+func (mkc *memoryKeyCacher) Concurrent() bool {
+	return true
+}
+
+// Stats returns the counters of the cache
+func (mkc *memoryKeyCacher) Stats() KeyCacherStats {
+	mkc.mu.RLock()
+	size := len(mkc.entries)
+	mkc.mu.RUnlock()
+	return KeyCacherStats{
+		Hits:        atomic.LoadUint64(&mkc.hits),
+		Misses:      atomic.LoadUint64(&mkc.misses),
+		Evictions:   atomic.LoadUint64(&mkc.evictions),
+		Expirations: atomic.LoadUint64(&mkc.expirations),
+		Size:        size,
+	}
+}
+
+// GetStale obtains a key from the cache even if it is expired, as long as
+// it is retained for MaxStaleAge.
+func (mkc *memoryKeyCacher) GetStale(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	mkc.mu.RLock()
+	searchKey, ok := mkc.entries[keyID]
+	mkc.mu.RUnlock()
+	if !ok {
+		return nil, time.Time{}, ErrNoKeyFound
+	}
+	if mkc.maxKeyAge == MaxKeyAgeNoCheck {
+		mkc.touch(keyID)
+		return &searchKey.JSONWebKey, time.Time{}, nil
+	}
+	if mkc.entryIsExpired(keyID, searchKey) && !mkc.retained(searchKey, mkc.now()) {
+		return nil, time.Time{}, ErrNoKeyFound
+	}
+	mkc.touch(keyID)
+	return &searchKey.JSONWebKey, mkc.expiresAt(searchKey), nil
+}
+
 // Add adds a key into the cache and handles overflow
 func (mkc *memoryKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
-	var addingKey jose.JSONWebKey
+	return mkc.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL adds a key into the cache expiring after ttl instead of the max
+// key age, and handles overflow. The ttl is ignored when keys never expire
+// or when it is not positive.
+func (mkc *memoryKeyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	if mkc.maxKeyAge == MaxKeyAgeNoCheck || ttl < 0 {
+		ttl = 0
+	}
+
+	mkc.mu.Lock()
+	addingKey, added, evicted := mkc.addEntries(keyID, downloadedKeys, ttl)
+	mkc.mu.Unlock()
+
+	notify(mkc.onAdd, added)
+	notify(mkc.onEvict, evicted)
+	logKeyEvents(mkc.logger, "auth0: key evicted", evicted)
+	if addingKey.Key != nil {
+		return &addingKey, nil
+	}
+	return nil, ErrNoKeyFound
+}
 
+// addEntries adds the keys into the cache, the caller holding the write
+// lock, and returns the searched key along with the added and evicted keys
+func (mkc *memoryKeyCacher) addEntries(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (addingKey jose.JSONWebKey, added, evicted []string) {
 	for _, key := range downloadedKeys {
 		if key.KeyID == keyID {
 			addingKey = key
 		}
 		if mkc.maxCacheSize == -1 {
 			mkc.entries[key.KeyID] = keyCacherEntry{
-				addedAt:    time.Now(),
+				addedAt:    mkc.now(),
 				JSONWebKey: key,
+				maxAge:     mkc.entryMaxAge(ttl),
 			}
+			added = append(added, key.KeyID)
 		}
 	}
-	if addingKey.Key != nil {
-		if mkc.maxCacheSize != -1 {
-			mkc.entries[addingKey.KeyID] = keyCacherEntry{
-				addedAt:    time.Now(),
-				JSONWebKey: addingKey,
-			}
-			mkc.handleOverflow()
+	if addingKey.Key != nil && mkc.maxCacheSize != -1 {
+		mkc.entries[addingKey.KeyID] = keyCacherEntry{
+			addedAt:    mkc.now(),
+			JSONWebKey: addingKey,
+			maxAge:     mkc.entryMaxAge(ttl),
 		}
-		return &addingKey, nil
+		added = append(added, addingKey.KeyID)
+		mkc.touch(addingKey.KeyID)
+		mkc.lastAdded = addingKey.KeyID
+		evicted = mkc.handleOverflow()
 	}
-	return nil, ErrNoKeyFound
+	return addingKey, added, evicted
 }
 
 // keyIsExpired deletes the key from cache if it is expired
+// and no longer retained to be served stale
 func (mkc *memoryKeyCacher) keyIsExpired(keyID string) bool {
-	if time.Now().After(mkc.entries[keyID].addedAt.Add(mkc.maxKeyAge)) {
-		delete(mkc.entries, keyID)
-		return true
-	}
-	return false
-}
-
-// handleOverflow deletes the oldest key from the cache if overflowed
-func (mkc *memoryKeyCacher) handleOverflow() {
-	if mkc.maxCacheSize < len(mkc.entries) {
-		var oldestEntryKeyID string
-		var latestAddedTime = time.Now()
-		for entryKeyID, entry := range mkc.entries {
-			if entry.addedAt.Before(latestAddedTime) {
-				latestAddedTime = entry.addedAt
-				oldestEntryKeyID = entryKeyID
-			}
+	mkc.mu.RLock()
+	entry := mkc.entries[keyID]
+	mkc.mu.RUnlock()
+	return mkc.entryIsExpired(keyID, entry)
+}
+
+// entryIsExpired deletes the entry read for keyID from cache if it is
+// expired and no longer retained to be served stale, unless it was
+// replaced in the meantime
+func (mkc *memoryKeyCacher) entryIsExpired(keyID string, entry keyCacherEntry) bool {
+	now := mkc.now()
+	if !now.After(mkc.expiresAt(entry)) {
+		return false
+	}
+	if !mkc.retained(entry, now) {
+		mkc.mu.Lock()
+		current, ok := mkc.entries[keyID]
+		deleted := ok && current.addedAt.Equal(entry.addedAt)
+		if deleted {
+			mkc.deleteEntry(keyID)
+			atomic.AddUint64(&mkc.expirations, 1)
 		}
-		delete(mkc.entries, oldestEntryKeyID)
+		mkc.mu.Unlock()
+
+		if deleted {
+			notify(mkc.onExpire, []string{keyID})
+			logKeyEvents(mkc.logger, "auth0: key expired", []string{keyID})
+		}
+	}
+	return true
+}
+
+// now returns the time of the clock of the cacher
+func (mkc *memoryKeyCacher) now() time.Time {
+	return mkc.clock.Now()
+}
+
+// retained reports whether the expired entry can still be served stale
+func (mkc *memoryKeyCacher) retained(entry keyCacherEntry, now time.Time) bool {
+	return mkc.maxStaleAge > 0 && !now.After(mkc.expiresAt(entry).Add(mkc.maxStaleAge))
+}
+
+// runJanitor purges the expired keys every interval until stopped
+func (mkc *memoryKeyCacher) runJanitor(interval time.Duration) {
+	defer close(mkc.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-mkc.stop:
+			return
+		case <-ticker.C:
+			mkc.purgeExpired()
+		}
+	}
+}
+
+// Stop terminates the background cleanup goroutine and waits for it to
+// exit. It is safe to call Stop more than once or without cleanup.
+func (mkc *memoryKeyCacher) Stop() {
+	mkc.stopOnce.Do(func() {
+		if mkc.stop != nil {
+			close(mkc.stop)
+			<-mkc.stopped
+		}
+	})
+}
+
+// purgeExpired deletes the expired keys no longer retained to be served stale
+func (mkc *memoryKeyCacher) purgeExpired() {
+	if mkc.maxKeyAge == MaxKeyAgeNoCheck {
+		return
+	}
+	var expired []string
+	now := mkc.now()
+	mkc.mu.Lock()
+	for keyID, entry := range mkc.entries {
+		if now.After(mkc.expiresAt(entry)) && !mkc.retained(entry, now) {
+			mkc.deleteEntry(keyID)
+			atomic.AddUint64(&mkc.expirations, 1)
+			expired = append(expired, keyID)
+		}
+	}
+	mkc.mu.Unlock()
+
+	notify(mkc.onExpire, expired)
+	logKeyEvents(mkc.logger, "auth0: key expired", expired)
+}
+
+// notify calls the callback, if any, for each key, outside of the locks
+// so that callbacks may use the cache
+func notify(callback func(keyID string), keyIDs []string) {
+	if callback == nil {
+		return
+	}
+	for _, keyID := range keyIDs {
+		callback(keyID)
+	}
+}
+
+// entryMaxAge returns the max age of an entry added with ttl, randomly
+// shortened by the jitter
+func (mkc *memoryKeyCacher) entryMaxAge(ttl time.Duration) time.Duration {
+	maxAge := ttl
+	if maxAge <= 0 {
+		maxAge = mkc.maxKeyAge
+	}
+	if mkc.jitter <= 0 || maxAge <= 0 || mkc.maxKeyAge == MaxKeyAgeNoCheck {
+		return ttl
+	}
+	jitter := mkc.jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	if maxAge -= time.Duration(jitter * rand.Float64() * float64(maxAge)); maxAge <= 0 {
+		maxAge = 1
+	}
+	return maxAge
+}
+
+// expiresAt returns the time at which the entry expires
+func (mkc *memoryKeyCacher) expiresAt(entry keyCacherEntry) time.Time {
+	if entry.maxAge > 0 {
+		return entry.addedAt.Add(entry.maxAge)
 	}
+	return entry.addedAt.Add(mkc.maxKeyAge)
 }
@@ -0,0 +1,162 @@
+package auth0
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// EvictionPolicy chooses the keys evicted from the in-memory key cacher
+// when it overflows.
+type EvictionPolicy int
+
+const (
+	// EvictLRU evicts the least recently used key.
+	EvictLRU EvictionPolicy = iota
+	// EvictLFU evicts the least frequently used key, suited to a few hot
+	// keys among many rarely used ones.
+	EvictLFU
+	// EvictFIFO evicts the oldest key, suited to keys rotated regularly.
+	EvictFIFO
+	// EvictRandom evicts a random key, the cheapest to track.
+	EvictRandom
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictLRU:
+		return "lru"
+	case EvictLFU:
+		return "lfu"
+	case EvictFIFO:
+		return "fifo"
+	case EvictRandom:
+		return "random"
+	}
+	return "unknown"
+}
+
+// keyUsage tracks how a cached key is used.
+type keyUsage struct {
+	// lastUsed is the usage clock of the last use of the key.
+	lastUsed uint64
+	// uses counts the uses of the key.
+	uses uint64
+}
+
+// touch records a use of keyID
+func (mkc *memoryKeyCacher) touch(keyID string) {
+	if mkc.maxCacheSize == MaxCacheSizeNoCheck || mkc.eviction == EvictFIFO || mkc.eviction == EvictRandom {
+		return
+	}
+	mkc.usageMu.Lock()
+	defer mkc.usageMu.Unlock()
+	if mkc.usage == nil {
+		mkc.usage = map[string]keyUsage{}
+	}
+	mkc.usageClock++
+	usage := mkc.usage[keyID]
+	mkc.usage[keyID] = keyUsage{lastUsed: mkc.usageClock, uses: usage.uses + 1}
+}
+
+// deleteEntry deletes the key from cache, the caller holding the write lock
+func (mkc *memoryKeyCacher) deleteEntry(keyID string) {
+	delete(mkc.entries, keyID)
+	mkc.usageMu.Lock()
+	delete(mkc.usage, keyID)
+	mkc.usageMu.Unlock()
+}
+
+// handleOverflow deletes keys from the cache according to the eviction
+// policy while overflowed, and returns the evicted keys. It stops once no key
+// can be evicted, the key just added being spared.
+func (mkc *memoryKeyCacher) handleOverflow() []string {
+	mkc.usageMu.Lock()
+	for keyID := range mkc.usage {
+		if _, ok := mkc.entries[keyID]; !ok {
+			delete(mkc.usage, keyID)
+		}
+	}
+	mkc.usageMu.Unlock()
+
+	var evicted []string
+	for mkc.maxCacheSize < len(mkc.entries) {
+		keyID := mkc.victim()
+		if keyID == "" {
+			break
+		}
+		mkc.deleteEntry(keyID)
+		atomic.AddUint64(&mkc.evictions, 1)
+		evicted = append(evicted, keyID)
+	}
+	return evicted
+}
+
+// victim returns the ID of the key to evict, sparing the key just added, or
+// an empty ID when there is no key to evict. Apart from EvictRandom, ties
+// are broken by age and then by ID so that eviction is deterministic, keys
+// never used being evicted first.
+func (mkc *memoryKeyCacher) victim() string {
+	spared := ""
+	if len(mkc.entries) > 1 {
+		spared = mkc.lastAdded
+	}
+
+	if mkc.eviction == EvictRandom {
+		candidates := len(mkc.entries)
+		if _, ok := mkc.entries[spared]; ok {
+			candidates--
+		}
+		if candidates == 0 {
+			return ""
+		}
+		n := rand.Intn(candidates)
+		for keyID := range mkc.entries {
+			if keyID == spared {
+				continue
+			}
+			if n == 0 {
+				return keyID
+			}
+			n--
+		}
+	}
+
+	mkc.usageMu.Lock()
+	defer mkc.usageMu.Unlock()
+
+	var victimKeyID string
+	var victimEntry keyCacherEntry
+	var victimUsage keyUsage
+	first := true
+	for keyID, entry := range mkc.entries {
+		usage := mkc.usage[keyID]
+		if keyID == spared {
+			continue
+		}
+		if first || mkc.evictsBefore(usage, victimUsage, keyID, victimKeyID, entry, victimEntry) {
+			victimKeyID, victimEntry, victimUsage, first = keyID, entry, usage, false
+		}
+	}
+	return victimKeyID
+}
+
+// evictsBefore reports whether the key a should be evicted before the key b
+func (mkc *memoryKeyCacher) evictsBefore(a, b keyUsage, aKeyID, bKeyID string, aEntry, bEntry keyCacherEntry) bool {
+	switch mkc.eviction {
+	case EvictLRU:
+		if a.lastUsed != b.lastUsed {
+			return a.lastUsed < b.lastUsed
+		}
+	case EvictLFU:
+		if a.uses != b.uses {
+			return a.uses < b.uses
+		}
+		if a.lastUsed != b.lastUsed {
+			return a.lastUsed < b.lastUsed
+		}
+	}
+	if !aEntry.addedAt.Equal(bEntry.addedAt) {
+		return aEntry.addedAt.Before(bEntry.addedAt)
+	}
+	return aKeyID < bKeyID
+}
@@ -0,0 +1,86 @@
+package auth0
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestEvictionPolicies(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{
+		{Key: "test1", KeyID: "key1"},
+		{Key: "test2", KeyID: "key2"},
+		{Key: "test3", KeyID: "key3"},
+		{Key: "test4", KeyID: "key4"},
+	}
+
+	tests := []struct {
+		policy         EvictionPolicy
+		expectedVictim string
+	}{
+		{EvictLRU, "key1"},
+		{EvictLFU, "key2"},
+		{EvictFIFO, "key1"},
+		{EvictRandom, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			mkc := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+				MaxKeyAge:      MaxKeyAgeNoCheck,
+				MaxCacheSize:   3,
+				EvictionPolicy: test.policy,
+			}).(*memoryKeyCacher)
+
+			for _, keyID := range []string{"key1", "key2", "key3"} {
+				_, err := mkc.Add(keyID, downloadedKeys)
+				assert.NoError(t, err)
+			}
+			// key1 is used the most but the longest ago
+			for _, keyID := range []string{"key1", "key1", "key2", "key3"} {
+				_, err := mkc.Get(keyID)
+				assert.NoError(t, err)
+			}
+
+			_, err := mkc.Add("key4", downloadedKeys)
+			assert.NoError(t, err)
+			assert.Len(t, mkc.entries, 3)
+			assert.Contains(t, mkc.entries, "key4")
+			if test.expectedVictim != "" {
+				assert.NotContains(t, mkc.entries, test.expectedVictim)
+			}
+		})
+	}
+}
+
+func TestEvictionNegativeMaxCacheSize(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{Key: "test1", KeyID: "key1"}, {Key: "test2", KeyID: "key2"}}
+
+	for _, policy := range []EvictionPolicy{EvictLRU, EvictLFU, EvictFIFO, EvictRandom} {
+		t.Run(policy.String(), func(t *testing.T) {
+			mkc := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+				MaxKeyAge:      MaxKeyAgeNoCheck,
+				MaxCacheSize:   -2,
+				EvictionPolicy: policy,
+			}).(*memoryKeyCacher)
+			assert.Equal(t, MaxCacheSizeNoCheck, mkc.maxCacheSize)
+
+			for _, keyID := range []string{"key1", "key2"} {
+				_, err := mkc.Add(keyID, downloadedKeys)
+				assert.NoError(t, err)
+			}
+			assert.Len(t, mkc.entries, 2, "negative sizes should cache every key")
+
+			// the eviction stops once the cache is empty
+			mkc.maxCacheSize = -2
+			assert.Len(t, mkc.handleOverflow(), 2)
+			assert.Empty(t, mkc.entries)
+		})
+	}
+}
+
+func TestEvictionPolicyString(t *testing.T) {
+	assert.Equal(t, "lru", EvictLRU.String())
+	assert.Equal(t, "unknown", EvictionPolicy(42).String())
+}
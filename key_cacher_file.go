@@ -0,0 +1,125 @@
+package auth0
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// FileKeyCacherOptions configures the file persisted key cacher.
+type FileKeyCacherOptions struct {
+	// Path is the JSON file the keys are persisted to, created when missing.
+	Path string
+	// MemoryKeyCacherOptions configures the in-memory cache the keys are
+	// served from, the persisted keys expiring as they would in memory.
+	// Shards is ignored.
+	MemoryKeyCacherOptions
+	// OnPersistError, when set, is called when the keys cannot be written to
+	// Path. The keys are still served from memory.
+	OnPersistError func(err error)
+}
+
+type fileKeyCacher struct {
+	*memoryKeyCacher
+	path           string
+	onPersistError func(err error)
+
+	// persistMu serializes the writes of the file
+	persistMu sync.Mutex
+}
+
+// NewFileKeyCacher creates a key cacher serving the keys from memory and
+// persisting them to a JSON snapshot, from which they are loaded on startup so
+// that a restart does not depend on the JWKS endpoint being reachable.
+// Loaded keys keep their age: combine MaxStaleAge with the FailOpen outage
+// policy to serve them while the JWKS endpoint is down at boot.
+//
+// Keys are stored as JSON Web Keys: only cache public keys.
+func NewFileKeyCacher(options FileKeyCacherOptions) (KeyCacher, error) {
+	fkc := &fileKeyCacher{
+		memoryKeyCacher: newMemoryKeyCacher(options.MemoryKeyCacherOptions),
+		path:            options.Path,
+		onPersistError:  options.OnPersistError,
+	}
+	if err := fkc.load(); err != nil {
+		fkc.Stop()
+		return nil, err
+	}
+	return fkc, nil
+}
+
+// Add adds a key into the cache and persists the cached keys
+func (fkc *fileKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return fkc.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL adds a key into the cache expiring after ttl instead of the
+// max key age, and persists the cached keys
+func (fkc *fileKeyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	key, err := fkc.memoryKeyCacher.AddWithTTL(keyID, downloadedKeys, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if err := fkc.persist(); err != nil && fkc.onPersistError != nil {
+		fkc.onPersistError(err)
+	}
+	return key, nil
+}
+
+// load adds the keys persisted to the file, if any, into the cache,
+// skipping the ones no longer retained
+func (fkc *fileKeyCacher) load() error {
+	data, err := os.ReadFile(fkc.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snapshot keyCacheSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("invalid key cache file %s: %v", fkc.path, err)
+	}
+	fkc.restore(snapshot)
+	return nil
+}
+
+// ImportSnapshot adds the keys exported to r into the cache and persists
+// the cached keys
+func (fkc *fileKeyCacher) ImportSnapshot(r io.Reader) error {
+	if err := fkc.memoryKeyCacher.ImportSnapshot(r); err != nil {
+		return err
+	}
+	return fkc.persist()
+}
+
+// persist writes the cached keys to a temporary file renamed over the file,
+// so that a crash never leaves a partially written file behind
+func (fkc *fileKeyCacher) persist() error {
+	fkc.persistMu.Lock()
+	defer fkc.persistMu.Unlock()
+
+	data, err := json.Marshal(fkc.snapshot())
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(fkc.path), filepath.Base(fkc.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fkc.path)
+}
@@ -0,0 +1,142 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestFileKeyCacher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	downloadedKeys := []jose.JSONWebKey{
+		genPublicRSASSAJWK("key1"),
+		genPublicRSASSAJWK("key2"),
+	}
+
+	keyCacher, err := NewFileKeyCacher(FileKeyCacherOptions{
+		Path:                   path,
+		MemoryKeyCacherOptions: MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: MaxCacheSizeNoCheck},
+	})
+	assert.NoError(t, err)
+	_, err = keyCacher.Get("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	_, err = keyCacher.Add("key1", downloadedKeys)
+	assert.NoError(t, err)
+	_, err = keyCacher.Add("key3", downloadedKeys)
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	restarted, err := NewFileKeyCacher(FileKeyCacherOptions{
+		Path:                   path,
+		MemoryKeyCacherOptions: MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: MaxCacheSizeNoCheck},
+	})
+	assert.NoError(t, err)
+	for _, downloadedKey := range downloadedKeys {
+		key, err := restarted.Get(downloadedKey.KeyID)
+		assert.NoError(t, err)
+		assert.Equal(t, downloadedKey.KeyID, key.KeyID)
+		assert.Equal(t, downloadedKey.Key, key.Key)
+	}
+}
+
+func TestFileKeyCacherLoad(t *testing.T) {
+	key := genPublicRSASSAJWK("key1")
+
+	tests := []struct {
+		name        string
+		options     MemoryKeyCacherOptions
+		addedAt     time.Time
+		maxAge      time.Duration
+		expectedErr error
+	}{
+		{
+			name:    "pass - key not expired",
+			options: MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1},
+			addedAt: time.Now().Add(-30 * time.Second),
+		},
+		{
+			name:    "pass - key never expires",
+			options: MemoryKeyCacherOptions{MaxKeyAge: MaxKeyAgeNoCheck, MaxCacheSize: 1},
+			addedAt: time.Now().Add(-24 * time.Hour),
+		},
+		{
+			name:        "fail - key retained once expired",
+			options:     MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1, MaxStaleAge: time.Hour},
+			addedAt:     time.Now().Add(-2 * time.Minute),
+			expectedErr: ErrKeyExpired,
+		},
+		{
+			name:        "fail - key expired after its ttl",
+			options:     MemoryKeyCacherOptions{MaxKeyAge: time.Hour, MaxCacheSize: 1, MaxStaleAge: time.Hour},
+			addedAt:     time.Now().Add(-2 * time.Minute),
+			maxAge:      time.Minute,
+			expectedErr: ErrKeyExpired,
+		},
+		{
+			name:        "fail - key no longer retained",
+			options:     MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1},
+			addedAt:     time.Now().Add(-2 * time.Minute),
+			expectedErr: ErrNoKeyFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+			data, err := json.Marshal(keyCacheSnapshot{Keys: []snapshotKey{{AddedAt: test.addedAt, MaxAge: test.maxAge, Key: key}}})
+			assert.NoError(t, err)
+			assert.NoError(t, os.WriteFile(path, data, 0600))
+
+			keyCacher, err := NewFileKeyCacher(FileKeyCacherOptions{Path: path, MemoryKeyCacherOptions: test.options})
+			assert.NoError(t, err)
+			_, err = keyCacher.Get("key1")
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func TestFileKeyCacherLoadOverflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	data, err := json.Marshal(keyCacheSnapshot{Keys: []snapshotKey{
+		{AddedAt: time.Now(), Key: genPublicRSASSAJWK("key1")},
+		{AddedAt: time.Now(), Key: genPublicRSASSAJWK("key2")},
+	}})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+
+	keyCacher, err := NewFileKeyCacher(FileKeyCacherOptions{
+		Path:                   path,
+		MemoryKeyCacherOptions: MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, keyCacher.(StatsKeyCacher).Stats().Size)
+}
+
+func TestFileKeyCacherInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+
+	_, err := NewFileKeyCacher(FileKeyCacherOptions{Path: path})
+	assert.Error(t, err)
+}
+
+func TestFileKeyCacherPersistError(t *testing.T) {
+	var persistErr error
+	keyCacher, err := NewFileKeyCacher(FileKeyCacherOptions{
+		Path:                   filepath.Join(t.TempDir(), "missing", "keys.json"),
+		MemoryKeyCacherOptions: MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1},
+		OnPersistError:         func(err error) { persistErr = err },
+	})
+	assert.NoError(t, err)
+
+	key, err := keyCacher.Add("key1", []jose.JSONWebKey{genPublicRSASSAJWK("key1")})
+	assert.NoError(t, err, "keys should be served from memory when they cannot be persisted")
+	assert.Equal(t, "key1", key.KeyID)
+	assert.True(t, errors.Is(persistErr, os.ErrNotExist))
+}
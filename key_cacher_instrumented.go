@@ -0,0 +1,148 @@
+package auth0
+
+import (
+	"io"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// KeyCacheOperation is an operation of a key cacher.
+type KeyCacheOperation string
+
+const (
+	// KeyCacheGet is a lookup of a key.
+	KeyCacheGet KeyCacheOperation = "get"
+	// KeyCacheGetStale is a lookup of a key, even expired.
+	KeyCacheGetStale KeyCacheOperation = "get_stale"
+	// KeyCacheAdd is an addition of downloaded keys.
+	KeyCacheAdd KeyCacheOperation = "add"
+)
+
+// KeyCacheOutcome is the outcome of a key cacher operation.
+type KeyCacheOutcome string
+
+const (
+	// KeyCacheHit is a key found, or added for KeyCacheAdd.
+	KeyCacheHit KeyCacheOutcome = "hit"
+	// KeyCacheMiss is a key not found, or missing from the added keys.
+	KeyCacheMiss KeyCacheOutcome = "miss"
+	// KeyCacheExpired is a key found expired.
+	KeyCacheExpired KeyCacheOutcome = "expired"
+	// KeyCacheError is any other failure, e.g. of a remote cache.
+	KeyCacheError KeyCacheOutcome = "error"
+)
+
+// KeyCacherMetrics records the operations of an instrumented key cacher.
+type KeyCacherMetrics interface {
+	ObserveKeyCacheOperation(operation KeyCacheOperation, outcome KeyCacheOutcome, duration time.Duration)
+}
+
+// KeyCacherMetricsFunc is a function implementing KeyCacherMetrics.
+type KeyCacherMetricsFunc func(operation KeyCacheOperation, outcome KeyCacheOutcome, duration time.Duration)
+
+// ObserveKeyCacheOperation calls f.
+func (f KeyCacherMetricsFunc) ObserveKeyCacheOperation(operation KeyCacheOperation, outcome KeyCacheOutcome, duration time.Duration) {
+	f(operation, outcome, duration)
+}
+
+type instrumentedKeyCacher struct {
+	keyCacher KeyCacher
+	metrics   KeyCacherMetrics
+}
+
+// NewInstrumentedKeyCacher decorates keyCacher, recording the latency and
+// outcome of each of its operations to metrics. The optional interfaces of
+// keyCacher, such as StaleKeyCacher or StatsKeyCacher, are forwarded.
+func NewInstrumentedKeyCacher(keyCacher KeyCacher, metrics KeyCacherMetrics) KeyCacher {
+	return &instrumentedKeyCacher{keyCacher: keyCacher, metrics: metrics}
+}
+
+// Get obtains a key from the decorated key cacher
+func (ikc *instrumentedKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	start := time.Now()
+	key, err := ikc.keyCacher.Get(keyID)
+	ikc.observe(KeyCacheGet, err, start)
+	return key, err
+}
+
+// GetStale obtains a key, even expired, from the decorated key cacher when
+// it retains expired keys
+func (ikc *instrumentedKeyCacher) GetStale(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	staleKeyCacher, ok := ikc.keyCacher.(StaleKeyCacher)
+	if !ok {
+		return nil, time.Time{}, ErrNoKeyFound
+	}
+	start := time.Now()
+	key, expiresAt, err := staleKeyCacher.GetStale(keyID)
+	ikc.observe(KeyCacheGetStale, err, start)
+	return key, expiresAt, err
+}
+
+// Add adds the keys into the decorated key cacher
+func (ikc *instrumentedKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return ikc.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL adds the keys into the decorated key cacher, expiring after
+// ttl when positive and supported
+func (ikc *instrumentedKeyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	start := time.Now()
+	key, err := addWithTTL(ikc.keyCacher, keyID, downloadedKeys, ttl)
+	ikc.observe(KeyCacheAdd, err, start)
+	return key, err
+}
+
+// Stats returns the counters of the decorated key cacher, if any
+func (ikc *instrumentedKeyCacher) Stats() KeyCacherStats {
+	if statsKeyCacher, ok := ikc.keyCacher.(StatsKeyCacher); ok {
+		return statsKeyCacher.Stats()
+	}
+	return KeyCacherStats{}
+}
+
+// Concurrent reports whether the decorated key cacher is safe for
+// concurrent use
+func (ikc *instrumentedKeyCacher) Concurrent() bool {
+	return isConcurrentKeyCacher(ikc.keyCacher)
+}
+
+// Stop stops the decorated key cacher when it is stoppable
+func (ikc *instrumentedKeyCacher) Stop() {
+	if stoppable, ok := ikc.keyCacher.(StoppableKeyCacher); ok {
+		stoppable.Stop()
+	}
+}
+
+// ExportSnapshot writes the keys of the decorated key cacher to w
+func (ikc *instrumentedKeyCacher) ExportSnapshot(w io.Writer) error {
+	snapshotKeyCacher, ok := ikc.keyCacher.(SnapshotKeyCacher)
+	if !ok {
+		return ErrSnapshotNotSupported
+	}
+	return snapshotKeyCacher.ExportSnapshot(w)
+}
+
+// ImportSnapshot adds the keys exported to r into the decorated key cacher
+func (ikc *instrumentedKeyCacher) ImportSnapshot(r io.Reader) error {
+	snapshotKeyCacher, ok := ikc.keyCacher.(SnapshotKeyCacher)
+	if !ok {
+		return ErrSnapshotNotSupported
+	}
+	return snapshotKeyCacher.ImportSnapshot(r)
+}
+
+// observe records an operation started at start and failed with err
+func (ikc *instrumentedKeyCacher) observe(operation KeyCacheOperation, err error, start time.Time) {
+	outcome := KeyCacheHit
+	switch err {
+	case nil:
+	case ErrNoKeyFound:
+		outcome = KeyCacheMiss
+	case ErrKeyExpired:
+		outcome = KeyCacheExpired
+	default:
+		outcome = KeyCacheError
+	}
+	ikc.metrics.ObserveKeyCacheOperation(operation, outcome, time.Since(start))
+}
@@ -0,0 +1,84 @@
+package auth0
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+type observation struct {
+	operation KeyCacheOperation
+	outcome   KeyCacheOutcome
+}
+
+type recordingMetrics struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (m *recordingMetrics) ObserveKeyCacheOperation(operation KeyCacheOperation, outcome KeyCacheOutcome, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations = append(m.observations, observation{operation, outcome})
+}
+
+func TestInstrumentedKeyCacher(t *testing.T) {
+	metrics := &recordingMetrics{}
+	clock := clocktest.NewClock(time.Now())
+	keyCacher := NewInstrumentedKeyCacher(NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: MaxCacheSizeNoCheck,
+		MaxStaleAge:  time.Minute,
+		Clock:        clock,
+	}), metrics)
+
+	_, err := keyCacher.Get("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+	_, err = keyCacher.Add("key1", []jose.JSONWebKey{genPublicRSASSAJWK("key1")})
+	assert.NoError(t, err)
+	_, err = keyCacher.Add("key2", []jose.JSONWebKey{genPublicRSASSAJWK("key1")})
+	assert.Equal(t, ErrNoKeyFound, err)
+	_, err = keyCacher.Get("key1")
+	assert.NoError(t, err)
+	clock.Advance(time.Minute + time.Second)
+	_, err = keyCacher.Get("key1")
+	assert.Equal(t, ErrKeyExpired, err)
+	_, _, err = keyCacher.(StaleKeyCacher).GetStale("key1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []observation{
+		{KeyCacheGet, KeyCacheMiss},
+		{KeyCacheAdd, KeyCacheHit},
+		{KeyCacheAdd, KeyCacheMiss},
+		{KeyCacheGet, KeyCacheHit},
+		{KeyCacheGet, KeyCacheExpired},
+		{KeyCacheGetStale, KeyCacheHit},
+	}, metrics.observations)
+	assert.Equal(t, uint64(1), keyCacher.(StatsKeyCacher).Stats().Hits)
+}
+
+func TestInstrumentedKeyCacherErrors(t *testing.T) {
+	var outcomes []KeyCacheOutcome
+	metrics := KeyCacherMetricsFunc(func(operation KeyCacheOperation, outcome KeyCacheOutcome, duration time.Duration) {
+		outcomes = append(outcomes, outcome)
+	})
+	keyCacher := NewInstrumentedKeyCacher(failingKeyCacher{err: errors.New("connection refused")}, metrics)
+
+	_, err := keyCacher.Get("key1")
+	assert.Error(t, err)
+	_, err = keyCacher.Add("key1", nil)
+	assert.Error(t, err)
+	assert.Equal(t, []KeyCacheOutcome{KeyCacheError, KeyCacheError}, outcomes)
+
+	_, _, err = keyCacher.(StaleKeyCacher).GetStale("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+	assert.Equal(t, KeyCacherStats{}, keyCacher.(StatsKeyCacher).Stats())
+	assert.Equal(t, ErrSnapshotNotSupported, keyCacher.(SnapshotKeyCacher).ExportSnapshot(&bytes.Buffer{}))
+	assert.Len(t, outcomes, 2, "unsupported operations should not be recorded")
+}
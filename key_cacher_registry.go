@@ -0,0 +1,102 @@
+package auth0
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// KeyCacheRegistry shares a single key cacher between the JWKClients of
+// several JWKS endpoints, e.g. one per tenant, keying the keys by JWKS URI
+// and key ID. Clients of the same endpoint share their keys, so a key
+// downloaded by one of them is not downloaded again by the others.
+type KeyCacheRegistry struct {
+	keyCacher KeyCacher
+
+	mu    sync.Mutex
+	views map[string]*registryKeyCacher
+}
+
+// registryKeyCacher is the view of the shared key cacher for a JWKS URI.
+type registryKeyCacher struct {
+	keyCacher KeyCacher
+	prefix    string
+}
+
+// NewKeyCacheRegistry creates a registry sharing keyCacher.
+func NewKeyCacheRegistry(keyCacher KeyCacher) *KeyCacheRegistry {
+	return &KeyCacheRegistry{keyCacher: keyCacher, views: map[string]*registryKeyCacher{}}
+}
+
+// KeyCacher returns the key cacher of the JWKS endpoint at jwksURI, to be
+// given to the JWKClients of that endpoint:
+//
+//	client := NewJWKClientWithCache(opts, nil, registry.KeyCacher(opts.URI))
+func (r *KeyCacheRegistry) KeyCacher(jwksURI string) KeyCacher {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	view, ok := r.views[jwksURI]
+	if !ok {
+		// spaces are not allowed in URIs, the prefix is unambiguous
+		view = &registryKeyCacher{keyCacher: r.keyCacher, prefix: jwksURI + " "}
+		r.views[jwksURI] = view
+	}
+	return view
+}
+
+// Get obtains a key of the JWKS endpoint from the shared cache
+func (rkc *registryKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	key, err := rkc.keyCacher.Get(rkc.prefix + keyID)
+	if err != nil {
+		return nil, err
+	}
+	return rkc.unscoped(key), nil
+}
+
+// GetStale obtains a key of the JWKS endpoint, even expired, from the
+// shared cache when it retains expired keys
+func (rkc *registryKeyCacher) GetStale(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	staleKeyCacher, ok := rkc.keyCacher.(StaleKeyCacher)
+	if !ok {
+		return nil, time.Time{}, ErrNoKeyFound
+	}
+	key, expiresAt, err := staleKeyCacher.GetStale(rkc.prefix + keyID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return rkc.unscoped(key), expiresAt, nil
+}
+
+// Add adds the keys of the JWKS endpoint into the shared cache
+func (rkc *registryKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return rkc.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL adds the keys of the JWKS endpoint into the shared cache,
+// expiring after ttl when positive and supported by the shared cache
+func (rkc *registryKeyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	scoped := make([]jose.JSONWebKey, len(downloadedKeys))
+	for i, key := range downloadedKeys {
+		key.KeyID = rkc.prefix + key.KeyID
+		scoped[i] = key
+	}
+	key, err := addWithTTL(rkc.keyCacher, rkc.prefix+keyID, scoped, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return rkc.unscoped(key), nil
+}
+
+// Concurrent reports whether the shared cache is safe for concurrent use
+func (rkc *registryKeyCacher) Concurrent() bool {
+	return isConcurrentKeyCacher(rkc.keyCacher)
+}
+
+// unscoped returns a copy of the key with the ID of the JWKS endpoint
+func (rkc *registryKeyCacher) unscoped(key *jose.JSONWebKey) *jose.JSONWebKey {
+	unscoped := *key
+	unscoped.KeyID = strings.TrimPrefix(key.KeyID, rkc.prefix)
+	return &unscoped
+}
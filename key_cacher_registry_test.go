@@ -0,0 +1,89 @@
+package auth0
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestKeyCacheRegistry(t *testing.T) {
+	shared := NewMemoryKeyCacher(time.Minute, MaxCacheSizeNoCheck)
+	registry := NewKeyCacheRegistry(shared)
+	tenant1 := registry.KeyCacher("https://tenant1.auth0.com/.well-known/jwks.json")
+	tenant2 := registry.KeyCacher("https://tenant2.auth0.com/.well-known/jwks.json")
+	assert.True(t, tenant1 == registry.KeyCacher("https://tenant1.auth0.com/.well-known/jwks.json"))
+
+	key1, key2 := genPublicRSASSAJWK("key1"), genPublicRSASSAJWK("key1")
+	key, err := tenant1.Add("key1", []jose.JSONWebKey{key1})
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+	_, err = tenant2.Get("key1")
+	assert.Equal(t, ErrNoKeyFound, err, "keys of other endpoints should not be shared")
+
+	_, err = tenant2.Add("key1", []jose.JSONWebKey{key2})
+	assert.NoError(t, err)
+	for _, test := range []struct {
+		keyCacher KeyCacher
+		expected  jose.JSONWebKey
+	}{{tenant1, key1}, {tenant2, key2}} {
+		key, err := test.keyCacher.Get("key1")
+		assert.NoError(t, err)
+		assert.Equal(t, "key1", key.KeyID)
+		assert.Equal(t, test.expected.Key, key.Key)
+	}
+	assert.Equal(t, 2, shared.(StatsKeyCacher).Stats().Size)
+
+	_, err = tenant1.Add("key2", []jose.JSONWebKey{key1})
+	assert.Equal(t, ErrNoKeyFound, err)
+}
+
+func TestKeyCacheRegistryGetStale(t *testing.T) {
+	shared := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: MaxCacheSizeNoCheck,
+		MaxStaleAge:  time.Hour,
+	}).(*memoryKeyCacher)
+	addedAt := time.Now().Add(-2 * time.Minute)
+	shared.entries["https://tenant1.auth0.com/ key1"] = keyCacherEntry{addedAt: addedAt, JSONWebKey: genPublicRSASSAJWK("key1")}
+
+	keyCacher := NewKeyCacheRegistry(shared).KeyCacher("https://tenant1.auth0.com/")
+	_, err := keyCacher.Get("key1")
+	assert.Equal(t, ErrKeyExpired, err)
+
+	key, expiresAt, err := keyCacher.(StaleKeyCacher).GetStale("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+	assert.Equal(t, addedAt.Add(time.Minute), expiresAt)
+
+	_, _, err = NewKeyCacheRegistry(failingKeyCacher{}).KeyCacher("https://tenant1.auth0.com/").(StaleKeyCacher).GetStale("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+}
+
+func TestKeyCacheRegistrySharedDownloads(t *testing.T) {
+	var calls uint64
+	jwk := genRSASSAJWK(jose.RS256, "key1")
+	jwks, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{jwk.Public()}})
+	assert.NoError(t, err)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jwks)
+	}))
+	defer ts.Close()
+
+	registry := NewKeyCacheRegistry(NewMemoryKeyCacher(time.Minute, MaxCacheSizeNoCheck))
+	for i := 0; i < 3; i++ {
+		opts := JWKClientOptions{URI: ts.URL}
+		client := NewJWKClientWithCache(opts, nil, registry.KeyCacher(opts.URI))
+		key, err := client.GetKey("key1")
+		assert.NoError(t, err)
+		assert.Equal(t, "key1", key.KeyID)
+	}
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&calls), "clients of the same endpoint should share their keys")
+}
@@ -0,0 +1,97 @@
+package auth0
+
+import (
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// shardedKeyCacher spreads the keys over in-memory key cachers with
+// independent locks according to the hash of their ID.
+type shardedKeyCacher struct {
+	shards []*memoryKeyCacher
+}
+
+func newShardedKeyCacher(options MemoryKeyCacherOptions) *shardedKeyCacher {
+	skc := &shardedKeyCacher{shards: make([]*memoryKeyCacher, options.Shards)}
+	if options.MaxCacheSize > 0 {
+		options.MaxCacheSize = (options.MaxCacheSize + options.Shards - 1) / options.Shards
+	}
+	for i := range skc.shards {
+		skc.shards[i] = newMemoryKeyCacher(options)
+	}
+	return skc
+}
+
+// shard returns the shard holding keyID, hashing it with FNV-1a inline
+// rather than through hash/fnv so that lookups do not allocate
+func (skc *shardedKeyCacher) shard(keyID string) *memoryKeyCacher {
+	hash := uint32(2166136261)
+	for i := 0; i < len(keyID); i++ {
+		hash ^= uint32(keyID[i])
+		hash *= 16777619
+	}
+	return skc.shards[hash%uint32(len(skc.shards))]
+}
+
+// Get obtains a key from its shard, and checks if the key is expired
+func (skc *shardedKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	return skc.shard(keyID).Get(keyID)
+}
+
+// GetStale obtains a key from its shard even if it is expired, as long as
+// it is retained for MaxStaleAge.
+func (skc *shardedKeyCacher) GetStale(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	return skc.shard(keyID).GetStale(keyID)
+}
+
+// Add adds the keys into their shards and handles overflow
+func (skc *shardedKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return skc.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL adds the keys into their shards expiring after ttl instead of
+// the max key age, and handles overflow.
+func (skc *shardedKeyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	keysByShard := map[*memoryKeyCacher][]jose.JSONWebKey{}
+	for _, key := range downloadedKeys {
+		shard := skc.shard(key.KeyID)
+		keysByShard[shard] = append(keysByShard[shard], key)
+	}
+
+	addingShard := skc.shard(keyID)
+	for shard, keys := range keysByShard {
+		// the other shards only cache their keys when every downloaded
+		// key is cached
+		if shard != addingShard && shard.maxCacheSize == MaxCacheSizeNoCheck {
+			_, _ = shard.AddWithTTL(keyID, keys, ttl)
+		}
+	}
+	return addingShard.AddWithTTL(keyID, keysByShard[addingShard], ttl)
+}
+
+// Concurrent reports that the shards are safe for concurrent use
+func (skc *shardedKeyCacher) Concurrent() bool {
+	return true
+}
+
+// Stats returns the counters of the cache, summed over the shards
+func (skc *shardedKeyCacher) Stats() KeyCacherStats {
+	var stats KeyCacherStats
+	for _, shard := range skc.shards {
+		shardStats := shard.Stats()
+		stats.Hits += shardStats.Hits
+		stats.Misses += shardStats.Misses
+		stats.Evictions += shardStats.Evictions
+		stats.Expirations += shardStats.Expirations
+		stats.Size += shardStats.Size
+	}
+	return stats
+}
+
+// Stop terminates the background cleanup goroutines of the shards
+func (skc *shardedKeyCacher) Stop() {
+	for _, shard := range skc.shards {
+		shard.Stop()
+	}
+}
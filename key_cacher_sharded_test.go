@@ -0,0 +1,182 @@
+package auth0
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestShardedKeyCacher(t *testing.T) {
+	var downloadedKeys []jose.JSONWebKey
+	for i := 0; i < 8; i++ {
+		downloadedKeys = append(downloadedKeys, genPublicRSASSAJWK(fmt.Sprintf("key%d", i)))
+	}
+
+	tests := []struct {
+		name         string
+		maxCacheSize int
+		expectedSize int
+	}{
+		{"every downloaded key cached", MaxCacheSizeNoCheck, len(downloadedKeys)},
+		{"added key cached", 8, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+				MaxKeyAge:    time.Minute,
+				MaxCacheSize: test.maxCacheSize,
+				Shards:       4,
+			})
+			assert.IsType(t, &shardedKeyCacher{}, keyCacher)
+
+			key, err := keyCacher.Add("key3", downloadedKeys)
+			assert.NoError(t, err)
+			assert.Equal(t, "key3", key.KeyID)
+			_, err = keyCacher.Add("key8", downloadedKeys)
+			assert.Equal(t, ErrNoKeyFound, err)
+
+			key, err = keyCacher.Get("key3")
+			assert.NoError(t, err)
+			assert.Equal(t, "key3", key.KeyID)
+			assert.Equal(t, test.expectedSize, keyCacher.(StatsKeyCacher).Stats().Size)
+		})
+	}
+}
+
+func TestShardedKeyCacherMaxCacheSize(t *testing.T) {
+	keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: 6,
+		Shards:       4,
+	}).(*shardedKeyCacher)
+	for _, shard := range keyCacher.shards {
+		assert.Equal(t, 2, shard.maxCacheSize)
+	}
+
+	for i := 0; i < 32; i++ {
+		keyID := fmt.Sprintf("key%d", i)
+		_, err := keyCacher.Add(keyID, []jose.JSONWebKey{{KeyID: keyID, Key: []byte("secret")}})
+		assert.NoError(t, err)
+	}
+	stats := keyCacher.Stats()
+	assert.Equal(t, 8, stats.Size)
+	assert.Equal(t, uint64(24), stats.Evictions)
+}
+
+func TestShardedKeyCacherExpiry(t *testing.T) {
+	clock := clocktest.NewClock(time.Now())
+	keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: MaxCacheSizeNoCheck,
+		MaxStaleAge:  time.Minute,
+		Shards:       2,
+		Clock:        clock,
+	})
+	_, err := keyCacher.Add("key1", []jose.JSONWebKey{genPublicRSASSAJWK("key1")})
+	assert.NoError(t, err)
+
+	clock.Advance(time.Minute + time.Second)
+	_, err = keyCacher.Get("key1")
+	assert.Equal(t, ErrKeyExpired, err)
+	key, _, err := keyCacher.(StaleKeyCacher).GetStale("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+}
+
+func TestShardedKeyCacherConcurrency(t *testing.T) {
+	keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:       time.Minute,
+		MaxCacheSize:    4,
+		Shards:          4,
+		CleanupInterval: time.Millisecond,
+	})
+	defer keyCacher.(StoppableKeyCacher).Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				keyID := fmt.Sprintf("key%d", (i+j)%10)
+				if j%10 == 0 {
+					_, _ = keyCacher.Add(keyID, []jose.JSONWebKey{{KeyID: keyID, Key: []byte("secret")}})
+				}
+				_, _ = keyCacher.Get(keyID)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// BenchmarkMemoryKeyCacherGet compares concurrent lookups of an unsharded
+// cache with a sharded one, e.g. with go test -bench MemoryKeyCacher -cpu 8
+func BenchmarkMemoryKeyCacherGet(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+				MaxKeyAge:    time.Hour,
+				MaxCacheSize: 1024,
+				Shards:       shards,
+			})
+			keyIDs := make([]string, 64)
+			for i := range keyIDs {
+				keyIDs[i] = fmt.Sprintf("key%d", i)
+				if _, err := keyCacher.Add(keyIDs[i], []jose.JSONWebKey{{KeyID: keyIDs[i], Key: []byte("secret")}}); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					_, _ = keyCacher.Get(keyIDs[i%len(keyIDs)])
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkJWKClientGetSecretParallel compares concurrent key lookups through
+// the JWKClient with an unsharded cache and a sharded one, e.g. with
+// go test -bench JWKClientGetSecretParallel -cpu 8. The client used to
+// serialize the lookups behind its own lock, whatever the number of shards.
+func BenchmarkJWKClientGetSecretParallel(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+				MaxKeyAge:    time.Hour,
+				MaxCacheSize: 1024,
+				Shards:       shards,
+			})
+			client := NewJWKClientWithCache(JWKClientOptions{URI: "http://localhost"}, nil, keyCacher)
+			secret := []byte("01234567890123456789012345678901")
+			requests := make([]*http.Request, 64)
+			for i := range requests {
+				keyID := fmt.Sprintf("key%d", i)
+				if _, err := keyCacher.Add(keyID, []jose.JSONWebKey{{KeyID: keyID, Key: secret}}); err != nil {
+					b.Fatal(err)
+				}
+				requests[i], _ = http.NewRequest("", "http://localhost", nil)
+				requests[i].Header.Set("Authorization", "Bearer "+getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, secret, keyID))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					if _, err := client.GetSecret(requests[i%len(requests)]); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
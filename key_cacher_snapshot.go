@@ -0,0 +1,119 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// ErrSnapshotNotSupported is returned when exporting or importing the keys
+// of a key cacher not implementing SnapshotKeyCacher.
+var ErrSnapshotNotSupported = errors.New("key cacher does not support snapshots")
+
+// SnapshotKeyCacher is implemented by key cachers able to export their keys
+// to JSON and import them back, e.g. to bake them into a container image or
+// hand them over between blue/green deployments.
+type SnapshotKeyCacher interface {
+	// ExportSnapshot writes the cached keys, along with their age, to w.
+	ExportSnapshot(w io.Writer) error
+	// ImportSnapshot adds the keys exported to r into the cache, skipping
+	// the ones no longer retained.
+	ImportSnapshot(r io.Reader) error
+}
+
+// keyCacheSnapshot is the JSON snapshot of a key cache.
+type keyCacheSnapshot struct {
+	Keys []snapshotKey `json:"keys"`
+}
+
+type snapshotKey struct {
+	AddedAt time.Time       `json:"added_at"`
+	MaxAge  time.Duration   `json:"max_age,omitempty"`
+	Key     jose.JSONWebKey `json:"key"`
+}
+
+// ExportSnapshot writes the cached keys to w
+func (mkc *memoryKeyCacher) ExportSnapshot(w io.Writer) error {
+	return json.NewEncoder(w).Encode(mkc.snapshot())
+}
+
+// ImportSnapshot adds the keys exported to r into the cache
+func (mkc *memoryKeyCacher) ImportSnapshot(r io.Reader) error {
+	var snapshot keyCacheSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	mkc.restore(snapshot)
+	return nil
+}
+
+// snapshot returns the cached keys
+func (mkc *memoryKeyCacher) snapshot() keyCacheSnapshot {
+	mkc.mu.RLock()
+	defer mkc.mu.RUnlock()
+	snapshot := keyCacheSnapshot{Keys: make([]snapshotKey, 0, len(mkc.entries))}
+	for _, entry := range mkc.entries {
+		snapshot.Keys = append(snapshot.Keys, snapshotKey{
+			AddedAt: entry.addedAt,
+			MaxAge:  entry.maxAge,
+			Key:     entry.JSONWebKey,
+		})
+	}
+	return snapshot
+}
+
+// restore adds the keys of the snapshot still retained into the cache,
+// replacing the cached ones, and handles overflow
+func (mkc *memoryKeyCacher) restore(snapshot keyCacheSnapshot) {
+	var added, evicted []string
+	now := mkc.now()
+	mkc.mu.Lock()
+	for _, key := range snapshot.Keys {
+		entry := keyCacherEntry{addedAt: key.AddedAt, JSONWebKey: key.Key, maxAge: key.MaxAge}
+		if mkc.maxKeyAge != MaxKeyAgeNoCheck && now.After(mkc.expiresAt(entry)) && !mkc.retained(entry, now) {
+			continue
+		}
+		mkc.entries[key.Key.KeyID] = entry
+		mkc.touch(key.Key.KeyID)
+		added = append(added, key.Key.KeyID)
+	}
+	if mkc.maxCacheSize != MaxCacheSizeNoCheck {
+		evicted = mkc.handleOverflow()
+	}
+	mkc.mu.Unlock()
+
+	notify(mkc.onAdd, added)
+	notify(mkc.onEvict, evicted)
+	logKeyEvents(mkc.logger, "auth0: key evicted", evicted)
+}
+
+// ExportSnapshot writes the keys cached by every shard to w
+func (skc *shardedKeyCacher) ExportSnapshot(w io.Writer) error {
+	var snapshot keyCacheSnapshot
+	for _, shard := range skc.shards {
+		snapshot.Keys = append(snapshot.Keys, shard.snapshot().Keys...)
+	}
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// ImportSnapshot adds the keys exported to r into their shards
+func (skc *shardedKeyCacher) ImportSnapshot(r io.Reader) error {
+	var snapshot keyCacheSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	snapshots := map[*memoryKeyCacher]keyCacheSnapshot{}
+	for _, key := range snapshot.Keys {
+		shard := skc.shard(key.Key.KeyID)
+		shardSnapshot := snapshots[shard]
+		shardSnapshot.Keys = append(shardSnapshot.Keys, key)
+		snapshots[shard] = shardSnapshot
+	}
+	for shard, shardSnapshot := range snapshots {
+		shard.restore(shardSnapshot)
+	}
+	return nil
+}
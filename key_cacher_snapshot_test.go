@@ -0,0 +1,109 @@
+package auth0
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestKeyCacherSnapshot(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genPublicRSASSAJWK("key1"), genPublicRSASSAJWK("key2")}
+
+	tests := []struct {
+		name    string
+		options MemoryKeyCacherOptions
+	}{
+		{"memory", MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: MaxCacheSizeNoCheck}},
+		{"sharded", MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: MaxCacheSizeNoCheck, Shards: 4}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exporting := NewMemoryKeyCacherWithOptions(test.options)
+			_, err := exporting.Add("key1", downloadedKeys)
+			assert.NoError(t, err)
+
+			var snapshot bytes.Buffer
+			assert.NoError(t, exporting.(SnapshotKeyCacher).ExportSnapshot(&snapshot))
+
+			importing := NewMemoryKeyCacherWithOptions(test.options)
+			assert.NoError(t, importing.(SnapshotKeyCacher).ImportSnapshot(&snapshot))
+			for _, downloadedKey := range downloadedKeys {
+				key, err := importing.Get(downloadedKey.KeyID)
+				assert.NoError(t, err)
+				assert.Equal(t, downloadedKey.KeyID, key.KeyID)
+				assert.Equal(t, downloadedKey.Key, key.Key)
+			}
+		})
+	}
+}
+
+func TestKeyCacherImportSnapshot(t *testing.T) {
+	snapshot := keyCacheSnapshot{Keys: []snapshotKey{
+		{AddedAt: time.Now(), Key: genPublicRSASSAJWK("key1")},
+		{AddedAt: time.Now().Add(-time.Hour), Key: genPublicRSASSAJWK("key2")},
+		{AddedAt: time.Now(), Key: genPublicRSASSAJWK("key3")},
+	}}
+	data, err := json.Marshal(snapshot)
+	assert.NoError(t, err)
+
+	var added []string
+	keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: 1,
+		OnAdd:        func(keyID string) { added = append(added, keyID) },
+	})
+	assert.NoError(t, keyCacher.(SnapshotKeyCacher).ImportSnapshot(bytes.NewReader(data)))
+	assert.Equal(t, []string{"key1", "key3"}, added, "keys no longer retained should be skipped")
+	assert.Equal(t, 1, keyCacher.(StatsKeyCacher).Stats().Size)
+
+	assert.Error(t, keyCacher.(SnapshotKeyCacher).ImportSnapshot(strings.NewReader("{")))
+}
+
+func TestFileKeyCacherImportSnapshot(t *testing.T) {
+	var snapshot bytes.Buffer
+	exporting := NewMemoryKeyCacher(time.Minute, MaxCacheSizeNoCheck)
+	_, err := exporting.Add("key1", []jose.JSONWebKey{genPublicRSASSAJWK("key1")})
+	assert.NoError(t, err)
+	assert.NoError(t, exporting.(SnapshotKeyCacher).ExportSnapshot(&snapshot))
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	options := FileKeyCacherOptions{Path: path, MemoryKeyCacherOptions: MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1}}
+	keyCacher, err := NewFileKeyCacher(options)
+	assert.NoError(t, err)
+	assert.NoError(t, keyCacher.(SnapshotKeyCacher).ImportSnapshot(&snapshot))
+
+	restarted, err := NewFileKeyCacher(options)
+	assert.NoError(t, err)
+	_, err = restarted.Get("key1")
+	assert.NoError(t, err, "imported keys should be persisted")
+}
+
+func TestJWKClientExportImportKeys(t *testing.T) {
+	opts, _, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	exporting := NewJWKClient(opts, nil)
+	assert.NoError(t, exporting.PreloadKeys(context.Background()))
+	var snapshot bytes.Buffer
+	assert.NoError(t, exporting.ExportKeys(&snapshot))
+
+	importing := NewJWKClient(JWKClientOptions{URI: "invalidURI"}, nil)
+	assert.NoError(t, importing.ImportKeys(&snapshot))
+	key, err := importing.GetKey("keyRS256")
+	assert.NoError(t, err, "imported keys should be served without downloading the JWKS")
+	assert.Equal(t, "keyRS256", key.KeyID)
+
+	unsupported := NewJWKClientWithCache(opts, nil, NewTieredKeyCacher(NewMemoryKeyCacher(time.Minute, 1), NewMemoryKeyCacher(time.Minute, 1)))
+	assert.Equal(t, ErrSnapshotNotSupported, unsupported.ExportKeys(&snapshot))
+	assert.Equal(t, ErrSnapshotNotSupported, unsupported.ImportKeys(&snapshot))
+}
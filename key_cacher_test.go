@@ -1,11 +1,13 @@
 package auth0
 
 import (
+	"errors"
 	"strconv"
-	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
 	"github.com/stretchr/testify/assert"
 
 	"gopkg.in/square/go-jose.v2"
@@ -13,10 +15,11 @@ import (
 
 func TestGet(t *testing.T) {
 	tests := []struct {
-		name             string
-		mkc              *memoryKeyCacher
-		key              string
-		expectedErrorMsg string
+		name        string
+		mkc         *memoryKeyCacher
+		key         string
+		elapsed     time.Duration
+		expectedErr error
 	}{
 		{
 			name: "pass - persistent cacher",
@@ -25,8 +28,7 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: MaxCacheSizeNoCheck,
 			},
-			key:              "key1",
-			expectedErrorMsg: "",
+			key: "key1",
 		},
 		{
 			name: "fail - invalid key",
@@ -35,8 +37,8 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: MaxCacheSizeNoCheck,
 			},
-			key:              "invalid key",
-			expectedErrorMsg: "no Keys has been found",
+			key:         "invalid key",
+			expectedErr: ErrNoKeyFound,
 		},
 		{
 			name: "fail - persistent cacher get immediately expired key",
@@ -45,8 +47,9 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    time.Duration(0),
 				maxCacheSize: MaxCacheSizeNoCheck,
 			},
-			key:              "key1",
-			expectedErrorMsg: "key exists but is expired",
+			key:         "key1",
+			elapsed:     time.Nanosecond,
+			expectedErr: ErrKeyExpired,
 		},
 		{
 			name: "pass - persistent cacher get not expired key",
@@ -55,8 +58,7 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    time.Duration(10) * time.Second,
 				maxCacheSize: MaxCacheSizeNoCheck,
 			},
-			key:              "key1",
-			expectedErrorMsg: "",
+			key: "key1",
 		},
 		{
 			name: "fail - no cacher with -1 maxKeyAge",
@@ -65,8 +67,8 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: 0,
 			},
-			key:              "key1",
-			expectedErrorMsg: "no Keys has been found",
+			key:         "key1",
+			expectedErr: ErrNoKeyFound,
 		},
 		{
 			name: "fail - no cacher",
@@ -75,8 +77,8 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    time.Duration(0),
 				maxCacheSize: 0,
 			},
-			key:              "key1",
-			expectedErrorMsg: "no Keys has been found",
+			key:         "key1",
+			expectedErr: ErrNoKeyFound,
 		},
 		{
 			name: "fail - no cacher with 10sec max age",
@@ -85,8 +87,8 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    time.Duration(10) * time.Second,
 				maxCacheSize: 0,
 			},
-			key:              "key1",
-			expectedErrorMsg: "no Keys has been found",
+			key:         "key1",
+			expectedErr: ErrNoKeyFound,
 		},
 		{
 			name: "pass - custom cacher with -1 max age",
@@ -95,8 +97,7 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: 1,
 			},
-			key:              "key1",
-			expectedErrorMsg: "",
+			key: "key1",
 		},
 		{
 			name: "fail - custom cacher get immediately expired key",
@@ -105,8 +106,9 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    time.Duration(0),
 				maxCacheSize: 1,
 			},
-			key:              "key1",
-			expectedErrorMsg: "key exists but is expired",
+			key:         "key1",
+			elapsed:     time.Nanosecond,
+			expectedErr: ErrKeyExpired,
 		},
 		{
 			name: "pass - custom cacher not expired",
@@ -115,33 +117,34 @@ func TestGet(t *testing.T) {
 				maxKeyAge:    time.Duration(100) * time.Second,
 				maxCacheSize: 1,
 			},
-			key:              "key1",
-			expectedErrorMsg: "",
+			key: "key1",
 		},
 		{
 			name: "fail - custom cacher with expired key",
 			mkc: &memoryKeyCacher{
 				entries:      make(map[string]keyCacherEntry),
-				maxKeyAge:    time.Duration(-100) * time.Second, // setting max age negavtive time duration is equivalent to expired keys
+				maxKeyAge:    time.Duration(100) * time.Second,
 				maxCacheSize: 1,
 			},
-			key:              "key1",
-			expectedErrorMsg: "key exists but is expired",
+			key:         "key1",
+			elapsed:     time.Duration(101) * time.Second,
+			expectedErr: ErrKeyExpired,
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			clock := clocktest.NewClock(time.Now())
+			test.mkc.clock = clock
 			if test.mkc.entries != nil {
-				test.mkc.entries["key1"] = keyCacherEntry{time.Now(), jose.JSONWebKey{KeyID: "test1"}}
+				test.mkc.entries["key1"] = keyCacherEntry{addedAt: clock.Now(), JSONWebKey: jose.JSONWebKey{KeyID: "test1"}}
 			}
+			clock.Advance(test.elapsed)
 
 			_, err := test.mkc.Get(test.key)
 
-			if test.expectedErrorMsg != "" {
-				if err == nil {
-					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg)
-				} else if !strings.Contains(err.Error(), test.expectedErrorMsg) {
-					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg + ", but got: " + err.Error())
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Errorf("Validation should have failed with error %v, but got: %v", test.expectedErr, err)
 				}
 			} else {
 				if err != nil {
@@ -165,7 +168,7 @@ func TestAdd(t *testing.T) {
 		addingKey        string
 		gettingKey       string
 		expectedFoundKey bool
-		expectedErrorMsg string
+		expectedErr      error
 	}{
 		{
 			name: "pass - persistent cacher",
@@ -173,11 +176,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: MaxCacheSizeNoCheck,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: true,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "fail - invalid key",
@@ -185,11 +188,12 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: MaxCacheSizeNoCheck,
+				clock:        SystemClock,
 			},
 			addingKey:        "invalid key",
 			gettingKey:       "invalid key",
 			expectedFoundKey: false,
-			expectedErrorMsg: "no Keys has been found",
+			expectedErr:      ErrNoKeyFound,
 		},
 		{
 			name: "pass - add key for persistent cacher",
@@ -197,11 +201,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(0),
 				maxCacheSize: MaxCacheSizeNoCheck,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: true,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - add key for persistent cacher",
@@ -209,11 +213,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(10) * time.Second,
 				maxCacheSize: MaxCacheSizeNoCheck,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: true,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "fail - no cacher with -1 max age",
@@ -221,11 +225,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: 0,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: false,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "fail - no cacher",
@@ -233,11 +237,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(0),
 				maxCacheSize: 0,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: false,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "fail - no cacher with 10sec max age",
@@ -245,11 +249,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(10) * time.Second,
 				maxCacheSize: 0,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: false,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - custom cacher with -1 max age",
@@ -257,11 +261,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    MaxKeyAgeNoCheck,
 				maxCacheSize: 1,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: true,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - custom cacher with 0 max age",
@@ -269,11 +273,11 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(0),
 				maxCacheSize: 1,
+				clock:        SystemClock,
 			},
 			addingKey:        "test1",
 			gettingKey:       "test1",
 			expectedFoundKey: true,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - custom cacher get latest added key",
@@ -281,10 +285,10 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(100) * time.Second,
 				maxCacheSize: 1,
+				clock:        SystemClock,
 			},
 			gettingKey:       "test3",
 			expectedFoundKey: true,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "fail - custom cacher add invalid key",
@@ -292,11 +296,12 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(100) * time.Second,
 				maxCacheSize: 1,
+				clock:        SystemClock,
 			},
 			addingKey:        "invalid key",
 			gettingKey:       "test1",
 			expectedFoundKey: false,
-			expectedErrorMsg: "no Keys has been found",
+			expectedErr:      ErrNoKeyFound,
 		},
 		{
 			name: "fail - custom cacher get key not in cache",
@@ -304,10 +309,10 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(100) * time.Second,
 				maxCacheSize: 1,
+				clock:        SystemClock,
 			},
 			gettingKey:       "test1",
 			expectedFoundKey: false,
-			expectedErrorMsg: "",
 		},
 		{
 			name: "pass - custom cacher with capacity 3",
@@ -315,10 +320,10 @@ func TestAdd(t *testing.T) {
 				entries:      make(map[string]keyCacherEntry),
 				maxKeyAge:    time.Duration(100) * time.Second,
 				maxCacheSize: 3,
+				clock:        SystemClock,
 			},
 			gettingKey:       "test2",
 			expectedFoundKey: true,
-			expectedErrorMsg: "",
 		},
 	}
 	for _, test := range tests {
@@ -334,11 +339,9 @@ func TestAdd(t *testing.T) {
 			_, ok := test.mkc.entries[test.gettingKey]
 			assert.Equal(t, test.expectedFoundKey, ok)
 
-			if test.expectedErrorMsg != "" {
-				if err == nil {
-					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg)
-				} else if !strings.Contains(err.Error(), test.expectedErrorMsg) {
-					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg + ", but got: " + err.Error())
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Errorf("Validation should have failed with error %v, but got: %v", test.expectedErr, err)
 				}
 			} else {
 				if err != nil {
@@ -353,7 +356,7 @@ func TestKeyIsExpired(t *testing.T) {
 	tests := []struct {
 		name         string
 		mkc          *memoryKeyCacher
-		sleepingTime int
+		elapsed      time.Duration
 		expectedBool bool
 	}{
 		{
@@ -363,6 +366,7 @@ func TestKeyIsExpired(t *testing.T) {
 				maxKeyAge:    time.Duration(1) * time.Second,
 				maxCacheSize: 1,
 			},
+			elapsed:      time.Duration(10) * time.Second,
 			expectedBool: true,
 		},
 		{
@@ -377,11 +381,10 @@ func TestKeyIsExpired(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			if test.expectedBool {
-				test.mkc.entries["test1"] = keyCacherEntry{time.Now().Add(time.Duration(-10) * time.Second), jose.JSONWebKey{KeyID: "test1"}}
-			} else {
-				test.mkc.entries["test1"] = keyCacherEntry{time.Now(), jose.JSONWebKey{KeyID: "test1"}}
-			}
+			clock := clocktest.NewClock(time.Now())
+			test.mkc.clock = clock
+			test.mkc.entries["test1"] = keyCacherEntry{addedAt: clock.Now(), JSONWebKey: jose.JSONWebKey{KeyID: "test1"}}
+			clock.Advance(test.elapsed)
 			if test.mkc.keyIsExpired("test1") != test.expectedBool {
 				t.Errorf("Should have been " + strconv.FormatBool(test.expectedBool) + " but got different")
 			}
@@ -403,6 +406,7 @@ func TestHandleOverflow(t *testing.T) {
 				entries:      map[string]keyCacherEntry{},
 				maxKeyAge:    time.Duration(2) * time.Second,
 				maxCacheSize: 1,
+				clock:        SystemClock,
 			},
 			expectedLength: 1,
 		},
@@ -412,6 +416,7 @@ func TestHandleOverflow(t *testing.T) {
 				entries:      map[string]keyCacherEntry{},
 				maxKeyAge:    time.Duration(2) * time.Second,
 				maxCacheSize: 2,
+				clock:        SystemClock,
 			},
 			expectedLength: 2,
 		},
@@ -427,3 +432,325 @@ func TestHandleOverflow(t *testing.T) {
 		})
 	}
 }
+
+func TestGetStale(t *testing.T) {
+	tests := []struct {
+		name             string
+		mkc              *memoryKeyCacher
+		addedAt          time.Time
+		expectedGetErr   error
+		expectedStaleErr error
+	}{
+		{
+			name:           "pass - fresh key",
+			mkc:            NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1, MaxStaleAge: time.Minute}).(*memoryKeyCacher),
+			addedAt:        time.Now(),
+			expectedGetErr: nil,
+		},
+		{
+			name:           "pass - expired key retained",
+			mkc:            NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1, MaxStaleAge: time.Minute}).(*memoryKeyCacher),
+			addedAt:        time.Now().Add(-90 * time.Second),
+			expectedGetErr: ErrKeyExpired,
+		},
+		{
+			name:             "fail - expired key past stale age",
+			mkc:              NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: 1, MaxStaleAge: time.Minute}).(*memoryKeyCacher),
+			addedAt:          time.Now().Add(-3 * time.Minute),
+			expectedGetErr:   ErrKeyExpired,
+			expectedStaleErr: ErrNoKeyFound,
+		},
+		{
+			name:             "fail - expired key without stale age",
+			mkc:              NewMemoryKeyCacher(time.Minute, 1).(*memoryKeyCacher),
+			addedAt:          time.Now().Add(-90 * time.Second),
+			expectedGetErr:   ErrKeyExpired,
+			expectedStaleErr: ErrNoKeyFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mkc.entries["key1"] = keyCacherEntry{addedAt: test.addedAt, JSONWebKey: jose.JSONWebKey{KeyID: "key1"}}
+
+			_, err := test.mkc.Get("key1")
+			assert.Equal(t, test.expectedGetErr, err)
+
+			key, expiresAt, err := test.mkc.GetStale("key1")
+			assert.Equal(t, test.expectedStaleErr, err)
+			if err == nil {
+				assert.Equal(t, "key1", key.KeyID)
+				assert.Equal(t, test.addedAt.Add(time.Minute), expiresAt)
+			}
+		})
+	}
+}
+
+func TestAddWithTTL(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "key1", Key: "test1"}}
+
+	tests := []struct {
+		name      string
+		maxKeyAge time.Duration
+		ttl       time.Duration
+		expired   bool
+	}{
+		{
+			name:      "ttl overrides the max key age",
+			maxKeyAge: time.Duration(1) * time.Second,
+			ttl:       time.Duration(100) * time.Second,
+			expired:   false,
+		},
+		{
+			name:      "non positive ttl keeps the max key age",
+			maxKeyAge: time.Duration(1) * time.Second,
+			ttl:       time.Duration(0),
+			expired:   true,
+		},
+		{
+			name:      "ttl is ignored when keys never expire",
+			maxKeyAge: MaxKeyAgeNoCheck,
+			ttl:       time.Duration(1) * time.Second,
+			expired:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := clocktest.NewClock(time.Now())
+			mkc := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+				MaxKeyAge:    test.maxKeyAge,
+				MaxCacheSize: MaxCacheSizeNoCheck,
+				Clock:        clock,
+			}).(*memoryKeyCacher)
+			_, err := mkc.AddWithTTL("key1", downloadedKeys, test.ttl)
+			assert.NoError(t, err)
+			clock.Advance(time.Duration(10) * time.Second)
+			_, err = mkc.Get("key1")
+			if test.expired {
+				assert.Equal(t, ErrKeyExpired, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMemoryKeyCacherConcurrency(t *testing.T) {
+	mkc := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Millisecond,
+		MaxCacheSize: 3,
+		MaxStaleAge:  time.Millisecond,
+	}).(*memoryKeyCacher)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				keyID := "key" + strconv.Itoa((i+j)%5)
+				_, _ = mkc.Add(keyID, []jose.JSONWebKey{{KeyID: keyID, Key: "test"}})
+				_, _ = mkc.Get(keyID)
+				_, _, _ = mkc.GetStale(keyID)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	mkc.mu.RLock()
+	defer mkc.mu.RUnlock()
+	assert.True(t, len(mkc.entries) <= 3)
+}
+
+func TestLRUEviction(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{
+		{Key: "test1", KeyID: "key1"},
+		{Key: "test2", KeyID: "key2"},
+		{Key: "test3", KeyID: "key3"},
+		{Key: "test4", KeyID: "key4"},
+	}
+	mkc := NewMemoryKeyCacher(MaxKeyAgeNoCheck, 3).(*memoryKeyCacher)
+
+	for _, keyID := range []string{"key1", "key2", "key3"} {
+		_, err := mkc.Add(keyID, downloadedKeys)
+		assert.NoError(t, err)
+	}
+
+	// key1 is the oldest but hot, so key2 is the least recently used
+	_, err := mkc.Get("key1")
+	assert.NoError(t, err)
+	_, err = mkc.Add("key4", downloadedKeys)
+	assert.NoError(t, err)
+
+	for keyID, expectedErr := range map[string]error{"key1": nil, "key2": ErrNoKeyFound, "key3": nil, "key4": nil} {
+		_, err := mkc.Get(keyID)
+		assert.Equal(t, expectedErr, err, keyID)
+	}
+	assert.Len(t, mkc.usage, 3)
+}
+
+func TestLRUEvictionTies(t *testing.T) {
+	addedAt := time.Now()
+	mkc := &memoryKeyCacher{
+		entries: map[string]keyCacherEntry{
+			"b": {addedAt: addedAt},
+			"a": {addedAt: addedAt},
+			"c": {addedAt: addedAt.Add(-time.Second)},
+		},
+		maxKeyAge:    MaxKeyAgeNoCheck,
+		maxCacheSize: 1,
+		clock:        SystemClock,
+	}
+
+	// keys never used are evicted the oldest first, then by ID
+	assert.Equal(t, "c", mkc.victim())
+	mkc.handleOverflow()
+	assert.Len(t, mkc.entries, 1)
+	assert.Contains(t, mkc.entries, "b")
+}
+
+func TestMaxKeyAgeJitter(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "key1", Key: "test1"}}
+
+	mkc := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:       time.Hour,
+		MaxCacheSize:    MaxCacheSizeNoCheck,
+		MaxKeyAgeJitter: 0.5,
+	}).(*memoryKeyCacher)
+
+	maxAges := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		_, err := mkc.Add("key1", downloadedKeys)
+		assert.NoError(t, err)
+		maxAge := mkc.entries["key1"].maxAge
+		assert.True(t, maxAge > 30*time.Minute && maxAge <= time.Hour, "unexpected jittered max age %v", maxAge)
+		maxAges[maxAge] = true
+
+		_, err = mkc.AddWithTTL("key1", downloadedKeys, time.Minute)
+		assert.NoError(t, err)
+		maxAge = mkc.entries["key1"].maxAge
+		assert.True(t, maxAge > 30*time.Second && maxAge <= time.Minute, "unexpected jittered ttl %v", maxAge)
+	}
+	assert.True(t, len(maxAges) > 1, "max ages should be jittered")
+
+	persistent := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:       MaxKeyAgeNoCheck,
+		MaxCacheSize:    MaxCacheSizeNoCheck,
+		MaxKeyAgeJitter: 0.5,
+	}).(*memoryKeyCacher)
+	_, err := persistent.Add("key1", downloadedKeys)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), persistent.entries["key1"].maxAge)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	mkc := &memoryKeyCacher{
+		entries: map[string]keyCacherEntry{
+			"fresh":    {addedAt: time.Now()},
+			"retained": {addedAt: time.Now().Add(-90 * time.Second)},
+			"expired":  {addedAt: time.Now().Add(-time.Hour)},
+		},
+		maxKeyAge:    time.Minute,
+		maxCacheSize: MaxCacheSizeNoCheck,
+		maxStaleAge:  time.Minute,
+		clock:        SystemClock,
+	}
+	mkc.purgeExpired()
+	assert.Len(t, mkc.entries, 2)
+	assert.Contains(t, mkc.entries, "fresh")
+	assert.Contains(t, mkc.entries, "retained")
+}
+
+func TestMemoryKeyCacherJanitor(t *testing.T) {
+	keyCacher := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:       10 * time.Millisecond,
+		MaxCacheSize:    MaxCacheSizeNoCheck,
+		CleanupInterval: 5 * time.Millisecond,
+	})
+	mkc := keyCacher.(*memoryKeyCacher)
+	_, err := mkc.Add("key1", []jose.JSONWebKey{{KeyID: "key1", Key: "test1"}})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mkc.mu.RLock()
+		defer mkc.mu.RUnlock()
+		return len(mkc.entries) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	stoppable, ok := keyCacher.(StoppableKeyCacher)
+	assert.True(t, ok)
+	stoppable.Stop()
+	stoppable.Stop()
+
+	// key cachers without cleanup can be stopped too
+	NewMemoryKeyCacher(time.Minute, 5).(StoppableKeyCacher).Stop()
+}
+
+func TestMemoryKeyCacherStats(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{
+		{Key: "test1", KeyID: "key1"},
+		{Key: "test2", KeyID: "key2"},
+		{Key: "test3", KeyID: "key3"},
+	}
+	keyCacher := NewMemoryKeyCacher(time.Minute, 2)
+	mkc := keyCacher.(*memoryKeyCacher)
+
+	for _, keyID := range []string{"key1", "key2", "key3"} {
+		_, err := mkc.Add(keyID, downloadedKeys)
+		assert.NoError(t, err)
+	}
+	_, _ = mkc.Get("key2")
+	_, _ = mkc.Get("key3")
+	_, _ = mkc.Get("key1")
+
+	mkc.mu.Lock()
+	mkc.entries["key2"] = keyCacherEntry{addedAt: time.Now().Add(-time.Hour), JSONWebKey: downloadedKeys[1]}
+	mkc.mu.Unlock()
+	_, _ = mkc.Get("key2")
+
+	stats, ok := keyCacher.(StatsKeyCacher)
+	assert.True(t, ok)
+	assert.Equal(t, KeyCacherStats{
+		Hits:        2,
+		Misses:      2,
+		Evictions:   1,
+		Expirations: 1,
+		Size:        1,
+	}, stats.Stats())
+}
+
+func TestMemoryKeyCacherCallbacks(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{
+		{Key: "test1", KeyID: "key1"},
+		{Key: "test2", KeyID: "key2"},
+	}
+
+	var events []string
+	var keyCacher KeyCacher
+	keyCacher = NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: 1,
+		OnAdd:        func(keyID string) { events = append(events, "add "+keyID) },
+		OnEvict:      func(keyID string) { events = append(events, "evict "+keyID) },
+		OnExpire: func(keyID string) {
+			// callbacks may use the cache
+			_, err := keyCacher.Get(keyID)
+			assert.Equal(t, ErrNoKeyFound, err)
+			events = append(events, "expire "+keyID)
+		},
+	})
+	mkc := keyCacher.(*memoryKeyCacher)
+
+	_, err := mkc.Add("key1", downloadedKeys)
+	assert.NoError(t, err)
+	_, err = mkc.Add("key2", downloadedKeys)
+	assert.NoError(t, err)
+
+	mkc.mu.Lock()
+	mkc.entries["key2"] = keyCacherEntry{addedAt: time.Now().Add(-time.Hour), JSONWebKey: downloadedKeys[1]}
+	mkc.mu.Unlock()
+	_, err = mkc.Get("key2")
+	assert.Equal(t, ErrKeyExpired, err)
+
+	assert.Equal(t, []string{"add key1", "add key2", "evict key1", "expire key2"}, events)
+}
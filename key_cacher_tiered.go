@@ -0,0 +1,134 @@
+package auth0
+
+import (
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// TieredKeyCacherOptions configures the tiered key cacher.
+type TieredKeyCacherOptions struct {
+	// Clock tells the time the keys found in the remote cache expire at.
+	// Defaults to SystemClock.
+	Clock Clock
+}
+
+type tieredKeyCacher struct {
+	local  KeyCacher
+	remote KeyCacher
+	clock  Clock
+}
+
+// NewTieredKeyCacher creates a key cacher looking keys up in local, usually
+// an in-memory key cacher, and then in remote, usually a key cacher shared by
+// a fleet of instances such as the Redis one, before they are downloaded.
+// Keys found in remote are added to local until they expire, and downloaded
+// keys are added to both.
+//
+// Failures of remote are treated as cache misses so that an outage of the
+// shared cache does not fail the key lookups.
+func NewTieredKeyCacher(local, remote KeyCacher) KeyCacher {
+	return NewTieredKeyCacherWithOptions(local, remote, TieredKeyCacherOptions{})
+}
+
+// NewTieredKeyCacherWithOptions creates a tiered key cacher, see
+// NewTieredKeyCacher, from the provided options.
+func NewTieredKeyCacherWithOptions(local, remote KeyCacher, options TieredKeyCacherOptions) KeyCacher {
+	return &tieredKeyCacher{local: local, remote: remote, clock: clockOrSystem(options.Clock)}
+}
+
+// Get obtains a key from the local cache, or else from the remote one
+func (tkc *tieredKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	key, localErr := tkc.local.Get(keyID)
+	if localErr == nil {
+		return key, nil
+	}
+
+	key, expiresAt, err := tkc.getRemote(keyID)
+	if err == ErrKeyExpired {
+		return nil, err
+	}
+	if err != nil {
+		return nil, localErr
+	}
+	now := tkc.clock.Now()
+	if !expiresAt.IsZero() && !now.Before(expiresAt) {
+		return nil, ErrKeyExpired
+	}
+
+	var ttl time.Duration
+	if !expiresAt.IsZero() {
+		ttl = expiresAt.Sub(now)
+	}
+	if _, err := addWithTTL(tkc.local, keyID, []jose.JSONWebKey{*key}, ttl); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetStale obtains a key, even expired, from the local cache, or else from
+// the remote one, when they retain expired keys
+func (tkc *tieredKeyCacher) GetStale(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	if local, ok := tkc.local.(StaleKeyCacher); ok {
+		if key, expiresAt, err := local.GetStale(keyID); err == nil {
+			return key, expiresAt, nil
+		}
+	}
+	if remote, ok := tkc.remote.(StaleKeyCacher); ok {
+		return remote.GetStale(keyID)
+	}
+	return nil, time.Time{}, ErrNoKeyFound
+}
+
+// Add adds the downloaded keys into both caches
+func (tkc *tieredKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return tkc.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL adds the downloaded keys into both caches, expiring after ttl
+// in the caches implementing TTLKeyCacher when positive
+func (tkc *tieredKeyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	// the keys are served from the local cache whether the remote one
+	// stored them or not
+	_, _ = addWithTTL(tkc.remote, keyID, downloadedKeys, ttl)
+	return addWithTTL(tkc.local, keyID, downloadedKeys, ttl)
+}
+
+// Concurrent reports whether both caches are safe for concurrent use
+func (tkc *tieredKeyCacher) Concurrent() bool {
+	return isConcurrentKeyCacher(tkc.local) && isConcurrentKeyCacher(tkc.remote)
+}
+
+// Stop stops both caches when they are stoppable
+func (tkc *tieredKeyCacher) Stop() {
+	for _, keyCacher := range []KeyCacher{tkc.local, tkc.remote} {
+		if stoppable, ok := keyCacher.(StoppableKeyCacher); ok {
+			stoppable.Stop()
+		}
+	}
+}
+
+// getRemote obtains a key from the remote cache along with its expiry time,
+// when known
+func (tkc *tieredKeyCacher) getRemote(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	if remote, ok := tkc.remote.(StaleKeyCacher); ok {
+		return remote.GetStale(keyID)
+	}
+	key, err := tkc.remote.Get(keyID)
+	return key, time.Time{}, err
+}
+
+// isConcurrentKeyCacher reports whether keyCacher is safe for concurrent use
+func isConcurrentKeyCacher(keyCacher KeyCacher) bool {
+	concurrentKeyCacher, ok := keyCacher.(ConcurrentKeyCacher)
+	return ok && concurrentKeyCacher.Concurrent()
+}
+
+// addWithTTL adds the keys into keyCacher, expiring after ttl when positive
+// and supported by keyCacher
+func addWithTTL(keyCacher KeyCacher, keyID string, keys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	if ttlKeyCacher, ok := keyCacher.(TTLKeyCacher); ok && ttl > 0 {
+		return ttlKeyCacher.AddWithTTL(keyID, keys, ttl)
+	}
+	return keyCacher.Add(keyID, keys)
+}
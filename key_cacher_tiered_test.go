@@ -0,0 +1,145 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// failingKeyCacher fails every operation, as an unreachable remote cache.
+type failingKeyCacher struct {
+	err error
+}
+
+func (f failingKeyCacher) Get(string) (*jose.JSONWebKey, error) {
+	return nil, f.err
+}
+
+func (f failingKeyCacher) Add(string, []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return nil, f.err
+}
+
+func TestTieredKeyCacher(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genPublicRSASSAJWK("key1"), genPublicRSASSAJWK("key2")}
+	local := NewMemoryKeyCacher(time.Minute, MaxCacheSizeNoCheck)
+	remote := NewMemoryKeyCacher(time.Minute, MaxCacheSizeNoCheck)
+	keyCacher := NewTieredKeyCacher(local, remote)
+
+	_, err := keyCacher.Get("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	key, err := keyCacher.Add("key1", downloadedKeys)
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+	for _, tier := range []KeyCacher{local, remote} {
+		_, err := tier.Get("key2")
+		assert.NoError(t, err, "downloaded keys should be added to both tiers")
+	}
+
+	_, err = keyCacher.Add("key3", downloadedKeys)
+	assert.Equal(t, ErrNoKeyFound, err)
+}
+
+func TestTieredKeyCacherRemoteHit(t *testing.T) {
+	tests := []struct {
+		name        string
+		remote      MemoryKeyCacherOptions
+		addedAt     time.Time
+		expectedErr error
+	}{
+		{
+			name:    "pass - key found in remote",
+			remote:  MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: MaxCacheSizeNoCheck},
+			addedAt: time.Now(),
+		},
+		{
+			name:    "pass - key never expires in remote",
+			remote:  MemoryKeyCacherOptions{MaxKeyAge: MaxKeyAgeNoCheck, MaxCacheSize: MaxCacheSizeNoCheck},
+			addedAt: time.Now().Add(-time.Hour),
+		},
+		{
+			name:        "fail - key expired in remote",
+			remote:      MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: MaxCacheSizeNoCheck, MaxStaleAge: time.Hour},
+			addedAt:     time.Now().Add(-2 * time.Minute),
+			expectedErr: ErrKeyExpired,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			local := NewMemoryKeyCacher(time.Hour, MaxCacheSizeNoCheck)
+			remote := NewMemoryKeyCacherWithOptions(test.remote).(*memoryKeyCacher)
+			remote.entries["key1"] = keyCacherEntry{addedAt: test.addedAt, JSONWebKey: genPublicRSASSAJWK("key1")}
+			keyCacher := NewTieredKeyCacher(local, remote)
+
+			_, err := keyCacher.Get("key1")
+			assert.Equal(t, test.expectedErr, err)
+
+			_, err = local.Get("key1")
+			if test.expectedErr == nil {
+				assert.NoError(t, err, "keys found in remote should be added to local")
+			} else {
+				assert.Equal(t, ErrNoKeyFound, err)
+			}
+		})
+	}
+}
+
+func TestTieredKeyCacherRemoteTTL(t *testing.T) {
+	clock := clocktest.NewClock(time.Now())
+	local := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{MaxKeyAge: time.Hour, MaxCacheSize: MaxCacheSizeNoCheck, Clock: clock}).(*memoryKeyCacher)
+	remote := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{MaxKeyAge: time.Minute, MaxCacheSize: MaxCacheSizeNoCheck, Clock: clock}).(*memoryKeyCacher)
+	addedAt := clock.Now()
+	remote.entries["key1"] = keyCacherEntry{addedAt: addedAt, JSONWebKey: genPublicRSASSAJWK("key1")}
+	keyCacher := NewTieredKeyCacherWithOptions(local, remote, TieredKeyCacherOptions{Clock: clock})
+
+	clock.Advance(30 * time.Second)
+	_, err := keyCacher.Get("key1")
+	assert.NoError(t, err)
+
+	_, expiresAt, err := local.GetStale("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, addedAt.Add(time.Minute), expiresAt, "keys found in remote should expire from local along with remote")
+
+	clock.Advance(31 * time.Second)
+	_, err = keyCacher.Get("key1")
+	assert.Equal(t, ErrKeyExpired, err)
+}
+
+func TestTieredKeyCacherRemoteFailure(t *testing.T) {
+	remote := failingKeyCacher{err: errors.New("connection refused")}
+	keyCacher := NewTieredKeyCacher(NewMemoryKeyCacher(time.Minute, MaxCacheSizeNoCheck), remote)
+
+	_, err := keyCacher.Get("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	key, err := keyCacher.Add("key1", []jose.JSONWebKey{genPublicRSASSAJWK("key1")})
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+
+	_, err = keyCacher.Get("key1")
+	assert.NoError(t, err)
+}
+
+func TestTieredKeyCacherGetStale(t *testing.T) {
+	local := NewMemoryKeyCacher(time.Minute, MaxCacheSizeNoCheck)
+	remote := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: MaxCacheSizeNoCheck,
+		MaxStaleAge:  time.Hour,
+	}).(*memoryKeyCacher)
+	addedAt := time.Now().Add(-2 * time.Minute)
+	remote.entries["key1"] = keyCacherEntry{addedAt: addedAt, JSONWebKey: genPublicRSASSAJWK("key1")}
+
+	key, expiresAt, err := NewTieredKeyCacher(local, remote).(StaleKeyCacher).GetStale("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+	assert.Equal(t, addedAt.Add(time.Minute), expiresAt)
+
+	_, _, err = NewTieredKeyCacher(local, remote).(StaleKeyCacher).GetStale("key2")
+	assert.Equal(t, ErrNoKeyFound, err)
+}
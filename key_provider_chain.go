@@ -0,0 +1,47 @@
+package auth0
+
+import "net/http"
+
+// chainedKeys are the keys of chained providers, obtained from one provider
+// after the other while verifying a token
+type chainedKeys struct {
+	r         *http.Request
+	providers []SecretProvider
+}
+
+// ChainProviders provides the keys of providers tried in order until one of
+// them returns a key verifying the token, e.g. static keys and then a
+// JWKClient to trust both locally issued tokens and the ones of an identity
+// provider:
+//
+//	provider := ChainProviders(NewKeyProvider(localKey), NewJWKClient(opts, nil))
+//
+// A provider is only called when the keys of the previous ones fail, so the
+// JWKS is not downloaded for locally issued tokens. Providers failing or
+// returning a nil key are skipped. When no key verifies the token, the error
+// of the last provider is returned.
+func ChainProviders(providers ...SecretProvider) SecretProvider {
+	return SecretProviderFunc(func(r *http.Request) (interface{}, error) {
+		return chainedKeys{r: r, providers: providers}, nil
+	})
+}
+
+// verifiedClaims unmarshalls the claims of the token once its signature is
+// verified with the keys of a provider
+func (c chainedKeys) verifiedClaims(verify claimsVerifier, values ...interface{}) error {
+	err := ErrNoKeyFound
+	for _, provider := range c.providers {
+		key, providerErr := provider.GetSecret(c.r)
+		if providerErr != nil {
+			err = providerErr
+			continue
+		}
+		if key == nil {
+			continue
+		}
+		if err = verifiedClaims(verify, key, values...); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// countingProvider provides key, or fails with err, counting the calls
+type countingProvider struct {
+	key   interface{}
+	err   error
+	calls int
+}
+
+func (p *countingProvider) GetSecret(_ *http.Request) (interface{}, error) {
+	p.calls++
+	return p.key, p.err
+}
+
+func TestChainProviders(t *testing.T) {
+	localSecret, remoteSecret := []byte("local-secret"), []byte("remote-secret")
+	providerErr := errors.New("provider failed")
+
+	tests := []struct {
+		name          string
+		providers     []*countingProvider
+		signingSecret []byte
+		expectedCalls []int
+		expectedErr   error
+	}{
+		{
+			name:          "pass - first provider",
+			providers:     []*countingProvider{{key: localSecret}, {key: remoteSecret}},
+			signingSecret: localSecret,
+			expectedCalls: []int{1, 0},
+		},
+		{
+			name:          "pass - second provider",
+			providers:     []*countingProvider{{key: localSecret}, {key: remoteSecret}},
+			signingSecret: remoteSecret,
+			expectedCalls: []int{1, 1},
+		},
+		{
+			name:          "pass - failing provider skipped",
+			providers:     []*countingProvider{{err: providerErr}, {key: remoteSecret}},
+			signingSecret: remoteSecret,
+			expectedCalls: []int{1, 1},
+		},
+		{
+			name:          "pass - nil key skipped",
+			providers:     []*countingProvider{{}, {key: remoteSecret}},
+			signingSecret: remoteSecret,
+			expectedCalls: []int{1, 1},
+		},
+		{
+			name:          "pass - rotated keys",
+			providers:     []*countingProvider{{key: RotatedKeys{[]byte("new-secret"), localSecret}}, {key: remoteSecret}},
+			signingSecret: localSecret,
+			expectedCalls: []int{1, 0},
+		},
+		{
+			name:          "fail - last provider error",
+			providers:     []*countingProvider{{key: localSecret}, {err: providerErr}},
+			signingSecret: remoteSecret,
+			expectedCalls: []int{1, 1},
+			expectedErr:   providerErr,
+		},
+		{
+			name:          "fail - no provider",
+			signingSecret: remoteSecret,
+			expectedErr:   ErrNoKeyFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			providers := make([]SecretProvider, len(test.providers))
+			for i, provider := range test.providers {
+				providers[i] = provider
+			}
+
+			token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, test.signingSecret)
+			validator, req := genTestConfiguration(NewConfiguration(ChainProviders(providers...), defaultAudience, defaultIssuer, jose.HS256), token)
+			_, err := validator.ValidateRequest(req)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, test.expectedCalls, []int{test.providers[0].calls, test.providers[1].calls})
+			}
+		})
+	}
+}
+
+func TestChainProvidersKeyMismatch(t *testing.T) {
+	provider := ChainProviders(NewKeyProvider([]byte("local-secret")), NewKeyProvider([]byte("remote-secret")))
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("unknown-secret"))
+	validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), token)
+
+	_, err := validator.ValidateRequest(req)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
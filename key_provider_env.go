@@ -0,0 +1,52 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrEnvSecretNotSet is returned when the environment variable of the
+// primary secret is not set.
+var ErrEnvSecretNotSet = errors.New("secret environment variable is not set")
+
+// NewKeyProviderFromEnv provides the secret read from the primary environment
+// variable, along with the one read from the secondary environment variable
+// when set, so that tokens signed with the previous secret stay valid while
+// rotating secrets. The secondary variable name may be empty.
+//
+// Values starting with "-----BEGIN" are PEM encoded public keys or
+// certificates, see NewKeyProviderFromPEM, other values are HMAC secrets.
+// The variables are read once, when the provider is created.
+func NewKeyProviderFromEnv(primary, secondary string) (SecretProvider, error) {
+	value, ok := os.LookupEnv(primary)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("%w: %s", ErrEnvSecretNotSet, primary)
+	}
+	primaryKey, err := parseSecret(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret %s: %v", primary, err)
+	}
+
+	value = ""
+	if secondary != "" {
+		value = os.Getenv(secondary)
+	}
+	if value == "" {
+		return NewKeyProvider(primaryKey), nil
+	}
+	secondaryKey, err := parseSecret(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret %s: %v", secondary, err)
+	}
+	return NewKeyProvider(RotatedKeys{primaryKey, secondaryKey}), nil
+}
+
+// parseSecret returns the PEM public key or the HMAC secret of value
+func parseSecret(value string) (interface{}, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return ParsePEMPublicKey([]byte(value))
+	}
+	return []byte(value), nil
+}
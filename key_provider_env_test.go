@@ -0,0 +1,84 @@
+package auth0
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func setenv(t *testing.T, name, value string) {
+	t.Helper()
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Unsetenv(name) })
+}
+
+func TestNewKeyProviderFromEnv(t *testing.T) {
+	primarySecret, secondarySecret := []byte("primary-secret"), []byte("secondary-secret")
+	setenv(t, "TEST_JWT_SECRET", string(primarySecret))
+	setenv(t, "TEST_JWT_SECRET_PREVIOUS", string(secondarySecret))
+
+	tests := []struct {
+		name          string
+		secondary     string
+		signingSecret []byte
+		expectedErr   bool
+	}{
+		{"pass - primary secret", "TEST_JWT_SECRET_PREVIOUS", primarySecret, false},
+		{"pass - secondary secret", "TEST_JWT_SECRET_PREVIOUS", secondarySecret, false},
+		{"pass - no secondary secret", "", primarySecret, false},
+		{"fail - secondary secret not set", "TEST_JWT_SECRET_UNSET", secondarySecret, true},
+		{"fail - unknown secret", "TEST_JWT_SECRET_PREVIOUS", []byte("unknown-secret"), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := NewKeyProviderFromEnv("TEST_JWT_SECRET", test.secondary)
+			assert.NoError(t, err)
+
+			token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, test.signingSecret)
+			validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), token)
+			jwt, err := validator.ValidateRequest(req)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			claims := map[string]interface{}{}
+			assert.NoError(t, validator.Claims(req, jwt, &claims))
+			assert.Equal(t, defaultIssuer, claims["iss"])
+		})
+	}
+}
+
+func TestNewKeyProviderFromEnvPEM(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	setenv(t, "TEST_JWT_PUBLIC_KEY", string(encodePKIXPublicKey(t, &key.PublicKey)))
+
+	provider, err := NewKeyProviderFromEnv("TEST_JWT_PUBLIC_KEY", "")
+	assert.NoError(t, err)
+
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, key)
+	validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.RS256), token)
+	_, err = validator.ValidateRequest(req)
+	assert.NoError(t, err)
+}
+
+func TestNewKeyProviderFromEnvErrors(t *testing.T) {
+	_, err := NewKeyProviderFromEnv("TEST_JWT_SECRET_UNSET", "")
+	assert.True(t, errors.Is(err, ErrEnvSecretNotSet))
+
+	setenv(t, "TEST_JWT_INVALID_PEM", "-----BEGIN PUBLIC KEY-----\ninvalid\n-----END PUBLIC KEY-----\n")
+	setenv(t, "TEST_JWT_SECRET", "secret")
+	_, err = NewKeyProviderFromEnv("TEST_JWT_INVALID_PEM", "")
+	assert.Error(t, err)
+	_, err = NewKeyProviderFromEnv("TEST_JWT_SECRET", "TEST_JWT_INVALID_PEM")
+	assert.Error(t, err)
+}
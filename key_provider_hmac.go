@@ -0,0 +1,97 @@
+package auth0
+
+import (
+	"net/http"
+	"sync"
+)
+
+// HMACSecretProvider provides an ordered set of HMAC secrets, newest first,
+// tokens being verified with the first secret validating their signature.
+// Rotating secrets then only takes adding the new secret in front of the
+// previous ones, and retiring the previous ones once the tokens signed with
+// them expired.
+type HMACSecretProvider struct {
+	mu      sync.RWMutex
+	secrets RotatedKeys
+}
+
+// NewHMACSecretProvider creates a provider of the secrets, newest first.
+func NewHMACSecretProvider(secrets ...[]byte) *HMACSecretProvider {
+	p := &HMACSecretProvider{}
+	p.SetSecrets(secrets...)
+	return p
+}
+
+// GetSecret returns the secrets as RotatedKeys, or the only secret.
+func (p *HMACSecretProvider) GetSecret(_ *http.Request) (interface{}, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	switch len(p.secrets) {
+	case 0:
+		return nil, ErrNoKeyFound
+	case 1:
+		return p.secrets[0], nil
+	}
+	return p.secrets, nil
+}
+
+// SetSecrets replaces the secrets, newest first.
+func (p *HMACSecretProvider) SetSecrets(secrets ...[]byte) {
+	keys := make(RotatedKeys, len(secrets))
+	for i, secret := range secrets {
+		keys[i] = secret
+	}
+	p.mu.Lock()
+	p.secrets = keys
+	p.mu.Unlock()
+}
+
+// Rotate adds the new secret in front of the secrets, keeping at most keep
+// secrets including the new one, or every secret when keep is not positive.
+func (p *HMACSecretProvider) Rotate(secret []byte, keep int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// the served secrets are never modified in place, they may be in use
+	keys := append(RotatedKeys{secret}, p.secrets...)
+	if keep > 0 && len(keys) > keep {
+		keys = keys[:keep]
+	}
+	p.secrets = keys
+}
+
+// NewHMACKeyIDProvider provides the HMAC secret selected by the key ID of the
+// token read from the Authorization header, for issuers minting HMAC tokens
+// with key IDs. Tokens without key ID are verified with the only secret.
+func NewHMACKeyIDProvider(secrets map[string][]byte) SecretProvider {
+	return NewHMACKeyIDProviderWithExtractor(secrets, nil)
+}
+
+// NewHMACKeyIDProviderWithExtractor provides the HMAC secret selected by the
+// key ID of the token read by extractor, which defaults to reading the
+// Authorization header.
+func NewHMACKeyIDProviderWithExtractor(secrets map[string][]byte, extractor RequestTokenExtractor) SecretProvider {
+	if extractor == nil {
+		extractor = RawTokenExtractorFunc(FromHeaderRaw)
+	}
+	// copied so that the secrets cannot be changed behind the provider
+	keys := make(map[string][]byte, len(secrets))
+	for keyID, secret := range secrets {
+		keys[keyID] = secret
+	}
+	return SecretProviderFunc(func(r *http.Request) (interface{}, error) {
+		keyID, err := requestKeyID(extractor, r)
+		if err != nil {
+			return nil, err
+		}
+		if keyID == "" && len(keys) == 1 {
+			for _, secret := range keys {
+				return secret, nil
+			}
+		}
+		secret, ok := keys[keyID]
+		if !ok {
+			return nil, ErrNoKeyFound
+		}
+		return secret, nil
+	})
+}
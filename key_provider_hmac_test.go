@@ -0,0 +1,82 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestHMACSecretProvider(t *testing.T) {
+	provider := NewHMACSecretProvider([]byte("secret-2"), []byte("secret-1"))
+
+	validate := func(secret string) error {
+		token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte(secret))
+		validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), token)
+		_, err := validator.ValidateRequest(req)
+		return err
+	}
+
+	assert.NoError(t, validate("secret-2"))
+	assert.NoError(t, validate("secret-1"))
+	assert.Error(t, validate("secret-3"))
+
+	provider.Rotate([]byte("secret-3"), 2)
+	assert.NoError(t, validate("secret-3"))
+	assert.NoError(t, validate("secret-2"))
+	assert.Error(t, validate("secret-1"), "the oldest secret should be retired")
+
+	provider.Rotate([]byte("secret-4"), 0)
+	for _, secret := range []string{"secret-4", "secret-3", "secret-2"} {
+		assert.NoError(t, validate(secret))
+	}
+
+	provider.SetSecrets([]byte("secret-5"))
+	key, err := provider.GetSecret(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret-5"), key)
+	assert.Error(t, validate("secret-4"))
+}
+
+func TestHMACSecretProviderNoSecret(t *testing.T) {
+	_, err := NewHMACSecretProvider().GetSecret(nil)
+	assert.Equal(t, ErrNoKeyFound, err)
+}
+
+func TestHMACSecretProviderRotateServedSecrets(t *testing.T) {
+	provider := NewHMACSecretProvider([]byte("secret-2"), []byte("secret-1"))
+	served, err := provider.GetSecret(nil)
+	assert.NoError(t, err)
+
+	provider.Rotate([]byte("secret-3"), 2)
+	assert.Equal(t, RotatedKeys{[]byte("secret-2"), []byte("secret-1")}, served, "served secrets should not change")
+}
+
+func TestHMACKeyIDProvider(t *testing.T) {
+	secrets := map[string][]byte{"key1": []byte("secret-1"), "key2": []byte("secret-2")}
+	expiry := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		secrets     map[string][]byte
+		token       string
+		expectedErr error
+	}{
+		{"pass - first key", secrets, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("secret-1"), "key1"), nil},
+		{"pass - second key", secrets, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("secret-2"), "key2"), nil},
+		{"pass - no key id with single secret", map[string][]byte{"key1": []byte("secret-1")}, getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("secret-1")), nil},
+		{"fail - wrong key id", secrets, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("secret-1"), "key2"), ErrInvalidSignature},
+		{"fail - unknown key id", secrets, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("secret-1"), "key3"), ErrNoKeyFound},
+		{"fail - no key id", secrets, getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("secret-1")), ErrNoKeyFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator, req := genTestConfiguration(NewConfiguration(NewHMACKeyIDProvider(test.secrets), defaultAudience, defaultIssuer, jose.HS256), test.token)
+			_, err := validator.ValidateRequest(req)
+			assert.True(t, errors.Is(err, test.expectedErr), err)
+		})
+	}
+}
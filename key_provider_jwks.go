@@ -0,0 +1,51 @@
+package auth0
+
+import (
+	"encoding/json"
+	"net/http"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// NewKeyProviderFromJWKS provides the keys of a fixed JWKS document, e.g.
+// embedded with go:embed, selected by the key ID of the token read from the
+// Authorization header. It suits environments without network access to the
+// JWKS endpoint, and fails with ErrNoKeyFound when the JWKS has no keys.
+func NewKeyProviderFromJWKS(data []byte) (SecretProvider, error) {
+	return NewKeyProviderFromJWKSWithExtractor(data, nil)
+}
+
+// NewKeyProviderFromJWKSWithExtractor provides the keys of a fixed JWKS
+// document selected by the key ID of the token read by extractor, which
+// defaults to reading the Authorization header.
+func NewKeyProviderFromJWKSWithExtractor(data []byte, extractor RequestTokenExtractor) (SecretProvider, error) {
+	if extractor == nil {
+		extractor = RawTokenExtractorFunc(FromHeaderRaw)
+	}
+	var jwks JWKS
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+	if len(jwks.Keys) == 0 {
+		return nil, ErrNoKeyFound
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		keys[key.KeyID] = key
+	}
+	return SecretProviderFunc(func(r *http.Request) (interface{}, error) {
+		keyID, err := requestKeyID(extractor, r)
+		if err != nil {
+			return nil, err
+		}
+		if keyID == "" && len(jwks.Keys) == 1 {
+			return jwks.Keys[0], nil
+		}
+		key, ok := keys[keyID]
+		if !ok {
+			return nil, ErrNoKeyFound
+		}
+		return key, nil
+	}), nil
+}
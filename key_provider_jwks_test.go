@@ -0,0 +1,91 @@
+package auth0
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestNewKeyProviderFromJWKS(t *testing.T) {
+	keyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	keyES384 := genECDSAJWK(jose.ES384, "keyES384")
+	jwks, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{keyRS256.Public(), keyES384.Public()}})
+	assert.NoError(t, err)
+	provider, err := NewKeyProviderFromJWKS(jwks)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		algorithm   jose.SignatureAlgorithm
+		token       string
+		expectedErr error
+	}{
+		{
+			name:      "pass - RS256 key",
+			algorithm: jose.RS256,
+			token:     getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, keyRS256, "keyRS256"),
+		},
+		{
+			name:      "pass - ES384 key",
+			algorithm: jose.ES384,
+			token:     getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.ES384, keyES384, "keyES384"),
+		},
+		{
+			name:        "fail - unknown key",
+			algorithm:   jose.RS256,
+			token:       getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, keyRS256, "unknown"),
+			expectedErr: ErrNoKeyFound,
+		},
+		{
+			name:        "fail - no key ID with several keys",
+			algorithm:   jose.RS256,
+			token:       getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, keyRS256.Key),
+			expectedErr: ErrNoKeyFound,
+		},
+		{
+			name:        "fail - key of another key ID",
+			algorithm:   jose.RS256,
+			token:       getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, genRSASSAJWK(jose.RS256, ""), "keyRS256"),
+			expectedErr: ErrInvalidSignature,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, test.algorithm), test.token)
+			_, err := validator.ValidateRequest(req)
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestNewKeyProviderFromJWKSSingleKey(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{key.Public()}})
+	assert.NoError(t, err)
+
+	provider, err := NewKeyProviderFromJWKSWithExtractor(jwks, FromCookie("access_token"))
+	assert.NoError(t, err)
+
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, key.Key)
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+	validator := NewValidator(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.RS256), FromCookie("access_token"))
+	_, err = validator.ValidateRequest(req)
+	assert.NoError(t, err, "the only key should be used for tokens without key ID")
+}
+
+func TestNewKeyProviderFromJWKSErrors(t *testing.T) {
+	_, err := NewKeyProviderFromJWKS([]byte("{"))
+	assert.Error(t, err)
+
+	_, err = NewKeyProviderFromJWKS([]byte(`{"keys":[]}`))
+	assert.Equal(t, ErrNoKeyFound, err)
+}
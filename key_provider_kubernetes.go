@@ -0,0 +1,220 @@
+package auth0
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKubernetesSecretPollInterval is the default interval at which the
+// mounted secret is checked for changes.
+const DefaultKubernetesSecretPollInterval = 10 * time.Second
+
+// kubernetesDataDir is the symbolic link the kubelet swaps atomically to the
+// directory holding the new files when updating a mounted secret.
+const kubernetesDataDir = "..data"
+
+// KubernetesSecretOptions configures the Kubernetes secret provider.
+type KubernetesSecretOptions struct {
+	// PollInterval is the interval at which the mounted secret is checked
+	// for changes. Defaults to DefaultKubernetesSecretPollInterval.
+	PollInterval time.Duration
+	// Extractor reads the token whose key ID selects the key.
+	// Defaults to reading the Authorization header.
+	Extractor RequestTokenExtractor
+	// OnReload, when set, is called after the keys are reloaded following a
+	// change of the secret, with the error preventing the reload if any.
+	// The previous keys are kept when the reload fails.
+	OnReload func(err error)
+}
+
+// KubernetesSecretProvider is a secret provider serving the keys of a
+// Kubernetes secret mounted as a volume, or projected, each key of the secret
+// being a file named after it. Files holding a PEM encoded public key or
+// certificate are served as public keys, other files as HMAC secrets.
+//
+// The kubelet updates mounted secrets in place, so the provider picks up
+// rotated keys without restarting the pod. Secrets mounted with subPath are
+// never updated by the kubelet.
+type KubernetesSecretProvider struct {
+	dir     string
+	options KubernetesSecretOptions
+
+	mu      sync.RWMutex
+	keys    map[string]interface{}
+	names   []string
+	version string
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewKubernetesSecretProvider creates a provider serving the keys of the
+// secret mounted at dir and checking it for changes until Close is called.
+// It fails when the keys cannot be loaded.
+func NewKubernetesSecretProvider(dir string, options KubernetesSecretOptions) (*KubernetesSecretProvider, error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultKubernetesSecretPollInterval
+	}
+	if options.Extractor == nil {
+		options.Extractor = RawTokenExtractorFunc(FromHeaderRaw)
+	}
+	p := &KubernetesSecretProvider{
+		dir:     dir,
+		options: options,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	go p.poll()
+	return p, nil
+}
+
+// GetSecret returns the key named after the key ID of the request token.
+// Tokens without key ID are verified with the only key of the secret, or with
+// every key of the secret, returned as RotatedKeys ordered by name.
+func (p *KubernetesSecretProvider) GetSecret(r *http.Request) (interface{}, error) {
+	keyID, err := requestKeyID(p.options.Extractor, r)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if keyID == "" {
+		if len(p.names) == 1 {
+			return p.keys[p.names[0]], nil
+		}
+		keys := make(RotatedKeys, len(p.names))
+		for i, name := range p.names {
+			keys[i] = p.keys[name]
+		}
+		return keys, nil
+	}
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrNoKeyFound
+	}
+	return key, nil
+}
+
+// Reload loads the keys of the secret, replacing the served keys at once on
+// success and keeping them on failure.
+func (p *KubernetesSecretProvider) Reload() error {
+	version, err := p.currentVersion()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]interface{}{}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// the kubelet keeps the files of the secret in dot directories
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(p.dir, name)
+		// the files are symbolic links to the data directory
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		key, err := parseSecret(string(data))
+		if err != nil {
+			return fmt.Errorf("invalid secret key %s: %v", name, err)
+		}
+		keys[name] = key
+		names = append(names, name)
+	}
+	if len(keys) == 0 {
+		return ErrNoKeyFound
+	}
+	sort.Strings(names)
+
+	p.mu.Lock()
+	p.keys, p.names, p.version = keys, names, version
+	p.mu.Unlock()
+	return nil
+}
+
+// Close stops checking the secret for changes, the provider keeping serving
+// the last loaded keys. It is safe to call Close more than once.
+func (p *KubernetesSecretProvider) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+	})
+	return nil
+}
+
+// poll reloads the keys whenever the version of the secret changes
+func (p *KubernetesSecretProvider) poll() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.options.PollInterval)
+	defer ticker.Stop()
+
+	p.mu.RLock()
+	// a version failing to load is reported once
+	seen := p.version
+	p.mu.RUnlock()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			version, err := p.currentVersion()
+			if err == nil && version == seen {
+				continue
+			}
+			if err == nil {
+				seen = version
+				err = p.Reload()
+			}
+			if p.options.OnReload != nil {
+				p.options.OnReload(err)
+			}
+		}
+	}
+}
+
+// currentVersion identifies the content of the secret: the target of the
+// data directory link of the kubelet, or else the size and modification time
+// of the files for directories not managed by the kubelet
+func (p *KubernetesSecretProvider) currentVersion() (string, error) {
+	if target, err := os.Readlink(filepath.Join(p.dir, kubernetesDataDir)); err == nil {
+		return target, nil
+	}
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return "", err
+	}
+	var version strings.Builder
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&version, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return version.String(), nil
+}
@@ -0,0 +1,156 @@
+package auth0
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// writeKubernetesSecret writes the files of a secret the way the kubelet
+// does: into a new data directory the ..data link is then swapped to, the
+// files being links through ..data
+func writeKubernetesSecret(t *testing.T, dir, version string, files map[string][]byte) {
+	t.Helper()
+	dataDir := filepath.Join(dir, ".."+version)
+	if err := os.Mkdir(dataDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dataDir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join(kubernetesDataDir, name), link); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(".."+version, tmpLink); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmpLink, filepath.Join(dir, kubernetesDataDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func validateWithProvider(provider SecretProvider, alg jose.SignatureAlgorithm, token string) error {
+	validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, alg), token)
+	_, err := validator.ValidateRequest(req)
+	return err
+}
+
+func TestKubernetesSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKubernetesSecret(t, dir, "v1", map[string][]byte{
+		"current":    []byte("current-secret"),
+		"previous":   []byte("previous-secret"),
+		"public.pem": encodePKIXPublicKey(t, &key.PublicKey),
+	})
+
+	provider, err := NewKubernetesSecretProvider(dir, KubernetesSecretOptions{})
+	assert.NoError(t, err)
+	defer provider.Close()
+
+	expiry := time.Now().Add(time.Hour)
+	tests := []struct {
+		name        string
+		alg         jose.SignatureAlgorithm
+		token       string
+		expectedErr bool
+	}{
+		{"pass - key id", jose.HS256, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("current-secret"), "current"), false},
+		{"pass - public key", jose.RS256, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.RS256, key, "public.pem"), false},
+		{"pass - no key id", jose.HS256, getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("previous-secret")), false},
+		{"fail - wrong key id", jose.HS256, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("current-secret"), "previous"), true},
+		{"fail - unknown key id", jose.HS256, getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("current-secret"), "unknown"), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateWithProvider(provider, test.alg, test.token)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKubernetesSecretProviderRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeKubernetesSecret(t, dir, "v1", map[string][]byte{"secret": []byte("old-secret")})
+
+	var mu sync.Mutex
+	var reloads []error
+	provider, err := NewKubernetesSecretProvider(dir, KubernetesSecretOptions{
+		PollInterval: 10 * time.Millisecond,
+		OnReload: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reloads = append(reloads, err)
+		},
+	})
+	assert.NoError(t, err)
+	defer provider.Close()
+
+	newToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("new-secret"))
+	assert.Error(t, validateWithProvider(provider, jose.HS256, newToken))
+
+	writeKubernetesSecret(t, dir, "v2", map[string][]byte{"secret": []byte("new-secret")})
+	assert.Eventually(t, func() bool {
+		return validateWithProvider(provider, jose.HS256, newToken) == nil
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []error{nil}, reloads)
+}
+
+func TestKubernetesSecretProviderPlainDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret")
+	assert.NoError(t, os.WriteFile(path, []byte("old-secret"), 0600))
+
+	provider, err := NewKubernetesSecretProvider(dir, KubernetesSecretOptions{PollInterval: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	defer provider.Close()
+
+	assert.NoError(t, os.WriteFile(path, []byte("new-secret-with-another-size"), 0600))
+	newToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("new-secret-with-another-size"))
+	assert.Eventually(t, func() bool {
+		return validateWithProvider(provider, jose.HS256, newToken) == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestKubernetesSecretProviderErrors(t *testing.T) {
+	_, err := NewKubernetesSecretProvider(filepath.Join(t.TempDir(), "missing"), KubernetesSecretOptions{})
+	assert.True(t, os.IsNotExist(err), err)
+
+	_, err = NewKubernetesSecretProvider(t.TempDir(), KubernetesSecretOptions{})
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	dir := t.TempDir()
+	writeKubernetesSecret(t, dir, "v1", map[string][]byte{"public.pem": []byte("-----BEGIN PUBLIC KEY-----\ninvalid\n-----END PUBLIC KEY-----\n")})
+	_, err = NewKubernetesSecretProvider(dir, KubernetesSecretOptions{})
+	assert.Error(t, err)
+}
+
+func TestKubernetesSecretProviderClose(t *testing.T) {
+	dir := t.TempDir()
+	writeKubernetesSecret(t, dir, "v1", map[string][]byte{"secret": []byte("secret")})
+	provider, err := NewKubernetesSecretProvider(dir, KubernetesSecretOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, provider.Close())
+	assert.NoError(t, provider.Close())
+}
@@ -0,0 +1,79 @@
+package auth0
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrNoPEMPublicKey is returned when PEM data holds no public key
+	// nor certificate.
+	ErrNoPEMPublicKey = errors.New("no PEM encoded public key nor certificate found")
+	// ErrUnsupportedPublicKey is returned for public keys other than
+	// RSA, ECDSA and Ed25519 ones.
+	ErrUnsupportedPublicKey = errors.New("unsupported public key type")
+)
+
+// NewKeyProviderFromPEMFile provides the public key read from a PEM file,
+// see NewKeyProviderFromPEM.
+func NewKeyProviderFromPEMFile(path string) (SecretProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyProviderFromPEM(data)
+}
+
+// NewKeyProviderFromPEM provides the first RSA, ECDSA or Ed25519 public key
+// of PEM data, encoded as a PKIX "PUBLIC KEY", a PKCS #1 "RSA PUBLIC KEY" or
+// the public key of a "CERTIFICATE". Other PEM blocks are skipped.
+func NewKeyProviderFromPEM(data []byte) (SecretProvider, error) {
+	key, err := ParsePEMPublicKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyProvider(key), nil
+}
+
+// ParsePEMPublicKey returns the first RSA, ECDSA or Ed25519 public key of
+// PEM data, as accepted by NewKeyProviderFromPEM.
+func ParsePEMPublicKey(data []byte) (interface{}, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, ErrNoPEMPublicKey
+		}
+
+		var key interface{}
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid PEM %s: %v", block.Type, err)
+		}
+
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+			return key, nil
+		}
+		return nil, ErrUnsupportedPublicKey
+	}
+}
@@ -0,0 +1,96 @@
+package auth0
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func encodePEM(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func encodePKIXPublicKey(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encodePEM("PUBLIC KEY", der)
+}
+
+func encodeCertificate(t *testing.T, publicKey interface{}, privateKey interface{}) []byte {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "issuer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, publicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encodePEM("CERTIFICATE", der)
+}
+
+func TestNewKeyProviderFromPEMFile(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublicKey, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name       string
+		pem        []byte
+		algorithm  jose.SignatureAlgorithm
+		signingKey interface{}
+	}{
+		{"pass - RSA public key", encodePKIXPublicKey(t, &rsaKey.PublicKey), jose.RS256, rsaKey},
+		{"pass - PKCS1 RSA public key", encodePEM("RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)), jose.RS256, rsaKey},
+		{"pass - EC public key", encodePKIXPublicKey(t, &ecKey.PublicKey), jose.ES256, ecKey},
+		{"pass - Ed25519 public key", encodePKIXPublicKey(t, edPublicKey), jose.EdDSA, edKey},
+		{"pass - certificate", encodeCertificate(t, &rsaKey.PublicKey, rsaKey), jose.RS256, rsaKey},
+		{"pass - key after other blocks", append(encodePEM("EC PARAMETERS", []byte{0x06}), encodePKIXPublicKey(t, &ecKey.PublicKey)...), jose.ES256, ecKey},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key.pem")
+			assert.NoError(t, os.WriteFile(path, test.pem, 0600))
+
+			provider, err := NewKeyProviderFromPEMFile(path)
+			assert.NoError(t, err)
+
+			token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), test.algorithm, test.signingKey)
+			validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, test.algorithm), token)
+			_, err = validator.ValidateRequest(req)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewKeyProviderFromPEMErrors(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	_, err := NewKeyProviderFromPEM([]byte("not a PEM file"))
+	assert.Equal(t, ErrNoPEMPublicKey, err)
+
+	_, err = NewKeyProviderFromPEM(encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)))
+	assert.Equal(t, ErrNoPEMPublicKey, err, "private keys should not be used")
+
+	_, err = NewKeyProviderFromPEM(encodePEM("PUBLIC KEY", []byte("invalid")))
+	assert.Error(t, err)
+
+	_, err = NewKeyProviderFromPEMFile(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.True(t, os.IsNotExist(err))
+}
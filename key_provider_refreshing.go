@@ -0,0 +1,112 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshRetryInterval is the default time after which the keys are
+// refreshed again once refreshing them failed.
+const DefaultRefreshRetryInterval = 30 * time.Second
+
+// KeyLoader loads the keys of a RefreshingKeyProvider from a remote store,
+// along with the time they are cached for.
+type KeyLoader func(ctx context.Context) (keys interface{}, ttl time.Duration, err error)
+
+// RefreshingKeyProviderOptions configures the refreshing key provider.
+type RefreshingKeyProviderOptions struct {
+	// RetryInterval is the time after which the keys are refreshed again once
+	// refreshing them failed, the previous keys being served meanwhile.
+	// Defaults to DefaultRefreshRetryInterval.
+	RetryInterval time.Duration
+	// OnRefreshError, when set, is called when the keys cannot be refreshed.
+	OnRefreshError func(err error)
+	// Clock tells the time the keys expire at. Defaults to SystemClock.
+	Clock Clock
+}
+
+// RefreshingKeyProvider is a secret provider caching the keys of a remote
+// store, such as a cloud secret manager, and loading them again once they
+// expire. It is the base of the secret providers of the Vault, AWS Secrets
+// Manager, Google Cloud Secret Manager and Azure Key Vault modules.
+//
+// A single request refreshes the expired keys, the others being served the
+// expired keys meanwhile rather than waiting for it. The expired keys are
+// served for RetryInterval when the refresh fails, so that an unreachable
+// store neither slows down nor fails the requests.
+type RefreshingKeyProvider struct {
+	load    KeyLoader
+	options RefreshingKeyProviderOptions
+
+	mu         sync.Mutex
+	keys       interface{}
+	expiresAt  time.Time
+	retryAt    time.Time
+	refreshing bool
+}
+
+// NewRefreshingKeyProvider creates a provider serving the keys loaded by
+// load, which are loaded on the first request, or by calling Refresh.
+func NewRefreshingKeyProvider(load KeyLoader, options RefreshingKeyProviderOptions) *RefreshingKeyProvider {
+	if options.RetryInterval <= 0 {
+		options.RetryInterval = DefaultRefreshRetryInterval
+	}
+	options.Clock = clockOrSystem(options.Clock)
+	return &RefreshingKeyProvider{load: load, options: options}
+}
+
+// GetSecret returns the cached keys, refreshing them first when expired
+// unless another request is refreshing them or the last refresh failed less
+// than RetryInterval ago. It returns ErrNoKeyFound when no keys were loaded.
+func (p *RefreshingKeyProvider) GetSecret(r *http.Request) (interface{}, error) {
+	now := p.options.Clock.Now()
+	p.mu.Lock()
+	if now.Before(p.expiresAt) || now.Before(p.retryAt) || p.refreshing {
+		keys := p.keys
+		p.mu.Unlock()
+		return keysOrNotFound(keys)
+	}
+	p.refreshing = true
+	p.mu.Unlock()
+
+	err := p.Refresh(r.Context())
+
+	p.mu.Lock()
+	p.refreshing = false
+	if err != nil {
+		p.retryAt = p.options.Clock.Now().Add(p.options.RetryInterval)
+	}
+	keys := p.keys
+	p.mu.Unlock()
+
+	if err != nil && p.options.OnRefreshError != nil {
+		p.options.OnRefreshError(err)
+	}
+	return keysOrNotFound(keys)
+}
+
+// Refresh loads the keys, replacing the cached keys on success and keeping
+// them on failure.
+func (p *RefreshingKeyProvider) Refresh(ctx context.Context) error {
+	keys, ttl, err := p.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.expiresAt = p.options.Clock.Now().Add(ttl)
+	p.retryAt = time.Time{}
+	return nil
+}
+
+// keysOrNotFound returns keys, or ErrNoKeyFound when they were never loaded
+func keysOrNotFound(keys interface{}) (interface{}, error) {
+	if keys == nil {
+		return nil, ErrNoKeyFound
+	}
+	return keys, nil
+}
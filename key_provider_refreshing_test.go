@@ -0,0 +1,107 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshingKeyProvider(t *testing.T) {
+	errUnreachable := errors.New("unreachable")
+	var loads int
+	var loadErr error
+	load := func(ctx context.Context) (interface{}, time.Duration, error) {
+		loads++
+		if loadErr != nil {
+			return nil, 0, loadErr
+		}
+		return []byte{byte(loads)}, time.Minute, nil
+	}
+	clock := clocktest.NewClock(time.Now())
+	var refreshErr error
+	provider := NewRefreshingKeyProvider(load, RefreshingKeyProviderOptions{
+		RetryInterval:  10 * time.Second,
+		OnRefreshError: func(err error) { refreshErr = err },
+		Clock:          clock,
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	key, err := provider.GetSecret(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, key, "the keys should be loaded on the first request")
+
+	clock.Advance(30 * time.Second)
+	key, _ = provider.GetSecret(req)
+	assert.Equal(t, []byte{1}, key)
+	assert.Equal(t, 1, loads, "fresh keys should be cached")
+
+	loadErr = errUnreachable
+	clock.Advance(time.Minute)
+	key, err = provider.GetSecret(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, key, "the expired keys should be served when they cannot be refreshed")
+	assert.Equal(t, errUnreachable, refreshErr)
+
+	clock.Advance(5 * time.Second)
+	_, _ = provider.GetSecret(req)
+	assert.Equal(t, 2, loads, "the refresh should not be retried before the retry interval")
+
+	loadErr = nil
+	clock.Advance(5 * time.Second)
+	key, _ = provider.GetSecret(req)
+	assert.Equal(t, []byte{3}, key, "the refresh should be retried after the retry interval")
+}
+
+func TestRefreshingKeyProviderSingleRefresh(t *testing.T) {
+	var loads int32
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context) (interface{}, time.Duration, error) {
+		if atomic.AddInt32(&loads, 1) > 1 {
+			close(started)
+			<-release
+		}
+		return "key", time.Minute, nil
+	}
+	clock := clocktest.NewClock(time.Now())
+	provider := NewRefreshingKeyProvider(load, RefreshingKeyProviderOptions{Clock: clock})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.NoError(t, provider.Refresh(context.Background()))
+
+	clock.Advance(2 * time.Minute)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = provider.GetSecret(req)
+	}()
+	<-started
+
+	// the other requests are served the expired keys during the refresh
+	for i := 0; i < 3; i++ {
+		key, err := provider.GetSecret(req)
+		assert.NoError(t, err)
+		assert.Equal(t, "key", key)
+	}
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loads))
+}
+
+func TestRefreshingKeyProviderNoKeys(t *testing.T) {
+	errUnreachable := errors.New("unreachable")
+	provider := NewRefreshingKeyProvider(func(ctx context.Context) (interface{}, time.Duration, error) {
+		return nil, 0, errUnreachable
+	}, RefreshingKeyProviderOptions{})
+
+	assert.Equal(t, errUnreachable, provider.Refresh(context.Background()))
+	_, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, ErrNoKeyFound, err)
+}
@@ -0,0 +1,20 @@
+module github.com/paulusrobin/go-auth0/keydir
+
+go 1.17
+
+require (
+	github.com/fsnotify/fsnotify v1.6.0
+	github.com/paulusrobin/go-auth0 v0.0.0-20261016110519-6a73be2dfea7
+	github.com/stretchr/testify v1.8.4
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/paulusrobin/go-auth0 => ../
@@ -0,0 +1,216 @@
+// Package keydir provides a secret provider serving the verification keys
+// stored in a directory, reloaded whenever its files change so that keys can
+// be rotated by configuration management without restarting.
+//
+// It lives in its own module so the core package does not depend on fsnotify.
+package keydir
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// Options configures the key directory provider.
+type Options struct {
+	// Extractor reads the token whose key ID selects the key.
+	// Defaults to reading the Authorization header.
+	Extractor auth0.RequestTokenExtractor
+	// OnReload, when set, is called after the keys are reloaded following a
+	// change of the directory, with the error preventing the reload if any.
+	// The previous keys are kept when the reload fails.
+	OnReload func(err error)
+}
+
+// Provider is a secret provider serving the keys of a directory:
+//
+//   - PEM files (.pem, .crt, .cer, .pub) holding a public key or a
+//     certificate, identified by their file name without extension;
+//   - JSON files (.json) holding a JSON Web Key or a JSON Web Key Set,
+//     identified by their key ID, or else by their file name.
+//
+// Other files, and files whose name starts with a dot, are ignored.
+type Provider struct {
+	dir     string
+	options Options
+	// keys holds the map[string]interface{} of the keys by ID,
+	// swapped at once on reload
+	keys atomic.Value
+
+	reloadMu  sync.Mutex
+	watcher   *fsnotify.Watcher
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// New creates a provider serving the keys of dir and watching it for
+// changes until Close is called. It fails when the keys cannot be loaded.
+func New(dir string, options Options) (*Provider, error) {
+	if options.Extractor == nil {
+		options.Extractor = auth0.RequestTokenExtractorFunc(auth0.FromHeader)
+	}
+	p := &Provider{dir: dir, options: options, done: make(chan struct{})}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	p.watcher = watcher
+	go p.watch()
+	return p, nil
+}
+
+// GetSecret returns the key identified by the key ID of the request token,
+// or the only key of the directory when the token has no key ID.
+func (p *Provider) GetSecret(r *http.Request) (interface{}, error) {
+	token, err := p.options.Extractor.Extract(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(token.Headers) < 1 {
+		return nil, auth0.ErrNoJWTHeaders
+	}
+
+	keys := p.keys.Load().(map[string]interface{})
+	keyID := token.Headers[0].KeyID
+	if keyID == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	key, ok := keys[keyID]
+	if !ok {
+		return nil, auth0.ErrNoKeyFound
+	}
+	return key, nil
+}
+
+// Reload loads the keys of the directory, replacing the served keys at once
+// on success and keeping them on failure.
+func (p *Provider) Reload() error {
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+	keys := map[string]interface{}{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || entry.IsDir() {
+			continue
+		}
+		if err := loadFile(filepath.Join(p.dir, name), keys); err != nil {
+			return fmt.Errorf("invalid key file %s: %v", name, err)
+		}
+	}
+	p.keys.Store(keys)
+	return nil
+}
+
+// Close stops watching the directory, the provider keeping serving the
+// last loaded keys. It is safe to call Close more than once.
+func (p *Provider) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		err = p.watcher.Close()
+		<-p.done
+	})
+	return err
+}
+
+// watch reloads the keys on every change of the directory until closed
+func (p *Provider) watch() {
+	defer close(p.done)
+	for {
+		select {
+		case event, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			err := p.Reload()
+			if p.options.OnReload != nil {
+				p.options.OnReload(err)
+			}
+		case err, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+			if p.options.OnReload != nil {
+				p.options.OnReload(err)
+			}
+		}
+	}
+}
+
+// loadFile adds the keys of the file at path into keys according to its
+// extension, ignoring unknown extensions
+func loadFile(path string, keys map[string]interface{}) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".pem", ".crt", ".cer", ".pub", ".json":
+	default:
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if ext != ".json" {
+		key, err := auth0.ParsePEMPublicKey(data)
+		if err != nil {
+			return err
+		}
+		keys[name] = key
+		return nil
+	}
+
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keySet); err != nil || len(keySet.Keys) == 0 {
+		var key jose.JSONWebKey
+		if err := json.Unmarshal(data, &key); err != nil {
+			return err
+		}
+		keySet.Keys = []jose.JSONWebKey{key}
+	}
+	for i := range keySet.Keys {
+		key := keySet.Keys[i]
+		keyID := key.KeyID
+		if keyID == "" {
+			keyID = name
+		}
+		keys[keyID] = verificationKey(&key)
+	}
+	return nil
+}
+
+// verificationKey returns the public key of asymmetric keys, and symmetric
+// keys as is
+func verificationKey(key *jose.JSONWebKey) interface{} {
+	if _, symmetric := key.Key.([]byte); symmetric || key.IsPublic() {
+		return key.Key
+	}
+	return key.Public().Key
+}
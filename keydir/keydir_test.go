@@ -0,0 +1,176 @@
+package keydir
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func genRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, path string, key *rsa.PrivateKey) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeJSON(t *testing.T, path string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newRequest(t *testing.T, key *rsa.PrivateKey, kid string) *http.Request {
+	options := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		options = options.WithHeader("kid", kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{Issuer: "issuer", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Set("Authorization", "Bearer "+raw)
+	return req
+}
+
+func validate(provider auth0.SecretProvider, req *http.Request) error {
+	validator := auth0.NewValidator(auth0.NewConfiguration(provider, nil, "issuer", jose.RS256), nil)
+	_, err := validator.ValidateRequest(req)
+	return err
+}
+
+func TestProvider(t *testing.T) {
+	dir := t.TempDir()
+	pemKey, jwkKey, jwksKey, privateJWKKey := genRSAKey(t), genRSAKey(t), genRSAKey(t), genRSAKey(t)
+	writePEM(t, filepath.Join(dir, "pem-key.pem"), pemKey)
+	writeJSON(t, filepath.Join(dir, "jwk.json"), jose.JSONWebKey{Key: &jwkKey.PublicKey, KeyID: "jwk-key", Algorithm: string(jose.RS256)})
+	writeJSON(t, filepath.Join(dir, "jwks.json"), jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &jwksKey.PublicKey, KeyID: "jwks-key"}}})
+	writeJSON(t, filepath.Join(dir, "private-key.json"), jose.JSONWebKey{Key: privateJWKKey})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden.pem"), []byte("ignored"), 0600))
+
+	provider, err := New(dir, Options{})
+	assert.NoError(t, err)
+	defer provider.Close()
+
+	tests := []struct {
+		name        string
+		key         *rsa.PrivateKey
+		kid         string
+		expectedErr error
+	}{
+		{"pass - PEM key", pemKey, "pem-key", nil},
+		{"pass - JWK", jwkKey, "jwk-key", nil},
+		{"pass - JWKS", jwksKey, "jwks-key", nil},
+		{"pass - private JWK", privateJWKKey, "private-key", nil},
+		{"fail - unknown key", pemKey, "unknown", auth0.ErrNoKeyFound},
+		{"fail - no key ID with several keys", pemKey, "", auth0.ErrNoKeyFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validate(provider, newRequest(t, test.key, test.kid))
+			assert.True(t, errors.Is(err, test.expectedErr), err)
+		})
+	}
+}
+
+func TestProviderSingleKey(t *testing.T) {
+	dir := t.TempDir()
+	key := genRSAKey(t)
+	writePEM(t, filepath.Join(dir, "key.pem"), key)
+
+	provider, err := New(dir, Options{})
+	assert.NoError(t, err)
+	defer provider.Close()
+	assert.NoError(t, validate(provider, newRequest(t, key, "")), "the only key should be used for tokens without key ID")
+}
+
+func TestProviderInvalidDirectory(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing"), Options{})
+	assert.True(t, os.IsNotExist(err))
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "key.pem"), []byte("invalid"), 0600))
+	_, err = New(dir, Options{})
+	assert.Error(t, err)
+}
+
+func TestProviderHotReload(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, newKey := genRSAKey(t), genRSAKey(t)
+	writePEM(t, filepath.Join(dir, "old.pem"), oldKey)
+
+	reloads := make(chan error, 16)
+	provider, err := New(dir, Options{OnReload: func(err error) { reloads <- err }})
+	assert.NoError(t, err)
+	defer provider.Close()
+
+	waitFor := func(condition func() bool) {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for !condition() {
+			select {
+			case <-reloads:
+			case <-deadline:
+				t.Fatal("keys not reloaded")
+			}
+		}
+	}
+
+	// a new key is served once added
+	writePEM(t, filepath.Join(dir, "new.pem"), newKey)
+	waitFor(func() bool { return validate(provider, newRequest(t, newKey, "new")) == nil })
+	assert.NoError(t, validate(provider, newRequest(t, oldKey, "old")))
+
+	// invalid files keep the previous keys
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("invalid"), 0600))
+	waitFor(func() bool {
+		select {
+		case err := <-reloads:
+			return err != nil
+		default:
+			return false
+		}
+	})
+	assert.NoError(t, validate(provider, newRequest(t, newKey, "new")))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "broken.pem")))
+
+	// removed keys are no longer served
+	assert.NoError(t, os.Remove(filepath.Join(dir, "old.pem")))
+	waitFor(func() bool { return errors.Is(validate(provider, newRequest(t, oldKey, "old")), auth0.ErrNoKeyFound) })
+
+	assert.NoError(t, provider.Close())
+	assert.NoError(t, provider.Close())
+}
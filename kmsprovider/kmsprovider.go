@@ -0,0 +1,115 @@
+// Package kmsprovider provides a secret provider serving the public keys of
+// asymmetric AWS KMS keys, verifying tokens signed with KMS without exporting
+// the private keys.
+//
+// It lives in its own module so the core package does not depend on the AWS
+// SDK.
+package kmsprovider
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	auth0 "github.com/paulusrobin/go-auth0"
+)
+
+// ErrNotSigningKey is returned when the KMS key is not a signing key.
+var ErrNotSigningKey = errors.New("kms key is not a signing key")
+
+// Client reads the public keys of KMS keys, implemented by *kms.Client.
+type Client interface {
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+}
+
+// Options configures the KMS public key provider.
+type Options struct {
+	// KeyIDs are the IDs, ARNs or aliases of the KMS keys whose public keys
+	// verify the tokens, selected by the key ID of the token. A token
+	// without key ID is verified with the public key of the only KMS key.
+	KeyIDs []string
+	// Extractor reads the token whose key ID selects the key.
+	// Defaults to reading the Authorization header.
+	Extractor auth0.RequestTokenExtractor
+}
+
+// Provider is a secret provider serving the public keys of KMS keys, read
+// once per key as they never change for a KMS key.
+type Provider struct {
+	client  Client
+	options Options
+	keyIDs  map[string]bool
+
+	mu   sync.Mutex
+	keys map[string]interface{}
+}
+
+// New creates a provider reading public keys with client, whose AWS
+// configuration provides the credentials allowed to call kms:GetPublicKey.
+// The public keys are read on first use.
+func New(client Client, options Options) (*Provider, error) {
+	if len(options.KeyIDs) == 0 {
+		return nil, errors.New("kmsprovider: KeyIDs must be set")
+	}
+	if options.Extractor == nil {
+		options.Extractor = auth0.RequestTokenExtractorFunc(auth0.FromHeader)
+	}
+
+	keyIDs := make(map[string]bool, len(options.KeyIDs))
+	for _, keyID := range options.KeyIDs {
+		keyIDs[keyID] = true
+	}
+	return &Provider{client: client, options: options, keyIDs: keyIDs, keys: map[string]interface{}{}}, nil
+}
+
+// GetSecret returns the public key of the KMS key identified by the key ID of
+// the request token, among the configured ones.
+func (p *Provider) GetSecret(r *http.Request) (interface{}, error) {
+	token, err := p.options.Extractor.Extract(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(token.Headers) < 1 {
+		return nil, auth0.ErrNoJWTHeaders
+	}
+
+	keyID := token.Headers[0].KeyID
+	if keyID == "" && len(p.options.KeyIDs) == 1 {
+		keyID = p.options.KeyIDs[0]
+	}
+	// only the configured KMS keys are looked up, whatever the token says
+	if !p.keyIDs[keyID] {
+		return nil, auth0.ErrNoKeyFound
+	}
+	return p.PublicKey(r.Context(), keyID)
+}
+
+// PublicKey returns the public key of the KMS key, reading it on first use.
+// Read failures are not cached.
+func (p *Provider) PublicKey(ctx context.Context, keyID string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+	output, err := p.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, err
+	}
+	if output.KeyUsage != types.KeyUsageTypeSignVerify {
+		return nil, fmt.Errorf("%w: %s", ErrNotSigningKey, keyID)
+	}
+	key, err := x509.ParsePKIXPublicKey(output.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of kms key %s: %v", keyID, err)
+	}
+	p.keys[keyID] = key
+	return key, nil
+}
@@ -0,0 +1,130 @@
+package kmsprovider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// fakeClient serves the public keys of its KMS keys, counting the reads
+type fakeClient struct {
+	outputs map[string]*kms.GetPublicKeyOutput
+	reads   int
+}
+
+func (c *fakeClient) GetPublicKey(_ context.Context, params *kms.GetPublicKeyInput, _ ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	c.reads++
+	output, ok := c.outputs[aws.ToString(params.KeyId)]
+	if !ok {
+		return nil, &types.NotFoundException{Message: aws.String("not found")}
+	}
+	return output, nil
+}
+
+func genECKey(t *testing.T) (*ecdsa.PrivateKey, *kms.GetPublicKeyOutput) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, &kms.GetPublicKeyOutput{PublicKey: der, KeyUsage: types.KeyUsageTypeSignVerify}
+}
+
+func newRequest(t *testing.T, key *ecdsa.PrivateKey, kid string) *http.Request {
+	options := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		options = options.WithHeader("kid", kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{Issuer: "issuer", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Set("Authorization", "Bearer "+raw)
+	return req
+}
+
+func TestProvider(t *testing.T) {
+	key1, output1 := genECKey(t)
+	key2, output2 := genECKey(t)
+	_, encryptOutput := genECKey(t)
+	encryptOutput.KeyUsage = types.KeyUsageTypeEncryptDecrypt
+
+	client := &fakeClient{outputs: map[string]*kms.GetPublicKeyOutput{
+		"alias/key1": output1,
+		"alias/key2": output2,
+		"alias/key3": encryptOutput,
+		"alias/key4": output1,
+	}}
+	provider, err := New(client, Options{KeyIDs: []string{"alias/key1", "alias/key2", "alias/key3", "alias/missing"}})
+	assert.NoError(t, err)
+	validator := auth0.NewValidator(auth0.NewConfiguration(provider, nil, "issuer", jose.ES256), nil)
+
+	tests := []struct {
+		name        string
+		req         *http.Request
+		expectedErr error
+	}{
+		{name: "pass - first key", req: newRequest(t, key1, "alias/key1")},
+		{name: "pass - second key", req: newRequest(t, key2, "alias/key2")},
+		{name: "fail - wrong key", req: newRequest(t, key2, "alias/key1"), expectedErr: jose.ErrCryptoFailure},
+		{name: "fail - not a signing key", req: newRequest(t, key1, "alias/key3"), expectedErr: ErrNotSigningKey},
+		{name: "fail - key not configured", req: newRequest(t, key1, "alias/key4"), expectedErr: auth0.ErrNoKeyFound},
+		{name: "fail - no key ID", req: newRequest(t, key1, ""), expectedErr: auth0.ErrNoKeyFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := validator.ValidateRequest(test.req)
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, test.expectedErr), err)
+		})
+	}
+
+	_, err = provider.GetSecret(newRequest(t, key1, "alias/missing"))
+	var notFound *types.NotFoundException
+	assert.True(t, errors.As(err, &notFound), err)
+}
+
+func TestProviderSingleKey(t *testing.T) {
+	key, output := genECKey(t)
+	client := &fakeClient{outputs: map[string]*kms.GetPublicKeyOutput{"alias/key": output}}
+	provider, err := New(client, Options{KeyIDs: []string{"alias/key"}})
+	assert.NoError(t, err)
+	validator := auth0.NewValidator(auth0.NewConfiguration(provider, nil, "issuer", jose.ES256), nil)
+
+	for i := 0; i < 3; i++ {
+		_, err = validator.ValidateRequest(newRequest(t, key, ""))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, client.reads, "the public key should be read once")
+}
+
+func TestProviderOptions(t *testing.T) {
+	_, err := New(&fakeClient{}, Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package auth0
+
+// Logger logs the notable events of validators, JWKS clients and key
+// cachers as a message and alternating keys and values, e.g. "uri" and the
+// JWKS URI. Adapters for zap, logrus or other structured loggers implement
+// it in a few lines; SlogLogger adapts a log/slog logger. Raw tokens and keys
+// are never logged.
+type Logger interface {
+	// Debug logs expected events, e.g. rejected tokens.
+	Debug(msg string, keysAndValues ...interface{})
+	// Warn logs events hinting at a misconfiguration or an outage,
+	// e.g. failed JWKS downloads.
+	Warn(msg string, keysAndValues ...interface{})
+}
+
+// logKeyEvents logs the event for each key, if there is a logger
+func logKeyEvents(logger Logger, msg string, keyIDs []string) {
+	if logger == nil {
+		return
+	}
+	for _, keyID := range keyIDs {
+		logger.Debug(msg, "kid", keyID)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build go1.21
+// +build go1.21
+
+package auth0
+
+import "log/slog"
+
+// slogLogger adapts a log/slog logger to Logger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// SlogLogger adapts logger to Logger, slog.Default() when nil.
+func SlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return slogLogger{logger: logger}
+}
+
+// Debug implements Logger.
+func (l slogLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.Debug(msg, keysAndValues...)
+}
+
+// Warn implements Logger.
+func (l slogLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.logger.Warn(msg, keysAndValues...)
+}
@@ -0,0 +1,31 @@
+//go:build go1.21
+// +build go1.21
+
+package auth0
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})))
+
+	logger.Debug("auth0: key evicted", "kid", "key1")
+	logger.Warn("auth0: JWKS download failed", "uri", "https://tenant/jwks")
+	assert.Equal(t, "level=DEBUG msg=\"auth0: key evicted\" kid=key1\n"+
+		"level=WARN msg=\"auth0: JWKS download failed\" uri=https://tenant/jwks\n", buf.String())
+	assert.NotNil(t, SlogLogger(nil))
+}
@@ -0,0 +1,108 @@
+package auth0
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// recordingLogger records the logged messages as
+// "level msg key=value ..."
+type recordingLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *recordingLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log("debug", msg, keysAndValues)
+}
+
+func (l *recordingLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log("warn", msg, keysAndValues)
+}
+
+func (l *recordingLogger) log(level, msg string, keysAndValues []interface{}) {
+	line := []string{level, msg}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		line = append(line, fmt.Sprintf("%v=%v", keysAndValues[i], keysAndValues[i+1]))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, strings.Join(line, " "))
+}
+
+func (l *recordingLogger) lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.logs...)
+}
+
+func TestValidatorLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{Logger: logger})
+
+	for _, token := range []string{
+		getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+		getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret),
+	} {
+		_, req := genTestConfiguration(configuration, token)
+		validator.ValidateRequest(req)
+	}
+	assert.Equal(t, []string{
+		"debug auth0: token rejected reason=expired error=" + jwt.ErrExpired.Error() + " issuer=" + defaultIssuer + " audience=" + fmt.Sprint([]string(defaultAudience)) + " kid=",
+	}, logger.lines())
+}
+
+func TestJWKClientLogger(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := &recordingLogger{}
+	opts.FallbackURIs = []string{opts.URI}
+	opts.URI = "http://127.0.0.1:0/jwks.json"
+	opts.Logger = logger
+	client := NewJWKClient(opts, nil)
+
+	_, req := genTestConfiguration(NewConfiguration(client, defaultAudience, defaultIssuer, jose.RS256), tokenRS256)
+	_, err = client.GetSecret(req)
+	assert.NoError(t, err)
+	lines := logger.lines()
+	if assert.Len(t, lines, 1) {
+		assert.True(t, strings.HasPrefix(lines[0], "warn auth0: JWKS download failed uri="+opts.URI+" reason=other error="), lines[0])
+	}
+}
+
+func TestMemoryKeyCacherLogger(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{
+		{Key: "test1", KeyID: "key1"},
+		{Key: "test2", KeyID: "key2"},
+	}
+	logger := &recordingLogger{}
+	mkc := NewMemoryKeyCacherWithOptions(MemoryKeyCacherOptions{
+		MaxKeyAge:    time.Minute,
+		MaxCacheSize: 1,
+		Logger:       logger,
+	}).(*memoryKeyCacher)
+
+	_, err := mkc.Add("key1", downloadedKeys)
+	assert.NoError(t, err)
+	_, err = mkc.Add("key2", downloadedKeys)
+	assert.NoError(t, err)
+	mkc.mu.Lock()
+	mkc.entries["key2"] = keyCacherEntry{addedAt: time.Now().Add(-time.Hour), JSONWebKey: downloadedKeys[1]}
+	mkc.mu.Unlock()
+	mkc.purgeExpired()
+
+	assert.Equal(t, []string{
+		"debug auth0: key evicted kid=key1",
+		"debug auth0: key expired kid=key2",
+	}, logger.lines())
+}
@@ -0,0 +1,176 @@
+// Package memcachedcache provides a key cacher storing the downloaded keys
+// in memcached, so that a fleet of instances shares them, with the same
+// expiry semantics as the in-memory key cacher.
+//
+// It lives in its own module so the core package does not depend on memcached.
+package memcachedcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// DefaultKeyPrefix prefixes the memcached keys when Options.KeyPrefix is empty.
+const DefaultKeyPrefix = "auth0:jwk:"
+
+// maxRelativeExpiration is the longest expiration memcached reads as a
+// number of seconds, longer ones must be given as a Unix time.
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// Client is the subset of *memcache.Client used by the key cacher.
+type Client interface {
+	Get(key string) (*memcache.Item, error)
+	Set(item *memcache.Item) error
+}
+
+// Options configures the memcached key cacher.
+type Options struct {
+	// MaxKeyAge is the age after which a key is expired,
+	// auth0.MaxKeyAgeNoCheck to never expire keys.
+	MaxKeyAge time.Duration
+	// MaxStaleAge is how long a key is retained once expired, and can be
+	// served stale while fresh keys are downloaded in the background.
+	// Zero lets memcached drop keys as soon as they expire.
+	MaxStaleAge time.Duration
+	// KeyPrefix prefixes the memcached keys, e.g. to share a memcached
+	// cluster between several issuers. Defaults to DefaultKeyPrefix.
+	KeyPrefix string
+}
+
+type keyCacher struct {
+	client  Client
+	options Options
+	now     func() time.Time
+}
+
+// entry is the value stored in memcached for a key.
+type entry struct {
+	AddedAt time.Time       `json:"added_at"`
+	MaxAge  time.Duration   `json:"max_age"`
+	Key     jose.JSONWebKey `json:"key"`
+}
+
+// New creates a key cacher storing the keys in memcached through client,
+// usually a *memcache.Client. The key cacher implements auth0.TTLKeyCacher,
+// so that the keys expire after the max-age of the JWKS response when sent,
+// and auth0.StaleKeyCacher.
+//
+// The cache size is bounded by memcached, which evicts the least recently
+// used items. Keys are stored as JSON Web Keys: only cache public keys.
+func New(client Client, options Options) auth0.KeyCacher {
+	if options.KeyPrefix == "" {
+		options.KeyPrefix = DefaultKeyPrefix
+	}
+	return &keyCacher{client: client, options: options, now: time.Now}
+}
+
+// Get obtains a key from memcached, returning auth0.ErrKeyExpired
+// for keys past their max age.
+func (c *keyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	key, expiresAt, err := c.GetStale(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if !expiresAt.IsZero() && !c.now().Before(expiresAt) {
+		return nil, auth0.ErrKeyExpired
+	}
+	return key, nil
+}
+
+// GetStale obtains a key from memcached whether or not it is expired,
+// along with its expiry time.
+func (c *keyCacher) GetStale(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	item, err := c.client.Get(c.itemKey(keyID))
+	if err == memcache.ErrCacheMiss {
+		return nil, time.Time{}, auth0.ErrNoKeyFound
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var cached entry
+	if err := json.Unmarshal(item.Value, &cached); err != nil {
+		return nil, time.Time{}, err
+	}
+	var expiresAt time.Time
+	if cached.MaxAge >= 0 {
+		expiresAt = cached.AddedAt.Add(cached.MaxAge)
+	}
+	return &cached.Key, expiresAt, nil
+}
+
+// Concurrent reports that the key cacher is safe for concurrent use, as
+// long as the memcached client is.
+func (c *keyCacher) Concurrent() bool {
+	return true
+}
+
+// Add stores every downloaded key in memcached, expiring after the max key
+// age, and returns the key associated with keyID.
+func (c *keyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return c.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL stores every downloaded key in memcached, expiring after ttl
+// instead of the max key age when positive, and returns the key associated
+// with keyID.
+func (c *keyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	if ttl <= 0 {
+		ttl = c.options.MaxKeyAge
+	}
+
+	var addingKey jose.JSONWebKey
+	now := c.now()
+	for _, key := range downloadedKeys {
+		data, err := json.Marshal(entry{AddedAt: now, MaxAge: ttl, Key: key})
+		if err != nil {
+			return nil, err
+		}
+		item := &memcache.Item{
+			Key:        c.itemKey(key.KeyID),
+			Value:      data,
+			Expiration: c.expiration(now, ttl),
+		}
+		if err := c.client.Set(item); err != nil {
+			return nil, err
+		}
+		if key.KeyID == keyID {
+			addingKey = key
+		}
+	}
+
+	if addingKey.Key == nil {
+		return nil, auth0.ErrNoKeyFound
+	}
+	return &addingKey, nil
+}
+
+// itemKey hashes keyID, as memcached keys are limited to 250 bytes
+// without spaces nor control characters.
+func (c *keyCacher) itemKey(keyID string) string {
+	sum := sha256.Sum256([]byte(keyID))
+	return c.options.KeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// expiration returns the memcached expiration of an item added at now
+// with the provided max age, zero for items that never expire.
+func (c *keyCacher) expiration(now time.Time, maxAge time.Duration) int32 {
+	if maxAge < 0 {
+		return 0
+	}
+	retention := maxAge + c.options.MaxStaleAge
+	if retention < time.Second {
+		// zero would never expire the item
+		retention = time.Second
+	}
+	if retention > maxRelativeExpiration {
+		return int32(now.Add(retention).Unix())
+	}
+	return int32((retention + time.Second - 1) / time.Second)
+}
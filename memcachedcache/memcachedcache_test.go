@@ -0,0 +1,217 @@
+package memcachedcache
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// fakeClient is an in-memory memcached client honoring item expirations.
+type fakeClient struct {
+	mu    sync.Mutex
+	now   time.Time
+	items map[string]memcache.Item
+	err   error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{now: time.Now(), items: map[string]memcache.Item{}}
+}
+
+func (f *fakeClient) Get(key string) (*memcache.Item, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	item, ok := f.items[key]
+	if !ok || f.expired(item) {
+		return nil, memcache.ErrCacheMiss
+	}
+	return &item, nil
+}
+
+func (f *fakeClient) Set(item *memcache.Item) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	if len(item.Key) > 250 || strings.ContainsAny(item.Key, " \n") {
+		return memcache.ErrMalformedKey
+	}
+	stored := *item
+	if stored.Expiration > 0 && time.Duration(stored.Expiration)*time.Second <= maxRelativeExpiration {
+		stored.Expiration = int32(f.now.Unix()) + stored.Expiration
+	}
+	f.items[item.Key] = stored
+	return nil
+}
+
+func (f *fakeClient) expired(item memcache.Item) bool {
+	return item.Expiration > 0 && f.now.Unix() >= int64(item.Expiration)
+}
+
+func (f *fakeClient) advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+func (f *fakeClient) clock() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func genRSAPublicJWK(t *testing.T, kid string) jose.JSONWebKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jose.JSONWebKey{Key: &privateKey.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"}
+}
+
+func newTestKeyCacher(options Options) (*fakeClient, auth0.KeyCacher) {
+	client := newFakeClient()
+	cacher := New(client, options)
+	cacher.(*keyCacher).now = client.clock
+	return client, cacher
+}
+
+func TestKeyCacher(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genRSAPublicJWK(t, "key1"), genRSAPublicJWK(t, "key with spaces")}
+
+	tests := []struct {
+		name             string
+		addingKey        string
+		expectedAddError error
+	}{
+		{
+			name:      "pass - key found",
+			addingKey: "key1",
+		},
+		{
+			name:      "pass - key with spaces found",
+			addingKey: "key with spaces",
+		},
+		{
+			name:             "fail - key not found",
+			addingKey:        "key3",
+			expectedAddError: auth0.ErrNoKeyFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, keyCacher := newTestKeyCacher(Options{MaxKeyAge: time.Minute})
+
+			_, err := keyCacher.Get("key1")
+			assert.Equal(t, auth0.ErrNoKeyFound, err)
+
+			addedKey, err := keyCacher.Add(test.addingKey, downloadedKeys)
+			assert.Equal(t, test.expectedAddError, err)
+			if err == nil {
+				assert.Equal(t, test.addingKey, addedKey.KeyID)
+			}
+
+			for _, downloadedKey := range downloadedKeys {
+				key, err := keyCacher.Get(downloadedKey.KeyID)
+				assert.NoError(t, err)
+				assert.Equal(t, downloadedKey.KeyID, key.KeyID)
+				assert.Equal(t, downloadedKey.Key, key.Key)
+			}
+		})
+	}
+}
+
+func TestKeyCacherExpiry(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genRSAPublicJWK(t, "key1")}
+
+	tests := []struct {
+		name        string
+		options     Options
+		elapsed     time.Duration
+		expectedErr error
+	}{
+		{"pass - key not expired", Options{MaxKeyAge: time.Minute}, 30 * time.Second, nil},
+		{"pass - key never expires", Options{MaxKeyAge: auth0.MaxKeyAgeNoCheck}, 365 * 24 * time.Hour, nil},
+		{"fail - key dropped once expired", Options{MaxKeyAge: time.Minute}, time.Minute, auth0.ErrNoKeyFound},
+		{"fail - key retained once expired", Options{MaxKeyAge: time.Minute, MaxStaleAge: time.Minute}, time.Minute, auth0.ErrKeyExpired},
+		{"fail - key dropped after max stale age", Options{MaxKeyAge: time.Minute, MaxStaleAge: time.Minute}, 2 * time.Minute, auth0.ErrNoKeyFound},
+		{"fail - key expired after long max age", Options{MaxKeyAge: 40 * 24 * time.Hour, MaxStaleAge: time.Hour}, 40 * 24 * time.Hour, auth0.ErrKeyExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, keyCacher := newTestKeyCacher(test.options)
+			_, err := keyCacher.Add("key1", downloadedKeys)
+			assert.NoError(t, err)
+
+			client.advance(test.elapsed)
+			_, err = keyCacher.Get("key1")
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func TestKeyCacherGetStale(t *testing.T) {
+	client, keyCacher := newTestKeyCacher(Options{MaxKeyAge: time.Minute, MaxStaleAge: time.Minute})
+	addedAt := client.clock()
+	_, err := keyCacher.Add("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")})
+	assert.NoError(t, err)
+
+	client.advance(90 * time.Second)
+	staleKeyCacher := keyCacher.(auth0.StaleKeyCacher)
+	key, expiresAt, err := staleKeyCacher.GetStale("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+	assert.True(t, addedAt.Add(time.Minute).Equal(expiresAt))
+
+	_, _, err = staleKeyCacher.GetStale("key2")
+	assert.Equal(t, auth0.ErrNoKeyFound, err)
+}
+
+func TestKeyCacherAddWithTTL(t *testing.T) {
+	client, keyCacher := newTestKeyCacher(Options{MaxKeyAge: time.Hour})
+	_, err := keyCacher.(auth0.TTLKeyCacher).AddWithTTL("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")}, time.Minute)
+	assert.NoError(t, err)
+
+	client.advance(30 * time.Second)
+	_, err = keyCacher.Get("key1")
+	assert.NoError(t, err)
+
+	client.advance(30 * time.Second)
+	_, err = keyCacher.Get("key1")
+	assert.Equal(t, auth0.ErrNoKeyFound, err)
+}
+
+func TestKeyCacherErrors(t *testing.T) {
+	client, keyCacher := newTestKeyCacher(Options{MaxKeyAge: time.Minute})
+	client.err = errors.New("memcache: connection refused")
+
+	_, err := keyCacher.Add("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")})
+	assert.Equal(t, client.err, err)
+
+	_, err = keyCacher.Get("key1")
+	assert.Equal(t, client.err, err)
+}
+
+func TestKeyCacherKeyPrefix(t *testing.T) {
+	client, keyCacher := newTestKeyCacher(Options{MaxKeyAge: time.Minute, KeyPrefix: "tenant:"})
+	_, err := keyCacher.Add("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")})
+	assert.NoError(t, err)
+
+	for key := range client.items {
+		assert.True(t, strings.HasPrefix(key, "tenant:"), "unexpected memcached key %q", key)
+	}
+}
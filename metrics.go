@@ -0,0 +1,103 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ValidatorMetrics records the validations of a validator,
+// err being nil for valid tokens.
+type ValidatorMetrics interface {
+	ObserveValidation(err error, duration time.Duration)
+}
+
+// ValidatorMetricsFunc is a function implementing ValidatorMetrics.
+type ValidatorMetricsFunc func(err error, duration time.Duration)
+
+// ObserveValidation calls f.
+func (f ValidatorMetricsFunc) ObserveValidation(err error, duration time.Duration) {
+	f(err, duration)
+}
+
+// JWKSMetrics records the JWKS download attempts of a JWKClient,
+// err being nil for successful downloads.
+type JWKSMetrics interface {
+	ObserveJWKSFetch(uri string, err error, duration time.Duration)
+}
+
+// JWKSMetricsFunc is a function implementing JWKSMetrics.
+type JWKSMetricsFunc func(uri string, err error, duration time.Duration)
+
+// ObserveJWKSFetch calls f.
+func (f JWKSMetricsFunc) ObserveJWKSFetch(uri string, err error, duration time.Duration) {
+	f(uri, err, duration)
+}
+
+// errorReasons are the reasons of the known errors, checked in order
+var errorReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrTokenNotFound, "token_not_found"},
+	{ErrMalformedHeader, "malformed_token"},
+	{ErrMalformedToken, "malformed_token"},
+	{ErrNoJWTHeaders, "malformed_token"},
+	{ErrInvalidAlgorithm, "invalid_algorithm"},
+	{ErrInvalidSignature, "invalid_signature"},
+	{ErrTokenExpired, "expired"},
+	{ErrTokenNotValidYet, "not_valid_yet"},
+	{ErrInvalidAudience, "invalid_audience"},
+	{ErrInvalidIssuer, "invalid_issuer"},
+	{ErrUnknownIssuer, "invalid_issuer"},
+	{ErrInvalidSubject, "invalid_subject"},
+	{ErrInvalidTokenID, "invalid_token_id"},
+	{jose.ErrCryptoFailure, "invalid_signature"},
+	{jwt.ErrExpired, "expired"},
+	{jwt.ErrNotValidYet, "not_valid_yet"},
+	{jwt.ErrInvalidAudience, "invalid_audience"},
+	{jwt.ErrInvalidIssuer, "invalid_issuer"},
+	{jwt.ErrInvalidSubject, "invalid_subject"},
+	{jwt.ErrInvalidID, "invalid_token_id"},
+	{ErrInvalidClaims, "invalid_claims"},
+	{ErrMissingClaim, "missing_claim"},
+	{ErrPolicyDenied, "policy_denied"},
+	{ErrAuthorizationUnavailable, "authorization_unavailable"},
+	{ErrNoKeyFound, "key_not_found"},
+	{ErrKeyExpired, "key_not_found"},
+	{ErrCircuitOpen, "jwks_unavailable"},
+	{ErrRateLimited, "jwks_unavailable"},
+	{ErrBackingOff, "jwks_unavailable"},
+	{ErrInvalidContentType, "invalid_jwks"},
+	{ErrResponseTooLarge, "invalid_jwks"},
+	{ErrUnsupportedContentEncoding, "invalid_jwks"},
+	{ErrInvalidDiscoveryDocument, "invalid_jwks"},
+	{context.DeadlineExceeded, "timeout"},
+	{context.Canceled, "canceled"},
+}
+
+// ErrorReason classifies err into a short label suited to metrics, such as
+// "expired", "invalid_signature", "key_not_found" or "jwks_status", and
+// "other" for unknown errors. It returns an empty reason for a nil err.
+func ErrorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, known := range errorReasons {
+		if errors.Is(err, known.err) {
+			return known.reason
+		}
+	}
+	var statusErr *StatusCodeError
+	if errors.As(err, &statusErr) {
+		return "jwks_status"
+	}
+	var discoveryErr *DiscoveryError
+	if errors.As(err, &discoveryErr) {
+		return "jwks_unavailable"
+	}
+	return "other"
+}
@@ -0,0 +1,75 @@
+package auth0
+
+import (
+	"expvar"
+	"time"
+)
+
+// ExpvarOptions configures the expvar metrics.
+type ExpvarOptions struct {
+	// KeyCacher, when set, publishes the size of the key cacher if it
+	// implements StatsKeyCacher.
+	KeyCacher KeyCacher
+}
+
+// ExpvarMetrics counts validations and JWKS downloads in a map published
+// with expvar, served by the /debug/vars handler of the expvar package:
+//
+//   - validations, validation_failures and validation_failures_by_reason,
+//     by ErrorReason
+//   - jwks_fetches and jwks_fetch_failures
+//   - key_cache_size, when a StatsKeyCacher is configured
+//
+// It implements ValidatorMetrics and JWKSMetrics, and can be shared by
+// several validators and JWKS clients.
+type ExpvarMetrics struct {
+	vars               *expvar.Map
+	validations        *expvar.Int
+	validationFailures *expvar.Int
+	failureReasons     *expvar.Map
+	fetches            *expvar.Int
+	fetchFailures      *expvar.Int
+}
+
+// NewExpvarMetrics publishes the counters under name. Like expvar.Publish,
+// it panics when name is already published, so it is called once per name,
+// usually at startup.
+func NewExpvarMetrics(name string, options ExpvarOptions) *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		vars:               new(expvar.Map).Init(),
+		validations:        new(expvar.Int),
+		validationFailures: new(expvar.Int),
+		failureReasons:     new(expvar.Map).Init(),
+		fetches:            new(expvar.Int),
+		fetchFailures:      new(expvar.Int),
+	}
+	m.vars.Set("validations", m.validations)
+	m.vars.Set("validation_failures", m.validationFailures)
+	m.vars.Set("validation_failures_by_reason", m.failureReasons)
+	m.vars.Set("jwks_fetches", m.fetches)
+	m.vars.Set("jwks_fetch_failures", m.fetchFailures)
+	if statsCacher, ok := options.KeyCacher.(StatsKeyCacher); ok {
+		m.vars.Set("key_cache_size", expvar.Func(func() interface{} {
+			return statsCacher.Stats().Size
+		}))
+	}
+	expvar.Publish(name, m.vars)
+	return m
+}
+
+// ObserveValidation implements ValidatorMetrics.
+func (m *ExpvarMetrics) ObserveValidation(err error, _ time.Duration) {
+	m.validations.Add(1)
+	if err != nil {
+		m.validationFailures.Add(1)
+		m.failureReasons.Add(ErrorReason(err), 1)
+	}
+}
+
+// ObserveJWKSFetch implements JWKSMetrics.
+func (m *ExpvarMetrics) ObserveJWKSFetch(_ string, err error, _ time.Duration) {
+	m.fetches.Add(1)
+	if err != nil {
+		m.fetchFailures.Add(1)
+	}
+}
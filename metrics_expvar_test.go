@@ -0,0 +1,53 @@
+package auth0
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyCacher := NewMemoryKeyCacher(time.Hour, 10)
+	metrics := NewExpvarMetrics("auth0_test_expvar_metrics", ExpvarOptions{KeyCacher: keyCacher})
+	opts.Metrics = metrics
+	client := NewJWKClientWithCache(opts, nil, keyCacher)
+	configuration := NewConfiguration(client, defaultAudience, defaultIssuer, jose.RS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{Metrics: metrics})
+
+	_, req := genTestConfiguration(configuration, tokenRS256)
+	_, err = validator.ValidateRequest(req)
+	assert.NoError(t, err)
+	_, req = genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+	_, err = validator.ValidateRequest(req)
+	assert.Error(t, err)
+
+	var vars map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get("auth0_test_expvar_metrics").String()), &vars))
+	assert.Equal(t, map[string]interface{}{
+		"validations":                   float64(2),
+		"validation_failures":           float64(1),
+		"validation_failures_by_reason": map[string]interface{}{"invalid_algorithm": float64(1)},
+		"jwks_fetches":                  float64(1),
+		"jwks_fetch_failures":           float64(0),
+		"key_cache_size":                float64(1),
+	}, vars)
+}
+
+func TestExpvarMetricsWithoutKeyCacher(t *testing.T) {
+	metrics := NewExpvarMetrics("auth0_test_expvar_metrics_without_key_cacher", ExpvarOptions{})
+	metrics.ObserveJWKSFetch("uri", ErrCircuitOpen, time.Millisecond)
+
+	assert.Nil(t, metrics.vars.Get("key_cache_size"))
+	assert.Equal(t, "1", metrics.fetchFailures.String())
+	assert.Panics(t, func() {
+		NewExpvarMetrics("auth0_test_expvar_metrics_without_key_cacher", ExpvarOptions{})
+	})
+}
@@ -0,0 +1,80 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{nil, ""},
+		{ErrTokenNotFound, "token_not_found"},
+		{&ExtractionError{Errors: []error{ErrTokenNotFound, ErrTokenNotFound}}, "token_not_found"},
+		{ErrMalformedToken, "malformed_token"},
+		{ErrInvalidAlgorithm, "invalid_algorithm"},
+		{jose.ErrCryptoFailure, "invalid_signature"},
+		{jwt.ErrExpired, "expired"},
+		{ErrTokenExpired, "expired"},
+		{wrapJOSEError(jwt.ErrInvalidIssuer), "invalid_issuer"},
+		{ErrInvalidSubject, "invalid_subject"},
+		{jwt.ErrInvalidAudience, "invalid_audience"},
+		{fmt.Errorf("wrapped: %w", ErrNoKeyFound), "key_not_found"},
+		{ErrCircuitOpen, "jwks_unavailable"},
+		{&StatusCodeError{StatusCode: 503}, "jwks_status"},
+		{ErrResponseTooLarge, "invalid_jwks"},
+		{context.DeadlineExceeded, "timeout"},
+		{errors.New("unknown"), "other"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ErrorReason(test.err), fmt.Sprint(test.err))
+	}
+}
+
+func TestValidatorMetrics(t *testing.T) {
+	var observed []string
+	metrics := ValidatorMetricsFunc(func(err error, duration time.Duration) {
+		assert.True(t, duration > 0)
+		observed = append(observed, ErrorReason(err))
+	})
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{Metrics: metrics})
+
+	for _, token := range []string{
+		getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+		getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret),
+	} {
+		_, req := genTestConfiguration(configuration, token)
+		validator.ValidateRequest(req)
+	}
+	assert.Equal(t, []string{"", "expired"}, observed)
+}
+
+func TestJWKSMetrics(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var observed []string
+	opts.FallbackURIs = []string{opts.URI}
+	fallback := opts.URI
+	opts.URI = "http://127.0.0.1:0/jwks.json"
+	opts.Metrics = JWKSMetricsFunc(func(uri string, err error, duration time.Duration) {
+		observed = append(observed, uri+" "+ErrorReason(err))
+	})
+	client := NewJWKClient(opts, nil)
+
+	_, req := genTestConfiguration(NewConfiguration(client, defaultAudience, defaultIssuer, jose.RS256), tokenRS256)
+	_, err = client.GetSecret(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{opts.URI + " other", fallback + " "}, observed)
+}
@@ -0,0 +1,89 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+)
+
+// ErrorHandler responds to a request failing the validation with err, e.g.
+// to render a JSON error body or localize the error message.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// DefaultErrorHandler responds with WriteBearerError.
+func DefaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	WriteBearerError(w, err)
+}
+
+// Authorizer authorizes the requests with a valid token, e.g. with OPA,
+// failing with ErrPolicyDenied for the requests it denies.
+type Authorizer interface {
+	Authorize(r *http.Request, result *ValidationResult) error
+}
+
+// AuthorizerFunc simple wrapper to implement
+// Authorizer with functions.
+type AuthorizerFunc func(r *http.Request, result *ValidationResult) error
+
+// Authorize implements the Authorizer interface.
+func (f AuthorizerFunc) Authorize(r *http.Request, result *ValidationResult) error {
+	return f(r, result)
+}
+
+// MiddlewareOptions configures the middleware.
+type MiddlewareOptions struct {
+	// ErrorHandler responds to the requests failing the validation.
+	// Defaults to DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+	// Policy, when set, authorizes the requests with a valid token from its
+	// claims, the requests it denies being passed to the error handler with
+	// ErrPolicyDenied. It allows different policies per route with the
+	// same validator. See Policy.
+	Policy Policy
+	// Authorizer, when set, authorizes the requests with a valid token
+	// allowed by the policy, the requests it fails being passed to the error
+	// handler. See NewOPAAuthorizer.
+	Authorizer Authorizer
+}
+
+// resultContextKey is the context key of the validation result
+type resultContextKey struct{}
+
+// NewMiddleware creates an HTTP middleware validating the token of every
+// request with validator, passing the requests with a valid token to the
+// next handler, their validation result in their context, and the other
+// requests, or the ones denied by the policy or the authorizer of the
+// options, to the error handler.
+func NewMiddleware(validator Validator, options MiddlewareOptions) func(http.Handler) http.Handler {
+	if options.ErrorHandler == nil {
+		options.ErrorHandler = DefaultErrorHandler
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := validator.Validate(r)
+			if err != nil {
+				options.ErrorHandler(w, r, err)
+				return
+			}
+			if options.Policy != nil {
+				if err := EvaluatePolicy(options.Policy, result); err != nil {
+					options.ErrorHandler(w, r, err)
+					return
+				}
+			}
+			if options.Authorizer != nil {
+				if err := options.Authorizer.Authorize(r, result); err != nil {
+					options.ErrorHandler(w, r, err)
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), resultContextKey{}, result)))
+		})
+	}
+}
+
+// ResultFromContext returns the validation result of the request stored
+// in its context by the middleware.
+func ResultFromContext(ctx context.Context) (*ValidationResult, bool) {
+	result, ok := ctx.Value(resultContextKey{}).(*ValidationResult)
+	return result, ok
+}
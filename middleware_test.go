@@ -0,0 +1,83 @@
+package auth0
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestMiddleware(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := ResultFromContext(r.Context())
+		if assert.True(t, ok) {
+			w.Write([]byte(result.Claims.Issuer))
+		}
+	})
+
+	tests := []struct {
+		name           string
+		options        MiddlewareOptions
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "pass",
+			token:          getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+			expectedStatus: http.StatusOK,
+			expectedBody:   defaultIssuer,
+		},
+		{
+			name:           "fail - default error handler",
+			token:          getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret),
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "The access token expired\n",
+		},
+		{
+			name: "fail - custom error handler",
+			options: MiddlewareOptions{ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"error": ErrorReason(err)})
+			}},
+			token:          getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret),
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "{\"error\":\"expired\"}\n",
+		},
+		{
+			name:           "pass - policy",
+			options:        MiddlewareOptions{Policy: Claim("iss").Equals(defaultIssuer)},
+			token:          getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+			expectedStatus: http.StatusOK,
+			expectedBody:   defaultIssuer,
+		},
+		{
+			name:           "fail - policy denied",
+			options:        MiddlewareOptions{Policy: MustParsePolicy(`scope contains "read:users"`)},
+			token:          getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "The access token is not authorized for the request\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, req := genTestConfiguration(configuration, test.token)
+			w := httptest.NewRecorder()
+			NewMiddleware(validator, test.options)(next).ServeHTTP(w, req)
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, test.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestResultFromContextMissing(t *testing.T) {
+	_, ok := ResultFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	assert.False(t, ok)
+}
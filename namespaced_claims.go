@@ -0,0 +1,88 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrClaimNotFound is returned when reading a custom claim the token
+	// does not have.
+	ErrClaimNotFound = errors.New("claim not found")
+	// ErrClaimType is returned when reading a custom claim as a type it
+	// does not have, e.g. a number as a string.
+	ErrClaimType = errors.New("unexpected claim type")
+)
+
+// Namespace reads the namespaced custom claims of the tokens, e.g. the
+// "https://myapp.example.com/roles" claim added by an Auth0 Action, with the
+// namespace configured once:
+//
+//	ns := auth0.NewNamespace("https://myapp.example.com/")
+//	roles, err := ns.StringSlice(result, "roles")
+type Namespace struct {
+	namespace string
+}
+
+// NewNamespace creates a reader of the claims of the namespace, which
+// prefixes the claim names as is, e.g. "https://myapp.example.com/" with its
+// trailing slash.
+func NewNamespace(namespace string) Namespace {
+	return Namespace{namespace: namespace}
+}
+
+// Claim returns the name of the claim in the namespace.
+func (n Namespace) Claim(name string) string {
+	return n.namespace + name
+}
+
+// Has tells whether the token of result has the claim of the namespace,
+// even null.
+func (n Namespace) Has(result *ValidationResult, name string) bool {
+	_, ok := result.CustomClaims[n.Claim(name)]
+	return ok
+}
+
+// Unmarshal unmarshals the claim of the namespace into value, failing with
+// ErrClaimNotFound when the token of result does not have it, and with
+// ErrClaimType when it does not fit into value.
+func (n Namespace) Unmarshal(result *ValidationResult, name string, value interface{}) error {
+	claim := n.Claim(name)
+	raw, ok := result.CustomClaims[claim]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrClaimNotFound, claim)
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrClaimType, claim, err)
+	}
+	return nil
+}
+
+// String returns the string claim of the namespace.
+func (n Namespace) String(result *ValidationResult, name string) (string, error) {
+	var value string
+	err := n.Unmarshal(result, name, &value)
+	return value, err
+}
+
+// StringSlice returns the claim of the namespace holding an array of
+// strings, e.g. roles or permissions, or a single string.
+func (n Namespace) StringSlice(result *ValidationResult, name string) ([]string, error) {
+	var values []string
+	err := n.Unmarshal(result, name, &values)
+	if errors.Is(err, ErrClaimType) {
+		var value string
+		if n.Unmarshal(result, name, &value) == nil {
+			return []string{value}, nil
+		}
+	}
+	return values, err
+}
+
+// Bool returns the boolean claim of the namespace.
+func (n Namespace) Bool(result *ValidationResult, name string) (bool, error) {
+	var value bool
+	err := n.Unmarshal(result, name, &value)
+	return value, err
+}
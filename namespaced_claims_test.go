@@ -0,0 +1,63 @@
+package auth0
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	result := &ValidationResult{CustomClaims: map[string]json.RawMessage{
+		"https://myapp.example.com/org_id":      json.RawMessage(`"org_123"`),
+		"https://myapp.example.com/roles":       json.RawMessage(`["admin","editor"]`),
+		"https://myapp.example.com/role":        json.RawMessage(`"admin"`),
+		"https://myapp.example.com/verified":    json.RawMessage(`true`),
+		"https://myapp.example.com/login_count": json.RawMessage(`3`),
+		"https://other.example.com/org_id":      json.RawMessage(`"org_456"`),
+	}}
+	ns := NewNamespace("https://myapp.example.com/")
+
+	assert.Equal(t, "https://myapp.example.com/roles", ns.Claim("roles"))
+	assert.True(t, ns.Has(result, "org_id"))
+	assert.False(t, ns.Has(result, "missing"))
+
+	orgID, err := ns.String(result, "org_id")
+	assert.NoError(t, err)
+	assert.Equal(t, "org_123", orgID)
+
+	roles, err := ns.StringSlice(result, "roles")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "editor"}, roles)
+	roles, err = ns.StringSlice(result, "role")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, roles)
+
+	verified, err := ns.Bool(result, "verified")
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	var count int
+	assert.NoError(t, ns.Unmarshal(result, "login_count", &count))
+	assert.Equal(t, 3, count)
+}
+
+func TestNamespaceErrors(t *testing.T) {
+	result := &ValidationResult{CustomClaims: map[string]json.RawMessage{
+		"https://myapp.example.com/login_count": json.RawMessage(`3`),
+	}}
+	ns := NewNamespace("https://myapp.example.com/")
+
+	_, err := ns.String(result, "org_id")
+	assert.ErrorIs(t, err, ErrClaimNotFound)
+	assert.Contains(t, err.Error(), "https://myapp.example.com/org_id")
+
+	_, err = ns.String(result, "login_count")
+	assert.ErrorIs(t, err, ErrClaimType)
+	_, err = ns.StringSlice(result, "login_count")
+	assert.ErrorIs(t, err, ErrClaimType)
+	_, err = ns.Bool(result, "login_count")
+	assert.ErrorIs(t, err, ErrClaimType)
+	_, err = ns.StringSlice(&ValidationResult{}, "roles")
+	assert.ErrorIs(t, err, ErrClaimNotFound)
+}
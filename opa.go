@@ -0,0 +1,205 @@
+package auth0
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ErrAuthorizationUnavailable is returned when the decision of the
+// authorization service, e.g. OPA, cannot be obtained. The requests are
+// denied meanwhile.
+var ErrAuthorizationUnavailable = errors.New("authorization service unavailable")
+
+// OPAInput is the input document given to the OPA policies: the claims of
+// the valid token of the request, registered and custom ones, along with the
+// request metadata. The headers and query params carrying credentials are
+// left out so the token never reaches the policies, see NewOPAInput.
+type OPAInput struct {
+	Claims     map[string]interface{} `json:"claims"`
+	Method     string                 `json:"method"`
+	Path       string                 `json:"path"`
+	Query      url.Values             `json:"query"`
+	Host       string                 `json:"host"`
+	RemoteAddr string                 `json:"remote_addr"`
+	Headers    http.Header            `json:"headers"`
+}
+
+// OPAQuery evaluates the decision of an OPA policy for the input, true to
+// allow the request. It is implemented by OPAClient querying an OPA sidecar,
+// and by OPAQueryFunc wrapping an embedded rego query.
+type OPAQuery interface {
+	Eval(ctx context.Context, input *OPAInput) (bool, error)
+}
+
+// OPAQueryFunc simple wrapper to implement
+// OPAQuery with functions, e.g. evaluating a prepared rego query.
+type OPAQueryFunc func(ctx context.Context, input *OPAInput) (bool, error)
+
+// Eval implements the OPAQuery interface.
+func (f OPAQueryFunc) Eval(ctx context.Context, input *OPAInput) (bool, error) {
+	return f(ctx, input)
+}
+
+// NewOPAAuthorizer creates an authorizer enforcing the decision of the OPA
+// query, failing with ErrPolicyDenied when the policy denies the request and
+// with ErrAuthorizationUnavailable when the query fails. See
+// MiddlewareOptions.
+func NewOPAAuthorizer(query OPAQuery) Authorizer {
+	return AuthorizerFunc(func(r *http.Request, result *ValidationResult) error {
+		input, err := NewOPAInput(r, result)
+		if err != nil {
+			return err
+		}
+		allowed, err := query.Eval(r.Context(), input)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrAuthorizationUnavailable, err)
+		}
+		if !allowed {
+			return ErrPolicyDenied
+		}
+		return nil
+	})
+}
+
+// NewOPAInput returns the OPA input document of the request and of the
+// result of the validation of its token. The Authorization,
+// Proxy-Authorization and Cookie headers and the DefaultForwardedHeaders are
+// left out, along with the other header and query param values holding a
+// compact serialized token, whichever extractor read it, e.g. the token query
+// param of FromParams or the Sec-WebSocket-Protocol header of FromWebSocket.
+func NewOPAInput(r *http.Request, result *ValidationResult) (*OPAInput, error) {
+	claims, err := result.decodedClaims()
+	if err != nil {
+		return nil, err
+	}
+	headers := http.Header(withoutTokens(r.Header))
+	for _, name := range append([]string{"Authorization", "Proxy-Authorization", "Cookie"}, DefaultForwardedHeaders...) {
+		headers.Del(name)
+	}
+	return &OPAInput{
+		Claims:     claims,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Query:      url.Values(withoutTokens(r.URL.Query())),
+		Host:       r.Host,
+		RemoteAddr: r.RemoteAddr,
+		Headers:    headers,
+	}, nil
+}
+
+// withoutTokens returns a copy of the header or query values without the
+// values holding a token, and without the names left with no value
+func withoutTokens(values map[string][]string) map[string][]string {
+	kept := make(map[string][]string, len(values))
+	for name, list := range values {
+		for _, value := range list {
+			if !holdsToken(value) {
+				kept[name] = append(kept[name], value)
+			}
+		}
+	}
+	return kept
+}
+
+// holdsToken tells whether one of the space or comma separated fields of
+// value is a compact serialized token, e.g. "Bearer <token>" or
+// "access_token, <token>"
+func holdsToken(value string) bool {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, field := range fields {
+		if strings.Count(field, ".") != 2 {
+			continue
+		}
+		if _, err := jwt.ParseSigned(field); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// OPAClient queries a decision of an OPA server, e.g. a sidecar, with its
+// Data API. It is safe for concurrent use by multiple goroutines.
+type OPAClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewOPAClient creates a client of the decision at url, e.g.
+// "http://localhost:8181/v1/data/httpapi/authz/allow", queried with client,
+// defaulting to http.DefaultClient. The client should have a timeout, the
+// requests waiting for the decision.
+func NewOPAClient(url string, client *http.Client) *OPAClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OPAClient{url: url, client: client}
+}
+
+// opaResponse is the response of the Data API, whose result is undefined
+// when the policy does not decide
+type opaResponse struct {
+	Result *json.RawMessage `json:"result"`
+}
+
+// Eval implements the OPAQuery interface. The decision is either a boolean,
+// or an object whose allow field is a boolean. Undefined decisions deny the
+// request.
+func (c *OPAClient) Eval(ctx context.Context, input *OPAInput) (bool, error) {
+	body, err := json.Marshal(struct {
+		Input *OPAInput `json:"input"`
+	}{input})
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, &StatusCodeError{StatusCode: resp.StatusCode}
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		return false, ErrInvalidContentType
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxResponseBytes+1))
+	if err != nil {
+		return false, err
+	}
+	if int64(len(data)) > DefaultMaxResponseBytes {
+		return false, ErrResponseTooLarge
+	}
+	var decision opaResponse
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return false, err
+	}
+	if decision.Result == nil {
+		return false, nil
+	}
+	var allowed bool
+	if err := json.Unmarshal(*decision.Result, &allowed); err == nil {
+		return allowed, nil
+	}
+	var document struct {
+		Allow bool `json:"allow"`
+	}
+	if err := json.Unmarshal(*decision.Result, &document); err != nil {
+		return false, fmt.Errorf("unexpected OPA decision %s", *decision.Result)
+	}
+	return document.Allow, nil
+}
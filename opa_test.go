@@ -0,0 +1,159 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestNewOPAInput(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodDelete, "https://api.example.com/users/42?force=true", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("Authorization", "Bearer token")
+	r.Header.Set("Cookie", "session=secret")
+	r.Header.Set("X-Request-Id", "abc")
+	result := &ValidationResult{
+		Claims:       jwt.Claims{Subject: "user"},
+		CustomClaims: map[string]json.RawMessage{"scope": json.RawMessage(`"delete:users"`)},
+	}
+
+	input, err := NewOPAInput(r, result)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{"sub": "user", "scope": "delete:users"}, input.Claims)
+	assert.Equal(t, http.MethodDelete, input.Method)
+	assert.Equal(t, "/users/42", input.Path)
+	assert.Equal(t, "true", input.Query.Get("force"))
+	assert.Equal(t, "api.example.com", input.Host)
+	assert.Equal(t, "10.0.0.1:1234", input.RemoteAddr)
+	assert.Equal(t, http.Header{"X-Request-Id": {"abc"}}, input.Headers)
+	// the headers of the request are left untouched
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+}
+
+func TestNewOPAInputWithoutTokens(t *testing.T) {
+	token := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("01234567890123456789012345678901"), "key")
+	r, _ := http.NewRequest(http.MethodGet, "https://api.example.com/events?token="+token+"&access_token="+token+"&force=true", nil)
+	r.Header.Set("Proxy-Authorization", "Basic dXNlcjpwYXNz")
+	r.Header.Set("X-Forwarded-Authorization", "Bearer "+token)
+	r.Header.Set("X-Original-Authorization", "Bearer "+token)
+	r.Header.Set("Sec-WebSocket-Protocol", "access_token, "+token)
+	r.Header.Set("X-Api-Token", token)
+	r.Header.Add("X-Request-Id", "abc")
+	r.Header.Add("X-Request-Id", "v1.2.3")
+
+	input, err := NewOPAInput(r, &ValidationResult{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.Header{"X-Request-Id": {"abc", "v1.2.3"}}, input.Headers)
+	assert.Equal(t, url.Values{"force": {"true"}}, input.Query)
+	encoded, err := json.Marshal(input)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), token)
+}
+
+func TestOPAClient(t *testing.T) {
+	var inputs []OPAInput
+	decision := `{"result": true}`
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/data/httpapi/authz", r.URL.Path)
+		var body struct {
+			Input OPAInput `json:"input"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		inputs = append(inputs, body.Input)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(decision))
+	}))
+	defer server.Close()
+	client := NewOPAClient(server.URL+"/v1/data/httpapi/authz", nil)
+	input := &OPAInput{Claims: map[string]interface{}{"sub": "user"}, Method: http.MethodGet, Path: "/users"}
+
+	tests := []struct {
+		name            string
+		decision        string
+		status          int
+		expectedAllowed bool
+		expectedErr     bool
+	}{
+		{name: "allow - boolean", decision: `{"result": true}`, expectedAllowed: true},
+		{name: "deny - boolean", decision: `{"result": false}`},
+		{name: "allow - document", decision: `{"result": {"allow": true, "reason": "admin"}}`, expectedAllowed: true},
+		{name: "deny - document", decision: `{"result": {"reason": "not an admin"}}`},
+		{name: "deny - undefined", decision: `{}`},
+		{name: "fail - unexpected decision", decision: `{"result": "yes"}`, expectedErr: true},
+		{name: "fail - status", decision: `{"code": "internal_error"}`, status: http.StatusInternalServerError, expectedErr: true},
+		{name: "fail - malformed", decision: `{"result": `, expectedErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decision, status = test.decision, test.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			allowed, err := client.Eval(context.Background(), input)
+			assert.Equal(t, test.expectedAllowed, allowed)
+			assert.Equal(t, test.expectedErr, err != nil)
+		})
+	}
+	if assert.Len(t, inputs, len(tests)) {
+		assert.Equal(t, *input, inputs[0])
+	}
+}
+
+func TestOPAAuthorizerMiddleware(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil)
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	// an embedded policy allowing the tokens of the issuer to read
+	query := OPAQueryFunc(func(ctx context.Context, input *OPAInput) (bool, error) {
+		if input.Path == "/unavailable" {
+			return false, errors.New("connection refused")
+		}
+		return input.Claims["iss"] == defaultIssuer && input.Method == http.MethodGet, nil
+	})
+	middleware := NewMiddleware(validator, MiddlewareOptions{Authorizer: NewOPAAuthorizer(query)})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "allow", method: http.MethodGet, path: "/users", expectedStatus: http.StatusOK, expectedBody: "ok"},
+		{name: "deny", method: http.MethodPost, path: "/users", expectedStatus: http.StatusForbidden, expectedBody: "The access token is not authorized for the request\n"},
+		{name: "unavailable", method: http.MethodGet, path: "/unavailable", expectedStatus: http.StatusServiceUnavailable, expectedBody: "Service Unavailable\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(test.method, "http://localhost"+test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			middleware(next).ServeHTTP(w, req)
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, test.expectedBody, w.Body.String())
+		})
+	}
+
+	err := NewOPAAuthorizer(query).Authorize(httptest.NewRequest(http.MethodGet, "/unavailable", nil), &ValidationResult{})
+	assert.ErrorIs(t, err, ErrAuthorizationUnavailable)
+	assert.Equal(t, "authorization_unavailable", ErrorReason(err))
+}
@@ -0,0 +1,183 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultDiscoveryRetryInterval is how long a failed discovery of the
+	// tenant of an allowlisted issuer is remembered when no interval is
+	// configured.
+	DefaultDiscoveryRetryInterval = 30 * time.Second
+	// defaultDiscoveryTimeout bounds the discoveries when the JWKClient
+	// options have no FetchTimeout
+	defaultDiscoveryTimeout = 10 * time.Second
+)
+
+// ErrInvalidDiscoveryDocument is returned when the OpenID configuration of
+// an issuer has no JWKS URI, or is the configuration of another issuer.
+var ErrInvalidDiscoveryDocument = errors.New("invalid OpenID configuration")
+
+// DiscoveryError is the error of the OpenID discovery of an issuer along with
+// the issuer. It wraps the error of the failure, e.g. a *StatusCodeError or
+// ErrInvalidDiscoveryDocument, which errors.Is and errors.As still match.
+type DiscoveryError struct {
+	// Issuer is the issuer whose OpenID configuration was downloaded.
+	Issuer string
+	// Err is the error of the failure.
+	Err error
+}
+
+// Error returns the message of the error of the failure along with the
+// issuer.
+func (e *DiscoveryError) Error() string {
+	return fmt.Sprintf("%v (issuer=%s)", e.Err, e.Issuer)
+}
+
+// Unwrap returns the error of the failure.
+func (e *DiscoveryError) Unwrap() error {
+	return e.Err
+}
+
+// OpenIDConfiguration is the OpenID provider metadata of an issuer needed to
+// validate its tokens.
+type OpenIDConfiguration struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// openIDConfigurationURI returns the URI of the OpenID configuration of the
+// issuer
+func openIDConfigurationURI(issuer string) string {
+	return strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+}
+
+// DiscoverOpenIDConfiguration downloads the OpenID configuration of the
+// issuer from its .well-known/openid-configuration with client, defaulting
+// to http.DefaultClient. It fails with ErrInvalidDiscoveryDocument when the
+// configuration has no JWKS URI or is the configuration of another issuer,
+// as OpenID Connect Discovery requires.
+func DiscoverOpenIDConfiguration(ctx context.Context, client *http.Client, issuer string) (OpenIDConfiguration, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var config OpenIDConfiguration
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, openIDConfigurationURI(issuer), nil)
+	if err != nil {
+		return config, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return config, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return config, &StatusCodeError{StatusCode: resp.StatusCode}
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		return config, ErrInvalidContentType
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxResponseBytes+1))
+	if err != nil {
+		return config, err
+	}
+	if int64(len(body)) > DefaultMaxResponseBytes {
+		return config, ErrResponseTooLarge
+	}
+	if err := json.Unmarshal(body, &config); err != nil {
+		return config, err
+	}
+	if config.Issuer != issuer || config.JWKSURI == "" {
+		return config, ErrInvalidDiscoveryDocument
+	}
+	return config, nil
+}
+
+// discovery is the discovery of the tenant of an allowlisted issuer, shared
+// by the tokens of the issuer arriving meanwhile
+type discovery struct {
+	done chan struct{}
+	// err and failedAt are set once done is closed
+	err      error
+	failedAt time.Time
+}
+
+// retryable tells whether the discovery failed more than interval ago
+func (d *discovery) retryable(now time.Time, interval time.Duration) bool {
+	select {
+	case <-d.done:
+		return d.err != nil && now.Sub(d.failedAt) >= interval
+	default:
+		return false
+	}
+}
+
+// discoverTenant returns the tenant of the allowlisted issuer, discovering
+// it unless a discovery is in progress or failed less than the
+// DiscoveryRetryInterval ago. The discovery outlives ctx, which only bounds
+// the wait.
+func (m *MultiTenantValidator) discoverTenant(ctx context.Context, issuer string) (*tenant, error) {
+	m.discoveryMu.Lock()
+	d, ok := m.discoveries[issuer]
+	if !ok || d.retryable(m.clock.Now(), m.options.DiscoveryRetryInterval) {
+		d = &discovery{done: make(chan struct{})}
+		m.discoveries[issuer] = d
+		go m.discover(issuer, d)
+	}
+	m.discoveryMu.Unlock()
+
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if d.err != nil {
+		return nil, &ValidationError{Reason: Reason(ErrorReason(d.err)), Issuer: issuer, Err: d.err}
+	}
+	if t, ok := m.loadTenants()[issuer]; ok {
+		return t, nil
+	}
+	// the tenant was removed meanwhile
+	return nil, unknownIssuerError(issuer)
+}
+
+// discover downloads the OpenID configuration of the issuer and adds its
+// tenant, unless it was added meanwhile, or remembers the failure
+func (m *MultiTenantValidator) discover(issuer string, d *discovery) {
+	defer close(d.done)
+	timeout := m.options.JWKClient.FetchTimeout
+	if timeout <= 0 {
+		timeout = defaultDiscoveryTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	config, err := DiscoverOpenIDConfiguration(ctx, m.options.JWKClient.Client, issuer)
+	if err != nil {
+		d.err, d.failedAt = &DiscoveryError{Issuer: issuer, Err: err}, m.clock.Now()
+		if m.options.Validator.Logger != nil {
+			m.options.Validator.Logger.Warn("auth0: OpenID discovery failed", "issuer", issuer, "reason", ErrorReason(d.err), "error", err)
+		}
+		return
+	}
+
+	discovered := m.newTenant(TenantConfig{Issuer: issuer, JWKSURI: config.JWKSURI})
+	m.updateTenants(func(tenants map[string]*tenant) {
+		if _, ok := tenants[issuer]; !ok {
+			tenants[issuer] = discovered
+		}
+	})
+	// the tenant is discovered again once removed
+	m.discoveryMu.Lock()
+	delete(m.discoveries, issuer)
+	m.discoveryMu.Unlock()
+}
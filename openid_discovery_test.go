@@ -0,0 +1,141 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverOpenIDConfiguration(t *testing.T) {
+	tenant := newTestTenant("kid")
+	defer tenant.server.Close()
+
+	config, err := DiscoverOpenIDConfiguration(context.Background(), nil, tenant.issuer)
+	assert.NoError(t, err)
+	assert.Equal(t, OpenIDConfiguration{Issuer: tenant.issuer, JWKSURI: tenant.server.URL + "/.well-known/jwks.json?discovered"}, config)
+
+	// the configuration of another issuer
+	_, err = DiscoverOpenIDConfiguration(context.Background(), nil, tenant.server.URL)
+	assert.Equal(t, ErrInvalidDiscoveryDocument, err)
+
+	atomic.StoreInt32(&tenant.discoveryStatus, http.StatusNotFound)
+	_, err = DiscoverOpenIDConfiguration(context.Background(), nil, tenant.issuer)
+	assert.Equal(t, &StatusCodeError{StatusCode: http.StatusNotFound}, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer ts.Close()
+	_, err = DiscoverOpenIDConfiguration(context.Background(), nil, ts.URL+"/")
+	assert.Equal(t, ErrInvalidContentType, err)
+}
+
+func TestMultiTenantValidatorDiscovery(t *testing.T) {
+	tenant := newTestTenant("kid")
+	defer tenant.server.Close()
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{
+		Audience:       defaultAudience,
+		AllowedIssuers: []string{tenant.issuer},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tokens arriving at once share a single discovery
+	token := tenant.token(defaultAudience)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := validator.ValidateRequest(bearerRequest(token))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tenant.discoveries))
+	assert.Equal(t, []string{tenant.issuer}, validator.Issuers())
+	discovered, _ := validator.Tenant(tenant.issuer)
+	assert.Equal(t, tenant.server.URL+"/.well-known/jwks.json?discovered", discovered.config.secretProvider.(*JWKClient).options.URI)
+
+	// the discovered tenant is validated as the others
+	_, err = validator.ValidateRequest(bearerRequest(tenant.token([]string{"other"})))
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tenant.discoveries))
+
+	// a removed tenant is discovered again
+	validator.RemoveTenant(tenant.issuer)
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tenant.discoveries))
+}
+
+func TestMultiTenantValidatorDiscoveryFailure(t *testing.T) {
+	tenant := newTestTenant("kid")
+	defer tenant.server.Close()
+	clock := clocktest.NewClock(time.Now())
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{
+		Audience:       defaultAudience,
+		AllowedIssuers: []string{tenant.issuer},
+		JWKClient:      JWKClientOptions{Clock: clock},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&tenant.discoveryStatus, http.StatusInternalServerError)
+	token := tenant.token(defaultAudience)
+
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	var discoveryErr *DiscoveryError
+	if assert.True(t, errors.As(err, &discoveryErr)) {
+		assert.Equal(t, tenant.issuer, discoveryErr.Issuer)
+	}
+	assert.Equal(t, "jwks_status", ErrorReason(err))
+	assert.Equal(t, http.StatusServiceUnavailable, NewBearerError(err).StatusCode)
+
+	// the failure is remembered for the retry interval
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tenant.discoveries))
+
+	atomic.StoreInt32(&tenant.discoveryStatus, 0)
+	clock.Advance(DefaultDiscoveryRetryInterval)
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tenant.discoveries))
+
+	// issuers missing from the allowlist are never discovered
+	other := newTestTenant("kid")
+	defer other.server.Close()
+	_, err = validator.ValidateRequest(bearerRequest(other.token(defaultAudience)))
+	assert.ErrorIs(t, err, ErrUnknownIssuer)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&other.discoveries))
+}
+
+func TestMultiTenantValidatorDiscoveryWait(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	defer close(release)
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{AllowedIssuers: []string{ts.URL + "/"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := getTestToken(defaultAudience, ts.URL+"/", time.Now().Add(time.Hour), "HS256", defaultSecret)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = validator.ValidateRequest(bearerRequest(token).WithContext(ctx))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,108 @@
+// Package otelmetrics records the metrics of go-auth0 validators, JWKS
+// clients and key cachers with OpenTelemetry. It lives in its own module so
+// the core package does not depend on OpenTelemetry.
+package otelmetrics
+
+import (
+	"context"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// InstrumentationName is the name of the meter obtained from
+// the meter provider.
+const InstrumentationName = "github.com/paulusrobin/go-auth0"
+
+// Options configures the metrics.
+type Options struct {
+	// MeterProvider provides the meter of the instruments.
+	// Defaults to the global meter provider.
+	MeterProvider metric.MeterProvider
+}
+
+// Metrics records, with OpenTelemetry instruments:
+//
+//   - auth0.validations{auth0.outcome, auth0.reason}
+//   - auth0.validation.duration{auth0.outcome}, in seconds
+//   - auth0.jwks.fetches{auth0.jwks.uri, auth0.outcome, auth0.reason}
+//   - auth0.jwks.fetch.duration{auth0.jwks.uri, auth0.outcome}, in seconds
+//   - auth0.key_cache.operations{auth0.key_cache.operation, auth0.outcome}
+//
+// The failure rate is the rate of the validations of the "failure" outcome,
+// the key cache hit ratio the one of the "get" operations of the "hit"
+// outcome. It implements auth0.ValidatorMetrics, auth0.JWKSMetrics and
+// auth0.KeyCacherMetrics.
+type Metrics struct {
+	validations        metric.Int64Counter
+	validationDuration metric.Float64Histogram
+	fetches            metric.Int64Counter
+	fetchDuration      metric.Float64Histogram
+	keyCache           metric.Int64Counter
+}
+
+// New creates the instruments of the metrics.
+func New(options Options) (*Metrics, error) {
+	if options.MeterProvider == nil {
+		options.MeterProvider = otel.GetMeterProvider()
+	}
+	meter := options.MeterProvider.Meter(InstrumentationName)
+
+	var m Metrics
+	var err error
+	if m.validations, err = meter.Int64Counter("auth0.validations",
+		metric.WithDescription("Token validations by outcome and failure reason.")); err != nil {
+		return nil, err
+	}
+	if m.validationDuration, err = meter.Float64Histogram("auth0.validation.duration",
+		metric.WithDescription("Duration of token validations."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if m.fetches, err = meter.Int64Counter("auth0.jwks.fetches",
+		metric.WithDescription("JWKS download attempts by outcome and failure reason.")); err != nil {
+		return nil, err
+	}
+	if m.fetchDuration, err = meter.Float64Histogram("auth0.jwks.fetch.duration",
+		metric.WithDescription("Duration of JWKS download attempts."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if m.keyCache, err = meter.Int64Counter("auth0.key_cache.operations",
+		metric.WithDescription("Key cache operations by outcome.")); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ObserveValidation implements auth0.ValidatorMetrics.
+func (m *Metrics) ObserveValidation(err error, duration time.Duration) {
+	ctx := context.Background()
+	outcome := attribute.String(auth0.AttributeOutcome, outcomeOf(err))
+	m.validations.Add(ctx, 1, metric.WithAttributes(outcome, attribute.String(auth0.AttributeReason, auth0.ErrorReason(err))))
+	m.validationDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(outcome))
+}
+
+// ObserveJWKSFetch implements auth0.JWKSMetrics.
+func (m *Metrics) ObserveJWKSFetch(uri string, err error, duration time.Duration) {
+	ctx := context.Background()
+	attrs := []attribute.KeyValue{attribute.String(auth0.AttributeURI, uri), attribute.String(auth0.AttributeOutcome, outcomeOf(err))}
+	m.fetches.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String(auth0.AttributeReason, auth0.ErrorReason(err)))...))
+	m.fetchDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
+}
+
+// ObserveKeyCacheOperation implements auth0.KeyCacherMetrics.
+func (m *Metrics) ObserveKeyCacheOperation(operation auth0.KeyCacheOperation, outcome auth0.KeyCacheOutcome, _ time.Duration) {
+	m.keyCache.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("auth0.key_cache.operation", string(operation)),
+		attribute.String(auth0.AttributeOutcome, string(outcome)),
+	))
+}
+
+func outcomeOf(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
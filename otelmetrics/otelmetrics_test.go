@@ -0,0 +1,86 @@
+package otelmetrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// collect returns the data points of the counters and the counts
+// of the histograms, by instrument name and attributes
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]map[attribute.Distinct]int64 {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	points := map[string]map[attribute.Distinct]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		assert.Equal(t, InstrumentationName, sm.Scope.Name)
+		for _, m := range sm.Metrics {
+			points[m.Name] = map[attribute.Distinct]int64{}
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					points[m.Name][point.Attributes.Equivalent()] = point.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					points[m.Name][point.Attributes.Equivalent()] = int64(point.Count)
+				}
+			}
+		}
+	}
+	return points
+}
+
+func attrs(kvs ...string) attribute.Distinct {
+	var list []attribute.KeyValue
+	for i := 0; i < len(kvs); i += 2 {
+		list = append(list, attribute.String(kvs[i], kvs[i+1]))
+	}
+	set := attribute.NewSet(list...)
+	return set.Equivalent()
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	metrics, err := New(Options{MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	metrics.ObserveValidation(nil, time.Millisecond)
+	metrics.ObserveValidation(jwt.ErrExpired, time.Millisecond)
+	metrics.ObserveValidation(jwt.ErrExpired, time.Millisecond)
+	metrics.ObserveJWKSFetch("https://issuer/jwks.json", errors.New("unreachable"), time.Second)
+	metrics.ObserveKeyCacheOperation(auth0.KeyCacheGet, auth0.KeyCacheHit, time.Microsecond)
+	metrics.ObserveKeyCacheOperation(auth0.KeyCacheGet, auth0.KeyCacheMiss, time.Microsecond)
+
+	points := collect(t, reader)
+	assert.Equal(t, map[attribute.Distinct]int64{
+		attrs(auth0.AttributeOutcome, "success", auth0.AttributeReason, ""):        1,
+		attrs(auth0.AttributeOutcome, "failure", auth0.AttributeReason, "expired"): 2,
+	}, points["auth0.validations"])
+	assert.Equal(t, map[attribute.Distinct]int64{
+		attrs(auth0.AttributeOutcome, "success"): 1,
+		attrs(auth0.AttributeOutcome, "failure"): 2,
+	}, points["auth0.validation.duration"])
+	assert.Equal(t, map[attribute.Distinct]int64{
+		attrs(auth0.AttributeURI, "https://issuer/jwks.json", auth0.AttributeOutcome, "failure", auth0.AttributeReason, "other"): 1,
+	}, points["auth0.jwks.fetches"])
+	assert.Equal(t, map[attribute.Distinct]int64{
+		attrs(auth0.AttributeURI, "https://issuer/jwks.json", auth0.AttributeOutcome, "failure"): 1,
+	}, points["auth0.jwks.fetch.duration"])
+	assert.Equal(t, map[attribute.Distinct]int64{
+		attrs("auth0.key_cache.operation", "get", auth0.AttributeOutcome, "hit"):  1,
+		attrs("auth0.key_cache.operation", "get", auth0.AttributeOutcome, "miss"): 1,
+	}, points["auth0.key_cache.operations"])
+}
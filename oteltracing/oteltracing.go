@@ -0,0 +1,62 @@
+// Package oteltracing traces the validations and JWKS downloads of go-auth0
+// with OpenTelemetry. It lives in its own module so the core package does not
+// depend on OpenTelemetry.
+package oteltracing
+
+import (
+	"context"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer obtained from
+// the tracer provider.
+const InstrumentationName = "github.com/paulusrobin/go-auth0"
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+// New adapts an OpenTelemetry tracer to an auth0.Tracer, to be set in the
+// ValidatorOptions and the JWKClientOptions.
+func New(t trace.Tracer) auth0.Tracer {
+	return tracer{tracer: t}
+}
+
+// NewFromProvider creates an auth0.Tracer from the tracer provider,
+// the global one when provider is nil.
+func NewFromProvider(provider trace.TracerProvider) auth0.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return New(provider.Tracer(InstrumentationName))
+}
+
+// Start implements auth0.Tracer.
+func (t tracer) Start(ctx context.Context, name string) (context.Context, auth0.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal))
+	return ctx, span{span: s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+// SetAttribute implements auth0.Span.
+func (s span) SetAttribute(key, value string) {
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+// End implements auth0.Span, recording err and setting the error status
+// described by its reason when err is not nil.
+func (s span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, auth0.ErrorReason(err))
+	}
+	s.span.End()
+}
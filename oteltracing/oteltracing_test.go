@@ -0,0 +1,87 @@
+package oteltracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func signToken(t *testing.T, key []byte, expiry time.Time) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: key}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "kid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.Claims{Issuer: "issuer", Audience: []string{"audience"}, Expiry: jwt.NewNumericDate(expiry)}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider([]byte("secret")), []string{"audience"}, "issuer", jose.HS256)
+	validator := auth0.NewValidatorWithOptions(configuration, nil, auth0.ValidatorOptions{Tracer: NewFromProvider(provider)})
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus codes.Code
+		expectedAttrs  []attribute.KeyValue
+	}{
+		{
+			name:           "pass - valid token",
+			token:          signToken(t, []byte("secret"), time.Now().Add(time.Hour)),
+			expectedStatus: codes.Unset,
+			expectedAttrs: []attribute.KeyValue{
+				attribute.String(auth0.AttributeIssuer, "issuer"),
+				attribute.String(auth0.AttributeKeyID, "kid"),
+				attribute.String(auth0.AttributeAlgorithm, "HS256"),
+				attribute.String(auth0.AttributeOutcome, "success"),
+			},
+		},
+		{
+			name:           "fail - expired token",
+			token:          signToken(t, []byte("secret"), time.Now().Add(-time.Hour)),
+			expectedStatus: codes.Error,
+			expectedAttrs: []attribute.KeyValue{
+				attribute.String(auth0.AttributeIssuer, "issuer"),
+				attribute.String(auth0.AttributeKeyID, "kid"),
+				attribute.String(auth0.AttributeAlgorithm, "HS256"),
+				attribute.String(auth0.AttributeOutcome, "failure"),
+				attribute.String(auth0.AttributeReason, "expired"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exporter.Reset()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+test.token)
+			validator.ValidateRequest(r)
+
+			spans := exporter.GetSpans()
+			if !assert.Len(t, spans, 1) {
+				return
+			}
+			assert.Equal(t, auth0.SpanValidateRequest, spans[0].Name)
+			assert.Equal(t, test.expectedStatus, spans[0].Status.Code)
+			assert.Equal(t, test.expectedAttrs, spans[0].Attributes)
+			for _, attr := range spans[0].Attributes {
+				assert.NotContains(t, attr.Value.Emit(), test.token)
+			}
+		})
+	}
+}
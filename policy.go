@@ -0,0 +1,191 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrPolicyDenied is returned when the claims of a valid token do not
+// satisfy the policy of the validator or of the middleware.
+var ErrPolicyDenied = errors.New("access denied by policy")
+
+// Policy authorizes the valid tokens from their claims, e.g. requiring a
+// scope and an organization:
+//
+//	policy := auth0.And(
+//		auth0.Claim("scope").Contains("read:users"),
+//		auth0.Claim("org_id").Equals("org_123"),
+//	)
+//
+// or, with ParsePolicy:
+//
+//	policy, err := auth0.ParsePolicy(`scope contains "read:users" AND org_id == "org_123"`)
+//
+// The claims, registered and custom ones, are given to Evaluate by name as
+// unmarshalled by encoding/json into an interface{}: strings, float64
+// numbers, booleans, []interface{} arrays and map[string]interface{}
+// objects.
+type Policy interface {
+	Evaluate(claims map[string]interface{}) bool
+}
+
+// PolicyFunc simple wrapper to implement
+// Policy with functions.
+type PolicyFunc func(claims map[string]interface{}) bool
+
+// Evaluate implements the Policy interface.
+func (f PolicyFunc) Evaluate(claims map[string]interface{}) bool {
+	return f(claims)
+}
+
+// ClaimCondition builds the policies of a claim.
+type ClaimCondition struct {
+	name string
+}
+
+// Claim returns the builder of the policies of the claim, e.g. "scope" or
+// "https://myapp.example.com/roles".
+func Claim(name string) ClaimCondition {
+	return ClaimCondition{name: name}
+}
+
+// Exists allows the tokens with the claim, unless null.
+func (c ClaimCondition) Exists() Policy {
+	return PolicyFunc(func(claims map[string]interface{}) bool {
+		return claims[c.name] != nil
+	})
+}
+
+// Equals allows the tokens whose claim is value, a string, a number or a
+// boolean.
+func (c ClaimCondition) Equals(value interface{}) Policy {
+	value = policyValue(value)
+	return PolicyFunc(func(claims map[string]interface{}) bool {
+		return claimEquals(claims[c.name], value)
+	})
+}
+
+// NotEquals allows the tokens whose claim is not value, including the
+// tokens without the claim.
+func (c ClaimCondition) NotEquals(value interface{}) Policy {
+	return Not(c.Equals(value))
+}
+
+// Contains allows the tokens whose claim is an array holding value, e.g.
+// roles, or a space separated string holding value, e.g. the scope.
+func (c ClaimCondition) Contains(value interface{}) Policy {
+	value = policyValue(value)
+	return PolicyFunc(func(claims map[string]interface{}) bool {
+		switch claim := claims[c.name].(type) {
+		case []interface{}:
+			for _, element := range claim {
+				if claimEquals(element, value) {
+					return true
+				}
+			}
+		case string:
+			if value, ok := value.(string); ok {
+				for _, field := range strings.Fields(claim) {
+					if field == value {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	})
+}
+
+// And allows the tokens allowed by all the policies.
+func And(policies ...Policy) Policy {
+	return PolicyFunc(func(claims map[string]interface{}) bool {
+		for _, policy := range policies {
+			if !policy.Evaluate(claims) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or allows the tokens allowed by one of the policies.
+func Or(policies ...Policy) Policy {
+	return PolicyFunc(func(claims map[string]interface{}) bool {
+		for _, policy := range policies {
+			if policy.Evaluate(claims) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not allows the tokens denied by the policy.
+func Not(policy Policy) Policy {
+	return PolicyFunc(func(claims map[string]interface{}) bool {
+		return !policy.Evaluate(claims)
+	})
+}
+
+// policyValue converts the integers of the policies to the float64 numbers
+// of the claims
+func policyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
+}
+
+// claimEquals tells whether the claim is the string, float64 or bool value
+func claimEquals(claim, value interface{}) bool {
+	switch claim.(type) {
+	case string, float64, bool:
+		return claim == value
+	}
+	return false
+}
+
+// EvaluatePolicy evaluates the policy against the claims of the token of
+// result, failing with ErrPolicyDenied when the policy denies the token.
+func EvaluatePolicy(policy Policy, result *ValidationResult) error {
+	claims, err := result.decodedClaims()
+	if err != nil {
+		return err
+	}
+	if !policy.Evaluate(claims) {
+		return ErrPolicyDenied
+	}
+	return nil
+}
+
+// decodedClaims returns the registered and the custom claims of the result
+// unmarshalled into interface{} values, failing with ErrInvalidClaims
+func (r *ValidationResult) decodedClaims() (map[string]interface{}, error) {
+	raw, err := r.allClaims()
+	if err != nil {
+		return nil, err
+	}
+	claims := make(map[string]interface{}, len(raw))
+	for name, value := range raw {
+		var claim interface{}
+		if err := json.Unmarshal(value, &claim); err != nil {
+			return nil, ErrInvalidClaims
+		}
+		claims[name] = claim
+	}
+	return claims, nil
+}
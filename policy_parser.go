@@ -0,0 +1,264 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPolicy is returned when parsing a policy expression fails.
+var ErrInvalidPolicy = errors.New("invalid policy expression")
+
+// policyToken is a token of a policy expression
+type policyToken struct {
+	text   string
+	quoted bool
+	offset int
+}
+
+// ParsePolicy parses the policy expression, made of conditions on claims
+// combined with AND, OR, NOT and parentheses, AND binding tighter than OR:
+//
+//	scope contains "read:users" AND (org_id == "org_123" OR NOT org_id exists)
+//
+// The conditions are:
+//
+//	claim == value        see ClaimCondition.Equals
+//	claim != value        see ClaimCondition.NotEquals
+//	claim contains value  see ClaimCondition.Contains
+//	claim exists          see ClaimCondition.Exists
+//
+// Claim names are written as is, e.g. https://myapp.example.com/roles, and
+// values are double quoted strings, numbers, true or false. The keywords
+// are case insensitive. Invalid expressions fail with ErrInvalidPolicy.
+func ParsePolicy(expr string) (Policy, error) {
+	tokens, err := tokenizePolicy(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &policyParser{tokens: tokens, end: len(expr)}
+	policy, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := p.peek(); ok {
+		return nil, p.errorf(token, "unexpected %q", token.text)
+	}
+	return policy, nil
+}
+
+// MustParsePolicy is like ParsePolicy but panics when the expression is
+// invalid, to initialize policies declared as variables.
+func MustParsePolicy(expr string) Policy {
+	policy, err := ParsePolicy(expr)
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+// tokenizePolicy splits the expression into parentheses, operators, quoted
+// strings and words
+func tokenizePolicy(expr string) ([]policyToken, error) {
+	var tokens []policyToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, policyToken{text: expr[i : i+1], offset: i})
+			i++
+		case strings.HasPrefix(expr[i:], "==") || strings.HasPrefix(expr[i:], "!="):
+			tokens = append(tokens, policyToken{text: expr[i : i+2], offset: i})
+			i += 2
+		case c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("%w: unterminated string at offset %d", ErrInvalidPolicy, i)
+			}
+			text, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid string at offset %d", ErrInvalidPolicy, i)
+			}
+			tokens = append(tokens, policyToken{text: text, quoted: true, offset: i})
+			i = end + 1
+		default:
+			end := i
+			for end < len(expr) && !strings.ContainsRune(" \t\n\r()\"=!", rune(expr[end])) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("%w: unexpected %q at offset %d", ErrInvalidPolicy, c, i)
+			}
+			tokens = append(tokens, policyToken{text: expr[i:end], offset: i})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// policyParser is a recursive descent parser of policy expressions
+type policyParser struct {
+	tokens []policyToken
+	pos    int
+	// end is the offset of the end of the expression
+	end int
+}
+
+func (p *policyParser) peek() (policyToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return policyToken{offset: p.end}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *policyParser) next() (policyToken, bool) {
+	token, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return token, ok
+}
+
+// keyword tells whether the next token is the unquoted keyword, consuming it
+func (p *policyParser) keyword(keyword string) bool {
+	token, ok := p.peek()
+	if ok && !token.quoted && strings.EqualFold(token.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *policyParser) errorf(token policyToken, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidPolicy, fmt.Sprintf(format, args...), token.offset)
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *policyParser) parseOr() (Policy, error) {
+	policy, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	policies := []Policy{policy}
+	for p.keyword("OR") {
+		if policy, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	if len(policies) == 1 {
+		return policies[0], nil
+	}
+	return Or(policies...), nil
+}
+
+// parseAnd parses: unary ("AND" unary)*
+func (p *policyParser) parseAnd() (Policy, error) {
+	policy, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	policies := []Policy{policy}
+	for p.keyword("AND") {
+		if policy, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	if len(policies) == 1 {
+		return policies[0], nil
+	}
+	return And(policies...), nil
+}
+
+// parseUnary parses: "NOT" unary | "(" or ")" | condition
+func (p *policyParser) parseUnary() (Policy, error) {
+	if p.keyword("NOT") {
+		policy, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(policy), nil
+	}
+	token, ok := p.peek()
+	if ok && !token.quoted && token.text == "(" {
+		p.pos++
+		policy, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.next(); !ok || closing.quoted || closing.text != ")" {
+			return nil, p.errorf(closing, "missing closing parenthesis")
+		}
+		return policy, nil
+	}
+	return p.parseCondition()
+}
+
+// parseCondition parses: claim ("==" | "!=" | "contains") value | claim "exists"
+func (p *policyParser) parseCondition() (Policy, error) {
+	name, ok := p.next()
+	if !ok {
+		return nil, p.errorf(name, "missing claim name")
+	}
+	if name.quoted || name.text == "(" || name.text == ")" || name.text == "==" || name.text == "!=" {
+		return nil, p.errorf(name, "unexpected %q, expecting a claim name", name.text)
+	}
+	claim := Claim(name.text)
+
+	operator, ok := p.next()
+	if !ok || operator.quoted {
+		return nil, p.errorf(operator, "missing operator after claim %s", name.text)
+	}
+	if strings.EqualFold(operator.text, "exists") {
+		return claim.Exists(), nil
+	}
+	if operator.text != "==" && operator.text != "!=" && !strings.EqualFold(operator.text, "contains") {
+		return nil, p.errorf(operator, "unknown operator %q", operator.text)
+	}
+
+	token, ok := p.next()
+	if !ok {
+		return nil, p.errorf(token, "missing value after %s", operator.text)
+	}
+	value, err := policyLiteral(token)
+	if err != nil {
+		return nil, p.errorf(token, "%v", err)
+	}
+	switch operator.text {
+	case "==":
+		return claim.Equals(value), nil
+	case "!=":
+		return claim.NotEquals(value), nil
+	}
+	return claim.Contains(value), nil
+}
+
+// policyLiteral returns the string, float64 or bool value of the token
+func policyLiteral(token policyToken) (interface{}, error) {
+	if token.quoted {
+		return token.text, nil
+	}
+	switch strings.ToLower(token.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	// words are never empty, and Inf or NaN are not numbers of the claims
+	if first := token.text[0]; first == '-' || first >= '0' && first <= '9' {
+		if number, err := strconv.ParseFloat(token.text, 64); err == nil {
+			return number, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid value %q, strings must be double quoted", token.text)
+}
@@ -0,0 +1,71 @@
+package auth0
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{expr: `scope contains "read:users" AND org_id == "org_123"`, expected: true},
+		{expr: `scope contains "delete:users" AND org_id == "org_123"`},
+		{expr: `scope contains "delete:users" OR org_id == "org_123"`, expected: true},
+		{expr: `org_id != "org_123"`},
+		{expr: `org_id!="org_456"`, expected: true},
+		{expr: `https://myapp.example.com/roles contains "admin"`, expected: true},
+		{expr: `level == 3 and email_verified == TRUE`, expected: true},
+		{expr: `level == -3.5`},
+		{expr: `missing exists`},
+		{expr: `NOT missing exists`, expected: true},
+		{expr: `not not org_id exists`, expected: true},
+		// AND binds tighter than OR
+		{expr: `org_id == "org_456" AND level == 3 OR sub == "user"`, expected: true},
+		{expr: `org_id == "org_456" AND (level == 3 OR sub == "user")`},
+		{expr: `(org_id == "org_123")`, expected: true},
+		{expr: `nickname == "say \"hi\""`},
+		{expr: `sub == "user" OR sub == "other" OR sub == "third"`, expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			policy, err := ParsePolicy(test.expr)
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.expected, policy.Evaluate(testPolicyClaims))
+			}
+		})
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		expr            string
+		expectedMessage string
+	}{
+		{expr: ``, expectedMessage: "missing claim name at offset 0"},
+		{expr: `org_id`, expectedMessage: "missing operator after claim org_id at offset 6"},
+		{expr: `org_id ==`, expectedMessage: "missing value after == at offset 9"},
+		{expr: `org_id == org_123`, expectedMessage: `invalid value "org_123", strings must be double quoted at offset 10`},
+		{expr: `org_id is "org_123"`, expectedMessage: `unknown operator "is" at offset 7`},
+		{expr: `org_id == "org_123`, expectedMessage: "unterminated string at offset 10"},
+		{expr: `org_id = "org_123"`, expectedMessage: `unexpected '=' at offset 7`},
+		{expr: `(org_id exists`, expectedMessage: "missing closing parenthesis at offset 14"},
+		{expr: `org_id exists)`, expectedMessage: `unexpected ")" at offset 13`},
+		{expr: `org_id exists AND`, expectedMessage: "missing claim name at offset 17"},
+		{expr: `"org_id" exists`, expectedMessage: `unexpected "org_id", expecting a claim name at offset 0`},
+		{expr: `org_id exists sub exists`, expectedMessage: `unexpected "sub" at offset 14`},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := ParsePolicy(test.expr)
+			assert.ErrorIs(t, err, ErrInvalidPolicy)
+			if err != nil {
+				assert.Equal(t, "invalid policy expression: "+test.expectedMessage, err.Error())
+			}
+		})
+	}
+
+	assert.Panics(t, func() { MustParsePolicy(`org_id ==`) })
+}
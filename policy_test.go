@@ -0,0 +1,126 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// testPolicyClaims are claims as unmarshalled by encoding/json
+var testPolicyClaims = map[string]interface{}{
+	"sub":                             "user",
+	"scope":                           "read:users write:users",
+	"org_id":                          "org_123",
+	"https://myapp.example.com/roles": []interface{}{"admin", "editor"},
+	"level":                           float64(3),
+	"email_verified":                  true,
+	"nickname":                        nil,
+}
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected bool
+	}{
+		{name: "exists", policy: Claim("org_id").Exists(), expected: true},
+		{name: "exists - missing", policy: Claim("missing").Exists()},
+		{name: "exists - null", policy: Claim("nickname").Exists()},
+		{name: "equals - string", policy: Claim("org_id").Equals("org_123"), expected: true},
+		{name: "equals - other string", policy: Claim("org_id").Equals("org_456")},
+		{name: "equals - int", policy: Claim("level").Equals(3), expected: true},
+		{name: "equals - float", policy: Claim("level").Equals(3.0), expected: true},
+		{name: "equals - bool", policy: Claim("email_verified").Equals(true), expected: true},
+		{name: "equals - number as string", policy: Claim("level").Equals("3")},
+		{name: "equals - array", policy: Claim("https://myapp.example.com/roles").Equals("admin")},
+		{name: "equals - missing", policy: Claim("missing").Equals("")},
+		{name: "not equals", policy: Claim("org_id").NotEquals("org_456"), expected: true},
+		{name: "not equals - missing", policy: Claim("missing").NotEquals("org_456"), expected: true},
+		{name: "contains - scope", policy: Claim("scope").Contains("read:users"), expected: true},
+		{name: "contains - scope prefix", policy: Claim("scope").Contains("read")},
+		{name: "contains - array", policy: Claim("https://myapp.example.com/roles").Contains("editor"), expected: true},
+		{name: "contains - array without value", policy: Claim("https://myapp.example.com/roles").Contains("owner")},
+		{name: "contains - number", policy: Claim("level").Contains(3)},
+		{name: "and", policy: And(Claim("scope").Contains("read:users"), Claim("org_id").Equals("org_123")), expected: true},
+		{name: "and - one denies", policy: And(Claim("scope").Contains("read:users"), Claim("org_id").Equals("org_456"))},
+		{name: "and - empty", policy: And(), expected: true},
+		{name: "or", policy: Or(Claim("org_id").Equals("org_456"), Claim("level").Equals(3)), expected: true},
+		{name: "or - all deny", policy: Or(Claim("org_id").Equals("org_456"), Claim("level").Equals(4))},
+		{name: "or - empty", policy: Or()},
+		{name: "not", policy: Not(Claim("missing").Exists()), expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.policy.Evaluate(testPolicyClaims))
+		})
+	}
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	result := &ValidationResult{
+		Claims: jwt.Claims{Issuer: defaultIssuer, Audience: jwt.Audience{"a", "b"}},
+		CustomClaims: map[string]json.RawMessage{
+			"scope":  json.RawMessage(`"read:users"`),
+			"org_id": json.RawMessage(`"org_123"`),
+		},
+	}
+	policy := And(Claim("iss").Equals(defaultIssuer), Claim("aud").Contains("b"), Claim("scope").Contains("read:users"))
+	assert.NoError(t, EvaluatePolicy(policy, result))
+	assert.Equal(t, ErrPolicyDenied, EvaluatePolicy(Claim("org_id").Equals("org_456"), result))
+
+	result.CustomClaims["broken"] = json.RawMessage(`{`)
+	assert.Equal(t, ErrInvalidClaims, EvaluatePolicy(policy, result))
+}
+
+func TestValidatorPolicy(t *testing.T) {
+	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret}, nil)
+	token, err := jwt.Signed(signer).
+		Claims(jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}).
+		Claims(map[string]interface{}{"scope": "read:users", "org_id": "org_123"}).
+		CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		policy      Policy
+		expectedErr error
+	}{
+		{name: "pass", policy: MustParsePolicy(`scope contains "read:users" AND org_id == "org_123"`)},
+		{name: "fail - denied", policy: Claim("scope").Contains("write:users"), expectedErr: ErrPolicyDenied},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator, err := NewValidatorBuilder(defaultSecretProvider).
+				WithAudience(defaultAudience...).
+				WithIssuer(defaultIssuer).
+				WithPolicy(test.policy).
+				Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := validator.Validate(bearerRequest(token))
+			if test.expectedErr == nil {
+				if assert.NoError(t, err) {
+					assert.Equal(t, json.RawMessage(`"org_123"`), result.CustomClaims["org_id"])
+				}
+				return
+			}
+			assert.ErrorIs(t, err, test.expectedErr)
+			var validationErr *ValidationError
+			if assert.True(t, errors.As(err, &validationErr)) {
+				assert.Equal(t, ReasonPolicyDenied, validationErr.Reason)
+			}
+			assert.Equal(t, "policy_denied", ErrorReason(err))
+			bearerErr := NewBearerError(err)
+			assert.Equal(t, 403, bearerErr.StatusCode)
+			assert.Equal(t, BearerErrorInsufficientScope, bearerErr.Code)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Package prometheusmetrics exports the metrics of go-auth0 validators, JWKS
+// clients and key cachers to Prometheus. It lives in its own module so the
+// core package does not depend on the Prometheus client.
+package prometheusmetrics
+
+import (
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultNamespace prefixes the metric names when no namespace is configured.
+const DefaultNamespace = "auth0"
+
+// Options configures the collector.
+type Options struct {
+	// Namespace prefixes the metric names. Defaults to DefaultNamespace.
+	Namespace string
+	// ConstLabels are added to every metric, e.g. the name of the service.
+	ConstLabels prometheus.Labels
+	// Buckets are the buckets of the latency histograms, in seconds.
+	// Defaults to prometheus.DefBuckets.
+	Buckets []float64
+}
+
+// Collector is a prometheus.Collector recording the metrics of validators,
+// JWKS clients and key cachers:
+//
+//   - <namespace>_validations_total{outcome, reason}
+//   - <namespace>_validation_duration_seconds{outcome}
+//   - <namespace>_jwks_fetches_total{uri, outcome, reason}
+//   - <namespace>_jwks_fetch_duration_seconds{uri, outcome}
+//   - <namespace>_key_cache_operations_total{operation, outcome}
+//   - <namespace>_key_cache_operation_duration_seconds{operation}
+//
+// The outcome is "success" or "failure", the reason the auth0.ErrorReason of
+// the failure. It implements auth0.ValidatorMetrics, auth0.JWKSMetrics and
+// auth0.KeyCacherMetrics.
+type Collector struct {
+	validations        *prometheus.CounterVec
+	validationDuration *prometheus.HistogramVec
+	fetches            *prometheus.CounterVec
+	fetchDuration      *prometheus.HistogramVec
+	keyCache           *prometheus.CounterVec
+	keyCacheDuration   *prometheus.HistogramVec
+}
+
+// New creates a collector, to be registered with a prometheus.Registerer.
+func New(options Options) *Collector {
+	if options.Namespace == "" {
+		options.Namespace = DefaultNamespace
+	}
+	if len(options.Buckets) == 0 {
+		options.Buckets = prometheus.DefBuckets
+	}
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   options.Namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: options.ConstLabels,
+		}, labels)
+	}
+	histogram := func(name, help string, labels ...string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   options.Namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: options.ConstLabels,
+			Buckets:     options.Buckets,
+		}, labels)
+	}
+	return &Collector{
+		validations:        counter("validations_total", "Token validations by outcome and failure reason.", "outcome", "reason"),
+		validationDuration: histogram("validation_duration_seconds", "Duration of token validations.", "outcome"),
+		fetches:            counter("jwks_fetches_total", "JWKS download attempts by outcome and failure reason.", "uri", "outcome", "reason"),
+		fetchDuration:      histogram("jwks_fetch_duration_seconds", "Duration of JWKS download attempts.", "uri", "outcome"),
+		keyCache:           counter("key_cache_operations_total", "Key cache operations by outcome.", "operation", "outcome"),
+		keyCacheDuration:   histogram("key_cache_operation_duration_seconds", "Duration of key cache operations.", "operation"),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// ObserveValidation implements auth0.ValidatorMetrics.
+func (c *Collector) ObserveValidation(err error, duration time.Duration) {
+	outcome := outcomeOf(err)
+	c.validations.WithLabelValues(outcome, auth0.ErrorReason(err)).Inc()
+	c.validationDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+// ObserveJWKSFetch implements auth0.JWKSMetrics.
+func (c *Collector) ObserveJWKSFetch(uri string, err error, duration time.Duration) {
+	outcome := outcomeOf(err)
+	c.fetches.WithLabelValues(uri, outcome, auth0.ErrorReason(err)).Inc()
+	c.fetchDuration.WithLabelValues(uri, outcome).Observe(duration.Seconds())
+}
+
+// ObserveKeyCacheOperation implements auth0.KeyCacherMetrics.
+func (c *Collector) ObserveKeyCacheOperation(operation auth0.KeyCacheOperation, outcome auth0.KeyCacheOutcome, duration time.Duration) {
+	c.keyCache.WithLabelValues(string(operation), string(outcome)).Inc()
+	c.keyCacheDuration.WithLabelValues(string(operation)).Observe(duration.Seconds())
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.validations, c.validationDuration, c.fetches, c.fetchDuration, c.keyCache, c.keyCacheDuration}
+}
+
+func outcomeOf(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,92 @@
+package prometheusmetrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	audience = []string{"audience"}
+	issuer   = "issuer"
+	secret   = []byte("secret")
+)
+
+func signToken(t *testing.T, key interface{}, alg jose.SignatureAlgorithm, kid string) string {
+	options := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		options = options.WithHeader("kid", kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.Claims{Issuer: issuer, Audience: audience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func request(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestCollectorValidations(t *testing.T) {
+	collector := New(Options{Namespace: "test"})
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, registry.Register(collector))
+
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(secret), audience, issuer, jose.HS256)
+	validator := auth0.NewValidatorWithOptions(configuration, nil, auth0.ValidatorOptions{Metrics: collector})
+	_, err := validator.ValidateRequest(request(signToken(t, secret, jose.HS256, "")))
+	assert.NoError(t, err)
+	_, err = validator.ValidateRequest(request(signToken(t, []byte("invalid"), jose.HS256, "")))
+	assert.Error(t, err)
+	_, err = validator.ValidateRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.validations.WithLabelValues("success", "")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.validations.WithLabelValues("failure", "invalid_signature")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.validations.WithLabelValues("failure", "token_not_found")))
+	count, err := testutil.GatherAndCount(registry, "test_validation_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestCollectorJWKS(t *testing.T) {
+	jwk := jose.JSONWebKey{Key: secret, KeyID: "kid", Algorithm: string(jose.HS256)}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(auth0.JWKS{Keys: []jose.JSONWebKey{jwk}})
+	}))
+	defer ts.Close()
+
+	collector := New(Options{})
+	cacher := auth0.NewInstrumentedKeyCacher(auth0.NewMemoryKeyCacher(time.Minute, 10), collector)
+	client := auth0.NewJWKClientWithCache(auth0.JWKClientOptions{URI: ts.URL, Metrics: collector}, nil, cacher)
+	configuration := auth0.NewConfiguration(client, audience, issuer, jose.HS256)
+	validator := auth0.NewValidator(configuration, nil)
+	for i := 0; i < 2; i++ {
+		_, err := validator.ValidateRequest(request(signToken(t, secret, jose.HS256, "kid")))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.fetches.WithLabelValues(ts.URL, "success", "")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.keyCache.WithLabelValues("get", "miss")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.keyCache.WithLabelValues("get", "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.keyCache.WithLabelValues("add", "hit")))
+	assert.Equal(t, 7, testutil.CollectAndCount(collector))
+}
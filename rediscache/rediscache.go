@@ -0,0 +1,125 @@
+// Package rediscache provides a key cacher storing the downloaded keys in
+// Redis, so that a fleet of instances shares them, reducing the JWKS
+// downloads and the latency of cold starts.
+//
+// It lives in its own module so the core package does not depend on Redis.
+package rediscache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// DefaultKeyPrefix prefixes the Redis keys when Options.KeyPrefix is empty.
+	DefaultKeyPrefix = "auth0:jwk:"
+	// DefaultTimeout bounds Redis commands when Options.Timeout is not set.
+	DefaultTimeout = time.Second
+)
+
+// Options configures the Redis key cacher.
+type Options struct {
+	// MaxKeyAge is the TTL of the cached keys,
+	// auth0.MaxKeyAgeNoCheck to never expire keys.
+	MaxKeyAge time.Duration
+	// KeyPrefix prefixes the Redis keys, e.g. to share a Redis database
+	// between several issuers. Defaults to DefaultKeyPrefix.
+	KeyPrefix string
+	// Timeout bounds every Redis command. Defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+type keyCacher struct {
+	client  redis.UniversalClient
+	options Options
+}
+
+// New creates a key cacher storing the keys in Redis through client.
+// The key cacher implements auth0.TTLKeyCacher, so that the keys expire
+// after the max-age of the JWKS response when sent.
+//
+// Keys are stored as JSON Web Keys: only cache public keys.
+func New(client redis.UniversalClient, options Options) auth0.KeyCacher {
+	if options.KeyPrefix == "" {
+		options.KeyPrefix = DefaultKeyPrefix
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	return &keyCacher{client: client, options: options}
+}
+
+// Get obtains a key from Redis, expired keys being deleted by Redis.
+func (c *keyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, c.options.KeyPrefix+keyID).Bytes()
+	if err == redis.Nil {
+		return nil, auth0.ErrNoKeyFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key jose.JSONWebKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Concurrent reports that the key cacher is safe for concurrent use.
+func (c *keyCacher) Concurrent() bool {
+	return true
+}
+
+// Add stores every downloaded key in Redis, expiring after the max key age,
+// and returns the key associated with keyID.
+func (c *keyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return c.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL stores every downloaded key in Redis, expiring after ttl
+// instead of the max key age when positive, and returns the key associated
+// with keyID.
+func (c *keyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	if ttl <= 0 {
+		ttl = c.options.MaxKeyAge
+	}
+	if ttl < 0 {
+		// redis.KeepTTL is negative too, keys without expiry have a zero TTL
+		ttl = 0
+	}
+
+	var addingKey jose.JSONWebKey
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+	defer cancel()
+
+	pipe := c.client.Pipeline()
+	for _, key := range downloadedKeys {
+		data, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		pipe.Set(ctx, c.options.KeyPrefix+key.KeyID, data, ttl)
+		if key.KeyID == keyID {
+			addingKey = key
+		}
+	}
+	if len(downloadedKeys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if addingKey.Key == nil {
+		return nil, auth0.ErrNoKeyFound
+	}
+	return &addingKey, nil
+}
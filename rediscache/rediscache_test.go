@@ -0,0 +1,118 @@
+package rediscache
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func genRSAPublicJWK(t *testing.T, kid string) jose.JSONWebKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jose.JSONWebKey{Key: &privateKey.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"}
+}
+
+func newTestKeyCacher(t *testing.T, options Options) (*miniredis.Miniredis, auth0.KeyCacher) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, New(client, options)
+}
+
+func TestKeyCacher(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genRSAPublicJWK(t, "key1"), genRSAPublicJWK(t, "key2")}
+
+	tests := []struct {
+		name             string
+		addingKey        string
+		expectedAddError error
+	}{
+		{
+			name:      "pass - key found",
+			addingKey: "key1",
+		},
+		{
+			name:             "fail - key not found",
+			addingKey:        "key3",
+			expectedAddError: auth0.ErrNoKeyFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: time.Minute})
+
+			_, err := keyCacher.Get("key1")
+			assert.Equal(t, auth0.ErrNoKeyFound, err)
+
+			addedKey, err := keyCacher.Add(test.addingKey, downloadedKeys)
+			assert.Equal(t, test.expectedAddError, err)
+			if err == nil {
+				assert.Equal(t, test.addingKey, addedKey.KeyID)
+			}
+
+			// every downloaded key is shared
+			for _, key := range downloadedKeys {
+				cachedKey, err := keyCacher.Get(key.KeyID)
+				assert.NoError(t, err)
+				assert.Equal(t, key.KeyID, cachedKey.KeyID)
+				assert.Equal(t, key.Key, cachedKey.Key)
+				assert.Equal(t, time.Minute, server.TTL(DefaultKeyPrefix+key.KeyID))
+			}
+		})
+	}
+}
+
+func TestKeyCacherTTL(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genRSAPublicJWK(t, "key1")}
+
+	tests := []struct {
+		name        string
+		maxKeyAge   time.Duration
+		ttl         time.Duration
+		expectedTTL time.Duration
+	}{
+		{"max key age", time.Minute, 0, time.Minute},
+		{"ttl overrides the max key age", time.Minute, time.Hour, time.Hour},
+		{"keys never expire", auth0.MaxKeyAgeNoCheck, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: test.maxKeyAge, KeyPrefix: "issuer:"})
+			_, err := keyCacher.(auth0.TTLKeyCacher).AddWithTTL("key1", downloadedKeys, test.ttl)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedTTL, server.TTL("issuer:key1"))
+
+			if test.expectedTTL > 0 {
+				server.FastForward(test.expectedTTL)
+				_, err = keyCacher.Get("key1")
+				assert.Equal(t, auth0.ErrNoKeyFound, err)
+			}
+		})
+	}
+}
+
+func TestKeyCacherErrors(t *testing.T) {
+	server, keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: time.Minute})
+
+	assert.NoError(t, server.Set(DefaultKeyPrefix+"key1", "invalid"))
+	_, err := keyCacher.Get("key1")
+	assert.Error(t, err)
+
+	server.Close()
+	_, err = keyCacher.Get("key1")
+	assert.Error(t, err)
+	assert.NotEqual(t, auth0.ErrNoKeyFound, err)
+	_, err = keyCacher.Add("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")})
+	assert.Error(t, err)
+}
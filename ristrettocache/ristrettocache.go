@@ -0,0 +1,193 @@
+// Package ristrettocache provides a key cacher built on Ristretto, a
+// concurrent cache sustaining a higher throughput than the mutex guarded
+// in-memory key cacher when validating many tokens in parallel.
+//
+// It lives in its own module so the core package does not depend on Ristretto.
+package ristrettocache
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// DefaultMaxCacheSize is the maximum number of cached keys
+	// when Options.MaxCacheSize is not set.
+	DefaultMaxCacheSize = 10000
+	// DefaultBufferItems is the size of the Get buffers
+	// when Options.BufferItems is not set.
+	DefaultBufferItems = 64
+)
+
+// Options configures the Ristretto key cacher.
+type Options struct {
+	// MaxKeyAge is the age after which a key is expired,
+	// auth0.MaxKeyAgeNoCheck to never expire keys.
+	MaxKeyAge time.Duration
+	// MaxStaleAge is how long a key is retained once expired, and can be
+	// served stale while fresh keys are downloaded in the background.
+	// Zero drops keys as soon as they expire.
+	MaxStaleAge time.Duration
+	// MaxCacheSize is the maximum number of cached keys, the least valuable
+	// ones being evicted by Ristretto. Defaults to DefaultMaxCacheSize.
+	MaxCacheSize int64
+	// BufferItems is the size of the Ristretto Get buffers.
+	// Defaults to DefaultBufferItems.
+	BufferItems int64
+}
+
+type keyCacher struct {
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+
+	cache   *ristretto.Cache
+	options Options
+}
+
+// entry is the value cached for a key.
+type entry struct {
+	addedAt time.Time
+	maxAge  time.Duration
+	key     jose.JSONWebKey
+}
+
+// New creates a key cacher storing the keys in a Ristretto cache.
+// The key cacher implements auth0.TTLKeyCacher, auth0.StaleKeyCacher,
+// auth0.StatsKeyCacher and auth0.StoppableKeyCacher: Stop releases the
+// Ristretto goroutines once the key cacher is no longer used.
+func New(options Options) (auth0.KeyCacher, error) {
+	if options.MaxCacheSize <= 0 {
+		options.MaxCacheSize = DefaultMaxCacheSize
+	}
+	if options.BufferItems <= 0 {
+		options.BufferItems = DefaultBufferItems
+	}
+
+	c := &keyCacher{options: options}
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		// Ristretto advises ten counters per cached item
+		NumCounters:        10 * options.MaxCacheSize,
+		MaxCost:            options.MaxCacheSize,
+		BufferItems:        options.BufferItems,
+		IgnoreInternalCost: true,
+		OnEvict: func(item *ristretto.Item) {
+			// Ristretto reports the expired keys it purges as evictions
+			if !item.Expiration.IsZero() && !time.Now().Before(item.Expiration) {
+				atomic.AddUint64(&c.expirations, 1)
+				return
+			}
+			atomic.AddUint64(&c.evictions, 1)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.cache = cache
+	return c, nil
+}
+
+// Get obtains a key from the cache, returning auth0.ErrKeyExpired
+// for keys past their max age.
+func (c *keyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	cached, ok := c.get(keyID)
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, auth0.ErrNoKeyFound
+	}
+	if cached.maxAge >= 0 && !time.Now().Before(cached.addedAt.Add(cached.maxAge)) {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, auth0.ErrKeyExpired
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return &cached.key, nil
+}
+
+// GetStale obtains a key from the cache whether or not it is expired,
+// along with its expiry time.
+func (c *keyCacher) GetStale(keyID string) (*jose.JSONWebKey, time.Time, error) {
+	cached, ok := c.get(keyID)
+	if !ok {
+		return nil, time.Time{}, auth0.ErrNoKeyFound
+	}
+	var expiresAt time.Time
+	if cached.maxAge >= 0 {
+		expiresAt = cached.addedAt.Add(cached.maxAge)
+	}
+	return &cached.key, expiresAt, nil
+}
+
+// Add caches every downloaded key, expiring after the max key age,
+// and returns the key associated with keyID.
+func (c *keyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return c.AddWithTTL(keyID, downloadedKeys, 0)
+}
+
+// AddWithTTL caches every downloaded key, expiring after ttl instead of the
+// max key age when positive, and returns the key associated with keyID.
+func (c *keyCacher) AddWithTTL(keyID string, downloadedKeys []jose.JSONWebKey, ttl time.Duration) (*jose.JSONWebKey, error) {
+	if ttl <= 0 {
+		ttl = c.options.MaxKeyAge
+	}
+	// a zero Ristretto TTL never expires the key
+	var retention time.Duration
+	if ttl >= 0 {
+		retention = ttl + c.options.MaxStaleAge
+		if retention <= 0 {
+			retention = time.Nanosecond
+		}
+	}
+
+	var addingKey *jose.JSONWebKey
+	now := time.Now()
+	for _, key := range downloadedKeys {
+		cached := &entry{addedAt: now, maxAge: ttl, key: key}
+		c.cache.SetWithTTL(key.KeyID, cached, 1, retention)
+		if key.KeyID == keyID {
+			addingKey = &cached.key
+		}
+	}
+	// Ristretto applies sets asynchronously, wait for the keys to be
+	// visible so that the following lookups do not download them again
+	c.cache.Wait()
+
+	if addingKey == nil {
+		return nil, auth0.ErrNoKeyFound
+	}
+	return addingKey, nil
+}
+
+// Stats returns the counters of the cache, Ristretto not reporting
+// the number of cached keys.
+func (c *keyCacher) Stats() auth0.KeyCacherStats {
+	return auth0.KeyCacherStats{
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		Evictions:   atomic.LoadUint64(&c.evictions),
+		Expirations: atomic.LoadUint64(&c.expirations),
+	}
+}
+
+// Concurrent reports that Ristretto is safe for concurrent use.
+func (c *keyCacher) Concurrent() bool {
+	return true
+}
+
+// Stop releases the Ristretto goroutines, the key cacher must not be
+// used afterwards.
+func (c *keyCacher) Stop() {
+	c.cache.Close()
+}
+
+func (c *keyCacher) get(keyID string) (*entry, bool) {
+	value, ok := c.cache.Get(keyID)
+	if !ok {
+		return nil, false
+	}
+	return value.(*entry), true
+}
@@ -0,0 +1,172 @@
+package ristrettocache
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func genRSAPublicJWK(t testing.TB, kid string) jose.JSONWebKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jose.JSONWebKey{Key: &privateKey.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"}
+}
+
+func newTestKeyCacher(t testing.TB, options Options) auth0.KeyCacher {
+	keyCacher, err := New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(keyCacher.(auth0.StoppableKeyCacher).Stop)
+	return keyCacher
+}
+
+func TestKeyCacher(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genRSAPublicJWK(t, "key1"), genRSAPublicJWK(t, "key2")}
+
+	tests := []struct {
+		name             string
+		addingKey        string
+		expectedAddError error
+	}{
+		{
+			name:      "pass - key found",
+			addingKey: "key1",
+		},
+		{
+			name:             "fail - key not found",
+			addingKey:        "key3",
+			expectedAddError: auth0.ErrNoKeyFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: time.Minute})
+
+			_, err := keyCacher.Get("key1")
+			assert.Equal(t, auth0.ErrNoKeyFound, err)
+
+			addedKey, err := keyCacher.Add(test.addingKey, downloadedKeys)
+			assert.Equal(t, test.expectedAddError, err)
+			if err == nil {
+				assert.Equal(t, test.addingKey, addedKey.KeyID)
+			}
+
+			for _, downloadedKey := range downloadedKeys {
+				key, err := keyCacher.Get(downloadedKey.KeyID)
+				assert.NoError(t, err)
+				assert.Equal(t, downloadedKey.KeyID, key.KeyID)
+			}
+		})
+	}
+}
+
+func TestKeyCacherExpiry(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{genRSAPublicJWK(t, "key1")}
+
+	tests := []struct {
+		name        string
+		options     Options
+		expectedErr error
+	}{
+		{"pass - key not expired", Options{MaxKeyAge: time.Minute}, nil},
+		{"pass - key never expires", Options{MaxKeyAge: auth0.MaxKeyAgeNoCheck}, nil},
+		{"fail - key retained once expired", Options{MaxKeyAge: time.Millisecond, MaxStaleAge: time.Minute}, auth0.ErrKeyExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyCacher := newTestKeyCacher(t, test.options)
+			_, err := keyCacher.Add("key1", downloadedKeys)
+			assert.NoError(t, err)
+
+			time.Sleep(5 * time.Millisecond)
+			_, err = keyCacher.Get("key1")
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func TestKeyCacherGetStale(t *testing.T) {
+	keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: time.Millisecond, MaxStaleAge: time.Minute})
+	before := time.Now()
+	_, err := keyCacher.Add("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")})
+	assert.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	key, expiresAt, err := keyCacher.(auth0.StaleKeyCacher).GetStale("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+	assert.False(t, expiresAt.Before(before.Add(time.Millisecond)))
+	assert.True(t, expiresAt.Before(time.Now()))
+
+	_, _, err = keyCacher.(auth0.StaleKeyCacher).GetStale("key2")
+	assert.Equal(t, auth0.ErrNoKeyFound, err)
+}
+
+func TestKeyCacherAddWithTTL(t *testing.T) {
+	keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: time.Hour, MaxStaleAge: time.Hour})
+	_, err := keyCacher.(auth0.TTLKeyCacher).AddWithTTL("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")}, time.Millisecond)
+	assert.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = keyCacher.Get("key1")
+	assert.Equal(t, auth0.ErrKeyExpired, err)
+}
+
+func TestKeyCacherStats(t *testing.T) {
+	keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: time.Minute})
+	_, err := keyCacher.Add("key1", []jose.JSONWebKey{genRSAPublicJWK(t, "key1")})
+	assert.NoError(t, err)
+
+	_, _ = keyCacher.Get("key1")
+	_, _ = keyCacher.Get("key2")
+
+	stats := keyCacher.(auth0.StatsKeyCacher).Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+}
+
+func TestKeyCacherConcurrency(t *testing.T) {
+	keyCacher := newTestKeyCacher(t, Options{MaxKeyAge: time.Minute})
+	downloadedKeys := []jose.JSONWebKey{genRSAPublicJWK(t, "key1"), genRSAPublicJWK(t, "key2")}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if j%10 == 0 {
+					_, _ = keyCacher.Add("key1", downloadedKeys)
+				}
+				_, _ = keyCacher.Get(fmt.Sprintf("key%d", (i+j)%3))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkKeyCacherGet(b *testing.B) {
+	keyCacher := newTestKeyCacher(b, Options{MaxKeyAge: time.Hour})
+	if _, err := keyCacher.Add("key1", []jose.JSONWebKey{genRSAPublicJWK(b, "key1")}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = keyCacher.Get("key1")
+		}
+	})
+}
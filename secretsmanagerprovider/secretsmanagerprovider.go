@@ -0,0 +1,179 @@
+// Package secretsmanagerprovider provides a secret provider serving the
+// verification keys stored in AWS Secrets Manager.
+//
+// It lives in its own module so the core package does not depend on the AWS
+// SDK.
+package secretsmanagerprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	auth0 "github.com/paulusrobin/go-auth0"
+)
+
+const (
+	// DefaultTTL is the time the keys are cached for
+	DefaultTTL = 5 * time.Minute
+
+	currentStage  = "AWSCURRENT"
+	previousStage = "AWSPREVIOUS"
+)
+
+var (
+	// ErrEmptySecret is returned when the secret value is empty.
+	ErrEmptySecret = errors.New("secrets manager secret is empty")
+	// ErrFieldNotFound is returned when the JSON secret has no such field.
+	ErrFieldNotFound = errors.New("secrets manager secret field not found")
+)
+
+// Client reads secret values, implemented by *secretsmanager.Client.
+type Client interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// Options configures the Secrets Manager secret provider.
+type Options struct {
+	// SecretID is the name or the ARN of the secret holding the key.
+	SecretID string
+	// Field, when set, is the field of the JSON secret holding the key.
+	// The whole secret value is the key otherwise.
+	Field string
+	// IncludePrevious serves the AWSPREVIOUS version of the secret along with
+	// the AWSCURRENT one, so that tokens signed with the previous key stay
+	// valid after the secret is rotated.
+	IncludePrevious bool
+	// TTL is the time the keys are cached for. Defaults to DefaultTTL.
+	TTL time.Duration
+	// RetryInterval is the time after which the keys are refreshed again
+	// once refreshing them failed. Defaults to
+	// auth0.DefaultRefreshRetryInterval.
+	RetryInterval time.Duration
+	// OnRefreshError, when set, is called when the keys cannot be refreshed.
+	// The previous keys are served until they can be.
+	OnRefreshError func(err error)
+	// Clock tells the time the keys expire at. Defaults to auth0.SystemClock.
+	Clock auth0.Clock
+}
+
+// Provider is a secret provider serving keys read from Secrets Manager,
+// refreshed once their TTL expires as described by
+// auth0.RefreshingKeyProvider.
+//
+// Secret values holding a PEM encoded public key or certificate are served
+// as public keys, other values as HMAC secrets.
+type Provider struct {
+	client  Client
+	options Options
+	keys    *auth0.RefreshingKeyProvider
+}
+
+// New creates a provider reading keys with client, whose AWS configuration
+// provides the credentials, e.g. those of the IAM role of the instance, task
+// or service account. It fails when the keys cannot be read.
+func New(client Client, options Options) (*Provider, error) {
+	if options.SecretID == "" {
+		return nil, errors.New("secretsmanagerprovider: SecretID must be set")
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+
+	p := &Provider{client: client, options: options}
+	p.keys = auth0.NewRefreshingKeyProvider(p.load, auth0.RefreshingKeyProviderOptions{
+		RetryInterval:  options.RetryInterval,
+		OnRefreshError: options.OnRefreshError,
+		Clock:          options.Clock,
+	})
+	if err := p.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSecret returns the cached keys, refreshing them first when expired.
+// Several keys are returned as auth0.RotatedKeys.
+func (p *Provider) GetSecret(r *http.Request) (interface{}, error) {
+	return p.keys.GetSecret(r)
+}
+
+// Refresh reads the keys from Secrets Manager, replacing the cached keys on
+// success and keeping them on failure.
+func (p *Provider) Refresh(ctx context.Context) error {
+	return p.keys.Refresh(ctx)
+}
+
+// load reads the keys from Secrets Manager along with the time they are
+// cached for
+func (p *Provider) load(ctx context.Context) (interface{}, time.Duration, error) {
+	key, err := p.getKey(ctx, currentStage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var keys interface{} = key
+	if p.options.IncludePrevious {
+		previousKey, err := p.getKey(ctx, previousStage)
+		var notFound *types.ResourceNotFoundException
+		switch {
+		case errors.As(err, &notFound):
+			// the secret was never rotated
+		case err != nil:
+			return nil, 0, err
+		default:
+			keys = auth0.RotatedKeys{key, previousKey}
+		}
+	}
+	return keys, p.options.TTL, nil
+}
+
+// getKey returns the key of the version of the secret labelled stage
+func (p *Provider) getKey(ctx context.Context, stage string) (interface{}, error) {
+	output, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(p.options.SecretID),
+		VersionStage: aws.String(stage),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	value := output.SecretBinary
+	if output.SecretString != nil {
+		value = []byte(*output.SecretString)
+	}
+	key, err := p.parseKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret %s (%s): %w", p.options.SecretID, stage, err)
+	}
+	return key, nil
+}
+
+// parseKey returns the PEM public key or the HMAC secret of the secret value
+func (p *Provider) parseKey(value []byte) (interface{}, error) {
+	if p.options.Field != "" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(value, &fields); err != nil {
+			return nil, err
+		}
+		field, _ := fields[p.options.Field].(string)
+		if field == "" {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotFound, p.options.Field)
+		}
+		value = []byte(field)
+	}
+	if len(value) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(value)), "-----BEGIN") {
+		return auth0.ParsePEMPublicKey(value)
+	}
+	return value, nil
+}
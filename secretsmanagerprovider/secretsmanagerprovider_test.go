@@ -0,0 +1,192 @@
+package secretsmanagerprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/paulusrobin/go-auth0/auth0test"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClient serves the secret outputs by version stage, counting the reads
+type fakeClient struct {
+	outputs map[string]*secretsmanager.GetSecretValueOutput
+	err     error
+	reads   int
+}
+
+func (c *fakeClient) GetSecretValue(_ context.Context, params *secretsmanager.GetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	c.reads++
+	if c.err != nil {
+		return nil, c.err
+	}
+	output, ok := c.outputs[aws.ToString(params.VersionStage)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	return output, nil
+}
+
+func secretString(value string) *secretsmanager.GetSecretValueOutput {
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}
+}
+
+func genPEMPublicKey(t *testing.T) (*rsa.PublicKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &key.PublicKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestProvider(t *testing.T) {
+	publicKey, publicKeyPEM := genPEMPublicKey(t)
+
+	tests := []struct {
+		name        string
+		options     Options
+		outputs     map[string]*secretsmanager.GetSecretValueOutput
+		expectedKey interface{}
+		expectedErr error
+	}{
+		{
+			name:        "pass - secret string",
+			options:     Options{SecretID: "jwt"},
+			outputs:     map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": secretString("secret")},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:    "pass - secret binary",
+			options: Options{SecretID: "jwt"},
+			outputs: map[string]*secretsmanager.GetSecretValueOutput{
+				"AWSCURRENT": {SecretBinary: []byte("secret")},
+			},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:        "pass - pem public key",
+			options:     Options{SecretID: "jwt"},
+			outputs:     map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": secretString(publicKeyPEM)},
+			expectedKey: publicKey,
+		},
+		{
+			name:        "pass - json field",
+			options:     Options{SecretID: "jwt", Field: "key"},
+			outputs:     map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": secretString(`{"key":"secret"}`)},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:    "pass - previous version",
+			options: Options{SecretID: "jwt", IncludePrevious: true},
+			outputs: map[string]*secretsmanager.GetSecretValueOutput{
+				"AWSCURRENT":  secretString("secret"),
+				"AWSPREVIOUS": secretString("old"),
+			},
+			expectedKey: auth0.RotatedKeys{[]byte("secret"), []byte("old")},
+		},
+		{
+			name:        "pass - never rotated",
+			options:     Options{SecretID: "jwt", IncludePrevious: true},
+			outputs:     map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": secretString("secret")},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:        "fail - json field not found",
+			options:     Options{SecretID: "jwt", Field: "key"},
+			outputs:     map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": secretString(`{"hmac":"secret"}`)},
+			expectedErr: ErrFieldNotFound,
+		},
+		{
+			name:        "fail - empty secret",
+			options:     Options{SecretID: "jwt"},
+			outputs:     map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": {}},
+			expectedErr: ErrEmptySecret,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := New(&fakeClient{outputs: test.outputs}, test.options)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedKey, key)
+		})
+	}
+}
+
+func TestProviderSecretNotFound(t *testing.T) {
+	_, err := New(&fakeClient{}, Options{SecretID: "jwt"})
+	var notFound *types.ResourceNotFoundException
+	assert.True(t, errors.As(err, &notFound), err)
+
+	_, err = New(&fakeClient{}, Options{})
+	assert.Error(t, err)
+}
+
+func TestProviderRefresh(t *testing.T) {
+	client := &fakeClient{outputs: map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": secretString("secret")}}
+	clock := auth0test.NewClock(time.Now())
+	provider, err := New(client, Options{SecretID: "jwt", TTL: time.Hour, Clock: clock})
+	assert.NoError(t, err)
+
+	_, err = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.reads, "the key should be cached for the ttl")
+
+	client.outputs["AWSCURRENT"] = secretString("rotated")
+	clock.Advance(time.Hour)
+	key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("rotated"), key)
+	assert.Equal(t, 2, client.reads)
+}
+
+func TestProviderRefreshError(t *testing.T) {
+	client := &fakeClient{outputs: map[string]*secretsmanager.GetSecretValueOutput{"AWSCURRENT": secretString("secret")}}
+	var refreshErr error
+	clock := auth0test.NewClock(time.Now())
+	provider, err := New(client, Options{
+		SecretID:       "jwt",
+		RetryInterval:  time.Minute,
+		OnRefreshError: func(err error) { refreshErr = err },
+		Clock:          clock,
+	})
+	assert.NoError(t, err)
+
+	client.err = errors.New("access denied")
+	clock.Advance(DefaultTTL)
+	key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err, "the previous key should be served when it cannot be refreshed")
+	assert.Equal(t, []byte("secret"), key)
+	assert.Equal(t, client.err, refreshErr)
+
+	_, _ = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 2, client.reads, "the refresh should not be retried before the retry interval")
+
+	client.err = nil
+	clock.Advance(time.Minute)
+	_, _ = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 3, client.reads, "the refresh should be retried after the retry interval")
+}
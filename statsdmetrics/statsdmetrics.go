@@ -0,0 +1,206 @@
+// Package statsdmetrics sends the metrics of go-auth0 validators, JWKS
+// clients and key cachers to a StatsD or DogStatsD agent over UDP.
+//
+// It writes the StatsD line protocol itself so that it does not depend on
+// a StatsD client library.
+package statsdmetrics
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+)
+
+const (
+	// DefaultAddress is the address of the local StatsD agent
+	DefaultAddress = "127.0.0.1:8125"
+	// DefaultPrefix prefixes the metric names when no prefix is configured
+	DefaultPrefix = "auth0."
+)
+
+// Options configures the emitter.
+type Options struct {
+	// Address is the UDP address of the StatsD agent.
+	// Defaults to DefaultAddress.
+	Address string
+	// Prefix prefixes the metric names. Defaults to DefaultPrefix.
+	Prefix string
+	// DogStatsD sends the labels of the metrics as DogStatsD tags, e.g.
+	// "auth0.validations:1|c|#outcome:failure,reason:expired". Plain StatsD
+	// has no tags, so the labels are otherwise appended to the metric names,
+	// e.g. "auth0.validations.failure.expired:1|c", the JWKS URI being left
+	// out.
+	DogStatsD bool
+	// Tags are added to every metric when DogStatsD is set,
+	// e.g. "service:api".
+	Tags []string
+	// Writer, when set, receives the metrics instead of the agent, one
+	// packet per write.
+	Writer io.Writer
+}
+
+// Emitter sends the metrics of validators, JWKS clients and key cachers:
+//
+//   - <prefix>validations{outcome, reason}, a counter
+//   - <prefix>validation.duration{outcome}, a timer
+//   - <prefix>jwks.fetches{uri, outcome, reason}, a counter
+//   - <prefix>jwks.fetch.duration{uri, outcome}, a timer
+//   - <prefix>key_cache.operations{operation, outcome}, a counter
+//   - <prefix>key_cache.operation.duration{operation}, a timer
+//
+// The outcome is "success" or "failure", the reason the auth0.ErrorReason of
+// the failure. Metrics are sent as they are observed and dropped when they
+// cannot be sent. It implements auth0.ValidatorMetrics, auth0.JWKSMetrics
+// and auth0.KeyCacherMetrics, and is safe for concurrent use.
+type Emitter struct {
+	options Options
+	// tags are the encoded constant tags
+	tags string
+
+	mu     sync.Mutex
+	writer io.Writer
+	conn   net.Conn
+}
+
+// label is a label of a metric
+type label struct {
+	name, value string
+	// tagOnly labels are left out of plain StatsD metric names
+	tagOnly bool
+}
+
+// New creates an emitter sending the metrics to the agent.
+func New(options Options) (*Emitter, error) {
+	if options.Address == "" {
+		options.Address = DefaultAddress
+	}
+	if options.Prefix == "" {
+		options.Prefix = DefaultPrefix
+	}
+	e := &Emitter{options: options, writer: options.Writer}
+	if e.writer == nil {
+		conn, err := net.Dial("udp", options.Address)
+		if err != nil {
+			return nil, err
+		}
+		e.conn, e.writer = conn, conn
+	}
+	sanitized := make([]string, len(options.Tags))
+	for i, tag := range options.Tags {
+		sanitized[i] = sanitize(tag)
+	}
+	e.tags = strings.Join(sanitized, ",")
+	return e, nil
+}
+
+// Close closes the connection to the agent.
+func (e *Emitter) Close() error {
+	if e.conn == nil {
+		return nil
+	}
+	return e.conn.Close()
+}
+
+// ObserveValidation implements auth0.ValidatorMetrics.
+func (e *Emitter) ObserveValidation(err error, duration time.Duration) {
+	outcome := label{name: "outcome", value: outcomeOf(err)}
+	e.send("validations", "1|c", outcome, label{name: "reason", value: auth0.ErrorReason(err)})
+	e.send("validation.duration", timing(duration), outcome)
+}
+
+// ObserveJWKSFetch implements auth0.JWKSMetrics.
+func (e *Emitter) ObserveJWKSFetch(uri string, err error, duration time.Duration) {
+	source := label{name: "uri", value: uri, tagOnly: true}
+	outcome := label{name: "outcome", value: outcomeOf(err)}
+	e.send("jwks.fetches", "1|c", source, outcome, label{name: "reason", value: auth0.ErrorReason(err)})
+	e.send("jwks.fetch.duration", timing(duration), source, outcome)
+}
+
+// ObserveKeyCacheOperation implements auth0.KeyCacherMetrics.
+func (e *Emitter) ObserveKeyCacheOperation(operation auth0.KeyCacheOperation, outcome auth0.KeyCacheOutcome, duration time.Duration) {
+	op := label{name: "operation", value: string(operation)}
+	e.send("key_cache.operations", "1|c", op, label{name: "outcome", value: string(outcome)})
+	e.send("key_cache.operation.duration", timing(duration), op)
+}
+
+// send writes the metric of the value, e.g. "1|c", in a packet
+func (e *Emitter) send(name, value string, labels ...label) {
+	var line strings.Builder
+	line.WriteString(e.options.Prefix)
+	line.WriteString(name)
+	if !e.options.DogStatsD {
+		for _, l := range labels {
+			if !l.tagOnly && l.value != "" {
+				line.WriteByte('.')
+				line.WriteString(sanitizeName(l.value))
+			}
+		}
+	}
+	line.WriteByte(':')
+	line.WriteString(value)
+	if e.options.DogStatsD {
+		separator := "|#"
+		if e.tags != "" {
+			line.WriteString(separator)
+			line.WriteString(e.tags)
+			separator = ","
+		}
+		for _, l := range labels {
+			if l.value == "" {
+				continue
+			}
+			line.WriteString(separator)
+			line.WriteString(l.name)
+			line.WriteByte(':')
+			line.WriteString(sanitize(l.value))
+			separator = ","
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// metrics are best effort, the agent being unavailable must not fail
+	// the validations
+	_, _ = io.WriteString(e.writer, line.String())
+}
+
+// timing is the value of a timer metric, in milliseconds
+func timing(duration time.Duration) string {
+	ms := float64(duration) / float64(time.Millisecond)
+	return strconv.FormatFloat(ms, 'f', -1, 64) + "|ms"
+}
+
+// sanitize replaces the characters of the line protocol in tag values
+func sanitize(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '|', ',', '#', '\n':
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// sanitizeName replaces the characters of the line protocol, and the dots
+// separating name segments, in name segments
+func sanitizeName(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '|', ',', '#', '\n', ':', '.', '@':
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+func outcomeOf(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
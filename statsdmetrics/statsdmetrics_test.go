@@ -0,0 +1,175 @@
+package statsdmetrics
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// packets records the written packets
+type packets struct {
+	mu      sync.Mutex
+	written []string
+}
+
+func (p *packets) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written = append(p.written, string(b))
+	return len(b), nil
+}
+
+func (p *packets) lines() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.written...)
+}
+
+func TestEmitter(t *testing.T) {
+	unknown := errors.New("unknown")
+	tests := []struct {
+		name     string
+		options  Options
+		observe  func(e *Emitter)
+		expected []string
+	}{
+		{
+			name:    "validation success",
+			observe: func(e *Emitter) { e.ObserveValidation(nil, 1500*time.Microsecond) },
+			expected: []string{
+				"auth0.validations.success:1|c",
+				"auth0.validation.duration.success:1.5|ms",
+			},
+		},
+		{
+			name:    "validation failure",
+			options: Options{Prefix: "api."},
+			observe: func(e *Emitter) { e.ObserveValidation(jwt.ErrExpired, 2*time.Millisecond) },
+			expected: []string{
+				"api.validations.failure.expired:1|c",
+				"api.validation.duration.failure:2|ms",
+			},
+		},
+		{
+			name:    "unknown validation failure",
+			observe: func(e *Emitter) { e.ObserveValidation(unknown, time.Millisecond) },
+			expected: []string{
+				"auth0.validations.failure.other:1|c",
+				"auth0.validation.duration.failure:1|ms",
+			},
+		},
+		{
+			name:    "jwks fetch",
+			observe: func(e *Emitter) { e.ObserveJWKSFetch("https://tenant/.well-known/jwks.json", nil, time.Millisecond) },
+			expected: []string{
+				"auth0.jwks.fetches.success:1|c",
+				"auth0.jwks.fetch.duration.success:1|ms",
+			},
+		},
+		{
+			name: "key cache operation",
+			observe: func(e *Emitter) {
+				e.ObserveKeyCacheOperation(auth0.KeyCacheGet, auth0.KeyCacheHit, time.Millisecond)
+			},
+			expected: []string{
+				"auth0.key_cache.operations.get.hit:1|c",
+				"auth0.key_cache.operation.duration.get:1|ms",
+			},
+		},
+		{
+			name:    "dogstatsd validation failure",
+			options: Options{DogStatsD: true, Tags: []string{"service:api", "env:a|b"}},
+			observe: func(e *Emitter) { e.ObserveValidation(jwt.ErrExpired, time.Millisecond) },
+			expected: []string{
+				"auth0.validations:1|c|#service:api,env:a_b,outcome:failure,reason:expired",
+				"auth0.validation.duration:1|ms|#service:api,env:a_b,outcome:failure",
+			},
+		},
+		{
+			name:    "dogstatsd jwks fetch",
+			options: Options{DogStatsD: true},
+			observe: func(e *Emitter) { e.ObserveJWKSFetch("https://tenant/jwks", nil, time.Millisecond) },
+			expected: []string{
+				"auth0.jwks.fetches:1|c|#uri:https://tenant/jwks,outcome:success",
+				"auth0.jwks.fetch.duration:1|ms|#uri:https://tenant/jwks,outcome:success",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			written := &packets{}
+			test.options.Writer = written
+			emitter, err := New(test.options)
+			assert.NoError(t, err)
+			test.observe(emitter)
+			assert.Equal(t, test.expected, written.lines())
+			assert.NoError(t, emitter.Close())
+		})
+	}
+}
+
+func TestEmitterValidator(t *testing.T) {
+	written := &packets{}
+	emitter, err := New(Options{Writer: written})
+	assert.NoError(t, err)
+
+	secret := []byte("secret")
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, nil)
+	assert.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   "issuer",
+		Audience: jwt.Audience{"audience"},
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).CompactSerialize()
+	assert.NoError(t, err)
+
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(secret), []string{"audience"}, "issuer", jose.HS256)
+	validator := auth0.NewValidatorWithOptions(configuration, nil, auth0.ValidatorOptions{Metrics: emitter})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	_, err = validator.ValidateRequest(r)
+	assert.NoError(t, err)
+	_, err = validator.ValidateRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Error(t, err)
+
+	lines := written.lines()
+	if assert.Len(t, lines, 4) {
+		assert.Equal(t, "auth0.validations.success:1|c", lines[0])
+		assert.Equal(t, "auth0.validations.failure.token_not_found:1|c", lines[2])
+	}
+}
+
+func TestEmitterUDP(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	emitter, err := New(Options{Address: agent.LocalAddr().String()})
+	assert.NoError(t, err)
+	defer emitter.Close()
+	emitter.ObserveKeyCacheOperation(auth0.KeyCacheAdd, auth0.KeyCacheHit, 0)
+
+	buf := make([]byte, 512)
+	assert.NoError(t, agent.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := agent.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "auth0.key_cache.operations.add.hit:1|c", string(buf[:n]))
+}
+
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "a_b_c_d", sanitizeName("a.b:c|d"))
+	assert.False(t, strings.ContainsAny(sanitizeName("x\n#,@"), "\n#,@"))
+}
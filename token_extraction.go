@@ -1,9 +1,18 @@
 package auth0
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"unicode"
 
 	"gopkg.in/square/go-jose.v2/jwt"
 )
@@ -12,8 +21,21 @@ var (
 	// ErrTokenNotFound is returned by the ValidateRequest if the token was not
 	// found in the request.
 	ErrTokenNotFound = errors.New("Token not found")
+	// ErrMalformedHeader is returned when the header carrying the token
+	// uses the expected scheme but is not of the "<scheme> <token>" form.
+	ErrMalformedHeader = errors.New("malformed authorization header")
+	// ErrFormBodyTooLarge is returned by FromForm when the request body
+	// exceeds the configured size limit.
+	ErrFormBodyTooLarge = errors.New("form body exceeds the size limit")
+	// ErrRawTokenUnsupported is returned when the raw token is requested
+	// from an extractor which does not implement RawTokenExtractor.
+	ErrRawTokenUnsupported = errors.New("extractor does not provide the raw token")
 )
 
+// DefaultMaxFormBodyBytes is the body size limit used by FromForm
+// when none is provided.
+const DefaultMaxFormBodyBytes int64 = 1 << 20
+
 // RequestTokenExtractor can extract a JWT
 // from a request.
 type RequestTokenExtractor interface {
@@ -29,42 +51,437 @@ func (f RequestTokenExtractorFunc) Extract(r *http.Request) (*jwt.JSONWebToken,
 	return f(r)
 }
 
-// FromMultiple combines multiple extractors by chaining.
+// RawTokenExtractor can extract the compact serialized JWT
+// from a request without parsing it.
+type RawTokenExtractor interface {
+	ExtractRaw(r *http.Request) (string, error)
+}
+
+// RawTokenExtractorFunc function conforming to both the
+// RawTokenExtractor and the RequestTokenExtractor interfaces.
+type RawTokenExtractorFunc func(r *http.Request) (string, error)
+
+// ExtractRaw calls f(r)
+func (f RawTokenExtractorFunc) ExtractRaw(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// Extract parses the token returned by f(r)
+func (f RawTokenExtractorFunc) Extract(r *http.Request) (*jwt.JSONWebToken, error) {
+	raw, err := f(r)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseSigned(raw)
+}
+
+// ExtractRaw returns the raw token of the request when the extractor
+// implements RawTokenExtractor, ErrRawTokenUnsupported otherwise.
+func ExtractRaw(extractor RequestTokenExtractor, r *http.Request) (string, error) {
+	if e, ok := extractor.(RawTokenExtractor); ok {
+		return e.ExtractRaw(r)
+	}
+	return "", ErrRawTokenUnsupported
+}
+
+// TokenFingerprint returns the hex encoded SHA-256 of a raw token,
+// to correlate a token in logs without disclosing it.
+func TokenFingerprint(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// MetadataTokenExtractor can extract a JWT from transport metadata,
+// such as gRPC's metadata.MD, for transports other than HTTP.
+type MetadataTokenExtractor interface {
+	ExtractMetadata(md map[string][]string) (*jwt.JSONWebToken, error)
+}
+
+// MetadataTokenExtractorFunc function conforming
+// to the MetadataTokenExtractor interface.
+type MetadataTokenExtractorFunc func(md map[string][]string) (*jwt.JSONWebToken, error)
+
+// ExtractMetadata calls f(md)
+func (f MetadataTokenExtractorFunc) ExtractMetadata(md map[string][]string) (*jwt.JSONWebToken, error) {
+	return f(md)
+}
+
+// MultipleExtractorPolicy controls when chained extractors
+// move on to the next extractor.
+type MultipleExtractorPolicy int
+
+const (
+	// SkipNotFound moves on only when the token was not found and fails on
+	// any other error, such as a token which cannot be parsed.
+	SkipNotFound MultipleExtractorPolicy = iota
+	// SkipErrors moves on past any error, so that e.g. a broken query param
+	// does not shadow a valid Authorization header.
+	SkipErrors
+)
+
+// ExtractionError is returned by chained extractors when none of them
+// returned a token and some failed for another reason than ErrTokenNotFound,
+// listing why each one failed.
+type ExtractionError struct {
+	Errors []error
+}
+
+func (e *ExtractionError) Error() string {
+	reasons := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		reasons[i] = fmt.Sprintf("extractor %d: %v", i, err)
+	}
+	return "no token extracted: " + strings.Join(reasons, "; ")
+}
+
+// Is reports whether every extractor failed with target, so that
+// errors.Is(err, ErrTokenNotFound) holds when no extractor found a token.
+func (e *ExtractionError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if !errors.Is(err, target) {
+			return false
+		}
+	}
+	return len(e.Errors) > 0
+}
+
+// FromMultiple combines multiple extractors by chaining with the SkipNotFound
+// policy. See FromMultipleWithPolicy.
 func FromMultiple(extractors ...RequestTokenExtractor) RequestTokenExtractor {
-	return RequestTokenExtractorFunc(func(r *http.Request) (*jwt.JSONWebToken, error) {
-		for _, e := range extractors {
-			token, err := e.Extract(r)
-			if err == ErrTokenNotFound {
-				continue
-			} else if err != nil {
-				return nil, err
-			}
+	return FromMultipleWithPolicy(SkipNotFound, extractors...)
+}
+
+// FromMultipleWithPolicy combines multiple extractors by chaining, trying them
+// in order and moving on according to the provided policy. The error which
+// stopped the chain is returned as is. When every extractor was skipped,
+// ErrTokenNotFound is returned if none of them found a token, and otherwise an
+// *ExtractionError lists each failure. The raw token is available when every
+// extractor implements RawTokenExtractor.
+func FromMultipleWithPolicy(policy MultipleExtractorPolicy, extractors ...RequestTokenExtractor) RequestTokenExtractor {
+	return &multipleExtractor{policy: policy, extractors: extractors}
+}
+
+type multipleExtractor struct {
+	policy     MultipleExtractorPolicy
+	extractors []RequestTokenExtractor
+}
+
+// Extract implements the RequestTokenExtractor interface.
+func (m *multipleExtractor) Extract(r *http.Request) (*jwt.JSONWebToken, error) {
+	var errs []error
+	for _, e := range m.extractors {
+		token, err := e.Extract(r)
+		if err == nil {
 			return token, nil
 		}
-		return nil, ErrTokenNotFound
-	})
+		if !m.skip(err) {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, m.aggregate(errs)
+}
+
+// ExtractRaw implements the RawTokenExtractor interface.
+func (m *multipleExtractor) ExtractRaw(r *http.Request) (string, error) {
+	var errs []error
+	for _, e := range m.extractors {
+		raw, err := ExtractRaw(e, r)
+		if err == nil {
+			return raw, nil
+		}
+		if err == ErrRawTokenUnsupported || !m.skip(err) {
+			return "", err
+		}
+		errs = append(errs, err)
+	}
+	return "", m.aggregate(errs)
 }
 
-// FromHeader looks for the request in the
-// authentication header or call ParseMultipartForm
-// if not present.
-// TODO: Implement parsing form data.
+func (m *multipleExtractor) skip(err error) bool {
+	return m.policy == SkipErrors || errors.Is(err, ErrTokenNotFound)
+}
+
+// aggregate returns the error of the skipped extractors, ErrTokenNotFound
+// when none of them found a token
+func (m *multipleExtractor) aggregate(errs []error) error {
+	for _, err := range errs {
+		if !errors.Is(err, ErrTokenNotFound) {
+			return &ExtractionError{Errors: errs}
+		}
+	}
+	return ErrTokenNotFound
+}
+
+// FromHeader looks for the token in the Authorization header using
+// the Bearer scheme, matched case insensitively. Surrounding whitespace is
+// ignored and multiple Authorization values are searched for the Bearer one.
+// ErrTokenNotFound is returned when no Bearer credentials are present and
+// ErrMalformedHeader when they are not of the "Bearer <token>" form.
+// Use FromForm to read the token from a form body.
 func FromHeader(r *http.Request) (*jwt.JSONWebToken, error) {
+	return RawTokenExtractorFunc(FromHeaderRaw).Extract(r)
+}
+
+// FromHeaderRaw is the RawTokenExtractor counterpart of FromHeader.
+func FromHeaderRaw(r *http.Request) (string, error) {
+	return fromValues(r.Header.Values("Authorization"), "Bearer")
+}
+
+// FromHeaderWithConfig looks for the JWT in the provided header, prefixed by
+// the provided authentication scheme, e.g. "X-Forwarded-Access-Token" or a
+// "Token" scheme used by legacy gateways. The scheme is matched case
+// insensitively; an empty scheme means the header value is the bare token.
+func FromHeaderWithConfig(headerName, scheme string) RawTokenExtractorFunc {
+	return func(r *http.Request) (string, error) {
+		return fromValues(r.Header.Values(headerName), scheme)
+	}
+}
+
+// fromValues looks for the token in the provided header values. Values using
+// another scheme are ignored; a value using the expected scheme but not
+// following the "<scheme> <token>" form is malformed, as are conflicting tokens
+// spread over multiple values.
+func fromValues(values []string, scheme string) (string, error) {
 	raw := ""
-	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[0:7], "BEARER ") {
-		raw = h[7:]
+	for _, value := range values {
+		token, ok := "", false
+		first, second, n := splitFields(value)
+		switch {
+		case n == 0:
+			continue
+		case scheme == "":
+			ok = n == 1
+			token = first
+		case !strings.EqualFold(first, scheme):
+			continue
+		default:
+			ok = n == 2
+			if ok {
+				token = second
+			}
+		}
+		if !ok || (raw != "" && raw != token) {
+			return "", ErrMalformedHeader
+		}
+		raw = token
 	}
 	if raw == "" {
-		return nil, ErrTokenNotFound
+		return "", ErrTokenNotFound
 	}
-	return jwt.ParseSigned(raw)
+	return raw, nil
+}
+
+// splitFields returns the first two fields of value separated by white
+// space, and the number of fields up to 3, without allocating like
+// strings.Fields
+func splitFields(value string) (first, second string, n int) {
+	for n < 3 {
+		value = strings.TrimLeftFunc(value, unicode.IsSpace)
+		if value == "" {
+			return first, second, n
+		}
+		end := strings.IndexFunc(value, unicode.IsSpace)
+		if end < 0 {
+			end = len(value)
+		}
+		switch n {
+		case 0:
+			first = value[:end]
+		case 1:
+			second = value[:end]
+		}
+		n++
+		value = value[end:]
+	}
+	return first, second, n
+}
+
+// DefaultForwardedHeaders are the headers searched by FromForwardedHeaders
+// when none are provided.
+var DefaultForwardedHeaders = []string{"X-Forwarded-Authorization", "X-Original-Authorization"}
+
+// FromForwardedHeaders returns the Bearer token an API gateway or proxy
+// forwarded in one of the provided headers, DefaultForwardedHeaders when none
+// are provided. The headers are only trusted when the request comes from one
+// of trustedProxies, given as IP addresses or CIDR ranges, and are ignored
+// otherwise so that clients cannot inject them.
+func FromForwardedHeaders(trustedProxies []string, headers ...string) (RawTokenExtractorFunc, error) {
+	if len(headers) == 0 {
+		headers = DefaultForwardedHeaders
+	}
+	trusted := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", proxy, err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	return func(r *http.Request) (string, error) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		for _, network := range trusted {
+			if ip != nil && network.Contains(ip) {
+				for _, header := range headers {
+					if raw, err := fromValues(r.Header.Values(header), "Bearer"); err != ErrTokenNotFound {
+						return raw, err
+					}
+				}
+				break
+			}
+		}
+		return "", ErrTokenNotFound
+	}, nil
 }
 
 // FromParams returns the JWT when passed as the URL query param "token".
 func FromParams(r *http.Request) (*jwt.JSONWebToken, error) {
-	raw := r.URL.Query().Get("token")
-	if raw == "" {
-		return nil, ErrTokenNotFound
+	return RawTokenExtractorFunc(FromParamsRaw).Extract(r)
+}
+
+// FromParamsRaw is the RawTokenExtractor counterpart of FromParams.
+func FromParamsRaw(r *http.Request) (string, error) {
+	return fromQuery(r, "token")
+}
+
+// FromMetadata looks for the JWT in the "authorization" metadata entry
+// using the Bearer scheme, the way gRPC clients send per-RPC credentials.
+func FromMetadata(md map[string][]string) (*jwt.JSONWebToken, error) {
+	return fromMetadata(md, "authorization", "Bearer")
+}
+
+// FromMetadataWithConfig looks for the JWT in the provided metadata key,
+// prefixed by the provided scheme, with the same rules as FromHeaderWithConfig.
+// Keys are matched lower-cased, as gRPC normalizes them.
+func FromMetadataWithConfig(key, scheme string) MetadataTokenExtractor {
+	return MetadataTokenExtractorFunc(func(md map[string][]string) (*jwt.JSONWebToken, error) {
+		return fromMetadata(md, key, scheme)
+	})
+}
+
+func fromMetadata(md map[string][]string, key, scheme string) (*jwt.JSONWebToken, error) {
+	raw, err := fromValues(md[strings.ToLower(key)], scheme)
+	if err != nil {
+		return nil, err
 	}
 	return jwt.ParseSigned(raw)
 }
+
+// WebSocketTokenProtocol is the subprotocol marker preceding the token
+// in the Sec-WebSocket-Protocol header.
+const WebSocketTokenProtocol = "access_token"
+
+// FromWebSocketProtocol looks for the JWT in the Sec-WebSocket-Protocol
+// header. Browsers cannot set the Authorization header on WebSocket upgrades,
+// so clients pass the token as the subprotocol following the
+// WebSocketTokenProtocol marker:
+//
+//	new WebSocket(url, ["access_token", token])
+//
+// The handshake response must select the marker as the accepted subprotocol.
+func FromWebSocketProtocol(r *http.Request) (*jwt.JSONWebToken, error) {
+	return RawTokenExtractorFunc(FromWebSocketProtocolRaw).Extract(r)
+}
+
+// FromWebSocketProtocolRaw is the RawTokenExtractor counterpart
+// of FromWebSocketProtocol.
+func FromWebSocketProtocolRaw(r *http.Request) (string, error) {
+	var protocols []string
+	for _, h := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(h, ",") {
+			protocols = append(protocols, strings.TrimSpace(p))
+		}
+	}
+	for i := 0; i < len(protocols)-1; i++ {
+		if protocols[i] == WebSocketTokenProtocol && protocols[i+1] != "" {
+			return protocols[i+1], nil
+		}
+	}
+	return "", ErrTokenNotFound
+}
+
+// FromWebSocket looks for the JWT in the Sec-WebSocket-Protocol header and
+// falls back to the provided URL query param. An empty queryParam disables
+// the fallback.
+func FromWebSocket(queryParam string) RawTokenExtractorFunc {
+	return func(r *http.Request) (string, error) {
+		raw, err := FromWebSocketProtocolRaw(r)
+		if err != ErrTokenNotFound || queryParam == "" {
+			return raw, err
+		}
+		return fromQuery(r, queryParam)
+	}
+}
+
+// FromCookie returns the JWT stored in the named cookie, for apps keeping
+// access tokens in HttpOnly cookies.
+func FromCookie(name string) RawTokenExtractorFunc {
+	return func(r *http.Request) (string, error) {
+		cookie, err := r.Cookie(name)
+		if err != nil || cookie.Value == "" {
+			return "", ErrTokenNotFound
+		}
+		return cookie.Value, nil
+	}
+}
+
+// FromForm returns the JWT passed as the "access_token" parameter of an
+// application/x-www-form-urlencoded body, as described in RFC 6750 section 2.2.
+// At most maxBytes are read (DefaultMaxFormBodyBytes when not positive) and the
+// body is restored afterwards so downstream handlers can still consume it.
+func FromForm(maxBytes int64) RawTokenExtractorFunc {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxFormBodyBytes
+	}
+	return func(r *http.Request) (string, error) {
+		if r.Body == nil || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return "", ErrTokenNotFound
+		}
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/x-www-form-urlencoded" {
+			return "", ErrTokenNotFound
+		}
+
+		body := r.Body
+		buf, err := io.ReadAll(io.LimitReader(body, maxBytes+1))
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), body), body}
+		if err != nil {
+			return "", err
+		}
+		if int64(len(buf)) > maxBytes {
+			return "", ErrFormBodyTooLarge
+		}
+
+		values, err := url.ParseQuery(string(buf))
+		if err != nil {
+			return "", err
+		}
+		raw := values.Get("access_token")
+		if raw == "" {
+			return "", ErrTokenNotFound
+		}
+		return raw, nil
+	}
+}
+
+func fromQuery(r *http.Request, name string) (string, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return "", ErrTokenNotFound
+	}
+	return raw, nil
+}
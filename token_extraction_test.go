@@ -2,11 +2,14 @@ package auth0
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
@@ -101,3 +104,354 @@ func TestInvalidExtract(t *testing.T) {
 		t.Error("A request without valid Authorization header should return an error.")
 	}
 }
+
+func TestFromWebSocketExtraction(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	protocolRequest, _ := http.NewRequest("", "http://localhost", nil)
+	protocolRequest.Header.Add("Sec-WebSocket-Protocol", "chat, access_token, "+referenceToken)
+	queryRequest, _ := http.NewRequest("", "http://localhost?access_token="+referenceToken, nil)
+	markerOnlyRequest, _ := http.NewRequest("", "http://localhost", nil)
+	markerOnlyRequest.Header.Add("Sec-WebSocket-Protocol", "chat, access_token")
+
+	tests := []struct {
+		name        string
+		extractor   RequestTokenExtractor
+		req         *http.Request
+		expectedErr error
+	}{
+		{"pass - subprotocol", FromWebSocket(""), protocolRequest, nil},
+		{"pass - query fallback", FromWebSocket("access_token"), queryRequest, nil},
+		{"fail - query fallback disabled", FromWebSocket(""), queryRequest, ErrTokenNotFound},
+		{"fail - marker without token", RequestTokenExtractorFunc(FromWebSocketProtocol), markerOnlyRequest, ErrTokenNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := test.extractor.Extract(test.req)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			claims := jwt.Claims{}
+			if err = token.Claims(defaultSecret, &claims); err != nil {
+				t.Errorf("Claims should be decoded correctly with default token: %q \n", err)
+			}
+		})
+	}
+}
+
+func TestFromCookieExtraction(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	cookieRequest, _ := http.NewRequest("", "http://localhost", nil)
+	cookieRequest.AddCookie(&http.Cookie{Name: "access_token", Value: referenceToken})
+
+	token, err := FromCookie("access_token").Extract(cookieRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.Claims{}
+	if err = token.Claims(defaultSecret, &claims); err != nil {
+		t.Errorf("Claims should be decoded correctly with default token: %q \n", err)
+	}
+
+	if _, err = FromCookie("session").Extract(cookieRequest); err != ErrTokenNotFound {
+		t.Errorf("A missing cookie should return ErrTokenNotFound, got: %v", err)
+	}
+}
+
+func TestFromHeaderWithConfigExtraction(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name        string
+		headerName  string
+		scheme      string
+		headerValue string
+		expectedErr error
+	}{
+		{"pass - bare token", "X-Forwarded-Access-Token", "", referenceToken, nil},
+		{"pass - custom scheme", "Authorization", "Token", "token " + referenceToken, nil},
+		{"fail - scheme mismatch", "Authorization", "Token", "Bearer " + referenceToken, ErrTokenNotFound},
+		{"fail - missing header", "X-Forwarded-Access-Token", "", "", ErrTokenNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("", "http://localhost", nil)
+			if test.headerValue != "" {
+				req.Header.Add(test.headerName, test.headerValue)
+			}
+
+			token, err := FromHeaderWithConfig(test.headerName, test.scheme).Extract(req)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			claims := jwt.Claims{}
+			if err = token.Claims(defaultSecret, &claims); err != nil {
+				t.Errorf("Claims should be decoded correctly with default token: %q \n", err)
+			}
+		})
+	}
+}
+
+func TestFromFormExtraction(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+	body := "access_token=" + referenceToken + "&foo=bar"
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		maxBytes    int64
+		expectedErr error
+	}{
+		{"pass - form body", http.MethodPost, "application/x-www-form-urlencoded", 0, nil},
+		{"fail - GET request", http.MethodGet, "application/x-www-form-urlencoded", 0, ErrTokenNotFound},
+		{"fail - json body", http.MethodPost, "application/json", 0, ErrTokenNotFound},
+		{"fail - body too large", http.MethodPost, "application/x-www-form-urlencoded", 10, ErrFormBodyTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(test.method, "http://localhost", strings.NewReader(body))
+			req.Header.Set("Content-Type", test.contentType)
+
+			token, err := FromForm(test.maxBytes).Extract(req)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+
+			// the body must still be readable by downstream handlers
+			remaining, _ := ioutil.ReadAll(req.Body)
+			if string(remaining) != body {
+				t.Errorf("Body should have been preserved, got: %q", remaining)
+			}
+			if err != nil {
+				return
+			}
+
+			claims := jwt.Claims{}
+			if err = token.Claims(defaultSecret, &claims); err != nil {
+				t.Errorf("Claims should be decoded correctly with default token: %q \n", err)
+			}
+		})
+	}
+}
+
+func TestFromMetadataExtraction(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name        string
+		extractor   MetadataTokenExtractor
+		md          map[string][]string
+		expectedErr error
+	}{
+		{"pass - authorization", MetadataTokenExtractorFunc(FromMetadata), map[string][]string{"authorization": {"Bearer " + referenceToken}}, nil},
+		{"pass - custom key", FromMetadataWithConfig("X-Access-Token", ""), map[string][]string{"x-access-token": {referenceToken}}, nil},
+		{"fail - missing key", MetadataTokenExtractorFunc(FromMetadata), map[string][]string{}, ErrTokenNotFound},
+		{"fail - nil metadata", MetadataTokenExtractorFunc(FromMetadata), nil, ErrTokenNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := test.extractor.ExtractMetadata(test.md)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			claims := jwt.Claims{}
+			if err = token.Claims(defaultSecret, &claims); err != nil {
+				t.Errorf("Claims should be decoded correctly with default token: %q \n", err)
+			}
+		})
+	}
+}
+
+func TestFromHeaderParsing(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+	otherToken := getTestToken(defaultAudience, "other", time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name         string
+		headerValues []string
+		expectedErr  error
+	}{
+		{"pass - lower case scheme", []string{"bearer " + referenceToken}, nil},
+		{"pass - surrounding whitespace", []string{"  Bearer \\t " + referenceToken + " "}, nil},
+		{"pass - multiple values", []string{"Basic dXNlcjpwYXNz", "Bearer " + referenceToken}, nil},
+		{"pass - repeated token", []string{"Bearer " + referenceToken, "Bearer " + referenceToken}, nil},
+		{"fail - missing header", nil, ErrTokenNotFound},
+		{"fail - other scheme", []string{"Basic dXNlcjpwYXNz"}, ErrTokenNotFound},
+		{"fail - scheme without token", []string{"Bearer"}, ErrMalformedHeader},
+		{"fail - extra parts", []string{"Bearer " + referenceToken + " extra"}, ErrMalformedHeader},
+		{"fail - conflicting tokens", []string{"Bearer " + referenceToken, "Bearer " + otherToken}, ErrMalformedHeader},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("", "http://localhost", nil)
+			for _, value := range test.headerValues {
+				req.Header.Add("Authorization", strings.Replace(value, "\\t", "\t", -1))
+			}
+
+			token, err := FromHeader(req)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			claims := jwt.Claims{}
+			if err = token.Claims(defaultSecret, &claims); err != nil || claims.Issuer != defaultIssuer {
+				t.Errorf("Claims should be decoded correctly with default token: %v (%v)", claims, err)
+			}
+		})
+	}
+}
+
+func TestRawExtraction(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	headerTokenRequest, _ := http.NewRequest("", "http://localhost", nil)
+	headerTokenRequest.Header.Add("Authorization", "Bearer "+referenceToken)
+	paramTokenRequest, _ := http.NewRequest("", "http://localhost?token="+referenceToken, nil)
+
+	tests := []struct {
+		name        string
+		extractor   RequestTokenExtractor
+		req         *http.Request
+		expectedRaw string
+		expectedErr error
+	}{
+		{"pass - header", RawTokenExtractorFunc(FromHeaderRaw), headerTokenRequest, referenceToken, nil},
+		{"pass - multiple", FromMultiple(RawTokenExtractorFunc(FromHeaderRaw), RawTokenExtractorFunc(FromParamsRaw)), paramTokenRequest, referenceToken, nil},
+		{"fail - not found", RawTokenExtractorFunc(FromParamsRaw), headerTokenRequest, "", ErrTokenNotFound},
+		{"fail - parsing extractor", RequestTokenExtractorFunc(FromHeader), headerTokenRequest, "", ErrRawTokenUnsupported},
+		{"fail - multiple with parsing extractor", FromMultiple(RequestTokenExtractorFunc(FromHeader)), headerTokenRequest, "", ErrRawTokenUnsupported},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := ExtractRaw(test.extractor, test.req)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if raw != test.expectedRaw {
+				t.Errorf("expected raw token %q, got %q", test.expectedRaw, raw)
+			}
+		})
+	}
+
+	if fingerprint := TokenFingerprint(referenceToken); len(fingerprint) != 64 || fingerprint == TokenFingerprint("other") {
+		t.Errorf("unexpected token fingerprint: %q", fingerprint)
+	}
+}
+
+func TestFromMultipleWithPolicy(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	shadowedRequest, _ := http.NewRequest("", "http://localhost?token=broken", nil)
+	shadowedRequest.Header.Add("Authorization", "Bearer "+referenceToken)
+	emptyRequest, _ := http.NewRequest("", "http://localhost", nil)
+	brokenRequest, _ := http.NewRequest("", "http://localhost?token=broken", nil)
+
+	extractors := []RequestTokenExtractor{RawTokenExtractorFunc(FromParamsRaw), RawTokenExtractorFunc(FromHeaderRaw)}
+	_, parseErr := extractors[0].Extract(brokenRequest)
+
+	tests := []struct {
+		name        string
+		policy      MultipleExtractorPolicy
+		req         *http.Request
+		expectedErr error
+	}{
+		{name: "fail - skip not found stops on parse error", policy: SkipNotFound, req: shadowedRequest, expectedErr: parseErr},
+		{name: "pass - skip errors moves past parse error", policy: SkipErrors, req: shadowedRequest},
+		{name: "fail - nothing found", policy: SkipNotFound, req: emptyRequest, expectedErr: ErrTokenNotFound},
+		{name: "fail - skip errors finds nothing", policy: SkipErrors, req: emptyRequest, expectedErr: ErrTokenNotFound},
+		{name: "fail - skip errors aggregates every failure", policy: SkipErrors, req: brokenRequest, expectedErr: &ExtractionError{Errors: []error{parseErr, ErrTokenNotFound}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := FromMultipleWithPolicy(test.policy, extractors...).Extract(test.req)
+			assert.Equal(t, test.expectedErr, err)
+			if test.expectedErr == nil {
+				assert.NotNil(t, token)
+			}
+		})
+	}
+}
+
+func TestFromForwardedHeadersExtraction(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	extractor, err := FromForwardedHeaders([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		remoteAddr  string
+		header      string
+		expectedErr error
+	}{
+		{"pass - trusted range", "10.1.2.3:4567", "X-Forwarded-Authorization", nil},
+		{"pass - trusted address", "192.168.1.1:4567", "X-Original-Authorization", nil},
+		{"pass - trusted ipv6 address", "[::1]:4567", "X-Forwarded-Authorization", nil},
+		{"fail - untrusted source", "172.16.0.1:4567", "X-Forwarded-Authorization", ErrTokenNotFound},
+		{"fail - unknown header", "10.1.2.3:4567", "X-Forwarded-Token", ErrTokenNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("", "http://localhost", nil)
+			req.RemoteAddr = test.remoteAddr
+			req.Header.Add(test.header, "Bearer "+referenceToken)
+
+			raw, err := extractor.ExtractRaw(req)
+			assert.Equal(t, test.expectedErr, err)
+			if err == nil {
+				assert.Equal(t, referenceToken, raw)
+			}
+		})
+	}
+
+	_, err = FromForwardedHeaders([]string{"not an address"})
+	assert.Error(t, err)
+	_, err = FromForwardedHeaders([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestSplitFields(t *testing.T) {
+	tests := []struct {
+		value         string
+		first, second string
+		n             int
+	}{
+		{"", "", "", 0},
+		{" \t ", "", "", 0},
+		{"token", "token", "", 1},
+		{"Bearer  token ", "Bearer", "token", 2},
+		{"Bearer token extra", "Bearer", "token", 3},
+		{"Bearer\ttoken extra more", "Bearer", "token", 3},
+	}
+	for _, test := range tests {
+		first, second, n := splitFields(test.value)
+		assert.Equal(t, []interface{}{test.first, test.second, test.n}, []interface{}{first, second, n}, test.value)
+	}
+}
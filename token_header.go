@@ -0,0 +1,107 @@
+package auth0
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// ErrMalformedToken is returned when the header of a
+// compact serialized token cannot be decoded.
+var ErrMalformedToken = errors.New("malformed token")
+
+// TokenHeader is the JOSE header of a token, read without validating the token.
+type TokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+// PeekHeader reads the JOSE header of the compact serialized token without
+// verifying its signature nor its claims.
+//
+// The header is UNTRUSTED: anyone can forge it. Use it only for routing
+// decisions taken before the token is validated, e.g. mapping the key ID to
+// the tenant whose validator then validates the token, never to authorize
+// the request.
+func PeekHeader(token string) (TokenHeader, error) {
+	var header TokenHeader
+	i := strings.IndexByte(token, '.')
+	if i < 0 || strings.Count(token, ".") != 2 {
+		return header, ErrMalformedToken
+	}
+
+	// the segment and its decoded bytes share a pooled buffer, the header
+	// copying the strings it decodes
+	buf := getBuffer()
+	defer putBuffer(buf)
+	segment := token[:i]
+	buf.Grow(len(segment) + base64.RawURLEncoding.DecodedLen(len(segment)))
+	scratch := buf.Bytes()[:buf.Cap()]
+	src := scratch[:copy(scratch, segment)]
+	n, err := base64.RawURLEncoding.Decode(scratch[len(src):], src)
+	if err != nil {
+		return header, ErrMalformedToken
+	}
+	if err := json.Unmarshal(scratch[len(src):len(src)+n], &header); err != nil {
+		return header, ErrMalformedToken
+	}
+	return header, nil
+}
+
+// PeekRequestHeader reads the untrusted JOSE header of the token of the
+// request extracted with extractor, defaulting to the Authorization header.
+// See PeekHeader.
+func PeekRequestHeader(r *http.Request, extractor RequestTokenExtractor) (TokenHeader, error) {
+	if extractor == nil {
+		extractor = RawTokenExtractorFunc(FromHeaderRaw)
+	}
+	if raw, err := ExtractRaw(extractor, r); err != ErrRawTokenUnsupported {
+		if err != nil {
+			return TokenHeader{}, err
+		}
+		return PeekHeader(raw)
+	}
+
+	token, err := extractor.Extract(r)
+	if err != nil {
+		return TokenHeader{}, err
+	}
+	if len(token.Headers) < 1 {
+		return TokenHeader{}, ErrNoJWTHeaders
+	}
+	return headerOf(token.Headers[0]), nil
+}
+
+// headerOf returns the TokenHeader of a parsed header
+func headerOf(header jose.Header) TokenHeader {
+	typ, _ := header.ExtraHeaders[jose.HeaderType].(string)
+	return TokenHeader{Algorithm: header.Algorithm, KeyID: header.KeyID, Type: typ}
+}
+
+// requestKeyID returns the key ID of the token of the request, only decoding
+// the token header when the extractor provides the raw token
+func requestKeyID(extractor RequestTokenExtractor, r *http.Request) (string, error) {
+	raw, err := ExtractRaw(extractor, r)
+	if err == nil {
+		if header, err := PeekHeader(raw); err == nil {
+			return header.KeyID, nil
+		}
+	} else if err != ErrRawTokenUnsupported {
+		return "", err
+	}
+
+	// report the errors of the extractor parsing the token
+	token, err := extractor.Extract(r)
+	if err != nil {
+		return "", err
+	}
+	if len(token.Headers) < 1 {
+		return "", ErrNoJWTHeaders
+	}
+	return token.Headers[0].KeyID, nil
+}
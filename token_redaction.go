@@ -0,0 +1,100 @@
+package auth0
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// signatureHashLength is the number of hexadecimal digits of the truncated
+// signature hash of redacted tokens
+const signatureHashLength = 16
+
+// RedactedToken is the metadata of a token safe to log: neither the token
+// nor its claim values can be recovered from it.
+type RedactedToken struct {
+	// Header is the untrusted JOSE header of the token.
+	Header TokenHeader
+	// ClaimNames are the sorted names of the claims of the token,
+	// without their values.
+	ClaimNames []string
+	// SignatureHash is the truncated SHA-256 hash of the signature,
+	// telling tokens apart in the logs.
+	SignatureHash string
+}
+
+// RedactToken returns the metadata of the compact serialized token without
+// verifying it, e.g. to troubleshoot rejected tokens. It returns the metadata
+// decoded so far along with ErrMalformedToken when the token is malformed.
+func RedactToken(token string) (RedactedToken, error) {
+	var redacted RedactedToken
+	header, err := PeekHeader(token)
+	if err != nil {
+		return redacted, err
+	}
+	redacted.Header = header
+
+	segments := strings.Split(token, ".")
+	redacted.SignatureHash = TokenFingerprint(segments[2])[:signatureHashLength]
+
+	payload, err := base64.RawURLEncoding.DecodeString(segments[1])
+	if err != nil {
+		return redacted, ErrMalformedToken
+	}
+	var claims map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return redacted, ErrMalformedToken
+	}
+	redacted.ClaimNames = claimNames(claims)
+	return redacted, nil
+}
+
+// redactRequestToken returns the metadata of the token of the request, the
+// signature hash being unknown when the extractor does not provide the raw
+// token
+func redactRequestToken(extractor RequestTokenExtractor, r *http.Request) (RedactedToken, error) {
+	if raw, err := ExtractRaw(extractor, r); err != ErrRawTokenUnsupported {
+		if err != nil {
+			return RedactedToken{}, err
+		}
+		return RedactToken(raw)
+	}
+
+	token, err := extractor.Extract(r)
+	if err != nil {
+		return RedactedToken{}, err
+	}
+	if len(token.Headers) < 1 {
+		return RedactedToken{}, ErrNoJWTHeaders
+	}
+	redacted := RedactedToken{Header: headerOf(token.Headers[0])}
+	var claims map[string]json.RawMessage
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return redacted, err
+	}
+	redacted.ClaimNames = claimNames(claims)
+	return redacted, nil
+}
+
+// logValues returns the metadata as alternating keys and values for a Logger
+func (t RedactedToken) logValues() []interface{} {
+	return []interface{}{
+		"alg", t.Header.Algorithm,
+		"kid", t.Header.KeyID,
+		"typ", t.Header.Type,
+		"claims", strings.Join(t.ClaimNames, ","),
+		"signature_hash", t.SignatureHash,
+	}
+}
+
+// claimNames returns the sorted names of the claims
+func claimNames(claims map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,98 @@
+package auth0
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestRedactToken(t *testing.T) {
+	token := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret, "kid1")
+	signature := token[strings.LastIndexByte(token, '.')+1:]
+
+	tests := []struct {
+		name        string
+		token       string
+		expected    RedactedToken
+		expectedErr error
+	}{
+		{
+			name:  "pass",
+			token: token,
+			expected: RedactedToken{
+				Header:        TokenHeader{Algorithm: "HS256", KeyID: "kid1", Type: "JWT"},
+				ClaimNames:    []string{"aud", "exp", "iat", "iss"},
+				SignatureHash: TokenFingerprint(signature)[:signatureHashLength],
+			},
+		},
+		{
+			name:        "fail - malformed header",
+			token:       "header.payload.signature",
+			expectedErr: ErrMalformedToken,
+		},
+		{
+			name:  "fail - malformed payload",
+			token: token[:strings.IndexByte(token, '.')] + ".payload." + signature,
+			expected: RedactedToken{
+				Header:        TokenHeader{Algorithm: "HS256", KeyID: "kid1", Type: "JWT"},
+				SignatureHash: TokenFingerprint(signature)[:signatureHashLength],
+			},
+			expectedErr: ErrMalformedToken,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			redacted, err := RedactToken(test.token)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expected, redacted)
+		})
+	}
+}
+
+func TestValidatorDebugTokens(t *testing.T) {
+	token := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret, "kid1")
+	redacted, err := RedactToken(token)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		extractor RequestTokenExtractor
+		expected  string
+	}{
+		{
+			name:     "raw token",
+			expected: "alg=HS256 kid=kid1 typ=JWT claims=aud,exp,iat,iss signature_hash=" + redacted.SignatureHash,
+		},
+		{
+			name:      "parsed token",
+			extractor: RequestTokenExtractorFunc(FromHeader),
+			expected:  "alg=HS256 kid=kid1 typ=JWT claims=aud,exp,iat,iss signature_hash=",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+			validator := NewValidatorWithOptions(configuration, test.extractor, ValidatorOptions{Logger: logger, DebugTokens: true})
+			_, req := genTestConfiguration(configuration, token)
+			_, err := validator.ValidateRequest(req)
+			assert.Error(t, err)
+
+			lines := logger.lines()
+			if assert.Len(t, lines, 1) {
+				assert.True(t, strings.HasPrefix(lines[0], "debug auth0: token rejected reason=expired"), lines[0])
+				assert.True(t, strings.HasSuffix(lines[0], test.expected), lines[0])
+				assert.NotContains(t, lines[0], token[strings.LastIndexByte(token, '.')+1:])
+			}
+		})
+	}
+
+	logger := &recordingLogger{}
+	validator := NewValidatorWithOptions(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, ValidatorOptions{Logger: logger, DebugTokens: true})
+	validator.ValidateRequest(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"debug auth0: token rejected reason=token_not_found error=" + ErrTokenNotFound.Error() + " alg= kid= typ= claims= signature_hash="}, logger.lines())
+}
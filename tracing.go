@@ -0,0 +1,45 @@
+package auth0
+
+import "context"
+
+// Span names of the operations traced by validators and JWKS clients.
+const (
+	SpanValidateRequest = "auth0.ValidateRequest"
+	SpanGetSecret       = "auth0.JWKClient.GetSecret"
+	SpanDownloadKeys    = "auth0.JWKClient.downloadKeys"
+)
+
+// Span attributes set on the traced operations. Raw tokens are never
+// recorded.
+const (
+	AttributeIssuer    = "auth0.issuer"
+	AttributeKeyID     = "auth0.kid"
+	AttributeAlgorithm = "auth0.alg"
+	AttributeURI       = "auth0.jwks.uri"
+	AttributeOutcome   = "auth0.outcome"
+	AttributeReason    = "auth0.reason"
+)
+
+// Tracer starts the spans of the operations of validators and JWKS clients,
+// e.g. to trace them with OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced operation.
+type Span interface {
+	SetAttribute(key, value string)
+	// End ends the span of an operation failing with err, if not nil.
+	End(err error)
+}
+
+// endSpan records the outcome of the operation and ends span
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.SetAttribute(AttributeOutcome, "failure")
+		span.SetAttribute(AttributeReason, ErrorReason(err))
+	} else {
+		span.SetAttribute(AttributeOutcome, "success")
+	}
+	span.End(err)
+}
@@ -0,0 +1,91 @@
+package auth0
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+type parentKey struct{}
+
+// recordedSpan is a span recorded by recordingTracer
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]string
+	err        error
+	ended      bool
+}
+
+// recordingTracer records the spans it starts
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(parentKey{}).(string)
+	span := &recordedSpan{name: name, parent: parent, attributes: map[string]string{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, parentKey{}, name), span
+}
+
+func (s *recordedSpan) SetAttribute(key, value string) {
+	s.attributes[key] = value
+}
+
+func (s *recordedSpan) End(err error) {
+	s.err, s.ended = err, true
+}
+
+func TestTracer(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := &recordingTracer{}
+	opts.Tracer = tracer
+	configuration := NewConfiguration(NewJWKClient(opts, nil), defaultAudience, defaultIssuer, jose.RS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{Tracer: tracer})
+
+	_, req := genTestConfiguration(configuration, tokenRS256)
+	_, err = validator.ValidateRequest(req)
+	assert.NoError(t, err)
+	if !assert.Len(t, tracer.spans, 3) {
+		return
+	}
+
+	validation, getSecret, download := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	assert.Equal(t, SpanValidateRequest, validation.name)
+	assert.Equal(t, map[string]string{
+		AttributeIssuer:    defaultIssuer,
+		AttributeKeyID:     "keyRS256",
+		AttributeAlgorithm: "RS256",
+		AttributeOutcome:   "success",
+	}, validation.attributes)
+	assert.Equal(t, SpanGetSecret, getSecret.name)
+	assert.Equal(t, SpanValidateRequest, getSecret.parent)
+	assert.Equal(t, "keyRS256", getSecret.attributes[AttributeKeyID])
+	assert.Equal(t, SpanDownloadKeys, download.name)
+	assert.Equal(t, SpanGetSecret, download.parent)
+	assert.Equal(t, opts.URI, download.attributes[AttributeURI])
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended)
+		assert.NoError(t, span.err)
+	}
+
+	tracer.spans = nil
+	_, req = genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret))
+	_, err = validator.ValidateRequest(req)
+	if assert.Len(t, tracer.spans, 1) {
+		assert.Equal(t, err, tracer.spans[0].err)
+		assert.Equal(t, "failure", tracer.spans[0].attributes[AttributeOutcome])
+		assert.Equal(t, "invalid_algorithm", tracer.spans[0].attributes[AttributeReason])
+	}
+}
@@ -0,0 +1,69 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sync"
+)
+
+// TokenResult is the outcome of the validation
+// of one of the tokens of a batch.
+type TokenResult struct {
+	Result *ValidationResult
+	Err    error
+}
+
+// ValidateTokens validates the raw tokens concurrently, e.g. the tokens of
+// queued events, and returns their results in the order of tokens. At most
+// BatchConcurrency tokens, defaulting to GOMAXPROCS, are validated at once.
+// Tokens not validated yet when ctx is done fail with the error of ctx.
+//
+// Each token is validated as the Bearer token of the Authorization header of
+// a request carrying ctx, which secret providers read by default.
+func (v *JWTValidator) ValidateTokens(ctx context.Context, tokens []string) []TokenResult {
+	results := make([]TokenResult, len(tokens))
+	workers := v.batchConcurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(tokens) {
+		workers = len(tokens)
+	}
+
+	// the tokens are not read from the requests with the extractor of v
+	validator := *v
+	validator.extractor = RawTokenExtractorFunc(FromHeaderRaw)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = validator.validateToken(ctx, tokens[i])
+			}
+		}()
+	}
+	for i := range tokens {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// validateToken validates token as the Bearer token of a request carrying ctx
+func (v *JWTValidator) validateToken(ctx context.Context, token string) TokenResult {
+	if err := ctx.Err(); err != nil {
+		return TokenResult{Err: err}
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return TokenResult{Err: err}
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	result, err := v.Validate(r)
+	return TokenResult{Result: result, Err: err}
+}
@@ -0,0 +1,61 @@
+package auth0
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestValidateTokens(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	tokens := []string{
+		getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, defaultSecret),
+		getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, []byte("invalid secret")),
+		"not-a-token",
+		getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.HS256, defaultSecret, "kid"),
+	}
+
+	var running, maxRunning int32
+	backend := JOSEBackendFunc(func(token string, key interface{}, values ...interface{}) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return GoJoseV2Backend{}.VerifyClaims(token, key, values...)
+	})
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, RequestTokenExtractorFunc(FromHeader), ValidatorOptions{Backend: backend, BatchConcurrency: 2})
+
+	results := validator.ValidateTokens(context.Background(), tokens)
+	if !assert.Len(t, results, len(tokens)) {
+		return
+	}
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, defaultIssuer, results[0].Result.Claims.Issuer)
+	assert.ErrorIs(t, results[1].Err, ErrInvalidSignature)
+	assert.Error(t, results[2].Err)
+	assert.Nil(t, results[2].Result)
+	assert.NoError(t, results[3].Err)
+	assert.Equal(t, "kid", results[3].Result.KeyID)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+}
+
+func TestValidateTokensCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+
+	results := NewValidator(configuration, nil).ValidateTokens(ctx, []string{token, token})
+	assert.Equal(t, []TokenResult{{Err: context.Canceled}, {Err: context.Canceled}}, results)
+	assert.Empty(t, NewValidator(configuration, nil).ValidateTokens(context.Background(), nil))
+}
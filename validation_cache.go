@@ -0,0 +1,91 @@
+package auth0
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultResultCacheSize is the number of validation results cached
+// when the validator options do not bound it.
+const DefaultResultCacheSize = 10000
+
+// resultCache remembers the tokens successfully validated, keyed by the
+// SHA-256 of the raw token, so that a token seen again is not verified again
+// until the entry expires.
+type resultCache struct {
+	ttl   time.Duration
+	size  int
+	clock Clock
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]resultCacheEntry
+}
+
+type resultCacheEntry struct {
+	token     *jwt.JSONWebToken
+	expiresAt time.Time
+}
+
+// newResultCache returns nil when ttl is not positive,
+// nil caches never remembering results.
+func newResultCache(ttl time.Duration, size int, clock Clock) *resultCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if size <= 0 {
+		size = DefaultResultCacheSize
+	}
+	return &resultCache{ttl: ttl, size: size, clock: clock, entries: map[[sha256.Size]byte]resultCacheEntry{}}
+}
+
+// get returns the validated token of raw, unless its entry expired.
+// Empty raw tokens, of extractors not providing them, are never remembered.
+func (c *resultCache) get(raw string) (*jwt.JSONWebToken, bool) {
+	if c == nil || raw == "" {
+		return nil, false
+	}
+	sum := sha256.Sum256([]byte(raw))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[sum]
+	if ok && !c.clock.Now().Before(entry.expiresAt) {
+		delete(c.entries, sum)
+		return nil, false
+	}
+	return entry.token, ok
+}
+
+// add remembers the token validated from raw for the ttl, and no longer than
+// the token expiry. The expired entries are dropped when the cache is full,
+// the token not being remembered when it is still full.
+func (c *resultCache) add(raw string, token *jwt.JSONWebToken, expiry jwt.NumericDate) {
+	if c == nil || raw == "" {
+		return
+	}
+	now := c.clock.Now()
+	expiresAt := now.Add(c.ttl)
+	if expiry != 0 && expiry.Time().Before(expiresAt) {
+		expiresAt = expiry.Time()
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+
+	sum := sha256.Sum256([]byte(raw))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.size {
+			return
+		}
+	}
+	c.entries[sum] = resultCacheEntry{token: token, expiresAt: expiresAt}
+}
@@ -0,0 +1,87 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paulusrobin/go-auth0/internal/clocktest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestValidatorResultCache(t *testing.T) {
+	verifications := 0
+	backend := JOSEBackendFunc(func(token string, key interface{}, values ...interface{}) error {
+		verifications++
+		return GoJoseV2Backend{}.VerifyClaims(token, key, values...)
+	})
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, nil, ValidatorOptions{Backend: backend, ResultCacheTTL: time.Minute})
+	_, req := genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+
+	first, err := validator.ValidateRequest(req)
+	assert.NoError(t, err)
+	claims := map[string]interface{}{}
+	second, err := validator.ValidateRequestWithClaims(req, &claims)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, defaultIssuer, claims["iss"])
+	assert.Equal(t, 1, verifications)
+
+	_, req = genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("invalid secret")))
+	for i := 0; i < 2; i++ {
+		_, err = validator.ValidateRequest(req)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 3, verifications)
+}
+
+func TestValidatorResultCacheNotRaw(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidatorWithOptions(configuration, RequestTokenExtractorFunc(FromHeader), ValidatorOptions{ResultCacheTTL: time.Minute})
+	_, req := genTestConfiguration(configuration, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+
+	_, err := validator.ValidateRequest(req)
+	assert.NoError(t, err)
+	assert.Empty(t, validator.results.entries)
+}
+
+func TestResultCache(t *testing.T) {
+	token := &jwt.JSONWebToken{}
+	clock := clocktest.NewClock(time.Now())
+	cache := newResultCache(time.Hour, 2, clock)
+
+	cache.add("token", token, 0)
+	cached, ok := cache.get("token")
+	assert.True(t, ok)
+	assert.Same(t, token, cached)
+
+	// bounded by the token expiry
+	cache.add("expiring", token, jwt.NewNumericDate(time.Now().Add(-time.Second)))
+	_, ok = cache.get("expiring")
+	assert.False(t, ok)
+
+	// full
+	cache.add("other", token, 0)
+	cache.add("dropped", token, 0)
+	_, ok = cache.get("dropped")
+	assert.False(t, ok)
+
+	// expired entries are evicted
+	cache.entries = map[[32]byte]resultCacheEntry{}
+	cache.ttl = time.Minute
+	cache.add("expired", token, 0)
+	clock.Advance(time.Minute)
+	cache.ttl = time.Hour
+	cache.add("first", token, 0)
+	cache.add("second", token, 0)
+	_, ok = cache.get("second")
+	assert.True(t, ok)
+
+	var disabled *resultCache
+	disabled.add("token", token, 0)
+	_, ok = disabled.get("token")
+	assert.False(t, ok)
+	assert.Nil(t, newResultCache(0, 0, SystemClock))
+}
@@ -0,0 +1,110 @@
+package auth0
+
+import (
+	"errors"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Reason is the machine-readable reason of a validation failure. Reasons are
+// the labels returned by ErrorReason, suited to metrics and client responses.
+type Reason string
+
+// Reasons of the validation failures.
+const (
+	ReasonExpiredToken      Reason = "expired"
+	ReasonTokenNotValidYet  Reason = "not_valid_yet"
+	ReasonInvalidAudience   Reason = "invalid_audience"
+	ReasonInvalidIssuer     Reason = "invalid_issuer"
+	ReasonInvalidSubject    Reason = "invalid_subject"
+	ReasonInvalidTokenID    Reason = "invalid_token_id"
+	ReasonInvalidAlgorithm  Reason = "invalid_algorithm"
+	ReasonUnknownKeyID      Reason = "key_not_found"
+	ReasonSignatureMismatch Reason = "invalid_signature"
+	ReasonMissingClaim      Reason = "missing_claim"
+	ReasonPolicyDenied      Reason = "policy_denied"
+)
+
+// ValidationError is the error of a token failing the validation, along with
+// the offending claim or header parameter and the context of the validation.
+// It wraps the error of the failure, e.g. ErrTokenExpired, which errors.Is
+// still matches.
+//
+// Failures that are not caused by the token, e.g. the JWKS being
+// unavailable, are reported with a ValidationError too, without offending
+// claim, to keep the context of the validation.
+type ValidationError struct {
+	// Reason is the reason of the failure, the ErrorReason of the error for
+	// failures without offending claim, e.g. "jwks_unavailable".
+	Reason Reason
+	// Claim is the name of the offending claim or header parameter,
+	// e.g. "aud" or "kid".
+	Claim string
+	// Value is the value of the offending claim in the token, e.g. the
+	// []string audience of the token or its expiry time.
+	Value interface{}
+	// Expected is the expected value of the claim when configured, e.g. the
+	// expected audience, or the validation time for time based claims.
+	Expected interface{}
+	// KeyID is the key ID of the token (kid header parameter).
+	KeyID string
+	// Issuer is the issuer expected by the validator.
+	Issuer string
+	// Audience is the audience expected by the validator.
+	Audience []string
+	// Err is the error of the failure.
+	Err error
+}
+
+// Error returns the message of the error of the failure.
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the failure.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// newValidationError returns err as a ValidationError of the token of the
+// header and claims, with the offending claim when it is a known failure
+func newValidationError(err error, header jose.Header, claims jwt.Claims, expected jwt.Expected) *ValidationError {
+	validationErr := &ValidationError{
+		KeyID:    header.KeyID,
+		Issuer:   expected.Issuer,
+		Audience: []string(expected.Audience),
+		Err:      err,
+	}
+	switch {
+	case errors.Is(err, ErrInvalidAlgorithm):
+		validationErr.Reason, validationErr.Claim, validationErr.Value = ReasonInvalidAlgorithm, "alg", header.Algorithm
+	case errors.Is(err, ErrTokenExpired):
+		validationErr.Reason, validationErr.Claim = ReasonExpiredToken, "exp"
+		validationErr.Value, validationErr.Expected = claims.Expiry.Time(), expected.Time
+	case errors.Is(err, ErrTokenNotValidYet):
+		validationErr.Reason, validationErr.Claim = ReasonTokenNotValidYet, "nbf"
+		validationErr.Value, validationErr.Expected = claims.NotBefore.Time(), expected.Time
+	case errors.Is(err, ErrInvalidAudience):
+		validationErr.Reason, validationErr.Claim = ReasonInvalidAudience, "aud"
+		validationErr.Value, validationErr.Expected = []string(claims.Audience), []string(expected.Audience)
+	case errors.Is(err, ErrInvalidIssuer):
+		validationErr.Reason, validationErr.Claim = ReasonInvalidIssuer, "iss"
+		validationErr.Value, validationErr.Expected = claims.Issuer, expected.Issuer
+	case errors.Is(err, ErrInvalidSubject):
+		validationErr.Reason, validationErr.Claim = ReasonInvalidSubject, "sub"
+		validationErr.Value, validationErr.Expected = claims.Subject, expected.Subject
+	case errors.Is(err, ErrInvalidTokenID):
+		validationErr.Reason, validationErr.Claim = ReasonInvalidTokenID, "jti"
+		validationErr.Value, validationErr.Expected = claims.ID, expected.ID
+	case errors.Is(err, ErrInvalidSignature):
+		validationErr.Reason, validationErr.Claim, validationErr.Value = ReasonSignatureMismatch, "kid", header.KeyID
+	case errors.Is(err, ErrMissingClaim):
+		validationErr.Reason = ReasonMissingClaim
+	case errors.Is(err, ErrNoKeyFound):
+		validationErr.Reason, validationErr.Claim, validationErr.Value = ReasonUnknownKeyID, "kid", header.KeyID
+	default:
+		validationErr.Reason = Reason(ErrorReason(err))
+	}
+	return validationErr
+}
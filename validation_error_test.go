@@ -0,0 +1,146 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestValidationError(t *testing.T) {
+	expiry := time.Now().Add(-time.Hour).Truncate(time.Second)
+	secrets := map[string][]byte{"key1": defaultSecret}
+	tests := []struct {
+		name             string
+		provider         SecretProvider
+		token            string
+		expectedErr      error
+		expectedReason   Reason
+		expectedClaim    string
+		expectedValue    interface{}
+		expectedExpected interface{}
+	}{
+		{
+			name:           "expired",
+			token:          getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, defaultSecret),
+			expectedErr:    ErrTokenExpired,
+			expectedReason: ReasonExpiredToken,
+			expectedClaim:  "exp",
+			expectedValue:  expiry,
+		},
+		{
+			name:             "audience",
+			token:            getTestToken([]string{"other"}, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+			expectedErr:      ErrInvalidAudience,
+			expectedReason:   ReasonInvalidAudience,
+			expectedClaim:    "aud",
+			expectedValue:    []string{"other"},
+			expectedExpected: []string(defaultAudience),
+		},
+		{
+			name:             "issuer",
+			token:            getTestToken(defaultAudience, "other", time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+			expectedErr:      ErrInvalidIssuer,
+			expectedReason:   ReasonInvalidIssuer,
+			expectedClaim:    "iss",
+			expectedValue:    "other",
+			expectedExpected: defaultIssuer,
+		},
+		{
+			name:             "algorithm",
+			token:            getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS384, defaultSecret),
+			expectedErr:      ErrInvalidAlgorithm,
+			expectedReason:   ReasonInvalidAlgorithm,
+			expectedClaim:    "alg",
+			expectedValue:    "HS384",
+			expectedExpected: "HS256",
+		},
+		{
+			name:           "unknown key ID",
+			provider:       NewHMACKeyIDProvider(secrets),
+			token:          getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret, "key2"),
+			expectedErr:    ErrNoKeyFound,
+			expectedReason: ReasonUnknownKeyID,
+			expectedClaim:  "kid",
+			expectedValue:  "key2",
+		},
+		{
+			name:           "signature",
+			provider:       NewHMACKeyIDProvider(secrets),
+			token:          getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("other"), "key1"),
+			expectedErr:    ErrInvalidSignature,
+			expectedReason: ReasonSignatureMismatch,
+			expectedClaim:  "kid",
+			expectedValue:  "key1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := test.provider
+			if provider == nil {
+				provider = defaultSecretProvider
+			}
+			validator, req := genTestConfiguration(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), test.token)
+			_, err := validator.ValidateRequest(req)
+
+			var validationErr *ValidationError
+			if !assert.True(t, errors.As(err, &validationErr), err) {
+				return
+			}
+			assert.ErrorIs(t, err, test.expectedErr)
+			assert.Equal(t, test.expectedReason, validationErr.Reason)
+			assert.Equal(t, string(test.expectedReason), ErrorReason(err))
+			assert.Equal(t, test.expectedClaim, validationErr.Claim)
+			if expected, ok := validationErr.Expected.(time.Time); ok {
+				// time based claims are checked at the validation time
+				assert.WithinDuration(t, time.Now(), expected, time.Minute)
+				assert.True(t, test.expectedValue.(time.Time).Equal(validationErr.Value.(time.Time)))
+				return
+			}
+			assert.Equal(t, test.expectedValue, validationErr.Value)
+			assert.Equal(t, test.expectedExpected, validationErr.Expected)
+		})
+	}
+}
+
+func TestValidationErrorUnknownFailure(t *testing.T) {
+	validator, req := genTestConfiguration(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), "")
+	_, err := validator.ValidateRequest(req)
+
+	var validationErr *ValidationError
+	assert.False(t, errors.As(err, &validationErr), err)
+	assert.Error(t, err)
+}
+
+func TestValidationErrorContext(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	client := NewJWKClient(JWKClientOptions{URI: unavailable.URL}, nil)
+	configuration := NewConfiguration(client, defaultAudience, defaultIssuer, jose.RS256)
+	token := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, genRSASSAJWK(jose.RS256, "keyRS256"), "keyRS256")
+	validator, req := genTestConfiguration(configuration, token)
+	_, err := validator.ValidateRequest(req)
+
+	var validationErr *ValidationError
+	if !assert.True(t, errors.As(err, &validationErr), err) {
+		return
+	}
+	assert.Equal(t, Reason("jwks_status"), validationErr.Reason)
+	assert.Empty(t, validationErr.Claim)
+	assert.Equal(t, "keyRS256", validationErr.KeyID)
+	assert.Equal(t, defaultIssuer, validationErr.Issuer)
+	assert.Equal(t, []string(defaultAudience), validationErr.Audience)
+
+	var jwksErr *JWKSError
+	if assert.True(t, errors.As(err, &jwksErr), err) {
+		assert.Equal(t, unavailable.URL, jwksErr.URI)
+		assert.Equal(t, "keyRS256", jwksErr.KeyID)
+	}
+}
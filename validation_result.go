@@ -0,0 +1,55 @@
+package auth0
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// registeredClaims are the claim names of jwt.Claims
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// ValidationResult is the validated token of a request
+// with its decoded claims.
+type ValidationResult struct {
+	Token *jwt.JSONWebToken
+	// Claims are the registered claims of the token.
+	Claims jwt.Claims
+	// CustomClaims are the other claims of the token, left encoded to be
+	// unmarshalled into the types of the caller.
+	CustomClaims map[string]json.RawMessage
+	// KeyID and Algorithm are the key ID and the signature
+	// algorithm of the token header.
+	KeyID     string
+	Algorithm string
+	// Expiry is the expiration time of the token, zero when the token
+	// does not expire.
+	Expiry time.Time
+	// TTL is the remaining lifetime of the token when validated,
+	// zero when the token does not expire.
+	TTL time.Duration
+}
+
+// Validate validates the token within the http request,
+// returning the token with its decoded claims.
+func (v *JWTValidator) Validate(r *http.Request) (*ValidationResult, error) {
+	result := &ValidationResult{}
+	token, err := v.ValidateRequestWithClaims(r, &result.Claims, &result.CustomClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range registeredClaims {
+		delete(result.CustomClaims, name)
+	}
+	result.Token = token
+	result.KeyID = token.Headers[0].KeyID
+	result.Algorithm = token.Headers[0].Algorithm
+	if result.Claims.Expiry != 0 {
+		result.Expiry = result.Claims.Expiry.Time()
+		result.TTL = result.Expiry.Sub(v.clock.Now())
+	}
+	return result, nil
+}
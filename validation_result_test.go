@@ -0,0 +1,52 @@
+package auth0
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestValidate(t *testing.T) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "kid"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	token, err := jwt.Signed(signer).
+		Claims(jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Subject: "user", Expiry: jwt.NewNumericDate(expiry)}).
+		Claims(map[string]interface{}{"scope": "read:news"}).
+		CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator, req := genTestConfiguration(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), token)
+	result, err := validator.Validate(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotNil(t, result.Token)
+	assert.Equal(t, "user", result.Claims.Subject)
+	assert.Equal(t, map[string]json.RawMessage{"scope": json.RawMessage(`"read:news"`)}, result.CustomClaims)
+	assert.Equal(t, "kid", result.KeyID)
+	assert.Equal(t, string(jose.HS256), result.Algorithm)
+	assert.True(t, expiry.Equal(result.Expiry))
+	assert.InDelta(t, time.Hour, result.TTL, float64(time.Minute))
+}
+
+func TestValidateErrors(t *testing.T) {
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret)
+	validator, req := genTestConfiguration(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), token)
+
+	result, err := validator.Validate(req)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	assert.ErrorIs(t, err, jwt.ErrExpired)
+	assert.Nil(t, result)
+}
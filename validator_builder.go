@@ -0,0 +1,173 @@
+package auth0
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ErrNoSecretProvider is returned when building
+// a validator without secret provider.
+var ErrNoSecretProvider = errors.New("no secret provider configured")
+
+// ValidatorBuilder builds a JWTValidator step by step. The validator copies
+// everything it is built with, so it is not affected by later changes to the
+// builder, nor to the slices given to it.
+type ValidatorBuilder struct {
+	provider  SecretProvider
+	audience  []string
+	issuer    string
+	algorithm jose.SignatureAlgorithm
+	extractor RequestTokenExtractor
+	options   ValidatorOptions
+}
+
+// NewValidatorBuilder creates a builder of validators
+// verifying tokens with the keys of provider.
+func NewValidatorBuilder(provider SecretProvider) *ValidatorBuilder {
+	return &ValidatorBuilder{provider: provider}
+}
+
+// WithAudience sets the audiences the token must be intended for.
+func (b *ValidatorBuilder) WithAudience(audience ...string) *ValidatorBuilder {
+	b.audience = append([]string(nil), audience...)
+	return b
+}
+
+// WithIssuer sets the expected issuer of the token.
+func (b *ValidatorBuilder) WithIssuer(issuer string) *ValidatorBuilder {
+	b.issuer = issuer
+	return b
+}
+
+// WithAlgorithm sets the signature algorithm of the token. Without it, the
+// algorithm is not checked, the keys of the provider being trusted.
+func (b *ValidatorBuilder) WithAlgorithm(algorithm jose.SignatureAlgorithm) *ValidatorBuilder {
+	b.algorithm = algorithm
+	return b
+}
+
+// WithAudiencePatterns accepts the tokens with an audience matching one of
+// the patterns, e.g. "https://api.example.com/*". See ValidatorOptions.
+func (b *ValidatorBuilder) WithAudiencePatterns(patterns ...string) *ValidatorBuilder {
+	b.options.AudiencePatterns = append([]string(nil), patterns...)
+	return b
+}
+
+// WithAllowedAlgorithms sets the signature algorithms the token may be
+// signed with.
+func (b *ValidatorBuilder) WithAllowedAlgorithms(algorithms ...jose.SignatureAlgorithm) *ValidatorBuilder {
+	b.options.Algorithms = append([]jose.SignatureAlgorithm(nil), algorithms...)
+	return b
+}
+
+// WithLeeway sets the clock skew tolerated when validating the time based
+// claims, negative for none.
+func (b *ValidatorBuilder) WithLeeway(leeway time.Duration) *ValidatorBuilder {
+	b.options.Leeway = leeway
+	return b
+}
+
+// WithRequiredClaims sets the names of the claims the token must have.
+func (b *ValidatorBuilder) WithRequiredClaims(names ...string) *ValidatorBuilder {
+	b.options.RequiredClaims = append([]string(nil), names...)
+	return b
+}
+
+// WithExtractor sets the extractor reading the token of the requests.
+func (b *ValidatorBuilder) WithExtractor(extractor RequestTokenExtractor) *ValidatorBuilder {
+	b.extractor = extractor
+	return b
+}
+
+// WithBackend sets the JOSE backend verifying the tokens.
+func (b *ValidatorBuilder) WithBackend(backend JOSEBackend) *ValidatorBuilder {
+	b.options.Backend = backend
+	return b
+}
+
+// WithJSONCodec sets the codec unmarshalling the claims of the tokens.
+func (b *ValidatorBuilder) WithJSONCodec(codec JSONCodec) *ValidatorBuilder {
+	b.options.JSONCodec = codec
+	return b
+}
+
+// WithPolicy sets the policy authorizing the valid tokens.
+func (b *ValidatorBuilder) WithPolicy(policy Policy) *ValidatorBuilder {
+	b.options.Policy = policy
+	return b
+}
+
+// WithResultCache remembers the validated tokens for ttl,
+// at most size of them. See ValidatorOptions.
+func (b *ValidatorBuilder) WithResultCache(ttl time.Duration, size int) *ValidatorBuilder {
+	b.options.ResultCacheTTL = ttl
+	b.options.ResultCacheSize = size
+	return b
+}
+
+// WithBatchConcurrency bounds the tokens validated at once by ValidateTokens.
+func (b *ValidatorBuilder) WithBatchConcurrency(concurrency int) *ValidatorBuilder {
+	b.options.BatchConcurrency = concurrency
+	return b
+}
+
+// WithMetrics records the outcome and the duration of every validation.
+func (b *ValidatorBuilder) WithMetrics(metrics ValidatorMetrics) *ValidatorBuilder {
+	b.options.Metrics = metrics
+	return b
+}
+
+// WithTracer traces every validation.
+func (b *ValidatorBuilder) WithTracer(tracer Tracer) *ValidatorBuilder {
+	b.options.Tracer = tracer
+	return b
+}
+
+// WithLogger logs the rejected tokens.
+func (b *ValidatorBuilder) WithLogger(logger Logger) *ValidatorBuilder {
+	b.options.Logger = logger
+	return b
+}
+
+// OnValidationSuccess calls hook with the claims of every valid token.
+func (b *ValidatorBuilder) OnValidationSuccess(hook func(claims jwt.Claims)) *ValidatorBuilder {
+	b.options.OnValidationSuccess = hook
+	return b
+}
+
+// OnValidationFailure calls hook with the error and the reason of every
+// rejected token.
+func (b *ValidatorBuilder) OnValidationFailure(hook func(err error, reason string)) *ValidatorBuilder {
+	b.options.OnValidationFailure = hook
+	return b
+}
+
+// WithAuditSink writes the record of every validation decision to sink.
+func (b *ValidatorBuilder) WithAuditSink(sink AuditSink) *ValidatorBuilder {
+	b.options.AuditSink = sink
+	return b
+}
+
+// WithDebugTokens logs the redacted metadata of the rejected tokens.
+func (b *ValidatorBuilder) WithDebugTokens() *ValidatorBuilder {
+	b.options.DebugTokens = true
+	return b
+}
+
+// WithClock sets the clock telling the time the tokens are validated at.
+func (b *ValidatorBuilder) WithClock(clock Clock) *ValidatorBuilder {
+	b.options.Clock = clock
+	return b
+}
+
+// Build returns the validator, or ErrNoSecretProvider without provider.
+func (b *ValidatorBuilder) Build() (*JWTValidator, error) {
+	if b.provider == nil {
+		return nil, ErrNoSecretProvider
+	}
+	config := NewConfiguration(b.provider, b.audience, b.issuer, b.algorithm)
+	return NewValidatorWithOptions(config, b.extractor, b.options), nil
+}
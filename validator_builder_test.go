@@ -0,0 +1,55 @@
+package auth0
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestValidatorBuilder(t *testing.T) {
+	audience := []string{defaultAudience[0]}
+	builder := NewValidatorBuilder(defaultSecretProvider).
+		WithAudience(audience...).
+		WithIssuer(defaultIssuer).
+		WithAlgorithm(jose.HS256).
+		WithExtractor(RawTokenExtractorFunc(FromHeaderRaw)).
+		WithBackend(GoJoseV2Backend{}).
+		WithResultCache(time.Minute, 10).
+		WithBatchConcurrency(4)
+	validator, err := builder.Build()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, validator.batchConcurrency)
+	assert.NotNil(t, validator.results)
+
+	// later changes do not affect the validator
+	audience[0] = "changed"
+	builder.WithIssuer("changed")
+
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	_, req := genTestConfiguration(validator.config, token)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := validator.ValidateRequest(req)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	token = getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS384, defaultSecret)
+	_, req = genTestConfiguration(validator.config, token)
+	_, err = validator.ValidateRequest(req)
+	assert.ErrorIs(t, err, ErrInvalidAlgorithm)
+}
+
+func TestValidatorBuilderErrors(t *testing.T) {
+	_, err := NewValidatorBuilder(nil).Build()
+	assert.Equal(t, ErrNoSecretProvider, err)
+}
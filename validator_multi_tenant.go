@@ -0,0 +1,347 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	// ErrUnknownIssuer is returned by the MultiTenantValidator when the
+	// token is not issued by one of its tenants.
+	ErrUnknownIssuer = errors.New("unknown token issuer (iss)")
+	// ErrNoTenantIssuer is returned when configuring a tenant without
+	// issuer.
+	ErrNoTenantIssuer = errors.New("tenant without issuer")
+	// ErrDuplicateTenant is returned when configuring two tenants of the
+	// same issuer.
+	ErrDuplicateTenant = errors.New("duplicate tenant issuer")
+)
+
+// TenantConfig configures a tenant of a MultiTenantValidator. Its settings
+// left empty default to the ones of the MultiTenantOptions.
+type TenantConfig struct {
+	// Issuer is the issuer of the tokens of the tenant, e.g.
+	// "https://tenant.auth0.com/", matched exactly against the iss claim.
+	Issuer string
+	// JWKSURI is the URI of the JWKS of the tenant.
+	// Defaults to the .well-known/jwks.json of the issuer.
+	JWKSURI string
+	// Audience are the audiences the tokens of the tenant must be
+	// intended for.
+	Audience []string
+	// Algorithms are the signature algorithms the tokens of the tenant may
+	// be signed with.
+	Algorithms []jose.SignatureAlgorithm
+	// Leeway is the clock skew tolerated when validating the time based
+	// claims of the tokens of the tenant, negative for none.
+	Leeway time.Duration
+	// RequiredClaims are the names of the claims the tokens of the tenant
+	// must have.
+	RequiredClaims []string
+}
+
+// jwksURI returns the JWKS URI of the tenant
+func (c TenantConfig) jwksURI() string {
+	if c.JWKSURI != "" {
+		return c.JWKSURI
+	}
+	return strings.TrimSuffix(c.Issuer, "/") + "/.well-known/jwks.json"
+}
+
+// MultiTenantOptions configures a MultiTenantValidator.
+type MultiTenantOptions struct {
+	// Tenants are the tenants whose tokens are accepted.
+	Tenants []TenantConfig
+	// Audience is the audience of the tenants without audience.
+	Audience []string
+	// JWKClient configures the JWKClients of the tenants, their URI being
+	// the JWKS URI of their tenant. The tenants share its Client, built
+	// once from its Transport when not set.
+	JWKClient JWKClientOptions
+	// KeyCacher caches the keys of all the tenants, keyed by JWKS URI with
+	// a KeyCacheRegistry. Defaults to an unbounded memory key cacher, which
+	// keeps the keys of the removed tenants: bound it when tenants come
+	// and go.
+	KeyCacher KeyCacher
+	// Validator configures the validators of the tenants. Its Algorithms,
+	// Leeway and RequiredClaims apply to the tenants without them.
+	Validator ValidatorOptions
+	// AllowedIssuers are issuers whose tenants are set up on their first
+	// token, with the JWKS URI of their OpenID configuration and the
+	// settings of the options. A discovery is bounded by the FetchTimeout
+	// of the JWKClient options, 10 seconds when not set.
+	AllowedIssuers []string
+	// DiscoveryRetryInterval is how long a failed discovery is remembered,
+	// rejecting the tokens of its issuer without discovering it again.
+	// Defaults to DefaultDiscoveryRetryInterval.
+	DiscoveryRetryInterval time.Duration
+}
+
+// MultiTenantValidator validates the tokens of several Auth0 tenants, e.g.
+// for an API serving many customers, each with its own tenant. The token is
+// routed by its iss claim to the validator of its tenant, which then
+// verifies it against the JWKS, the issuer and the audience of the tenant.
+// Tokens of other issuers are rejected with ErrUnknownIssuer without
+// downloading anything, except for the AllowedIssuers, whose tenants are
+// discovered on their first token.
+//
+// Tenants are added and removed at runtime with AddTenant and RemoveTenant,
+// e.g. when customers are onboarded. It is safe for concurrent use by
+// multiple goroutines.
+type MultiTenantValidator struct {
+	extractor RequestTokenExtractor
+	options   MultiTenantOptions
+	registry  *KeyCacheRegistry
+	clock     Clock
+	// tenants holds the map[string]*tenant of the tenants by issuer,
+	// swapped at once when a tenant is added or removed
+	tenants atomic.Value
+	// tenantsMu serializes the swaps of tenants
+	tenantsMu sync.Mutex
+
+	allowed     map[string]bool
+	discoveryMu sync.Mutex
+	discoveries map[string]*discovery
+}
+
+// tenant is a tenant of a MultiTenantValidator
+type tenant struct {
+	client    *JWKClient
+	validator *JWTValidator
+}
+
+// NewMultiTenantValidator creates a validator of the tokens of the tenants,
+// extracted from the requests with extractor, defaulting to the
+// Authorization header.
+func NewMultiTenantValidator(options MultiTenantOptions, extractor RequestTokenExtractor) (*MultiTenantValidator, error) {
+	if extractor == nil {
+		extractor = RawTokenExtractorFunc(FromHeaderRaw)
+	}
+	if options.JWKClient.Client == nil {
+		options.JWKClient.Client = options.JWKClient.Transport.newClient()
+	}
+	if options.KeyCacher == nil {
+		options.KeyCacher = newMemoryPersistentKeyCacher()
+	}
+	if options.DiscoveryRetryInterval <= 0 {
+		options.DiscoveryRetryInterval = DefaultDiscoveryRetryInterval
+	}
+	m := &MultiTenantValidator{
+		extractor:   extractor,
+		options:     options,
+		registry:    NewKeyCacheRegistry(options.KeyCacher),
+		clock:       clockOrSystem(options.JWKClient.Clock),
+		allowed:     make(map[string]bool, len(options.AllowedIssuers)),
+		discoveries: map[string]*discovery{},
+	}
+	for _, issuer := range options.AllowedIssuers {
+		m.allowed[issuer] = true
+	}
+	tenants := make(map[string]*tenant, len(options.Tenants))
+	for _, config := range options.Tenants {
+		if config.Issuer == "" {
+			return nil, ErrNoTenantIssuer
+		}
+		if _, ok := tenants[config.Issuer]; ok {
+			return nil, ErrDuplicateTenant
+		}
+		tenants[config.Issuer] = m.newTenant(config)
+	}
+	m.tenants.Store(tenants)
+	return m, nil
+}
+
+// newTenant creates the JWKClient and the validator of the tenant, sharing
+// the key cacher and the http.Client of the other tenants
+func (m *MultiTenantValidator) newTenant(config TenantConfig) *tenant {
+	clientOptions := m.options.JWKClient
+	clientOptions.URI = config.jwksURI()
+	client := NewJWKClientWithCache(clientOptions, m.extractor, m.registry.KeyCacher(clientOptions.URI))
+
+	audience := config.Audience
+	if len(audience) == 0 {
+		audience = m.options.Audience
+	}
+	options := m.options.Validator
+	if len(config.Algorithms) > 0 {
+		options.Algorithms = config.Algorithms
+	}
+	if config.Leeway != 0 {
+		options.Leeway = config.Leeway
+	}
+	if len(config.RequiredClaims) > 0 {
+		options.RequiredClaims = config.RequiredClaims
+	}
+	configuration := NewConfigurationTrustProvider(client, audience, config.Issuer)
+	return &tenant{
+		client:    client,
+		validator: NewValidatorWithOptions(configuration, m.extractor, options),
+	}
+}
+
+// loadTenants returns the current tenants by issuer, which must not be
+// modified
+func (m *MultiTenantValidator) loadTenants() map[string]*tenant {
+	return m.tenants.Load().(map[string]*tenant)
+}
+
+// updateTenants replaces the tenants with a copy of the current ones
+// modified by update
+func (m *MultiTenantValidator) updateTenants(update func(tenants map[string]*tenant)) {
+	m.tenantsMu.Lock()
+	defer m.tenantsMu.Unlock()
+	current := m.loadTenants()
+	tenants := make(map[string]*tenant, len(current)+1)
+	for issuer, t := range current {
+		tenants[issuer] = t
+	}
+	update(tenants)
+	m.tenants.Store(tenants)
+}
+
+// AddTenant adds the tenant, whose tokens are accepted from then on. It
+// replaces the tenant of the same issuer, if any, the validations in
+// progress completing with the previous configuration.
+func (m *MultiTenantValidator) AddTenant(config TenantConfig) error {
+	if config.Issuer == "" {
+		return ErrNoTenantIssuer
+	}
+	replacement := m.newTenant(config)
+	var previous *tenant
+	m.updateTenants(func(tenants map[string]*tenant) {
+		previous = tenants[config.Issuer]
+		tenants[config.Issuer] = replacement
+	})
+	if previous != nil {
+		previous.client.Close()
+	}
+	return nil
+}
+
+// RemoveTenant removes the tenant of the issuer, whose tokens are rejected
+// with ErrUnknownIssuer from then on, and stops the background refresh of
+// its JWKClient. It reports whether the tenant was removed.
+func (m *MultiTenantValidator) RemoveTenant(issuer string) bool {
+	if _, ok := m.loadTenants()[issuer]; !ok {
+		return false
+	}
+	var previous *tenant
+	m.updateTenants(func(tenants map[string]*tenant) {
+		previous = tenants[issuer]
+		delete(tenants, issuer)
+	})
+	if previous == nil {
+		return false
+	}
+	previous.client.Close()
+	return true
+}
+
+// Issuers returns the issuers of the tenants, sorted.
+func (m *MultiTenantValidator) Issuers() []string {
+	tenants := m.loadTenants()
+	issuers := make([]string, 0, len(tenants))
+	for issuer := range tenants {
+		issuers = append(issuers, issuer)
+	}
+	sort.Strings(issuers)
+	return issuers
+}
+
+// Tenant returns the validator of the tenant of the issuer, if any.
+func (m *MultiTenantValidator) Tenant(issuer string) (*JWTValidator, bool) {
+	t, ok := m.loadTenants()[issuer]
+	if !ok {
+		return nil, false
+	}
+	return t.validator, true
+}
+
+// ValidateRequest validates the token within the http request with the
+// validator of its issuer.
+func (m *MultiTenantValidator) ValidateRequest(r *http.Request) (*jwt.JSONWebToken, error) {
+	return m.ValidateRequestWithClaims(r)
+}
+
+// ValidateRequestWithClaims validates the token within the http request with
+// the validator of its issuer, and unmarshalls its claims into values.
+func (m *MultiTenantValidator) ValidateRequestWithClaims(r *http.Request, values ...interface{}) (*jwt.JSONWebToken, error) {
+	validator, err := m.route(r)
+	if err != nil {
+		return nil, err
+	}
+	return validator.ValidateRequestWithClaims(r, values...)
+}
+
+// Validate validates the token within the http request with the validator
+// of its issuer, returning the token with its decoded claims.
+func (m *MultiTenantValidator) Validate(r *http.Request) (*ValidationResult, error) {
+	validator, err := m.route(r)
+	if err != nil {
+		return nil, err
+	}
+	return validator.Validate(r)
+}
+
+// route returns the validator of the issuer of the token of the request.
+// The tokens failing the routing are reported to the metrics, the logger
+// and the failure hook of the options, as the validators of the tenants
+// would.
+func (m *MultiTenantValidator) route(r *http.Request) (*JWTValidator, error) {
+	start := time.Now()
+	issuer, err := unverifiedIssuer(m.extractor, r)
+	if err == nil {
+		t, ok := m.loadTenants()[issuer]
+		switch {
+		case ok:
+			return t.validator, nil
+		case m.allowed[issuer]:
+			if t, err = m.discoverTenant(r.Context(), issuer); err == nil {
+				return t.validator, nil
+			}
+		default:
+			err = unknownIssuerError(issuer)
+		}
+	}
+	if m.options.Validator.Metrics != nil {
+		m.options.Validator.Metrics.ObserveValidation(err, time.Since(start))
+	}
+	if m.options.Validator.Logger != nil {
+		m.options.Validator.Logger.Debug("auth0: token rejected", "reason", ErrorReason(err), "error", err)
+	}
+	if m.options.Validator.OnValidationFailure != nil {
+		m.options.Validator.OnValidationFailure(err, ErrorReason(err))
+	}
+	return nil, err
+}
+
+// unknownIssuerError returns the error of a token of an issuer which is not
+// a tenant
+func unknownIssuerError(issuer string) error {
+	return &ValidationError{Reason: ReasonInvalidIssuer, Claim: "iss", Value: issuer, Err: ErrUnknownIssuer}
+}
+
+// unverifiedIssuer returns the iss claim of the token of the request without
+// verifying the token. The issuer is untrusted, and only routes the token to
+// the validator verifying it.
+func unverifiedIssuer(extractor RequestTokenExtractor, r *http.Request) (string, error) {
+	token, err := extractor.Extract(r)
+	if err != nil {
+		return "", err
+	}
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return "", ErrInvalidClaims
+	}
+	return claims.Issuer, nil
+}
@@ -0,0 +1,298 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// testTenant is an Auth0 tenant serving its JWKS at the default JWKS URI of
+// its issuer
+type testTenant struct {
+	key      jose.JSONWebKey
+	server   *httptest.Server
+	issuer   string
+	requests int32
+	// discoveries counts the requests of the OpenID configuration, which
+	// fail while discoveryStatus is set
+	discoveries     int32
+	discoveryStatus int32
+}
+
+func newTestTenant(kid string) *testTenant {
+	tenant := &testTenant{key: genRSASSAJWK(jose.RS256, kid)}
+	tenant.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/jwks.json":
+			atomic.AddInt32(&tenant.requests, 1)
+			_ = json.NewEncoder(w).Encode(JWKS{Keys: []jose.JSONWebKey{tenant.key.Public()}})
+		case "/.well-known/openid-configuration":
+			atomic.AddInt32(&tenant.discoveries, 1)
+			if status := atomic.LoadInt32(&tenant.discoveryStatus); status != 0 {
+				w.WriteHeader(int(status))
+				return
+			}
+			// the JWKS URI is not the default one of the issuer
+			_ = json.NewEncoder(w).Encode(OpenIDConfiguration{Issuer: tenant.issuer, JWKSURI: tenant.server.URL + "/.well-known/jwks.json?discovered"})
+		default:
+			atomic.AddInt32(&tenant.requests, 1)
+			http.NotFound(w, r)
+		}
+	}))
+	tenant.issuer = tenant.server.URL + "/"
+	return tenant
+}
+
+func (tenant *testTenant) token(audience []string) string {
+	return getTestTokenWithKid(audience, tenant.issuer, time.Now().Add(time.Hour), jose.RS256, tenant.key, tenant.key.KeyID)
+}
+
+func (tenant *testTenant) config() TenantConfig {
+	return TenantConfig{Issuer: tenant.issuer, Audience: defaultAudience, Algorithms: []jose.SignatureAlgorithm{jose.RS256}}
+}
+
+func bearerRequest(token string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestMultiTenantValidator(t *testing.T) {
+	tenantA, tenantB := newTestTenant("a"), newTestTenant("b")
+	defer tenantA.server.Close()
+	defer tenantB.server.Close()
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{
+		Tenants: []TenantConfig{tenantA.config(), tenantB.config()},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tenant := range []*testTenant{tenantA, tenantB} {
+		result, err := validator.Validate(bearerRequest(tenant.token(defaultAudience)))
+		if assert.NoError(t, err) {
+			assert.Equal(t, tenant.issuer, result.Claims.Issuer)
+			assert.Equal(t, tenant.key.KeyID, result.KeyID)
+		}
+	}
+
+	// a token claiming the issuer of A signed with the key of B
+	forged := getTestTokenWithKid(defaultAudience, tenantA.issuer, time.Now().Add(time.Hour), jose.RS256, tenantB.key, tenantB.key.KeyID)
+	_, err = validator.ValidateRequest(bearerRequest(forged))
+	assert.Equal(t, "key_not_found", ErrorReason(err))
+
+	_, err = validator.ValidateRequest(bearerRequest(tenantA.token([]string{"other"})))
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+
+	_, err = validator.ValidateRequest(&http.Request{Header: http.Header{}})
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+}
+
+func TestMultiTenantValidatorUnknownIssuer(t *testing.T) {
+	tenant := newTestTenant("kid")
+	defer tenant.server.Close()
+	var observed, failed []string
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{
+		Tenants: []TenantConfig{tenant.config()},
+		Validator: ValidatorOptions{
+			Metrics: ValidatorMetricsFunc(func(err error, _ time.Duration) {
+				observed = append(observed, ErrorReason(err))
+			}),
+			OnValidationFailure: func(_ error, reason string) {
+				failed = append(failed, reason)
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := getTestTokenWithKid(defaultAudience, "https://attacker.example.com/", time.Now().Add(time.Hour), jose.RS256, tenant.key, tenant.key.KeyID)
+	_, err = validator.ValidateRequest(bearerRequest(token))
+	assert.ErrorIs(t, err, ErrUnknownIssuer)
+	var validationErr *ValidationError
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, ReasonInvalidIssuer, validationErr.Reason)
+		assert.Equal(t, "iss", validationErr.Claim)
+		assert.Equal(t, "https://attacker.example.com/", validationErr.Value)
+	}
+	assert.Equal(t, BearerErrorInvalidToken, NewBearerError(err).Code)
+	assert.Equal(t, []string{"invalid_issuer"}, observed)
+	assert.Equal(t, []string{"invalid_issuer"}, failed)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&tenant.requests))
+
+	_, err = validator.ValidateRequest(bearerRequest("not.a.token"))
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&tenant.requests))
+}
+
+func TestMultiTenantValidatorSharedKeyCacher(t *testing.T) {
+	tenantA, tenantB := newTestTenant("kid"), newTestTenant("kid")
+	defer tenantA.server.Close()
+	defer tenantB.server.Close()
+	keyCacher := NewMemoryKeyCacher(time.Hour, 10)
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{
+		Tenants:   []TenantConfig{tenantA.config(), tenantB.config()},
+		KeyCacher: keyCacher,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tenants share a key ID, which the registry keys by JWKS URI
+	for i := 0; i < 2; i++ {
+		for _, tenant := range []*testTenant{tenantA, tenantB} {
+			_, err := validator.ValidateRequest(bearerRequest(tenant.token(defaultAudience)))
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tenantA.requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tenantB.requests))
+	_, err = keyCacher.Get(tenantA.issuer + ".well-known/jwks.json kid")
+	assert.NoError(t, err)
+}
+
+func TestNewMultiTenantValidatorErrors(t *testing.T) {
+	_, err := NewMultiTenantValidator(MultiTenantOptions{Tenants: []TenantConfig{{}}}, nil)
+	assert.Equal(t, ErrNoTenantIssuer, err)
+
+	_, err = NewMultiTenantValidator(MultiTenantOptions{Tenants: []TenantConfig{
+		{Issuer: "https://tenant.auth0.com/"},
+		{Issuer: "https://tenant.auth0.com/", Audience: []string{"other"}},
+	}}, nil)
+	assert.Equal(t, ErrDuplicateTenant, err)
+}
+
+func TestTenantConfigJWKSURI(t *testing.T) {
+	assert.Equal(t, "https://tenant.auth0.com/.well-known/jwks.json", TenantConfig{Issuer: "https://tenant.auth0.com/"}.jwksURI())
+	assert.Equal(t, "https://tenant.auth0.com/.well-known/jwks.json", TenantConfig{Issuer: "https://tenant.auth0.com"}.jwksURI())
+	assert.Equal(t, "https://keys", TenantConfig{Issuer: "https://tenant.auth0.com/", JWKSURI: "https://keys"}.jwksURI())
+
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{Tenants: []TenantConfig{{Issuer: "https://tenant.auth0.com/"}}}, nil)
+	assert.NoError(t, err)
+	_, ok := validator.Tenant("https://tenant.auth0.com/")
+	assert.True(t, ok)
+	_, ok = validator.Tenant("https://other.auth0.com/")
+	assert.False(t, ok)
+}
+
+func TestMultiTenantValidatorAddRemoveTenant(t *testing.T) {
+	tenantA, tenantB := newTestTenant("a"), newTestTenant("b")
+	defer tenantA.server.Close()
+	defer tenantB.server.Close()
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{Tenants: []TenantConfig{tenantA.config()}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = validator.ValidateRequest(bearerRequest(tenantB.token(defaultAudience)))
+	assert.ErrorIs(t, err, ErrUnknownIssuer)
+
+	assert.NoError(t, validator.AddTenant(tenantB.config()))
+	assert.ElementsMatch(t, []string{tenantA.issuer, tenantB.issuer}, validator.Issuers())
+	_, err = validator.ValidateRequest(bearerRequest(tenantB.token(defaultAudience)))
+	assert.NoError(t, err)
+
+	// replacing the tenant of B changes its audience
+	config := tenantB.config()
+	config.Audience = []string{"other"}
+	assert.NoError(t, validator.AddTenant(config))
+	_, err = validator.ValidateRequest(bearerRequest(tenantB.token(defaultAudience)))
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+	_, err = validator.ValidateRequest(bearerRequest(tenantB.token([]string{"other"})))
+	assert.NoError(t, err)
+
+	assert.True(t, validator.RemoveTenant(tenantA.issuer))
+	assert.False(t, validator.RemoveTenant(tenantA.issuer))
+	assert.Equal(t, []string{tenantB.issuer}, validator.Issuers())
+	_, err = validator.ValidateRequest(bearerRequest(tenantA.token(defaultAudience)))
+	assert.ErrorIs(t, err, ErrUnknownIssuer)
+
+	assert.Equal(t, ErrNoTenantIssuer, validator.AddTenant(TenantConfig{}))
+}
+
+func TestMultiTenantValidatorConcurrentTenants(t *testing.T) {
+	tenantA, tenantB := newTestTenant("a"), newTestTenant("b")
+	defer tenantA.server.Close()
+	defer tenantB.server.Close()
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{Tenants: []TenantConfig{tenantA.config()}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenA, tokenB := tenantA.token(defaultAudience), tenantB.token(defaultAudience)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			assert.NoError(t, validator.AddTenant(tenantB.config()))
+			validator.RemoveTenant(tenantB.issuer)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		// A is never removed, B comes and goes
+		_, err := validator.ValidateRequest(bearerRequest(tokenA))
+		assert.NoError(t, err)
+		_, err = validator.ValidateRequest(bearerRequest(tokenB))
+		if err != nil {
+			assert.Equal(t, "invalid_issuer", ErrorReason(err))
+		}
+	}
+	<-done
+}
+
+func TestMultiTenantValidatorOverrides(t *testing.T) {
+	tenantA, tenantB := newTestTenant("a"), newTestTenant("b")
+	defer tenantA.server.Close()
+	defer tenantB.server.Close()
+	configB := TenantConfig{
+		Issuer:         tenantB.issuer,
+		Audience:       []string{"b"},
+		Algorithms:     []jose.SignatureAlgorithm{jose.RS256},
+		Leeway:         time.Hour,
+		RequiredClaims: []string{"iat"},
+	}
+	validator, err := NewMultiTenantValidator(MultiTenantOptions{
+		Tenants:  []TenantConfig{{Issuer: tenantA.issuer}, configB},
+		Audience: defaultAudience,
+		Validator: ValidatorOptions{
+			Algorithms:     []jose.SignatureAlgorithm{jose.ES256},
+			Leeway:         -1,
+			RequiredClaims: []string{"sub"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A uses the defaults, rejecting RS256
+	_, err = validator.ValidateRequest(bearerRequest(tenantA.token(defaultAudience)))
+	assert.ErrorIs(t, err, ErrInvalidAlgorithm)
+
+	// B overrides the audience, the algorithms, the leeway and the
+	// required claims
+	_, err = validator.ValidateRequest(bearerRequest(tenantB.token(defaultAudience)))
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+	expired := getTestTokenWithKid([]string{"b"}, tenantB.issuer, time.Now().Add(-30*time.Minute), jose.RS256, tenantB.key, tenantB.key.KeyID)
+	_, err = validator.ValidateRequest(bearerRequest(expired))
+	assert.NoError(t, err)
+
+	a, _ := validator.Tenant(tenantA.issuer)
+	b, _ := validator.Tenant(tenantB.issuer)
+	assert.Equal(t, time.Duration(0), a.leeway)
+	assert.Equal(t, []string{"sub"}, a.requiredClaims)
+	assert.Equal(t, []string{"iat"}, b.requiredClaims)
+
+	// the tenants share the http.Client and the key cacher
+	clientA, clientB := a.config.secretProvider.(*JWKClient), b.config.secretProvider.(*JWKClient)
+	assert.Same(t, clientA.options.Client, clientB.options.Client)
+	assert.Same(t, clientA.keyCacher.(*registryKeyCacher).keyCacher, clientB.keyCacher.(*registryKeyCacher).keyCacher)
+}
@@ -0,0 +1,240 @@
+// Package vaultprovider provides a secret provider serving the verification
+// keys stored in HashiCorp Vault, either as secrets of a KV secrets engine or
+// as the public keys of a transit secrets engine key.
+//
+// It lives in its own module so the core package does not depend on the Vault
+// client.
+package vaultprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	auth0 "github.com/paulusrobin/go-auth0"
+)
+
+const (
+	// DefaultField is the field of the KV secret holding the key
+	DefaultField = "key"
+	// DefaultTransitMount is the path the transit secrets engine is mounted at
+	DefaultTransitMount = "transit"
+	// DefaultTTL is the time the keys are cached for when Vault does not
+	// lease them
+	DefaultTTL = 5 * time.Minute
+)
+
+var (
+	// ErrSecretNotFound is returned when there is no secret at the path.
+	ErrSecretNotFound = errors.New("vault secret not found")
+	// ErrFieldNotFound is returned when the secret has no such field.
+	ErrFieldNotFound = errors.New("vault secret field not found")
+	// ErrNoTransitPublicKey is returned when the transit key has no public
+	// key, e.g. because it is a symmetric key.
+	ErrNoTransitPublicKey = errors.New("vault transit key has no public key")
+)
+
+// Options configures the Vault secret provider. Either Path or TransitKey
+// must be set.
+type Options struct {
+	// Path is the path of the KV secret holding the key, including the
+	// "data/" segment for version 2 of the KV secrets engine, e.g.
+	// "secret/data/myapp/jwt".
+	Path string
+	// Field is the field of the KV secret holding the key.
+	// Defaults to DefaultField.
+	Field string
+	// PreviousField, when set, is the field of the KV secret holding the
+	// previous key, so that tokens signed with it stay valid while rotating
+	// keys. It may be missing from the secret.
+	PreviousField string
+
+	// TransitKey is the name of the transit key whose public keys verify the
+	// tokens, every version of the key being served so that tokens signed
+	// before a rotation stay valid.
+	TransitKey string
+	// TransitMount is the path the transit secrets engine is mounted at.
+	// Defaults to DefaultTransitMount.
+	TransitMount string
+
+	// TTL is the time the keys are cached for, or at most the lease duration
+	// of the secret when Vault leases it. Defaults to DefaultTTL.
+	TTL time.Duration
+	// RetryInterval is the time after which the keys are refreshed again
+	// once refreshing them failed. Defaults to
+	// auth0.DefaultRefreshRetryInterval.
+	RetryInterval time.Duration
+	// OnRefreshError, when set, is called when the keys cannot be refreshed.
+	// The previous keys are served until they can be.
+	OnRefreshError func(err error)
+	// Clock tells the time the keys expire at. Defaults to auth0.SystemClock.
+	Clock auth0.Clock
+}
+
+// Provider is a secret provider serving keys read from Vault, refreshed once
+// their TTL or lease expire as described by auth0.RefreshingKeyProvider.
+//
+// KV secret fields holding a PEM encoded public key or certificate are
+// served as public keys, other fields as HMAC secrets.
+type Provider struct {
+	client  *api.Client
+	options Options
+	keys    *auth0.RefreshingKeyProvider
+}
+
+// New creates a provider reading keys with client, the Vault client being
+// responsible for authenticating, e.g. with a token or the Kubernetes auth
+// method. It fails when the keys cannot be read.
+func New(client *api.Client, options Options) (*Provider, error) {
+	if (options.Path == "") == (options.TransitKey == "") {
+		return nil, errors.New("vaultprovider: exactly one of Path and TransitKey must be set")
+	}
+	if options.Field == "" {
+		options.Field = DefaultField
+	}
+	if options.TransitMount == "" {
+		options.TransitMount = DefaultTransitMount
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+
+	p := &Provider{client: client, options: options}
+	p.keys = auth0.NewRefreshingKeyProvider(p.load, auth0.RefreshingKeyProviderOptions{
+		RetryInterval:  options.RetryInterval,
+		OnRefreshError: options.OnRefreshError,
+		Clock:          options.Clock,
+	})
+	if err := p.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSecret returns the cached keys, refreshing them first when expired.
+// Several keys are returned as auth0.RotatedKeys.
+func (p *Provider) GetSecret(r *http.Request) (interface{}, error) {
+	return p.keys.GetSecret(r)
+}
+
+// Refresh reads the keys from Vault, replacing the cached keys on success
+// and keeping them on failure.
+func (p *Provider) Refresh(ctx context.Context) error {
+	return p.keys.Refresh(ctx)
+}
+
+// load reads the keys from Vault along with the time they are cached for
+func (p *Provider) load(ctx context.Context) (interface{}, time.Duration, error) {
+	path := p.options.Path
+	if p.options.TransitKey != "" {
+		path = strings.TrimSuffix(p.options.TransitMount, "/") + "/keys/" + p.options.TransitKey
+	}
+	secret, err := p.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrSecretNotFound, path)
+	}
+
+	var keys interface{}
+	if p.options.TransitKey != "" {
+		keys, err = transitKeys(secret.Data)
+	} else {
+		keys, err = p.kvKeys(secret.Data)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid vault secret %s: %w", path, err)
+	}
+
+	ttl := p.options.TTL
+	if lease := time.Duration(secret.LeaseDuration) * time.Second; lease > 0 && lease < ttl {
+		ttl = lease
+	}
+	return keys, ttl, nil
+}
+
+// kvKeys returns the key, along with the previous key if any, of the data of
+// a KV secret
+func (p *Provider) kvKeys(data map[string]interface{}) (interface{}, error) {
+	// version 2 of the KV secrets engine nests the secret in its metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	key, err := kvKey(data, p.options.Field)
+	if err != nil {
+		return nil, err
+	}
+	if p.options.PreviousField == "" {
+		return key, nil
+	}
+	if value, _ := data[p.options.PreviousField].(string); value == "" {
+		return key, nil
+	}
+	previousKey, err := kvKey(data, p.options.PreviousField)
+	if err != nil {
+		return nil, err
+	}
+	return auth0.RotatedKeys{key, previousKey}, nil
+}
+
+// kvKey returns the PEM public key or the HMAC secret of the field
+func kvKey(data map[string]interface{}, field string) (interface{}, error) {
+	value, _ := data[field].(string)
+	if value == "" {
+		return nil, fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+	}
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return auth0.ParsePEMPublicKey([]byte(value))
+	}
+	return []byte(value), nil
+}
+
+// transitKeys returns the public keys of the versions of a transit key,
+// newest first
+func transitKeys(data map[string]interface{}) (interface{}, error) {
+	versions, _ := data["keys"].(map[string]interface{})
+	type version struct {
+		number int
+		key    interface{}
+	}
+	var keys []version
+	for number, value := range versions {
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %q", number)
+		}
+		fields, _ := value.(map[string]interface{})
+		publicKey, _ := fields["public_key"].(string)
+		if publicKey == "" {
+			continue
+		}
+		key, err := auth0.ParsePEMPublicKey([]byte(publicKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %d: %v", n, err)
+		}
+		keys = append(keys, version{number: n, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoTransitPublicKey
+	}
+	if len(keys) == 1 {
+		return keys[0].key, nil
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].number > keys[j].number })
+	rotated := make(auth0.RotatedKeys, len(keys))
+	for i, key := range keys {
+		rotated[i] = key.key
+	}
+	return rotated, nil
+}
@@ -0,0 +1,274 @@
+package vaultprovider
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	auth0 "github.com/paulusrobin/go-auth0"
+	"github.com/paulusrobin/go-auth0/auth0test"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault serves the secrets of its responses by path, counting the reads
+type fakeVault struct {
+	responses map[string]interface{}
+	reads     int32
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&v.reads, 1)
+	if r.Header.Get("X-Vault-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	response, ok := v.responses[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func newClient(t *testing.T, vault http.Handler) *api.Client {
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+
+	config := api.DefaultConfig()
+	config.Address = server.URL
+	config.MaxRetries = 0
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("token")
+	return client
+}
+
+func genPEMPublicKey(t *testing.T) (*rsa.PublicKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &key.PublicKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestProviderKV(t *testing.T) {
+	publicKey, publicKeyPEM := genPEMPublicKey(t)
+
+	tests := []struct {
+		name        string
+		options     Options
+		response    interface{}
+		expectedKey interface{}
+		expectedErr error
+	}{
+		{
+			name:        "pass - kv v1 secret",
+			options:     Options{Path: "secret/jwt"},
+			response:    map[string]interface{}{"data": map[string]interface{}{"key": "secret"}},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:    "pass - kv v2 secret",
+			options: Options{Path: "secret/data/jwt"},
+			response: map[string]interface{}{"data": map[string]interface{}{
+				"data":     map[string]interface{}{"key": "secret"},
+				"metadata": map[string]interface{}{"version": 1},
+			}},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:        "pass - pem public key",
+			options:     Options{Path: "secret/jwt"},
+			response:    map[string]interface{}{"data": map[string]interface{}{"key": publicKeyPEM}},
+			expectedKey: publicKey,
+		},
+		{
+			name:        "pass - custom field",
+			options:     Options{Path: "secret/jwt", Field: "hmac"},
+			response:    map[string]interface{}{"data": map[string]interface{}{"hmac": "secret"}},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:        "pass - previous key",
+			options:     Options{Path: "secret/jwt", PreviousField: "previous"},
+			response:    map[string]interface{}{"data": map[string]interface{}{"key": "secret", "previous": "old"}},
+			expectedKey: auth0.RotatedKeys{[]byte("secret"), []byte("old")},
+		},
+		{
+			name:        "pass - no previous key",
+			options:     Options{Path: "secret/jwt", PreviousField: "previous"},
+			response:    map[string]interface{}{"data": map[string]interface{}{"key": "secret"}},
+			expectedKey: []byte("secret"),
+		},
+		{
+			name:        "fail - field not found",
+			options:     Options{Path: "secret/jwt"},
+			response:    map[string]interface{}{"data": map[string]interface{}{"hmac": "secret"}},
+			expectedErr: ErrFieldNotFound,
+		},
+		{
+			name:        "fail - secret not found",
+			options:     Options{Path: "secret/missing"},
+			expectedErr: ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vault := &fakeVault{responses: map[string]interface{}{"/v1/secret/jwt": test.response, "/v1/secret/data/jwt": test.response}}
+			provider, err := New(newClient(t, vault), test.options)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedKey, key)
+		})
+	}
+}
+
+func TestProviderTransit(t *testing.T) {
+	publicKey1, publicKeyPEM1 := genPEMPublicKey(t)
+	publicKey2, publicKeyPEM2 := genPEMPublicKey(t)
+
+	tests := []struct {
+		name        string
+		options     Options
+		path        string
+		keys        map[string]interface{}
+		expectedKey interface{}
+		expectedErr error
+	}{
+		{
+			name:        "pass - single version",
+			options:     Options{TransitKey: "jwt"},
+			path:        "/v1/transit/keys/jwt",
+			keys:        map[string]interface{}{"1": map[string]interface{}{"public_key": publicKeyPEM1}},
+			expectedKey: publicKey1,
+		},
+		{
+			name:    "pass - versions newest first",
+			options: Options{TransitKey: "jwt"},
+			path:    "/v1/transit/keys/jwt",
+			keys: map[string]interface{}{
+				"1": map[string]interface{}{"public_key": publicKeyPEM1},
+				"2": map[string]interface{}{"public_key": publicKeyPEM2},
+			},
+			expectedKey: auth0.RotatedKeys{publicKey2, publicKey1},
+		},
+		{
+			name:        "pass - custom mount",
+			options:     Options{TransitKey: "jwt", TransitMount: "signing"},
+			path:        "/v1/signing/keys/jwt",
+			keys:        map[string]interface{}{"1": map[string]interface{}{"public_key": publicKeyPEM1}},
+			expectedKey: publicKey1,
+		},
+		{
+			name:        "fail - symmetric key",
+			options:     Options{TransitKey: "jwt"},
+			path:        "/v1/transit/keys/jwt",
+			keys:        map[string]interface{}{"1": float64(1600000000)},
+			expectedErr: ErrNoTransitPublicKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vault := &fakeVault{responses: map[string]interface{}{
+				test.path: map[string]interface{}{"data": map[string]interface{}{"keys": test.keys}},
+			}}
+			provider, err := New(newClient(t, vault), test.options)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedKey, key)
+		})
+	}
+}
+
+func TestProviderOptions(t *testing.T) {
+	vault := &fakeVault{}
+	_, err := New(newClient(t, vault), Options{})
+	assert.Error(t, err)
+	_, err = New(newClient(t, vault), Options{Path: "secret/jwt", TransitKey: "jwt"})
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), vault.reads)
+}
+
+func TestProviderRefresh(t *testing.T) {
+	tests := []struct {
+		name          string
+		ttl           time.Duration
+		leaseDuration int
+		expectedReads int32
+	}{
+		{name: "pass - cached for the ttl", ttl: time.Hour, expectedReads: 1},
+		{name: "pass - cached for the lease", ttl: time.Hour, leaseDuration: 1, expectedReads: 2},
+		{name: "pass - lease longer than the ttl", ttl: time.Second, leaseDuration: 3600, expectedReads: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vault := &fakeVault{responses: map[string]interface{}{"/v1/secret/jwt": map[string]interface{}{
+				"lease_duration": test.leaseDuration,
+				"data":           map[string]interface{}{"key": "secret"},
+			}}}
+			clock := auth0test.NewClock(time.Now())
+			provider, err := New(newClient(t, vault), Options{Path: "secret/jwt", TTL: test.ttl, Clock: clock})
+			assert.NoError(t, err)
+
+			clock.Advance(time.Second)
+			_, err = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedReads, atomic.LoadInt32(&vault.reads))
+		})
+	}
+}
+
+func TestProviderRefreshError(t *testing.T) {
+	vault := &fakeVault{responses: map[string]interface{}{"/v1/secret/jwt": map[string]interface{}{
+		"data": map[string]interface{}{"key": "secret"},
+	}}}
+	var refreshErr error
+	clock := auth0test.NewClock(time.Now())
+	provider, err := New(newClient(t, vault), Options{
+		Path:           "secret/jwt",
+		OnRefreshError: func(err error) { refreshErr = err },
+		Clock:          clock,
+	})
+	assert.NoError(t, err)
+
+	delete(vault.responses, "/v1/secret/jwt")
+	clock.Advance(DefaultTTL)
+	key, err := provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err, "the previous key should be served when it cannot be refreshed")
+	assert.Equal(t, []byte("secret"), key)
+	assert.True(t, errors.Is(refreshErr, ErrSecretNotFound), refreshErr)
+
+	_, err = provider.GetSecret(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&vault.reads), "the refresh should not be retried before the retry interval")
+}